@@ -63,11 +63,11 @@ function createDoughnutChart(ctx, labels, datasets, options = {}) {
     });
 }
 
-function getData() {
-    const element = document.getElementById('visitOverTime');
+function getData(id = 'visitOverTime') {
+    const element = document.getElementById(id);
     if (!element) return [];
     try {
-        const input = JSON.parse(element.textContent);
+        const input = JSON.parse(element.textContent) || [];
         return input.map((p) => {
             return {
                 "x": new Date(p.Time),
@@ -75,17 +75,30 @@ function getData() {
             }
         });
     } catch (e) {
-        console.error("Failed to parse visitOverTime data", e);
+        console.error("Failed to parse " + id + " data", e);
         return [];
     }
 }
 
+// getPreviousData lays the visits of the previous period over the buckets of
+// the current one, so both periods can be compared on the same axis.
+function getPreviousData(current) {
+    const previous = getData('visitOverTimePrevious');
+    return current.map((p, i) => {
+        return {
+            "x": p.x,
+            "y": previous[i] ? previous[i].y : 0,
+        }
+    });
+}
+
 let mainChart = null;
 
 export function updateChart() {
     if (!mainChart) return;
     const data = getData();
     mainChart.data.datasets[0].data = data;
+    mainChart.data.datasets[1].data = getPreviousData(data);
     mainChart.update();
 }
 
@@ -143,6 +156,16 @@ export function initDashboardCharts() {
                     fill: true,
                     pointRadius: 0,
                     pointHoverRadius: 6
+                }, {
+                    label: 'Previous period',
+                    data: getPreviousData(data),
+                    borderColor: '#94a3b8',
+                    borderDash: [6, 4],
+                    borderWidth: 1.5,
+                    tension: 0.4,
+                    fill: false,
+                    pointRadius: 0,
+                    pointHoverRadius: 4
                 }]
             },
             options: {
@@ -157,9 +180,6 @@ export function initDashboardCharts() {
                     },
                     x: { 
                         type: 'time',
-                        time: {
-                            unit: 'hour'
-                        },
                         grid: { display: false },
                         ticks: { font: { size: 11 } }
                     }
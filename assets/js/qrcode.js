@@ -1,14 +1,39 @@
 // QR Code Component for Shortcut
+//
+// With an endpoint, the codes are rendered by the server so that their scans
+// are recorded as such. Without one, they are only a picture of the URL.
 export function registerQrCodeModal(Alpine) {
-    Alpine.data("qrCodeModal", (initialUrl = "") => ({
+    Alpine.data("qrCodeModal", (initialUrl = "", endpoint = "") => ({
 
         open: false,
         url: initialUrl,
+        endpoint: endpoint,
         qrSize: "medium",
         qrColor: "#000000",
+        qrBackground: "#ffffff",
+        qrLevel: "M",
+        qrLogo: false,
+        qrFormat: "png",
+
+        // A logo hides part of the code, which only scans with a high error
+        // correction level
+        get level() {
+            return this.qrLogo && (this.qrLevel === "L" || this.qrLevel === "M") ? "H" : this.qrLevel
+        },
 
         // Computed property for QR code URL
         get qrCodeUrl() {
+            if (this.endpoint) {
+                const size = this.qrSize === "small" ? 192 : this.qrSize === "medium" ? 256 : 384
+                const params = new URLSearchParams({
+                    size: size,
+                    fg: this.qrColor.replace("#", ""),
+                    bg: this.qrBackground.replace("#", ""),
+                    level: this.level,
+                    logo: this.qrLogo,
+                })
+                return `${this.endpoint}?${params}`
+            }
             const size = this.qrSize === "small" ? "150x150" : this.qrSize === "medium" ? "200x200" : "300x300"
             const color = this.qrColor.replace("#", "")
             return `https://api.qrserver.com/v1/create-qr-code/?size=${size}&data=${encodeURIComponent(this.url)}&color=${color}`
@@ -24,9 +49,18 @@ export function registerQrCodeModal(Alpine) {
         downloadQRCode() {
             // Create a temporary link element
             const link = document.createElement("a")
-            link.href = this.qrCodeUrl
-            link.download = `qrcode-${this.url.replace(/[^a-zA-Z0-9]/g, "-")}.png`
-            link.target = "_blank"
+            if (this.endpoint) {
+                // downloads are large enough to be printed
+                const params = new URLSearchParams(this.qrCodeUrl.split("?")[1])
+                params.set("size", 1024)
+                params.set("format", this.qrFormat)
+                params.set("download", true)
+                link.href = `${this.endpoint}?${params}`
+            } else {
+                link.href = this.qrCodeUrl
+                link.target = "_blank"
+            }
+            link.download = `qrcode-${this.url.replace(/[^a-zA-Z0-9]/g, "-")}.${this.endpoint ? this.qrFormat : "png"}`
             document.body.appendChild(link)
             link.click()
             document.body.removeChild(link)
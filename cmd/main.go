@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"os"
 	"syscall"
+	"time"
 
 	"github.com/urfave/cli/v2"
 
@@ -23,8 +24,8 @@ type config struct {
 
 	DBConnString string
 
-	GeoIPBucket string
 	GeoIPDBFile string
+	GeoIPQuery  bool
 
 	ForceDev bool
 
@@ -40,9 +41,24 @@ type config struct {
 
 	SentryDSN string
 
+	SMTPURL  string
+	MailFrom string
+
 	SessionLifetime int
 
 	GoogleWebRiskAPIKey string
+
+	UnambiguousSlugs bool
+
+	VisitQueueSize int
+	VisitWorkers   int
+
+	WebhookWorkers int
+
+	LinkCacheSize int
+	LinkCacheTTL  time.Duration
+
+	TrustedProxies string
 }
 
 func (c config) SafeDBString() string {
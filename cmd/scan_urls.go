@@ -185,7 +185,7 @@ func runScanURLs(cCtx *cli.Context, cfg config) error {
 				log.Info(fmt.Sprintf("Applying moderation action for URL %s...", item.ShortURL))
 
 				// 1. Deactivate URL
-				if err := urlStore.UpdateURLStatus(ctx, item.ShortURL, false); err != nil {
+				if err := urlStore.UpdateURLStatus(ctx, domain.ID(item.ID), false); err != nil {
 					log.Error("Failed to deactivate URL", "short_url", item.ShortURL, "err", err)
 					errorCount++
 					continue
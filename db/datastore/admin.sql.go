@@ -277,6 +277,7 @@ func (q *Queries) AdminGetTopReferrers(ctx context.Context) ([]AdminGetTopReferr
 const adminGetTopURLs = `-- name: AdminGetTopURLs :many
 SELECT
     u.short_url,
+    u.domain,
     u.long_url,
     COUNT(v.id)::bigint AS clicks
 FROM
@@ -292,6 +293,7 @@ LIMIT 5
 
 type AdminGetTopURLsRow struct {
 	ShortUrl string `json:"short_url"`
+	Domain   string `json:"domain"`
 	LongUrl  string `json:"long_url"`
 	Clicks   int64  `json:"clicks"`
 }
@@ -305,7 +307,12 @@ func (q *Queries) AdminGetTopURLs(ctx context.Context) ([]AdminGetTopURLsRow, er
 	items := []AdminGetTopURLsRow{}
 	for rows.Next() {
 		var i AdminGetTopURLsRow
-		if err := rows.Scan(&i.ShortUrl, &i.LongUrl, &i.Clicks); err != nil {
+		if err := rows.Scan(
+			&i.ShortUrl,
+			&i.Domain,
+			&i.LongUrl,
+			&i.Clicks,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -421,9 +428,9 @@ SELECT
 FROM
     users u
 LEFT JOIN
-    customer c ON u.guid = c.user_id
+    customer c ON u.guid = c.workspace_guid
 LEFT JOIN
-    subscription s ON c.user_id = s.customer_id
+    subscription s ON c.workspace_guid = s.customer_id
 LEFT JOIN
     urls ON u.id = urls.author_id
 LEFT JOIN
@@ -516,7 +523,7 @@ func (q *Queries) AdminGetUserGrowth(ctx context.Context) ([]AdminGetUserGrowthR
 
 const adminListURLSDetails = `-- name: AdminListURLSDetails :many
 SELECT
-    urls.id, urls.short_url, urls.long_url, urls.author_id, urls.created_at, urls.title, urls.is_archived, urls.is_active,
+    urls.id, urls.short_url, urls.long_url, urls.author_id, urls.created_at, urls.title, urls.is_archived, urls.is_active, urls.expires_at, urls.max_clicks, urls.password_hash, urls.password_salt, urls.redirect_status, urls.forward_query, urls.sticky_variants, urls.folder_id, urls.workspace_id, urls.domain, urls.clicks,
     users.id, users.username, users.email, users.created_at, users.is_oauth, users.guid, users.updated_at, users.is_suspended,
     users.username AS author_name,
     COUNT(visits.id) AS click_count
@@ -527,9 +534,9 @@ JOIN
 LEFT JOIN
     visits ON urls.id = visits.url_id
 LEFT JOIN
-    customer c ON users.guid = c.user_id
+    customer c ON users.guid = c.workspace_guid
 LEFT JOIN
-    subscription s ON c.user_id = s.customer_id
+    subscription s ON c.workspace_guid = s.customer_id
 WHERE
     ($1::text IS NULL OR urls.title ILIKE '%' || $1 || '%' OR urls.short_url ILIKE '%' || $1 || '%' OR urls.long_url ILIKE '%' || $1 || '%')
     AND ($2::boolean IS NULL OR urls.is_active = $2)
@@ -585,6 +592,17 @@ func (q *Queries) AdminListURLSDetails(ctx context.Context, arg AdminListURLSDet
 			&i.Url.Title,
 			&i.Url.IsArchived,
 			&i.Url.IsActive,
+			&i.Url.ExpiresAt,
+			&i.Url.MaxClicks,
+			&i.Url.PasswordHash,
+			&i.Url.PasswordSalt,
+			&i.Url.RedirectStatus,
+			&i.Url.ForwardQuery,
+			&i.Url.StickyVariants,
+			&i.Url.FolderID,
+			&i.Url.WorkspaceID,
+			&i.Url.Domain,
+			&i.Url.Clicks,
 			&i.User.ID,
 			&i.User.Username,
 			&i.User.Email,
@@ -608,7 +626,7 @@ func (q *Queries) AdminListURLSDetails(ctx context.Context, arg AdminListURLSDet
 
 const adminListUserURLs = `-- name: AdminListUserURLs :many
 SELECT
-    urls.id, urls.short_url, urls.long_url, urls.author_id, urls.created_at, urls.title, urls.is_archived, urls.is_active,
+    urls.id, urls.short_url, urls.long_url, urls.author_id, urls.created_at, urls.title, urls.is_archived, urls.is_active, urls.expires_at, urls.max_clicks, urls.password_hash, urls.password_salt, urls.redirect_status, urls.forward_query, urls.sticky_variants, urls.folder_id, urls.workspace_id, urls.domain, urls.clicks,
     users.id, users.username, users.email, users.created_at, users.is_oauth, users.guid, users.updated_at, users.is_suspended,
     users.username AS author_name,
     COUNT(visits.id) AS click_count
@@ -651,6 +669,17 @@ func (q *Queries) AdminListUserURLs(ctx context.Context, guid pgtype.UUID) ([]Ad
 			&i.Url.Title,
 			&i.Url.IsArchived,
 			&i.Url.IsActive,
+			&i.Url.ExpiresAt,
+			&i.Url.MaxClicks,
+			&i.Url.PasswordHash,
+			&i.Url.PasswordSalt,
+			&i.Url.RedirectStatus,
+			&i.Url.ForwardQuery,
+			&i.Url.StickyVariants,
+			&i.Url.FolderID,
+			&i.Url.WorkspaceID,
+			&i.Url.Domain,
+			&i.Url.Clicks,
 			&i.User.ID,
 			&i.User.Username,
 			&i.User.Email,
@@ -685,9 +714,9 @@ SELECT
 FROM
     users u
 LEFT JOIN
-    customer c ON u.guid = c.user_id
+    customer c ON u.guid = c.workspace_guid
 LEFT JOIN
-    subscription s ON c.user_id = s.customer_id
+    subscription s ON c.workspace_guid = s.customer_id
 LEFT JOIN
     urls ON u.id = urls.author_id
 LEFT JOIN
@@ -725,6 +754,8 @@ type AdminListUsersRow struct {
 	UserStatus  string           `json:"user_status"`
 }
 
+// The plan of a user is the one of their personal workspace, which has the
+// guid of its user.
 func (q *Queries) AdminListUsers(ctx context.Context, arg AdminListUsersParams) ([]AdminListUsersRow, error) {
 	rows, err := q.db.Query(ctx, adminListUsers,
 		arg.Search,
@@ -781,21 +812,39 @@ func (q *Queries) AdminToggleUserURLs(ctx context.Context, arg AdminToggleUserUR
 }
 
 const adminUpdateURL = `-- name: AdminUpdateURL :one
+WITH previous AS (
+    SELECT id, long_url
+    FROM urls
+    WHERE urls.id = $3
+    FOR UPDATE
+), history AS (
+    INSERT INTO url_destination_history (url_id, long_url, changed_by)
+    SELECT previous.id, previous.long_url, $4
+    FROM previous
+    WHERE previous.long_url <> $2
+)
 UPDATE urls
 SET title = $1,
     long_url = $2
-WHERE id = $3
-RETURNING id, short_url, long_url, author_id, created_at, title, is_archived, is_active
+FROM previous
+WHERE urls.id = previous.id
+RETURNING urls.id, urls.short_url, urls.long_url, urls.author_id, urls.created_at, urls.title, urls.is_archived, urls.is_active, urls.expires_at, urls.max_clicks, urls.password_hash, urls.password_salt, urls.redirect_status, urls.forward_query, urls.sticky_variants, urls.folder_id, urls.workspace_id, urls.domain, urls.clicks
 `
 
 type AdminUpdateURLParams struct {
-	Title   string `json:"title"`
-	LongUrl string `json:"long_url"`
-	ID      int32  `json:"id"`
+	Title     string      `json:"title"`
+	LongUrl   string      `json:"long_url"`
+	ID        int32       `json:"id"`
+	ChangedBy pgtype.Int4 `json:"changed_by"`
 }
 
 func (q *Queries) AdminUpdateURL(ctx context.Context, arg AdminUpdateURLParams) (Url, error) {
-	row := q.db.QueryRow(ctx, adminUpdateURL, arg.Title, arg.LongUrl, arg.ID)
+	row := q.db.QueryRow(ctx, adminUpdateURL,
+		arg.Title,
+		arg.LongUrl,
+		arg.ID,
+		arg.ChangedBy,
+	)
 	var i Url
 	err := row.Scan(
 		&i.ID,
@@ -806,6 +855,17 @@ func (q *Queries) AdminUpdateURL(ctx context.Context, arg AdminUpdateURLParams)
 		&i.Title,
 		&i.IsArchived,
 		&i.IsActive,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.PasswordHash,
+		&i.PasswordSalt,
+		&i.RedirectStatus,
+		&i.ForwardQuery,
+		&i.StickyVariants,
+		&i.FolderID,
+		&i.WorkspaceID,
+		&i.Domain,
+		&i.Clicks,
 	)
 	return i, err
 }
@@ -0,0 +1,128 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: copyfrom.go
+
+package datastore
+
+import (
+	"context"
+)
+
+// iteratorForInsertVisitLocations implements pgx.CopyFromSource.
+type iteratorForInsertVisitLocations struct {
+	rows                 []InsertVisitLocationsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForInsertVisitLocations) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForInsertVisitLocations) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].VisitID,
+		r.rows[0].Address,
+		r.rows[0].CountryCode,
+		r.rows[0].CountryName,
+		r.rows[0].Subdivision,
+		r.rows[0].Continent,
+		r.rows[0].CityName,
+		r.rows[0].Latitude,
+		r.rows[0].Longitude,
+		r.rows[0].Source,
+	}, nil
+}
+
+func (r iteratorForInsertVisitLocations) Err() error {
+	return nil
+}
+
+func (q *Queries) InsertVisitLocations(ctx context.Context, arg []InsertVisitLocationsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"visit_locations"}, []string{"visit_id", "address", "country_code", "country_name", "subdivision", "continent", "city_name", "latitude", "longitude", "source"}, &iteratorForInsertVisitLocations{rows: arg})
+}
+
+// iteratorForInsertVisits implements pgx.CopyFromSource.
+type iteratorForInsertVisits struct {
+	rows                 []InsertVisitsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForInsertVisits) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForInsertVisits) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ID,
+		r.rows[0].UrlID,
+		r.rows[0].VisitedAt,
+		r.rows[0].IpAddress,
+		r.rows[0].UserAgent,
+		r.rows[0].BrowserID,
+		r.rows[0].Referrer,
+		r.rows[0].RedirectRuleID,
+		r.rows[0].VariantID,
+		r.rows[0].IsBot,
+		r.rows[0].Source,
+	}, nil
+}
+
+func (r iteratorForInsertVisits) Err() error {
+	return nil
+}
+
+func (q *Queries) InsertVisits(ctx context.Context, arg []InsertVisitsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"visits"}, []string{"id", "url_id", "visited_at", "ip_address", "user_agent", "browser_id", "referrer", "redirect_rule_id", "variant_id", "is_bot", "source"}, &iteratorForInsertVisits{rows: arg})
+}
+
+// iteratorForInsertWebhookDeliveries implements pgx.CopyFromSource.
+type iteratorForInsertWebhookDeliveries struct {
+	rows                 []InsertWebhookDeliveriesParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForInsertWebhookDeliveries) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForInsertWebhookDeliveries) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].EndpointID,
+		r.rows[0].Event,
+		r.rows[0].Payload,
+		r.rows[0].NextAttemptAt,
+	}, nil
+}
+
+func (r iteratorForInsertWebhookDeliveries) Err() error {
+	return nil
+}
+
+func (q *Queries) InsertWebhookDeliveries(ctx context.Context, arg []InsertWebhookDeliveriesParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"webhook_deliveries"}, []string{"endpoint_id", "event", "payload", "next_attempt_at"}, &iteratorForInsertWebhookDeliveries{rows: arg})
+}
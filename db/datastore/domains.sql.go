@@ -0,0 +1,174 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: domains.sql
+
+package datastore
+
+import (
+	"context"
+)
+
+const countDomainURLs = `-- name: CountDomainURLs :one
+SELECT count(*)::INTEGER
+FROM urls
+WHERE workspace_id = $1
+AND domain = $2
+`
+
+type CountDomainURLsParams struct {
+	WorkspaceID int32  `json:"workspace_id"`
+	Domain      string `json:"domain"`
+}
+
+func (q *Queries) CountDomainURLs(ctx context.Context, arg CountDomainURLsParams) (int32, error) {
+	row := q.db.QueryRow(ctx, countDomainURLs, arg.WorkspaceID, arg.Domain)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const countDomains = `-- name: CountDomains :one
+SELECT count(*)::INTEGER
+FROM domains
+WHERE workspace_id = $1
+`
+
+func (q *Queries) CountDomains(ctx context.Context, workspaceID int32) (int32, error) {
+	row := q.db.QueryRow(ctx, countDomains, workspaceID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const deleteDomain = `-- name: DeleteDomain :execrows
+DELETE FROM domains
+WHERE id = $1
+AND workspace_id = $2
+`
+
+type DeleteDomainParams struct {
+	ID          int32 `json:"id"`
+	WorkspaceID int32 `json:"workspace_id"`
+}
+
+func (q *Queries) DeleteDomain(ctx context.Context, arg DeleteDomainParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDomain, arg.ID, arg.WorkspaceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getDomain = `-- name: GetDomain :one
+SELECT id, workspace_id, hostname, verification_token, verified_at, created_at
+FROM domains
+WHERE id = $1
+AND workspace_id = $2
+`
+
+type GetDomainParams struct {
+	ID          int32 `json:"id"`
+	WorkspaceID int32 `json:"workspace_id"`
+}
+
+func (q *Queries) GetDomain(ctx context.Context, arg GetDomainParams) (Domain, error) {
+	row := q.db.QueryRow(ctx, getDomain, arg.ID, arg.WorkspaceID)
+	var i Domain
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Hostname,
+		&i.VerificationToken,
+		&i.VerifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertDomain = `-- name: InsertDomain :one
+INSERT INTO domains (workspace_id, hostname, verification_token)
+VALUES ($1, $2, $3)
+RETURNING id, workspace_id, hostname, verification_token, verified_at, created_at
+`
+
+type InsertDomainParams struct {
+	WorkspaceID       int32  `json:"workspace_id"`
+	Hostname          string `json:"hostname"`
+	VerificationToken string `json:"verification_token"`
+}
+
+func (q *Queries) InsertDomain(ctx context.Context, arg InsertDomainParams) (Domain, error) {
+	row := q.db.QueryRow(ctx, insertDomain, arg.WorkspaceID, arg.Hostname, arg.VerificationToken)
+	var i Domain
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Hostname,
+		&i.VerificationToken,
+		&i.VerifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listDomains = `-- name: ListDomains :many
+SELECT id, workspace_id, hostname, verification_token, verified_at, created_at
+FROM domains
+WHERE workspace_id = $1
+ORDER BY hostname
+`
+
+func (q *Queries) ListDomains(ctx context.Context, workspaceID int32) ([]Domain, error) {
+	rows, err := q.db.Query(ctx, listDomains, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Domain{}
+	for rows.Next() {
+		var i Domain
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkspaceID,
+			&i.Hostname,
+			&i.VerificationToken,
+			&i.VerifiedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const verifyDomain = `-- name: VerifyDomain :one
+UPDATE domains
+SET verified_at = CURRENT_TIMESTAMP
+WHERE id = $1
+AND workspace_id = $2
+RETURNING id, workspace_id, hostname, verification_token, verified_at, created_at
+`
+
+type VerifyDomainParams struct {
+	ID          int32 `json:"id"`
+	WorkspaceID int32 `json:"workspace_id"`
+}
+
+func (q *Queries) VerifyDomain(ctx context.Context, arg VerifyDomainParams) (Domain, error) {
+	row := q.db.QueryRow(ctx, verifyDomain, arg.ID, arg.WorkspaceID)
+	var i Domain
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Hostname,
+		&i.VerificationToken,
+		&i.VerifiedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -8,6 +8,17 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiToken struct {
+	ID          int32            `json:"id"`
+	UserID      int32            `json:"user_id"`
+	Name        string           `json:"name"`
+	TokenPrefix string           `json:"token_prefix"`
+	TokenHash   []byte           `json:"token_hash"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	LastUsedAt  pgtype.Timestamp `json:"last_used_at"`
+	RevokedAt   pgtype.Timestamp `json:"revoked_at"`
+}
+
 type Browser struct {
 	ID        pgtype.UUID        `json:"id"`
 	Name      string             `json:"name"`
@@ -18,10 +29,26 @@ type Browser struct {
 }
 
 type Customer struct {
-	UserID    pgtype.UUID      `json:"user_id"`
-	StripeID  string           `json:"stripe_id"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+	WorkspaceGuid pgtype.UUID      `json:"workspace_guid"`
+	StripeID      string           `json:"stripe_id"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+}
+
+type Domain struct {
+	ID                int32            `json:"id"`
+	WorkspaceID       int32            `json:"workspace_id"`
+	Hostname          string           `json:"hostname"`
+	VerificationToken string           `json:"verification_token"`
+	VerifiedAt        pgtype.Timestamp `json:"verified_at"`
+	CreatedAt         pgtype.Timestamp `json:"created_at"`
+}
+
+type Folder struct {
+	ID          int32            `json:"id"`
+	Name        string           `json:"name"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	WorkspaceID int32            `json:"workspace_id"`
 }
 
 type ModerationFlag struct {
@@ -60,15 +87,68 @@ type Subscription struct {
 	UpdatedAt         pgtype.Timestamp `json:"updated_at"`
 }
 
+type Tag struct {
+	ID          int32  `json:"id"`
+	Name        string `json:"name"`
+	WorkspaceID int32  `json:"workspace_id"`
+}
+
 type Url struct {
-	ID         int32            `json:"id"`
-	ShortUrl   string           `json:"short_url"`
-	LongUrl    string           `json:"long_url"`
-	AuthorID   int32            `json:"author_id"`
-	CreatedAt  pgtype.Timestamp `json:"created_at"`
-	Title      string           `json:"title"`
-	IsArchived pgtype.Bool      `json:"is_archived"`
-	IsActive   bool             `json:"is_active"`
+	ID             int32            `json:"id"`
+	ShortUrl       string           `json:"short_url"`
+	LongUrl        string           `json:"long_url"`
+	AuthorID       pgtype.Int4      `json:"author_id"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	Title          string           `json:"title"`
+	IsArchived     pgtype.Bool      `json:"is_archived"`
+	IsActive       bool             `json:"is_active"`
+	ExpiresAt      pgtype.Timestamp `json:"expires_at"`
+	MaxClicks      pgtype.Int4      `json:"max_clicks"`
+	PasswordHash   []byte           `json:"password_hash"`
+	PasswordSalt   []byte           `json:"password_salt"`
+	RedirectStatus int16            `json:"redirect_status"`
+	ForwardQuery   bool             `json:"forward_query"`
+	StickyVariants bool             `json:"sticky_variants"`
+	FolderID       pgtype.Int4      `json:"folder_id"`
+	WorkspaceID    int32            `json:"workspace_id"`
+	Domain         string           `json:"domain"`
+	Clicks         int32            `json:"clicks"`
+}
+
+type UrlDestinationHistory struct {
+	ID        int32            `json:"id"`
+	UrlID     int32            `json:"url_id"`
+	LongUrl   string           `json:"long_url"`
+	ChangedBy pgtype.Int4      `json:"changed_by"`
+	ChangedAt pgtype.Timestamp `json:"changed_at"`
+}
+
+type UrlRedirectRule struct {
+	ID        int32            `json:"id"`
+	UrlID     int32            `json:"url_id"`
+	Position  int32            `json:"position"`
+	LongUrl   string           `json:"long_url"`
+	Platforms []string         `json:"platforms"`
+	Mobile    pgtype.Bool      `json:"mobile"`
+	Countries []string         `json:"countries"`
+	Languages []string         `json:"languages"`
+	StartsAt  pgtype.Timestamp `json:"starts_at"`
+	EndsAt    pgtype.Timestamp `json:"ends_at"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type UrlTag struct {
+	UrlID int32 `json:"url_id"`
+	TagID int32 `json:"tag_id"`
+}
+
+type UrlVariant struct {
+	ID        int32            `json:"id"`
+	UrlID     int32            `json:"url_id"`
+	Position  int32            `json:"position"`
+	LongUrl   string           `json:"long_url"`
+	Weight    int32            `json:"weight"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
 }
 
 type User struct {
@@ -97,13 +177,17 @@ type UserRole struct {
 }
 
 type Visit struct {
-	ID        int32            `json:"id"`
-	UrlID     int32            `json:"url_id"`
-	VisitedAt pgtype.Timestamp `json:"visited_at"`
-	IpAddress pgtype.Text      `json:"ip_address"`
-	UserAgent pgtype.Text      `json:"user_agent"`
-	BrowserID pgtype.UUID      `json:"browser_id"`
-	Referrer  pgtype.Text      `json:"referrer"`
+	ID             int32            `json:"id"`
+	UrlID          int32            `json:"url_id"`
+	VisitedAt      pgtype.Timestamp `json:"visited_at"`
+	IpAddress      pgtype.Text      `json:"ip_address"`
+	UserAgent      pgtype.Text      `json:"user_agent"`
+	BrowserID      pgtype.UUID      `json:"browser_id"`
+	Referrer       pgtype.Text      `json:"referrer"`
+	RedirectRuleID pgtype.Int4      `json:"redirect_rule_id"`
+	VariantID      pgtype.Int4      `json:"variant_id"`
+	IsBot          bool             `json:"is_bot"`
+	Source         pgtype.Text      `json:"source"`
 }
 
 type VisitLocation struct {
@@ -118,3 +202,58 @@ type VisitLocation struct {
 	Longitude   pgtype.Float8 `json:"longitude"`
 	Source      pgtype.Text   `json:"source"`
 }
+
+type WebhookDelivery struct {
+	ID             int64            `json:"id"`
+	EndpointID     int32            `json:"endpoint_id"`
+	Event          string           `json:"event"`
+	Payload        []byte           `json:"payload"`
+	Status         string           `json:"status"`
+	Attempts       int32            `json:"attempts"`
+	NextAttemptAt  pgtype.Timestamp `json:"next_attempt_at"`
+	LastAttemptAt  pgtype.Timestamp `json:"last_attempt_at"`
+	ResponseStatus pgtype.Int4      `json:"response_status"`
+	LastError      string           `json:"last_error"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+}
+
+type WebhookEndpoint struct {
+	ID          int32            `json:"id"`
+	Url         string           `json:"url"`
+	Secret      string           `json:"secret"`
+	Events      []string         `json:"events"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	WorkspaceID int32            `json:"workspace_id"`
+}
+
+type WebhookThreshold struct {
+	EndpointID int32 `json:"endpoint_id"`
+	UrlID      int32 `json:"url_id"`
+	Threshold  int64 `json:"threshold"`
+}
+
+type Workspace struct {
+	ID         int32            `json:"id"`
+	Guid       pgtype.UUID      `json:"guid"`
+	Name       string           `json:"name"`
+	PersonalOf pgtype.Int4      `json:"personal_of"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type WorkspaceInvitation struct {
+	ID          int32            `json:"id"`
+	WorkspaceID int32            `json:"workspace_id"`
+	Email       string           `json:"email"`
+	Role        string           `json:"role"`
+	TokenHash   []byte           `json:"token_hash"`
+	InvitedBy   pgtype.Int4      `json:"invited_by"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceID int32            `json:"workspace_id"`
+	UserID      int32            `json:"user_id"`
+	Role        string           `json:"role"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
@@ -11,6 +11,9 @@ import (
 )
 
 type Querier interface {
+	// Members keep their role when they accept another invitation to the same
+	// workspace.
+	AcceptInvitation(ctx context.Context, arg AcceptInvitationParams) error
 	AddShortURL(ctx context.Context, arg AddShortURLParams) (Url, error)
 	AdminDeleteURL(ctx context.Context, id int32) error
 	AdminGetDailyUniqueVisitors(ctx context.Context) ([]AdminGetDailyUniqueVisitorsRow, error)
@@ -25,6 +28,8 @@ type Querier interface {
 	AdminGetUserGrowth(ctx context.Context) ([]AdminGetUserGrowthRow, error)
 	AdminListURLSDetails(ctx context.Context, arg AdminListURLSDetailsParams) ([]AdminListURLSDetailsRow, error)
 	AdminListUserURLs(ctx context.Context, guid pgtype.UUID) ([]AdminListUserURLsRow, error)
+	// The plan of a user is the one of their personal workspace, which has the
+	// guid of its user.
 	AdminListUsers(ctx context.Context, arg AdminListUsersParams) ([]AdminListUsersRow, error)
 	AdminToggleUserURLs(ctx context.Context, arg AdminToggleUserURLsParams) error
 	AdminUpdateURL(ctx context.Context, arg AdminUpdateURLParams) (Url, error)
@@ -32,52 +37,168 @@ type Querier interface {
 	ArchiveURL(ctx context.Context, arg ArchiveURLParams) error
 	// SQL query to get the distribution of browsers for a specific URL
 	BrowserDistribution(ctx context.Context, arg BrowserDistributionParams) ([]BrowserDistributionRow, error)
-	CountTotalVisitThisMonth(ctx context.Context, authorID int32) (int64, error)
-	CountURLThisMonth(ctx context.Context, authorID int32) (int64, error)
+	// Due deliveries are leased until lease_until so that a dispatcher dying
+	// midway doesn't lose them, and skipped by the other dispatchers meanwhile.
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error)
+	// Counts a redirect toward the click limit of a link. No row is returned once
+	// the limit is reached.
+	CountClick(ctx context.Context, id int32) (int32, error)
+	CountDomainURLs(ctx context.Context, arg CountDomainURLsParams) (int32, error)
+	CountDomains(ctx context.Context, workspaceID int32) (int32, error)
+	CountFolders(ctx context.Context, workspaceID int32) (int32, error)
+	CountLinkVisits(ctx context.Context, urlIds []int32) ([]CountLinkVisitsRow, error)
+	CountLinksPerAuthor(ctx context.Context, arg CountLinksPerAuthorParams) (int32, error)
+	CountOwnedWorkspaces(ctx context.Context, userID int32) (int32, error)
+	CountRedirectRuleVisits(ctx context.Context, urlID int32) ([]CountRedirectRuleVisitsRow, error)
+	CountTotalVisitThisMonth(ctx context.Context, workspaceID int32) (int64, error)
+	CountURLThisMonth(ctx context.Context, workspaceID int32) (int64, error)
+	CountVariantVisits(ctx context.Context, urlID int32) ([]CountVariantVisitsRow, error)
+	DeleteDomain(ctx context.Context, arg DeleteDomainParams) (int64, error)
+	DeleteFolder(ctx context.Context, arg DeleteFolderParams) (int64, error)
+	DeleteInvitation(ctx context.Context, arg DeleteInvitationParams) (int64, error)
+	DeleteMember(ctx context.Context, arg DeleteMemberParams) (int64, error)
+	DeleteRedirectRulesExcept(ctx context.Context, arg DeleteRedirectRulesExceptParams) error
 	DeleteURL(ctx context.Context, arg DeleteURLParams) error
+	DeleteURLTags(ctx context.Context, urlID int32) error
+	DeleteUnusedTags(ctx context.Context, workspaceID int32) error
 	DeleteUser(ctx context.Context, guid pgtype.UUID) error
+	DeleteVariantsExcept(ctx context.Context, arg DeleteVariantsExceptParams) error
+	DeleteWebhookDeliveriesBefore(ctx context.Context, before pgtype.Timestamp) (int64, error)
+	DeleteWebhookEndpoint(ctx context.Context, arg DeleteWebhookEndpointParams) (int64, error)
 	DeviceDistribution(ctx context.Context, arg DeviceDistributionParams) ([]DeviceDistributionRow, error)
+	EnsurePersonalWorkspace(ctx context.Context, arg EnsurePersonalWorkspaceParams) error
+	// The highest id is a cheap, slightly pessimistic, stand-in for count(*): it
+	// uses the primary key index and also counts deleted links.
+	EstimateURLCount(ctx context.Context) (int64, error)
 	GetByID(ctx context.Context, id int32) (Url, error)
-	GetCustomer(ctx context.Context, userID pgtype.UUID) (Customer, error)
+	GetCustomer(ctx context.Context, workspaceGuid pgtype.UUID) (Customer, error)
 	// Description: Get customer by stripe id
 	GetCustomerByStripeId(ctx context.Context, stripeID string) (Customer, error)
+	GetDomain(ctx context.Context, arg GetDomainParams) (Domain, error)
+	GetFolder(ctx context.Context, arg GetFolderParams) (Folder, error)
+	GetInvitationByToken(ctx context.Context, tokenHash []byte) (GetInvitationByTokenRow, error)
+	GetMemberWorkspace(ctx context.Context, arg GetMemberWorkspaceParams) (GetMemberWorkspaceRow, error)
 	GetModerationFlagByID(ctx context.Context, id int32) (ModerationFlag, error)
 	GetOauth2State(ctx context.Context, state string) (Oauth2State, error)
-	GetShortURL(ctx context.Context, shortUrl string) (Url, error)
+	GetPersonalWorkspace(ctx context.Context, userID int32) (GetPersonalWorkspaceRow, error)
+	// Redirects of a verified custom domain only follow its links, any other
+	// host follows the links of the default domain.
+	GetRedirectURL(ctx context.Context, arg GetRedirectURLParams) (Url, error)
+	GetShortURL(ctx context.Context, arg GetShortURLParams) (Url, error)
+	GetUserByAPIToken(ctx context.Context, tokenHash []byte) (GetUserByAPITokenRow, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByGUID(ctx context.Context, guid pgtype.UUID) (User, error)
 	GetUserByID(ctx context.Context, id int32) (User, error)
 	GetUserProvider(ctx context.Context, arg GetUserProviderParams) (UserProvider, error)
 	GetUserProviderByProviderUserId(ctx context.Context, arg GetUserProviderByProviderUserIdParams) (UserProvider, error)
+	InsertAPIToken(ctx context.Context, arg InsertAPITokenParams) (ApiToken, error)
+	// Browsers are inserted outside of the visits transaction and in a stable
+	// order, so concurrent batches neither hold nor wait on each other's rows.
+	InsertBrowsers(ctx context.Context, arg InsertBrowsersParams) error
 	InsertCustomer(ctx context.Context, arg InsertCustomerParams) (Customer, error)
+	InsertDomain(ctx context.Context, arg InsertDomainParams) (Domain, error)
+	InsertFolder(ctx context.Context, arg InsertFolderParams) (Folder, error)
 	InsertModerationFlag(ctx context.Context, arg InsertModerationFlagParams) (ModerationFlag, error)
 	InsertOauth2State(ctx context.Context, arg InsertOauth2StateParams) error
+	InsertRedirectRule(ctx context.Context, arg InsertRedirectRuleParams) (UrlRedirectRule, error)
 	InsertSubscription(ctx context.Context, arg InsertSubscriptionParams) (Subscription, error)
+	InsertURLTags(ctx context.Context, arg InsertURLTagsParams) error
 	InsertUserOauth(ctx context.Context, arg InsertUserOauthParams) (User, error)
 	InsertUserProvider(ctx context.Context, arg InsertUserProviderParams) (UserProvider, error)
+	InsertVariant(ctx context.Context, arg InsertVariantParams) (UrlVariant, error)
 	InsertVisitLocation(ctx context.Context, arg InsertVisitLocationParams) (VisitLocation, error)
+	InsertVisitLocations(ctx context.Context, arg []InsertVisitLocationsParams) (int64, error)
+	InsertVisits(ctx context.Context, arg []InsertVisitsParams) (int64, error)
+	InsertWebhookDeliveries(ctx context.Context, arg []InsertWebhookDeliveriesParams) (int64, error)
+	InsertWebhookEndpoint(ctx context.Context, arg InsertWebhookEndpointParams) (WebhookEndpoint, error)
+	InsertWorkspace(ctx context.Context, arg InsertWorkspaceParams) (InsertWorkspaceRow, error)
 	IsAdmin(ctx context.Context, guid pgtype.UUID) (bool, error)
+	ListAPITokens(ctx context.Context, userID int32) ([]ApiToken, error)
+	ListBrowsers(ctx context.Context, arg ListBrowsersParams) ([]ListBrowsersRow, error)
 	ListCustomerSubscription(ctx context.Context, arg ListCustomerSubscriptionParams) ([]Subscription, error)
+	ListDestinationHistory(ctx context.Context, urlID int32) ([]ListDestinationHistoryRow, error)
+	ListDomains(ctx context.Context, workspaceID int32) ([]Domain, error)
+	ListFolders(ctx context.Context, workspaceID int32) ([]ListFoldersRow, error)
+	ListInvitations(ctx context.Context, workspaceID int32) ([]ListInvitationsRow, error)
+	// Endpoints subscribed to any of the events of the links of their workspace,
+	// used to notify the visits of a batch.
+	ListLinkWebhookEndpoints(ctx context.Context, arg ListLinkWebhookEndpointsParams) ([]ListLinkWebhookEndpointsRow, error)
+	// The personal workspace comes first.
+	ListMemberWorkspaces(ctx context.Context, userID int32) ([]ListMemberWorkspacesRow, error)
 	ListModerationFlags(ctx context.Context) ([]ListModerationFlagsRow, error)
+	ListRedirectRules(ctx context.Context, urlID int32) ([]UrlRedirectRule, error)
 	ListShortURLs(ctx context.Context, arg ListShortURLsParams) ([]Url, error)
+	// search_query is a tsquery, an empty one matches every link. Without a
+	// page_size all the links are returned.
 	ListStatisticsPerAuthor(ctx context.Context, arg ListStatisticsPerAuthorParams) ([]ListStatisticsPerAuthorRow, error)
+	ListSubscribedWebhookEndpoints(ctx context.Context, arg ListSubscribedWebhookEndpointsParams) ([]int32, error)
+	ListURLTags(ctx context.Context, urlID int32) ([]string, error)
 	ListUserProviders(ctx context.Context, userID pgtype.UUID) ([]UserProvider, error)
-	ListVisits(ctx context.Context) ([]Visit, error)
+	ListVariants(ctx context.Context, urlID int32) ([]UrlVariant, error)
+	// Exports read visits a page at a time, oldest first, starting after the
+	// last visit of the previous page. A NULL url_id lists the visits of all the
+	// links of the workspace.
+	ListVisits(ctx context.Context, arg ListVisitsParams) ([]ListVisitsRow, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]ListWebhookDeliveriesRow, error)
+	ListWebhookEndpoints(ctx context.Context, workspaceID int32) ([]WebhookEndpoint, error)
+	ListWorkspaceMembers(ctx context.Context, workspaceID int32) ([]ListWorkspaceMembersRow, error)
 	// SQL query to get the location distribution data for a specific URL
 	LocationDistribution(ctx context.Context, arg LocationDistributionParams) ([]LocationDistributionRow, error)
+	// Links deleted while their visits were queued are left out of the batch,
+	// the ones returned can't be deleted until the transaction ends.
+	LockVisitedURLs(ctx context.Context, ids []int32) ([]int32, error)
+	// Locks the owners of a workspace until the end of the transaction, so that
+	// concurrent changes of its members can't leave it without any.
+	LockWorkspaceOwners(ctx context.Context, workspaceID int32) ([]int32, error)
+	QRScanCount(ctx context.Context, arg QRScanCountParams) (int64, error)
+	RaiseWebhookThreshold(ctx context.Context, arg RaiseWebhookThresholdParams) (int64, error)
+	RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) error
+	// Redelivering queues a copy of the delivery, the original stays in the log.
+	RedeliverWebhook(ctx context.Context, arg RedeliverWebhookParams) (int64, error)
 	ReferrerDistribution(ctx context.Context, arg ReferrerDistributionParams) ([]ReferrerDistributionRow, error)
+	// Ids are reserved up front so visits written with COPY can be referenced by
+	// their locations in the same batch.
+	ReserveVisitIDs(ctx context.Context, count int32) ([]int32, error)
+	RevokeAPIToken(ctx context.Context, arg RevokeAPITokenParams) (int64, error)
+	// Endpoints start from the thresholds the links of their workspace already
+	// reached, only the next ones are notified.
+	SeedWebhookThresholds(ctx context.Context, arg SeedWebhookThresholdsParams) error
+	SetURLFolder(ctx context.Context, arg SetURLFolderParams) error
 	StatisticPerURL(ctx context.Context, arg StatisticPerURLParams) (StatisticPerURLRow, error)
-	TotalVisit(ctx context.Context, urlID int32) (int64, error)
+	TagStatistics(ctx context.Context, arg TagStatisticsParams) ([]TagStatisticsRow, error)
+	TotalVisit(ctx context.Context, arg TotalVisitParams) (int64, error)
+	// Only write when the previous timestamp is stale so that busy tokens don't
+	// turn every API call into an UPDATE.
+	TouchAPIToken(ctx context.Context, id int32) error
 	TrackRedirect(ctx context.Context, arg TrackRedirectParams) (Visit, error)
 	UnarchiveURL(ctx context.Context, arg UnarchiveURLParams) error
-	UniqueVisitCount(ctx context.Context, urlID int32) (int64, error)
+	// The links are given as two arrays of the same length, slugs[i] being
+	// served on domains[i].
+	UnarchiveURLs(ctx context.Context, arg UnarchiveURLsParams) (int64, error)
+	UniqueVisitCount(ctx context.Context, arg UniqueVisitCountParams) (int64, error)
+	// Saves the current destination in url_destination_history before replacing
+	// it, in a single statement so the history can't miss a change.
+	UpdateDestination(ctx context.Context, arg UpdateDestinationParams) (Url, error)
+	UpdateExpiration(ctx context.Context, arg UpdateExpirationParams) (Url, error)
+	UpdateForwardQuery(ctx context.Context, arg UpdateForwardQueryParams) (Url, error)
+	UpdateMemberRole(ctx context.Context, arg UpdateMemberRoleParams) (int64, error)
 	UpdateModerationFlagStatus(ctx context.Context, arg UpdateModerationFlagStatusParams) error
+	UpdatePassword(ctx context.Context, arg UpdatePasswordParams) (Url, error)
+	UpdateRedirectRule(ctx context.Context, arg UpdateRedirectRuleParams) (UrlRedirectRule, error)
+	UpdateStickyVariants(ctx context.Context, arg UpdateStickyVariantsParams) (Url, error)
 	UpdateSubscription(ctx context.Context, arg UpdateSubscriptionParams) error
 	UpdateTitle(ctx context.Context, arg UpdateTitleParams) (Url, error)
 	UpdateURLStatus(ctx context.Context, arg UpdateURLStatusParams) error
 	UpdateUserSuspension(ctx context.Context, arg UpdateUserSuspensionParams) error
 	UpdateUserSuspensionByID(ctx context.Context, arg UpdateUserSuspensionByIDParams) error
-	UpsertBrowser(ctx context.Context, arg UpsertBrowserParams) (Browser, error)
+	UpdateVariant(ctx context.Context, arg UpdateVariantParams) (UrlVariant, error)
+	// Inviting an email again replaces its previous invitation.
+	UpsertInvitation(ctx context.Context, arg UpsertInvitationParams) (WorkspaceInvitation, error)
+	// Conflicting names are updated so that their ids are returned as well.
+	UpsertTags(ctx context.Context, arg UpsertTagsParams) ([]int32, error)
+	VerifyDomain(ctx context.Context, arg VerifyDomainParams) (Domain, error)
+	// Visits are stored in UTC, buckets start at midnight, or the top of the
+	// hour, in the timezone of the viewer.
 	VisitOverTime(ctx context.Context, arg VisitOverTimeParams) ([]VisitOverTimeRow, error)
 }
 
@@ -27,11 +27,20 @@ CROSS JOIN (
   FROM visits
   JOIN urls ON urls.id = visits.url_id
   WHERE urls.id = $1
+  AND ($2::BOOLEAN OR NOT visits.is_bot)
+    AND ($3::TIMESTAMP IS NULL OR visits.visited_at >= $3)
+    AND ($4::TIMESTAMP IS NULL OR visits.visited_at < $4)
 ) AS total_visits
 WHERE
-	u.author_id = $2
+	u.workspace_id = $5
 AND
     u.id = $1
+AND
+    ($2::BOOLEAN OR NOT v.is_bot)
+AND
+    ($3::TIMESTAMP IS NULL OR v.visited_at >= $3)
+AND
+    ($4::TIMESTAMP IS NULL OR v.visited_at < $4)
 GROUP BY
     browsers.name, 
     total_visits.total
@@ -40,8 +49,11 @@ ORDER BY
 `
 
 type BrowserDistributionParams struct {
-	UrlID    int32 `json:"url_id"`
-	AuthorID int32 `json:"author_id"`
+	UrlID       int32            `json:"url_id"`
+	IncludeBots bool             `json:"include_bots"`
+	StartDate   pgtype.Timestamp `json:"start_date"`
+	EndDate     pgtype.Timestamp `json:"end_date"`
+	WorkspaceID int32            `json:"workspace_id"`
 }
 
 type BrowserDistributionRow struct {
@@ -52,7 +64,13 @@ type BrowserDistributionRow struct {
 
 // SQL query to get the distribution of browsers for a specific URL
 func (q *Queries) BrowserDistribution(ctx context.Context, arg BrowserDistributionParams) ([]BrowserDistributionRow, error) {
-	rows, err := q.db.Query(ctx, browserDistribution, arg.UrlID, arg.AuthorID)
+	rows, err := q.db.Query(ctx, browserDistribution,
+		arg.UrlID,
+		arg.IncludeBots,
+		arg.StartDate,
+		arg.EndDate,
+		arg.WorkspaceID,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -71,16 +89,59 @@ func (q *Queries) BrowserDistribution(ctx context.Context, arg BrowserDistributi
 	return items, nil
 }
 
+const countLinksPerAuthor = `-- name: CountLinksPerAuthor :one
+SELECT count(*)::INTEGER
+FROM urls u
+WHERE
+	u.workspace_id = $1
+	AND COALESCE(u.is_archived, false) = $2::BOOLEAN
+	AND (
+		$3::TEXT = ''
+		OR to_tsvector('simple', regexp_replace(u.title || ' ' || u.short_url || ' ' || u.long_url, '[^[:alnum:]]+', ' ', 'g')) @@ to_tsquery('simple', $3::TEXT)
+	)
+	AND ($4::INTEGER IS NULL OR u.folder_id = $4)
+	AND (
+		$5::TEXT IS NULL
+		OR EXISTS (
+			SELECT 1
+			FROM url_tags ut
+			JOIN tags t ON t.id = ut.tag_id
+			WHERE ut.url_id = u.id AND t.name = $5
+		)
+	)
+`
+
+type CountLinksPerAuthorParams struct {
+	WorkspaceID int32       `json:"workspace_id"`
+	IsArchived  bool        `json:"is_archived"`
+	SearchQuery string      `json:"search_query"`
+	FolderID    pgtype.Int4 `json:"folder_id"`
+	Tag         pgtype.Text `json:"tag"`
+}
+
+func (q *Queries) CountLinksPerAuthor(ctx context.Context, arg CountLinksPerAuthorParams) (int32, error) {
+	row := q.db.QueryRow(ctx, countLinksPerAuthor,
+		arg.WorkspaceID,
+		arg.IsArchived,
+		arg.SearchQuery,
+		arg.FolderID,
+		arg.Tag,
+	)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const countTotalVisitThisMonth = `-- name: CountTotalVisitThisMonth :one
 SELECT count(visits.*)
 FROM visits
 JOIN urls ON urls.id = visits.url_id
-WHERE urls.author_id = $1
+WHERE urls.workspace_id = $1
 AND created_at BETWEEN date_trunc('month', CURRENT_DATE) AND date_trunc('month', CURRENT_DATE) + INTERVAL '1 month'
 `
 
-func (q *Queries) CountTotalVisitThisMonth(ctx context.Context, authorID int32) (int64, error) {
-	row := q.db.QueryRow(ctx, countTotalVisitThisMonth, authorID)
+func (q *Queries) CountTotalVisitThisMonth(ctx context.Context, workspaceID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countTotalVisitThisMonth, workspaceID)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -89,12 +150,12 @@ func (q *Queries) CountTotalVisitThisMonth(ctx context.Context, authorID int32)
 const countURLThisMonth = `-- name: CountURLThisMonth :one
 SELECT count(*)
 FROM urls
-WHERE author_id = $1
+WHERE workspace_id = $1
 AND created_at BETWEEN date_trunc('month', CURRENT_DATE) AND date_trunc('month', CURRENT_DATE) + INTERVAL '1 month'
 `
 
-func (q *Queries) CountURLThisMonth(ctx context.Context, authorID int32) (int64, error) {
-	row := q.db.QueryRow(ctx, countURLThisMonth, authorID)
+func (q *Queries) CountURLThisMonth(ctx context.Context, workspaceID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countURLThisMonth, workspaceID)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -116,19 +177,31 @@ CROSS JOIN (
   FROM visits
   JOIN urls ON urls.id = visits.url_id
   WHERE urls.id = $1
+  AND ($2::BOOLEAN OR NOT visits.is_bot)
+    AND ($3::TIMESTAMP IS NULL OR visits.visited_at >= $3)
+    AND ($4::TIMESTAMP IS NULL OR visits.visited_at < $4)
 ) AS total_visits
 WHERE
-	u.author_id = $2
+	u.workspace_id = $5
 AND
     u.id = $1
+AND
+    ($2::BOOLEAN OR NOT v.is_bot)
+AND
+    ($3::TIMESTAMP IS NULL OR v.visited_at >= $3)
+AND
+    ($4::TIMESTAMP IS NULL OR v.visited_at < $4)
 GROUP BY
     browsers.mobile, 
     total_visits.total
 `
 
 type DeviceDistributionParams struct {
-	UrlID    int32 `json:"url_id"`
-	AuthorID int32 `json:"author_id"`
+	UrlID       int32            `json:"url_id"`
+	IncludeBots bool             `json:"include_bots"`
+	StartDate   pgtype.Timestamp `json:"start_date"`
+	EndDate     pgtype.Timestamp `json:"end_date"`
+	WorkspaceID int32            `json:"workspace_id"`
 }
 
 type DeviceDistributionRow struct {
@@ -138,7 +211,13 @@ type DeviceDistributionRow struct {
 }
 
 func (q *Queries) DeviceDistribution(ctx context.Context, arg DeviceDistributionParams) ([]DeviceDistributionRow, error) {
-	rows, err := q.db.Query(ctx, deviceDistribution, arg.UrlID, arg.AuthorID)
+	rows, err := q.db.Query(ctx, deviceDistribution,
+		arg.UrlID,
+		arg.IncludeBots,
+		arg.StartDate,
+		arg.EndDate,
+		arg.WorkspaceID,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -157,6 +236,36 @@ func (q *Queries) DeviceDistribution(ctx context.Context, arg DeviceDistribution
 	return items, nil
 }
 
+const insertBrowsers = `-- name: InsertBrowsers :exec
+INSERT INTO browsers (name, version, platform, mobile)
+SELECT
+    unnest($1::TEXT[]) AS name,
+    unnest($2::TEXT[]) AS version,
+    unnest($3::TEXT[]) AS platform,
+    unnest($4::BOOLEAN[]) AS mobile
+ORDER BY 1, 2, 3, 4
+ON CONFLICT (name, version, platform, mobile) DO NOTHING
+`
+
+type InsertBrowsersParams struct {
+	Names     []string `json:"names"`
+	Versions  []string `json:"versions"`
+	Platforms []string `json:"platforms"`
+	Mobiles   []bool   `json:"mobiles"`
+}
+
+// Browsers are inserted outside of the visits transaction and in a stable
+// order, so concurrent batches neither hold nor wait on each other's rows.
+func (q *Queries) InsertBrowsers(ctx context.Context, arg InsertBrowsersParams) error {
+	_, err := q.db.Exec(ctx, insertBrowsers,
+		arg.Names,
+		arg.Versions,
+		arg.Platforms,
+		arg.Mobiles,
+	)
+	return err
+}
+
 const insertVisitLocation = `-- name: InsertVisitLocation :one
 INSERT INTO visit_locations (
 	visit_id,
@@ -227,46 +336,181 @@ func (q *Queries) InsertVisitLocation(ctx context.Context, arg InsertVisitLocati
 	return i, err
 }
 
+type InsertVisitLocationsParams struct {
+	VisitID     int32         `json:"visit_id"`
+	Address     pgtype.Text   `json:"address"`
+	CountryCode pgtype.Text   `json:"country_code"`
+	CountryName pgtype.Text   `json:"country_name"`
+	Subdivision pgtype.Text   `json:"subdivision"`
+	Continent   pgtype.Text   `json:"continent"`
+	CityName    pgtype.Text   `json:"city_name"`
+	Latitude    pgtype.Float8 `json:"latitude"`
+	Longitude   pgtype.Float8 `json:"longitude"`
+	Source      pgtype.Text   `json:"source"`
+}
+
+type InsertVisitsParams struct {
+	ID             int32            `json:"id"`
+	UrlID          int32            `json:"url_id"`
+	VisitedAt      pgtype.Timestamp `json:"visited_at"`
+	IpAddress      pgtype.Text      `json:"ip_address"`
+	UserAgent      pgtype.Text      `json:"user_agent"`
+	BrowserID      pgtype.UUID      `json:"browser_id"`
+	Referrer       pgtype.Text      `json:"referrer"`
+	RedirectRuleID pgtype.Int4      `json:"redirect_rule_id"`
+	VariantID      pgtype.Int4      `json:"variant_id"`
+	IsBot          bool             `json:"is_bot"`
+	Source         pgtype.Text      `json:"source"`
+}
+
+const listBrowsers = `-- name: ListBrowsers :many
+SELECT b.id, b.name, b.version, b.platform, b.mobile
+FROM browsers b
+JOIN (
+    SELECT
+        unnest($1::TEXT[]) AS name,
+        unnest($2::TEXT[]) AS version,
+        unnest($3::TEXT[]) AS platform,
+        unnest($4::BOOLEAN[]) AS mobile
+) k ON b.name = k.name AND b.version = k.version AND b.platform = k.platform AND b.mobile = k.mobile
+`
+
+type ListBrowsersParams struct {
+	Names     []string `json:"names"`
+	Versions  []string `json:"versions"`
+	Platforms []string `json:"platforms"`
+	Mobiles   []bool   `json:"mobiles"`
+}
+
+type ListBrowsersRow struct {
+	ID       pgtype.UUID `json:"id"`
+	Name     string      `json:"name"`
+	Version  string      `json:"version"`
+	Platform string      `json:"platform"`
+	Mobile   bool        `json:"mobile"`
+}
+
+func (q *Queries) ListBrowsers(ctx context.Context, arg ListBrowsersParams) ([]ListBrowsersRow, error) {
+	rows, err := q.db.Query(ctx, listBrowsers,
+		arg.Names,
+		arg.Versions,
+		arg.Platforms,
+		arg.Mobiles,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBrowsersRow{}
+	for rows.Next() {
+		var i ListBrowsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Version,
+			&i.Platform,
+			&i.Mobile,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listStatisticsPerAuthor = `-- name: ListStatisticsPerAuthor :many
 SELECT
 	count(v.id) as nr_visits,
 	MIN(u.id)::INTEGER as id,
 	u.short_url as short_url,
+	MIN(u.domain)::TEXT as domain,
 	MIN(u.title):: TEXT as title,
 	MIN(u.long_url):: TEXT as long_url,
-	MIN(u.created_at)::TIMESTAMP as created_at
+	MIN(u.created_at)::TIMESTAMP as created_at,
+	BOOL_OR(COALESCE(u.is_archived, false))::BOOLEAN as is_archived,
+	COALESCE(MIN(u.folder_id), 0)::INTEGER as folder_id,
+	COALESCE(MIN(f.name), '')::TEXT as folder_name,
+	COALESCE((
+		SELECT array_agg(t.name ORDER BY t.name)
+		FROM url_tags ut
+		JOIN tags t ON t.id = ut.tag_id
+		WHERE ut.url_id = u.id
+	), '{}')::TEXT[] as tags
 FROM
 	urls u
-LEFT JOIN visits v ON u.id = v.url_id
+LEFT JOIN visits v ON u.id = v.url_id AND NOT v.is_bot
+LEFT JOIN folders f ON f.id = u.folder_id
 WHERE
-	u.author_id = $1
+	u.workspace_id = $1
+	AND COALESCE(u.is_archived, false) = $2::BOOLEAN
+	AND (
+		$3::TEXT = ''
+		OR to_tsvector('simple', regexp_replace(u.title || ' ' || u.short_url || ' ' || u.long_url, '[^[:alnum:]]+', ' ', 'g')) @@ to_tsquery('simple', $3::TEXT)
+	)
+	AND ($4::INTEGER IS NULL OR u.folder_id = $4)
 	AND (
-		$2 = ''
-		-- OR to_tsvector(regexp_replace(long_url, '^(https?://)?(www\.)?', '', 'i')) @@ to_tsquery(@search)
-		OR long_url ILIKE '%' || $2 || '%'
+		$5::TEXT IS NULL
+		OR EXISTS (
+			SELECT 1
+			FROM url_tags ut
+			JOIN tags t ON t.id = ut.tag_id
+			WHERE ut.url_id = u.id AND t.name = $5
+		)
 	)
 GROUP BY
 	u.short_url, u.id
 ORDER BY
+	CASE WHEN $6::TEXT = 'oldest' THEN MIN(u.created_at) END ASC,
+	CASE WHEN $6::TEXT = 'most-clicked' THEN count(v.id) END DESC,
+	CASE WHEN $6::TEXT = 'least-clicked' THEN count(v.id) END ASC,
+	CASE WHEN $6::TEXT = 'folder' THEN MIN(f.name) END ASC NULLS LAST,
+	MIN(u.created_at) DESC,
 	u.id DESC
+LIMIT $8::INTEGER
+OFFSET $7::INTEGER
 `
 
 type ListStatisticsPerAuthorParams struct {
-	AuthorID int32       `json:"author_id"`
-	Search   interface{} `json:"search"`
+	WorkspaceID int32       `json:"workspace_id"`
+	IsArchived  bool        `json:"is_archived"`
+	SearchQuery string      `json:"search_query"`
+	FolderID    pgtype.Int4 `json:"folder_id"`
+	Tag         pgtype.Text `json:"tag"`
+	Sort        string      `json:"sort"`
+	PageOffset  int32       `json:"page_offset"`
+	PageSize    pgtype.Int4 `json:"page_size"`
 }
 
 type ListStatisticsPerAuthorRow struct {
-	NrVisits  int64            `json:"nr_visits"`
-	ID        int32            `json:"id"`
-	ShortUrl  string           `json:"short_url"`
-	Title     string           `json:"title"`
-	LongUrl   string           `json:"long_url"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
+	NrVisits   int64            `json:"nr_visits"`
+	ID         int32            `json:"id"`
+	ShortUrl   string           `json:"short_url"`
+	Domain     string           `json:"domain"`
+	Title      string           `json:"title"`
+	LongUrl    string           `json:"long_url"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	IsArchived bool             `json:"is_archived"`
+	FolderID   int32            `json:"folder_id"`
+	FolderName string           `json:"folder_name"`
+	Tags       []string         `json:"tags"`
 }
 
+// search_query is a tsquery, an empty one matches every link. Without a
+// page_size all the links are returned.
 func (q *Queries) ListStatisticsPerAuthor(ctx context.Context, arg ListStatisticsPerAuthorParams) ([]ListStatisticsPerAuthorRow, error) {
-	rows, err := q.db.Query(ctx, listStatisticsPerAuthor, arg.AuthorID, arg.Search)
+	rows, err := q.db.Query(ctx, listStatisticsPerAuthor,
+		arg.WorkspaceID,
+		arg.IsArchived,
+		arg.SearchQuery,
+		arg.FolderID,
+		arg.Tag,
+		arg.Sort,
+		arg.PageOffset,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -278,9 +522,14 @@ func (q *Queries) ListStatisticsPerAuthor(ctx context.Context, arg ListStatistic
 			&i.NrVisits,
 			&i.ID,
 			&i.ShortUrl,
+			&i.Domain,
 			&i.Title,
 			&i.LongUrl,
 			&i.CreatedAt,
+			&i.IsArchived,
+			&i.FolderID,
+			&i.FolderName,
+			&i.Tags,
 		); err != nil {
 			return nil, err
 		}
@@ -293,28 +542,98 @@ func (q *Queries) ListStatisticsPerAuthor(ctx context.Context, arg ListStatistic
 }
 
 const listVisits = `-- name: ListVisits :many
-SELECT id, url_id, visited_at, ip_address, user_agent, browser_id, referrer
-FROM visits
-ORDER BY id DESC
+SELECT
+	v.id,
+	v.visited_at,
+	u.short_url,
+	COALESCE(vl.country_code, '')::TEXT AS country_code,
+	COALESCE(vl.country_name, '')::TEXT AS country_name,
+	COALESCE(vl.city_name, '')::TEXT AS city_name,
+	COALESCE(b.name, '')::TEXT AS browser,
+	COALESCE(b.platform, '')::TEXT AS platform,
+	COALESCE(b.mobile, false)::BOOLEAN AS mobile,
+	COALESCE(v.referrer, '')::TEXT AS referrer,
+	v.is_bot
+FROM
+	visits v
+JOIN
+	urls u ON u.id = v.url_id
+LEFT JOIN
+	browsers b ON b.id = v.browser_id
+LEFT JOIN LATERAL (
+	SELECT country_code, country_name, city_name
+	FROM visit_locations
+	WHERE visit_locations.visit_id = v.id
+	LIMIT 1
+) vl ON true
+WHERE
+	u.workspace_id = $1
+	AND ($2::INTEGER IS NULL OR u.id = $2)
+	AND v.visited_at >= $3
+	AND v.visited_at < $4
+	AND ($5::BOOLEAN OR NOT v.is_bot)
+	AND v.id > $6
+ORDER BY
+	v.id
+LIMIT $7
 `
 
-func (q *Queries) ListVisits(ctx context.Context) ([]Visit, error) {
-	rows, err := q.db.Query(ctx, listVisits)
+type ListVisitsParams struct {
+	WorkspaceID int32            `json:"workspace_id"`
+	UrlID       pgtype.Int4      `json:"url_id"`
+	StartDate   pgtype.Timestamp `json:"start_date"`
+	EndDate     pgtype.Timestamp `json:"end_date"`
+	IncludeBots bool             `json:"include_bots"`
+	AfterID     int32            `json:"after_id"`
+	PageSize    int32            `json:"page_size"`
+}
+
+type ListVisitsRow struct {
+	ID          int32            `json:"id"`
+	VisitedAt   pgtype.Timestamp `json:"visited_at"`
+	ShortUrl    string           `json:"short_url"`
+	CountryCode string           `json:"country_code"`
+	CountryName string           `json:"country_name"`
+	CityName    string           `json:"city_name"`
+	Browser     string           `json:"browser"`
+	Platform    string           `json:"platform"`
+	Mobile      bool             `json:"mobile"`
+	Referrer    string           `json:"referrer"`
+	IsBot       bool             `json:"is_bot"`
+}
+
+// Exports read visits a page at a time, oldest first, starting after the
+// last visit of the previous page. A NULL url_id lists the visits of all the
+// links of the workspace.
+func (q *Queries) ListVisits(ctx context.Context, arg ListVisitsParams) ([]ListVisitsRow, error) {
+	rows, err := q.db.Query(ctx, listVisits,
+		arg.WorkspaceID,
+		arg.UrlID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.IncludeBots,
+		arg.AfterID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Visit{}
+	items := []ListVisitsRow{}
 	for rows.Next() {
-		var i Visit
+		var i ListVisitsRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.UrlID,
 			&i.VisitedAt,
-			&i.IpAddress,
-			&i.UserAgent,
-			&i.BrowserID,
+			&i.ShortUrl,
+			&i.CountryCode,
+			&i.CountryName,
+			&i.CityName,
+			&i.Browser,
+			&i.Platform,
+			&i.Mobile,
 			&i.Referrer,
+			&i.IsBot,
 		); err != nil {
 			return nil, err
 		}
@@ -338,11 +657,24 @@ JOIN
     visits v ON vl.visit_id = v.id
 JOIN
     urls u ON v.url_id = u.id
-CROSS JOIN (SELECT count(*) as total FROM visits WHERE visits.url_id = $1) AS total_visits
+CROSS JOIN (
+    SELECT count(*) as total
+    FROM visits
+    WHERE visits.url_id = $1
+    AND ($2::BOOLEAN OR NOT visits.is_bot)
+    AND ($3::TIMESTAMP IS NULL OR visits.visited_at >= $3)
+    AND ($4::TIMESTAMP IS NULL OR visits.visited_at < $4)
+) AS total_visits
 WHERE
-	u.author_id = $2
+	u.workspace_id = $5
 AND
     u.id = $1 -- Replace 'your_short_url' with the actual short URL
+AND
+    ($2::BOOLEAN OR NOT v.is_bot)
+AND
+    ($3::TIMESTAMP IS NULL OR v.visited_at >= $3)
+AND
+    ($4::TIMESTAMP IS NULL OR v.visited_at < $4)
 GROUP BY
     vl.country_code, vl.country_name, total_visits.total
 ORDER BY
@@ -350,8 +682,11 @@ ORDER BY
 `
 
 type LocationDistributionParams struct {
-	UrlID    int32 `json:"url_id"`
-	AuthorID int32 `json:"author_id"`
+	UrlID       int32            `json:"url_id"`
+	IncludeBots bool             `json:"include_bots"`
+	StartDate   pgtype.Timestamp `json:"start_date"`
+	EndDate     pgtype.Timestamp `json:"end_date"`
+	WorkspaceID int32            `json:"workspace_id"`
 }
 
 type LocationDistributionRow struct {
@@ -363,7 +698,13 @@ type LocationDistributionRow struct {
 
 // SQL query to get the location distribution data for a specific URL
 func (q *Queries) LocationDistribution(ctx context.Context, arg LocationDistributionParams) ([]LocationDistributionRow, error) {
-	rows, err := q.db.Query(ctx, locationDistribution, arg.UrlID, arg.AuthorID)
+	rows, err := q.db.Query(ctx, locationDistribution,
+		arg.UrlID,
+		arg.IncludeBots,
+		arg.StartDate,
+		arg.EndDate,
+		arg.WorkspaceID,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -387,6 +728,66 @@ func (q *Queries) LocationDistribution(ctx context.Context, arg LocationDistribu
 	return items, nil
 }
 
+const lockVisitedURLs = `-- name: LockVisitedURLs :many
+SELECT id FROM urls
+WHERE id = ANY($1::INTEGER[])
+ORDER BY id
+FOR KEY SHARE
+`
+
+// Links deleted while their visits were queued are left out of the batch,
+// the ones returned can't be deleted until the transaction ends.
+func (q *Queries) LockVisitedURLs(ctx context.Context, ids []int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, lockVisitedURLs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const qRScanCount = `-- name: QRScanCount :one
+SELECT count(*)
+FROM
+	visits
+WHERE
+	url_id = $1
+	AND source = 'qr'
+	AND ($2::BOOLEAN OR NOT is_bot)
+	AND ($3::TIMESTAMP IS NULL OR visited_at >= $3)
+	AND ($4::TIMESTAMP IS NULL OR visited_at < $4)
+`
+
+type QRScanCountParams struct {
+	UrlID       int32            `json:"url_id"`
+	IncludeBots bool             `json:"include_bots"`
+	StartDate   pgtype.Timestamp `json:"start_date"`
+	EndDate     pgtype.Timestamp `json:"end_date"`
+}
+
+func (q *Queries) QRScanCount(ctx context.Context, arg QRScanCountParams) (int64, error) {
+	row := q.db.QueryRow(ctx, qRScanCount,
+		arg.UrlID,
+		arg.IncludeBots,
+		arg.StartDate,
+		arg.EndDate,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const referrerDistribution = `-- name: ReferrerDistribution :many
 SELECT
     v.referrer AS source,
@@ -400,11 +801,20 @@ CROSS JOIN (
     SELECT count(*) AS total
     FROM visits
     WHERE visits.url_id = $1
+    AND ($2::BOOLEAN OR NOT visits.is_bot)
+    AND ($3::TIMESTAMP IS NULL OR visits.visited_at >= $3)
+    AND ($4::TIMESTAMP IS NULL OR visits.visited_at < $4)
 ) AS total_visits
 WHERE
-    u.author_id = $2
+    u.workspace_id = $5
 AND
     u.id = $1
+AND
+    ($2::BOOLEAN OR NOT v.is_bot)
+AND
+    ($3::TIMESTAMP IS NULL OR v.visited_at >= $3)
+AND
+    ($4::TIMESTAMP IS NULL OR v.visited_at < $4)
 AND 
     v.referrer IS NOT NULL AND v.referrer != '' -- Exclude empty or null referrers
 GROUP BY
@@ -414,8 +824,11 @@ ORDER BY
 `
 
 type ReferrerDistributionParams struct {
-	UrlID    int32 `json:"url_id"`
-	AuthorID int32 `json:"author_id"`
+	UrlID       int32            `json:"url_id"`
+	IncludeBots bool             `json:"include_bots"`
+	StartDate   pgtype.Timestamp `json:"start_date"`
+	EndDate     pgtype.Timestamp `json:"end_date"`
+	WorkspaceID int32            `json:"workspace_id"`
 }
 
 type ReferrerDistributionRow struct {
@@ -425,7 +838,13 @@ type ReferrerDistributionRow struct {
 }
 
 func (q *Queries) ReferrerDistribution(ctx context.Context, arg ReferrerDistributionParams) ([]ReferrerDistributionRow, error) {
-	rows, err := q.db.Query(ctx, referrerDistribution, arg.UrlID, arg.AuthorID)
+	rows, err := q.db.Query(ctx, referrerDistribution,
+		arg.UrlID,
+		arg.IncludeBots,
+		arg.StartDate,
+		arg.EndDate,
+		arg.WorkspaceID,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -444,46 +863,78 @@ func (q *Queries) ReferrerDistribution(ctx context.Context, arg ReferrerDistribu
 	return items, nil
 }
 
+const reserveVisitIDs = `-- name: ReserveVisitIDs :many
+SELECT nextval('visits_id_seq')::INTEGER AS id
+FROM generate_series(1, $1::INTEGER)
+`
+
+// Ids are reserved up front so visits written with COPY can be referenced by
+// their locations in the same batch.
+func (q *Queries) ReserveVisitIDs(ctx context.Context, count int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, reserveVisitIDs, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const statisticPerURL = `-- name: StatisticPerURL :one
 SELECT
 	count(v.id) as nr_visits,
 	MIN(u.id)::INTEGER as id,
 	u.short_url as short_url,
+	MIN(u.domain)::TEXT as domain,
 	MIN(u.title):: TEXT as title,
 	MIN(u.long_url):: TEXT as long_url,
 	MIN(u.created_at)::TIMESTAMP as created_at
 FROM
 	urls u
-LEFT JOIN visits v ON u.id = v.url_id
+LEFT JOIN visits v ON u.id = v.url_id AND NOT v.is_bot
 WHERE
 	u.short_url = $1
-	AND u.author_id = $2
+	AND u.domain = $2
+	AND u.workspace_id = $3
 GROUP BY
 	u.short_url, u.id
 LIMIT 1
 `
 
 type StatisticPerURLParams struct {
-	ShortUrl string `json:"short_url"`
-	AuthorID int32  `json:"author_id"`
+	ShortUrl    string `json:"short_url"`
+	Domain      string `json:"domain"`
+	WorkspaceID int32  `json:"workspace_id"`
 }
 
 type StatisticPerURLRow struct {
 	NrVisits  int64            `json:"nr_visits"`
 	ID        int32            `json:"id"`
 	ShortUrl  string           `json:"short_url"`
+	Domain    string           `json:"domain"`
 	Title     string           `json:"title"`
 	LongUrl   string           `json:"long_url"`
 	CreatedAt pgtype.Timestamp `json:"created_at"`
 }
 
 func (q *Queries) StatisticPerURL(ctx context.Context, arg StatisticPerURLParams) (StatisticPerURLRow, error) {
-	row := q.db.QueryRow(ctx, statisticPerURL, arg.ShortUrl, arg.AuthorID)
+	row := q.db.QueryRow(ctx, statisticPerURL, arg.ShortUrl, arg.Domain, arg.WorkspaceID)
 	var i StatisticPerURLRow
 	err := row.Scan(
 		&i.NrVisits,
 		&i.ID,
 		&i.ShortUrl,
+		&i.Domain,
 		&i.Title,
 		&i.LongUrl,
 		&i.CreatedAt,
@@ -497,10 +948,25 @@ FROM
 	visits
 WHERE
 	url_id = $1
+	AND ($2::BOOLEAN OR NOT is_bot)
+	AND ($3::TIMESTAMP IS NULL OR visited_at >= $3)
+	AND ($4::TIMESTAMP IS NULL OR visited_at < $4)
 `
 
-func (q *Queries) TotalVisit(ctx context.Context, urlID int32) (int64, error) {
-	row := q.db.QueryRow(ctx, totalVisit, urlID)
+type TotalVisitParams struct {
+	UrlID       int32            `json:"url_id"`
+	IncludeBots bool             `json:"include_bots"`
+	StartDate   pgtype.Timestamp `json:"start_date"`
+	EndDate     pgtype.Timestamp `json:"end_date"`
+}
+
+func (q *Queries) TotalVisit(ctx context.Context, arg TotalVisitParams) (int64, error) {
+	row := q.db.QueryRow(ctx, totalVisit,
+		arg.UrlID,
+		arg.IncludeBots,
+		arg.StartDate,
+		arg.EndDate,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -509,7 +975,7 @@ func (q *Queries) TotalVisit(ctx context.Context, urlID int32) (int64, error) {
 const trackRedirect = `-- name: TrackRedirect :one
 INSERT INTO visits (url_id, ip_address, user_agent, browser_id, referrer)
 VALUES ($1, $2, $3, $4, $5)
-RETURNING id, url_id, visited_at, ip_address, user_agent, browser_id, referrer
+RETURNING id, url_id, visited_at, ip_address, user_agent, browser_id, referrer, redirect_rule_id, variant_id, is_bot, source
 `
 
 type TrackRedirectParams struct {
@@ -537,71 +1003,56 @@ func (q *Queries) TrackRedirect(ctx context.Context, arg TrackRedirectParams) (V
 		&i.UserAgent,
 		&i.BrowserID,
 		&i.Referrer,
+		&i.RedirectRuleID,
+		&i.VariantID,
+		&i.IsBot,
+		&i.Source,
 	)
 	return i, err
 }
 
 const uniqueVisitCount = `-- name: UniqueVisitCount :one
-SELECT 
-	count(*)
+SELECT
+	count(DISTINCT ip_address)
 FROM
 	visits
 WHERE
 	url_id = $1
-GROUP BY 
-	url_id, ip_address
-`
-
-func (q *Queries) UniqueVisitCount(ctx context.Context, urlID int32) (int64, error) {
-	row := q.db.QueryRow(ctx, uniqueVisitCount, urlID)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
-}
-
-const upsertBrowser = `-- name: UpsertBrowser :one
-INSERT INTO browsers (name, version, platform, mobile)
-VALUES ($1, $2, $3, $4)
-ON CONFLICT (name, version, platform, mobile) 
-DO UPDATE SET name = EXCLUDED.name
-RETURNING id, name, version, platform, mobile, created_at
+	AND ($2::BOOLEAN OR NOT is_bot)
+	AND ($3::TIMESTAMP IS NULL OR visited_at >= $3)
+	AND ($4::TIMESTAMP IS NULL OR visited_at < $4)
 `
 
-type UpsertBrowserParams struct {
-	Name     string `json:"name"`
-	Version  string `json:"version"`
-	Platform string `json:"platform"`
-	Mobile   bool   `json:"mobile"`
+type UniqueVisitCountParams struct {
+	UrlID       int32            `json:"url_id"`
+	IncludeBots bool             `json:"include_bots"`
+	StartDate   pgtype.Timestamp `json:"start_date"`
+	EndDate     pgtype.Timestamp `json:"end_date"`
 }
 
-func (q *Queries) UpsertBrowser(ctx context.Context, arg UpsertBrowserParams) (Browser, error) {
-	row := q.db.QueryRow(ctx, upsertBrowser,
-		arg.Name,
-		arg.Version,
-		arg.Platform,
-		arg.Mobile,
-	)
-	var i Browser
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Version,
-		&i.Platform,
-		&i.Mobile,
-		&i.CreatedAt,
+func (q *Queries) UniqueVisitCount(ctx context.Context, arg UniqueVisitCountParams) (int64, error) {
+	row := q.db.QueryRow(ctx, uniqueVisitCount,
+		arg.UrlID,
+		arg.IncludeBots,
+		arg.StartDate,
+		arg.EndDate,
 	)
-	return i, err
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
 const visitOverTime = `-- name: VisitOverTime :many
 SELECT
-    date_trunc($1, visited_at)::timestamp AS visit_date,
+    date_trunc($1::TEXT, visited_at AT TIME ZONE 'UTC', $2::TEXT)::TIMESTAMPTZ AS visit_date,
     COUNT(*) AS visit_count
 FROM
     visits
 WHERE
-    url_id = $2
-    AND visited_at BETWEEN $3 AND $4
+    url_id = $3
+    AND visited_at >= $4
+    AND visited_at < $5
+    AND ($6::BOOLEAN OR NOT is_bot)
 GROUP BY
     visit_date
 ORDER BY
@@ -609,23 +1060,29 @@ ORDER BY
 `
 
 type VisitOverTimeParams struct {
-	TimeTrunc string           `json:"time_trunc"`
-	UrlID     int32            `json:"url_id"`
-	StartDate pgtype.Timestamp `json:"start_date"`
-	EndDate   pgtype.Timestamp `json:"end_date"`
+	TimeTrunc   string           `json:"time_trunc"`
+	Timezone    string           `json:"timezone"`
+	UrlID       int32            `json:"url_id"`
+	StartDate   pgtype.Timestamp `json:"start_date"`
+	EndDate     pgtype.Timestamp `json:"end_date"`
+	IncludeBots bool             `json:"include_bots"`
 }
 
 type VisitOverTimeRow struct {
-	VisitDate  pgtype.Timestamp `json:"visit_date"`
-	VisitCount int64            `json:"visit_count"`
+	VisitDate  pgtype.Timestamptz `json:"visit_date"`
+	VisitCount int64              `json:"visit_count"`
 }
 
+// Visits are stored in UTC, buckets start at midnight, or the top of the
+// hour, in the timezone of the viewer.
 func (q *Queries) VisitOverTime(ctx context.Context, arg VisitOverTimeParams) ([]VisitOverTimeRow, error) {
 	rows, err := q.db.Query(ctx, visitOverTime,
 		arg.TimeTrunc,
+		arg.Timezone,
 		arg.UrlID,
 		arg.StartDate,
 		arg.EndDate,
+		arg.IncludeBots,
 	)
 	if err != nil {
 		return nil, err
@@ -12,17 +12,17 @@ import (
 )
 
 const getCustomer = `-- name: GetCustomer :one
-SELECT user_id, stripe_id, created_at, updated_at
+SELECT workspace_guid, stripe_id, created_at, updated_at
 FROM customer
-WHERE user_id = $1
+WHERE workspace_guid = $1
 LIMIT 1
 `
 
-func (q *Queries) GetCustomer(ctx context.Context, userID pgtype.UUID) (Customer, error) {
-	row := q.db.QueryRow(ctx, getCustomer, userID)
+func (q *Queries) GetCustomer(ctx context.Context, workspaceGuid pgtype.UUID) (Customer, error) {
+	row := q.db.QueryRow(ctx, getCustomer, workspaceGuid)
 	var i Customer
 	err := row.Scan(
-		&i.UserID,
+		&i.WorkspaceGuid,
 		&i.StripeID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
@@ -31,7 +31,7 @@ func (q *Queries) GetCustomer(ctx context.Context, userID pgtype.UUID) (Customer
 }
 
 const getCustomerByStripeId = `-- name: GetCustomerByStripeId :one
-SELECT user_id, stripe_id, created_at, updated_at 
+SELECT workspace_guid, stripe_id, created_at, updated_at 
 FROM customer
 WHERE stripe_id = $1
 LIMIT 1
@@ -42,7 +42,7 @@ func (q *Queries) GetCustomerByStripeId(ctx context.Context, stripeID string) (C
 	row := q.db.QueryRow(ctx, getCustomerByStripeId, stripeID)
 	var i Customer
 	err := row.Scan(
-		&i.UserID,
+		&i.WorkspaceGuid,
 		&i.StripeID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
@@ -51,21 +51,21 @@ func (q *Queries) GetCustomerByStripeId(ctx context.Context, stripeID string) (C
 }
 
 const insertCustomer = `-- name: InsertCustomer :one
-INSERT INTO customer (user_id, stripe_id)
+INSERT INTO customer (workspace_guid, stripe_id)
 VALUES ($1, $2)
-RETURNING user_id, stripe_id, created_at, updated_at
+RETURNING workspace_guid, stripe_id, created_at, updated_at
 `
 
 type InsertCustomerParams struct {
-	UserID   pgtype.UUID `json:"user_id"`
-	StripeID string      `json:"stripe_id"`
+	WorkspaceGuid pgtype.UUID `json:"workspace_guid"`
+	StripeID      string      `json:"stripe_id"`
 }
 
 func (q *Queries) InsertCustomer(ctx context.Context, arg InsertCustomerParams) (Customer, error) {
-	row := q.db.QueryRow(ctx, insertCustomer, arg.UserID, arg.StripeID)
+	row := q.db.QueryRow(ctx, insertCustomer, arg.WorkspaceGuid, arg.StripeID)
 	var i Customer
 	err := row.Scan(
-		&i.UserID,
+		&i.WorkspaceGuid,
 		&i.StripeID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
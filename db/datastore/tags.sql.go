@@ -0,0 +1,304 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: tags.sql
+
+package datastore
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countFolders = `-- name: CountFolders :one
+SELECT COUNT(*)::INTEGER
+FROM folders
+WHERE workspace_id = $1
+`
+
+func (q *Queries) CountFolders(ctx context.Context, workspaceID int32) (int32, error) {
+	row := q.db.QueryRow(ctx, countFolders, workspaceID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const deleteFolder = `-- name: DeleteFolder :execrows
+DELETE FROM folders
+WHERE id = $1
+AND workspace_id = $2
+`
+
+type DeleteFolderParams struct {
+	ID          int32 `json:"id"`
+	WorkspaceID int32 `json:"workspace_id"`
+}
+
+func (q *Queries) DeleteFolder(ctx context.Context, arg DeleteFolderParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFolder, arg.ID, arg.WorkspaceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteURLTags = `-- name: DeleteURLTags :exec
+DELETE FROM url_tags
+WHERE url_id = $1
+`
+
+func (q *Queries) DeleteURLTags(ctx context.Context, urlID int32) error {
+	_, err := q.db.Exec(ctx, deleteURLTags, urlID)
+	return err
+}
+
+const deleteUnusedTags = `-- name: DeleteUnusedTags :exec
+DELETE FROM tags t
+WHERE t.workspace_id = $1
+AND NOT EXISTS (SELECT 1 FROM url_tags ut WHERE ut.tag_id = t.id)
+`
+
+func (q *Queries) DeleteUnusedTags(ctx context.Context, workspaceID int32) error {
+	_, err := q.db.Exec(ctx, deleteUnusedTags, workspaceID)
+	return err
+}
+
+const getFolder = `-- name: GetFolder :one
+SELECT id, name, created_at, workspace_id
+FROM folders
+WHERE id = $1
+AND workspace_id = $2
+`
+
+type GetFolderParams struct {
+	ID          int32 `json:"id"`
+	WorkspaceID int32 `json:"workspace_id"`
+}
+
+func (q *Queries) GetFolder(ctx context.Context, arg GetFolderParams) (Folder, error) {
+	row := q.db.QueryRow(ctx, getFolder, arg.ID, arg.WorkspaceID)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+	)
+	return i, err
+}
+
+const insertFolder = `-- name: InsertFolder :one
+INSERT INTO folders (workspace_id, name)
+VALUES ($1, $2)
+RETURNING id, name, created_at, workspace_id
+`
+
+type InsertFolderParams struct {
+	WorkspaceID int32  `json:"workspace_id"`
+	Name        string `json:"name"`
+}
+
+func (q *Queries) InsertFolder(ctx context.Context, arg InsertFolderParams) (Folder, error) {
+	row := q.db.QueryRow(ctx, insertFolder, arg.WorkspaceID, arg.Name)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+	)
+	return i, err
+}
+
+const insertURLTags = `-- name: InsertURLTags :exec
+INSERT INTO url_tags (url_id, tag_id)
+SELECT $1, unnest($2::INTEGER[])
+`
+
+type InsertURLTagsParams struct {
+	UrlID  int32   `json:"url_id"`
+	TagIds []int32 `json:"tag_ids"`
+}
+
+func (q *Queries) InsertURLTags(ctx context.Context, arg InsertURLTagsParams) error {
+	_, err := q.db.Exec(ctx, insertURLTags, arg.UrlID, arg.TagIds)
+	return err
+}
+
+const listFolders = `-- name: ListFolders :many
+SELECT
+    f.id,
+    f.name,
+    COUNT(u.id)::INTEGER AS links
+FROM folders f
+LEFT JOIN urls u ON u.folder_id = f.id AND NOT COALESCE(u.is_archived, false)
+WHERE f.workspace_id = $1
+GROUP BY f.id
+ORDER BY f.name
+`
+
+type ListFoldersRow struct {
+	ID    int32  `json:"id"`
+	Name  string `json:"name"`
+	Links int32  `json:"links"`
+}
+
+func (q *Queries) ListFolders(ctx context.Context, workspaceID int32) ([]ListFoldersRow, error) {
+	rows, err := q.db.Query(ctx, listFolders, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFoldersRow{}
+	for rows.Next() {
+		var i ListFoldersRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Links); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listURLTags = `-- name: ListURLTags :many
+SELECT t.name
+FROM url_tags ut
+JOIN tags t ON t.id = ut.tag_id
+WHERE ut.url_id = $1
+ORDER BY t.name
+`
+
+func (q *Queries) ListURLTags(ctx context.Context, urlID int32) ([]string, error) {
+	rows, err := q.db.Query(ctx, listURLTags, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setURLFolder = `-- name: SetURLFolder :exec
+UPDATE urls
+SET folder_id = $1
+WHERE id = $2
+`
+
+type SetURLFolderParams struct {
+	FolderID pgtype.Int4 `json:"folder_id"`
+	UrlID    int32       `json:"url_id"`
+}
+
+func (q *Queries) SetURLFolder(ctx context.Context, arg SetURLFolderParams) error {
+	_, err := q.db.Exec(ctx, setURLFolder, arg.FolderID, arg.UrlID)
+	return err
+}
+
+const tagStatistics = `-- name: TagStatistics :many
+SELECT
+    t.name,
+    COUNT(DISTINCT ut.url_id)::INTEGER AS links,
+    COUNT(v.id)::INTEGER AS clicks,
+    COUNT(DISTINCT v.ip_address)::INTEGER AS unique_visitors
+FROM tags t
+JOIN url_tags ut ON ut.tag_id = t.id
+LEFT JOIN visits v ON v.url_id = ut.url_id
+    AND ($1::BOOLEAN OR NOT v.is_bot)
+    AND ($2::TIMESTAMP IS NULL OR v.visited_at >= $2)
+    AND ($3::TIMESTAMP IS NULL OR v.visited_at < $3)
+WHERE t.workspace_id = $4
+GROUP BY t.id
+ORDER BY clicks DESC, t.name
+`
+
+type TagStatisticsParams struct {
+	IncludeBots bool             `json:"include_bots"`
+	StartDate   pgtype.Timestamp `json:"start_date"`
+	EndDate     pgtype.Timestamp `json:"end_date"`
+	WorkspaceID int32            `json:"workspace_id"`
+}
+
+type TagStatisticsRow struct {
+	Name           string `json:"name"`
+	Links          int32  `json:"links"`
+	Clicks         int32  `json:"clicks"`
+	UniqueVisitors int32  `json:"unique_visitors"`
+}
+
+func (q *Queries) TagStatistics(ctx context.Context, arg TagStatisticsParams) ([]TagStatisticsRow, error) {
+	rows, err := q.db.Query(ctx, tagStatistics,
+		arg.IncludeBots,
+		arg.StartDate,
+		arg.EndDate,
+		arg.WorkspaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TagStatisticsRow{}
+	for rows.Next() {
+		var i TagStatisticsRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Links,
+			&i.Clicks,
+			&i.UniqueVisitors,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertTags = `-- name: UpsertTags :many
+INSERT INTO tags (workspace_id, name)
+SELECT $1, unnest($2::TEXT[])
+ON CONFLICT (workspace_id, name)
+DO UPDATE SET name = EXCLUDED.name
+RETURNING id
+`
+
+type UpsertTagsParams struct {
+	WorkspaceID int32    `json:"workspace_id"`
+	Names       []string `json:"names"`
+}
+
+// Conflicting names are updated so that their ids are returned as well.
+func (q *Queries) UpsertTags(ctx context.Context, arg UpsertTagsParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, upsertTags, arg.WorkspaceID, arg.Names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
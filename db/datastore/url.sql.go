@@ -12,26 +12,42 @@ import (
 )
 
 const addShortURL = `-- name: AddShortURL :one
-INSERT INTO urls (title, short_url, long_url, author_id, is_active) 
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, short_url, long_url, author_id, created_at, title, is_archived, is_active
+INSERT INTO urls (title, short_url, domain, long_url, author_id, workspace_id, is_active, expires_at, max_clicks, password_hash, password_salt, redirect_status, forward_query)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING id, short_url, long_url, author_id, created_at, title, is_archived, is_active, expires_at, max_clicks, password_hash, password_salt, redirect_status, forward_query, sticky_variants, folder_id, workspace_id, domain, clicks
 `
 
 type AddShortURLParams struct {
-	Title    string `json:"title"`
-	ShortUrl string `json:"short_url"`
-	LongUrl  string `json:"long_url"`
-	AuthorID int32  `json:"author_id"`
-	IsActive bool   `json:"is_active"`
+	Title          string           `json:"title"`
+	ShortUrl       string           `json:"short_url"`
+	Domain         string           `json:"domain"`
+	LongUrl        string           `json:"long_url"`
+	AuthorID       pgtype.Int4      `json:"author_id"`
+	WorkspaceID    int32            `json:"workspace_id"`
+	IsActive       bool             `json:"is_active"`
+	ExpiresAt      pgtype.Timestamp `json:"expires_at"`
+	MaxClicks      pgtype.Int4      `json:"max_clicks"`
+	PasswordHash   []byte           `json:"password_hash"`
+	PasswordSalt   []byte           `json:"password_salt"`
+	RedirectStatus int16            `json:"redirect_status"`
+	ForwardQuery   bool             `json:"forward_query"`
 }
 
 func (q *Queries) AddShortURL(ctx context.Context, arg AddShortURLParams) (Url, error) {
 	row := q.db.QueryRow(ctx, addShortURL,
 		arg.Title,
 		arg.ShortUrl,
+		arg.Domain,
 		arg.LongUrl,
 		arg.AuthorID,
+		arg.WorkspaceID,
 		arg.IsActive,
+		arg.ExpiresAt,
+		arg.MaxClicks,
+		arg.PasswordHash,
+		arg.PasswordSalt,
+		arg.RedirectStatus,
+		arg.ForwardQuery,
 	)
 	var i Url
 	err := row.Scan(
@@ -43,6 +59,17 @@ func (q *Queries) AddShortURL(ctx context.Context, arg AddShortURLParams) (Url,
 		&i.Title,
 		&i.IsArchived,
 		&i.IsActive,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.PasswordHash,
+		&i.PasswordSalt,
+		&i.RedirectStatus,
+		&i.ForwardQuery,
+		&i.StickyVariants,
+		&i.FolderID,
+		&i.WorkspaceID,
+		&i.Domain,
+		&i.Clicks,
 	)
 	return i, err
 }
@@ -51,37 +78,70 @@ const archiveURL = `-- name: ArchiveURL :exec
 UPDATE urls
 SET is_archived = true
 WHERE urls.short_url = $1
-AND urls.author_id = $2
+AND urls.domain = $2
+AND urls.workspace_id = $3
 `
 
 type ArchiveURLParams struct {
-	ShortUrl string `json:"short_url"`
-	AuthorID int32  `json:"author_id"`
+	ShortUrl    string `json:"short_url"`
+	Domain      string `json:"domain"`
+	WorkspaceID int32  `json:"workspace_id"`
 }
 
 func (q *Queries) ArchiveURL(ctx context.Context, arg ArchiveURLParams) error {
-	_, err := q.db.Exec(ctx, archiveURL, arg.ShortUrl, arg.AuthorID)
+	_, err := q.db.Exec(ctx, archiveURL, arg.ShortUrl, arg.Domain, arg.WorkspaceID)
 	return err
 }
 
+const countClick = `-- name: CountClick :one
+UPDATE urls
+SET clicks = clicks + 1
+WHERE id = $1
+AND (max_clicks IS NULL OR clicks < max_clicks)
+RETURNING clicks
+`
+
+// Counts a redirect toward the click limit of a link. No row is returned once
+// the limit is reached.
+func (q *Queries) CountClick(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, countClick, id)
+	var clicks int32
+	err := row.Scan(&clicks)
+	return clicks, err
+}
+
 const deleteURL = `-- name: DeleteURL :exec
 DELETE FROM urls
 WHERE id = $1
-AND urls.author_id = $2
+AND urls.workspace_id = $2
 `
 
 type DeleteURLParams struct {
-	ID       int32 `json:"id"`
-	AuthorID int32 `json:"author_id"`
+	ID          int32 `json:"id"`
+	WorkspaceID int32 `json:"workspace_id"`
 }
 
 func (q *Queries) DeleteURL(ctx context.Context, arg DeleteURLParams) error {
-	_, err := q.db.Exec(ctx, deleteURL, arg.ID, arg.AuthorID)
+	_, err := q.db.Exec(ctx, deleteURL, arg.ID, arg.WorkspaceID)
 	return err
 }
 
+const estimateURLCount = `-- name: EstimateURLCount :one
+SELECT COALESCE(MAX(id), 0)::BIGINT AS count
+FROM urls
+`
+
+// The highest id is a cheap, slightly pessimistic, stand-in for count(*): it
+// uses the primary key index and also counts deleted links.
+func (q *Queries) EstimateURLCount(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, estimateURLCount)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getByID = `-- name: GetByID :one
-SELECT id, short_url, long_url, author_id, created_at, title, is_archived, is_active
+SELECT id, short_url, long_url, author_id, created_at, title, is_archived, is_active, expires_at, max_clicks, password_hash, password_salt, redirect_status, forward_query, sticky_variants, folder_id, workspace_id, domain, clicks
 FROM urls
 WHERE urls.id = $1
 `
@@ -98,18 +158,83 @@ func (q *Queries) GetByID(ctx context.Context, id int32) (Url, error) {
 		&i.Title,
 		&i.IsArchived,
 		&i.IsActive,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.PasswordHash,
+		&i.PasswordSalt,
+		&i.RedirectStatus,
+		&i.ForwardQuery,
+		&i.StickyVariants,
+		&i.FolderID,
+		&i.WorkspaceID,
+		&i.Domain,
+		&i.Clicks,
+	)
+	return i, err
+}
+
+const getRedirectURL = `-- name: GetRedirectURL :one
+SELECT urls.id, urls.short_url, urls.long_url, urls.author_id, urls.created_at, urls.title, urls.is_archived, urls.is_active, urls.expires_at, urls.max_clicks, urls.password_hash, urls.password_salt, urls.redirect_status, urls.forward_query, urls.sticky_variants, urls.folder_id, urls.workspace_id, urls.domain, urls.clicks
+FROM urls
+WHERE urls.short_url = $1
+AND urls.domain = COALESCE((
+    SELECT domains.hostname
+    FROM domains
+    WHERE domains.hostname = $2
+    AND domains.verified_at IS NOT NULL
+), '')
+`
+
+type GetRedirectURLParams struct {
+	ShortUrl string `json:"short_url"`
+	Host     string `json:"host"`
+}
+
+// Redirects of a verified custom domain only follow its links, any other
+// host follows the links of the default domain.
+func (q *Queries) GetRedirectURL(ctx context.Context, arg GetRedirectURLParams) (Url, error) {
+	row := q.db.QueryRow(ctx, getRedirectURL, arg.ShortUrl, arg.Host)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortUrl,
+		&i.LongUrl,
+		&i.AuthorID,
+		&i.CreatedAt,
+		&i.Title,
+		&i.IsArchived,
+		&i.IsActive,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.PasswordHash,
+		&i.PasswordSalt,
+		&i.RedirectStatus,
+		&i.ForwardQuery,
+		&i.StickyVariants,
+		&i.FolderID,
+		&i.WorkspaceID,
+		&i.Domain,
+		&i.Clicks,
 	)
 	return i, err
 }
 
 const getShortURL = `-- name: GetShortURL :one
-SELECT id, short_url, long_url, author_id, created_at, title, is_archived, is_active
+SELECT id, short_url, long_url, author_id, created_at, title, is_archived, is_active, expires_at, max_clicks, password_hash, password_salt, redirect_status, forward_query, sticky_variants, folder_id, workspace_id, domain, clicks
 FROM urls
 WHERE urls.short_url = $1
+AND urls.domain = $2
+AND urls.workspace_id = $3
 `
 
-func (q *Queries) GetShortURL(ctx context.Context, shortUrl string) (Url, error) {
-	row := q.db.QueryRow(ctx, getShortURL, shortUrl)
+type GetShortURLParams struct {
+	ShortUrl    string `json:"short_url"`
+	Domain      string `json:"domain"`
+	WorkspaceID int32  `json:"workspace_id"`
+}
+
+func (q *Queries) GetShortURL(ctx context.Context, arg GetShortURLParams) (Url, error) {
+	row := q.db.QueryRow(ctx, getShortURL, arg.ShortUrl, arg.Domain, arg.WorkspaceID)
 	var i Url
 	err := row.Scan(
 		&i.ID,
@@ -120,25 +245,73 @@ func (q *Queries) GetShortURL(ctx context.Context, shortUrl string) (Url, error)
 		&i.Title,
 		&i.IsArchived,
 		&i.IsActive,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.PasswordHash,
+		&i.PasswordSalt,
+		&i.RedirectStatus,
+		&i.ForwardQuery,
+		&i.StickyVariants,
+		&i.FolderID,
+		&i.WorkspaceID,
+		&i.Domain,
+		&i.Clicks,
 	)
 	return i, err
 }
 
+const listDestinationHistory = `-- name: ListDestinationHistory :many
+SELECT
+    h.long_url,
+    h.changed_at,
+    COALESCE(users.username, '')::TEXT AS changed_by
+FROM url_destination_history h
+LEFT JOIN users ON users.id = h.changed_by
+WHERE h.url_id = $1
+ORDER BY h.changed_at DESC, h.id DESC
+`
+
+type ListDestinationHistoryRow struct {
+	LongUrl   string           `json:"long_url"`
+	ChangedAt pgtype.Timestamp `json:"changed_at"`
+	ChangedBy string           `json:"changed_by"`
+}
+
+func (q *Queries) ListDestinationHistory(ctx context.Context, urlID int32) ([]ListDestinationHistoryRow, error) {
+	rows, err := q.db.Query(ctx, listDestinationHistory, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDestinationHistoryRow{}
+	for rows.Next() {
+		var i ListDestinationHistoryRow
+		if err := rows.Scan(&i.LongUrl, &i.ChangedAt, &i.ChangedBy); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listShortURLs = `-- name: ListShortURLs :many
-SELECT id, short_url, long_url, author_id, created_at, title, is_archived, is_active
+SELECT id, short_url, long_url, author_id, created_at, title, is_archived, is_active, expires_at, max_clicks, password_hash, password_salt, redirect_status, forward_query, sticky_variants, folder_id, workspace_id, domain, clicks
 FROM urls
-WHERE urls.author_id = $1
+WHERE urls.workspace_id = $1
 AND urls.is_archived = $2
 ORDER BY id DESC
 `
 
 type ListShortURLsParams struct {
-	AuthorID   int32       `json:"author_id"`
-	IsArchived pgtype.Bool `json:"is_archived"`
+	WorkspaceID int32       `json:"workspace_id"`
+	IsArchived  pgtype.Bool `json:"is_archived"`
 }
 
 func (q *Queries) ListShortURLs(ctx context.Context, arg ListShortURLsParams) ([]Url, error) {
-	rows, err := q.db.Query(ctx, listShortURLs, arg.AuthorID, arg.IsArchived)
+	rows, err := q.db.Query(ctx, listShortURLs, arg.WorkspaceID, arg.IsArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -155,6 +328,17 @@ func (q *Queries) ListShortURLs(ctx context.Context, arg ListShortURLsParams) ([
 			&i.Title,
 			&i.IsArchived,
 			&i.IsActive,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.PasswordHash,
+			&i.PasswordSalt,
+			&i.RedirectStatus,
+			&i.ForwardQuery,
+			&i.StickyVariants,
+			&i.FolderID,
+			&i.WorkspaceID,
+			&i.Domain,
+			&i.Clicks,
 		); err != nil {
 			return nil, err
 		}
@@ -170,35 +354,342 @@ const unarchiveURL = `-- name: UnarchiveURL :exec
 UPDATE urls
 SET is_archived = false
 WHERE urls.short_url = $1
-AND urls.author_id = $2
+AND urls.domain = $2
+AND urls.workspace_id = $3
 `
 
 type UnarchiveURLParams struct {
-	ShortUrl string `json:"short_url"`
-	AuthorID int32  `json:"author_id"`
+	ShortUrl    string `json:"short_url"`
+	Domain      string `json:"domain"`
+	WorkspaceID int32  `json:"workspace_id"`
 }
 
 func (q *Queries) UnarchiveURL(ctx context.Context, arg UnarchiveURLParams) error {
-	_, err := q.db.Exec(ctx, unarchiveURL, arg.ShortUrl, arg.AuthorID)
+	_, err := q.db.Exec(ctx, unarchiveURL, arg.ShortUrl, arg.Domain, arg.WorkspaceID)
 	return err
 }
 
+const unarchiveURLs = `-- name: UnarchiveURLs :execrows
+UPDATE urls
+SET is_archived = false
+FROM (
+    SELECT unnest($2::TEXT[]) AS domain, unnest($3::TEXT[]) AS short_url
+) refs
+WHERE urls.domain = refs.domain
+AND urls.short_url = refs.short_url
+AND urls.workspace_id = $1
+`
+
+type UnarchiveURLsParams struct {
+	WorkspaceID int32    `json:"workspace_id"`
+	Domains     []string `json:"domains"`
+	Slugs       []string `json:"slugs"`
+}
+
+// The links are given as two arrays of the same length, slugs[i] being
+// served on domains[i].
+func (q *Queries) UnarchiveURLs(ctx context.Context, arg UnarchiveURLsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, unarchiveURLs, arg.WorkspaceID, arg.Domains, arg.Slugs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateDestination = `-- name: UpdateDestination :one
+WITH previous AS (
+    SELECT id, long_url
+    FROM urls
+    WHERE urls.short_url = $5
+    AND urls.domain = $6
+    AND urls.workspace_id = $7
+    FOR UPDATE
+), history AS (
+    INSERT INTO url_destination_history (url_id, long_url, changed_by)
+    SELECT previous.id, previous.long_url, $8
+    FROM previous
+    WHERE previous.long_url <> $2
+)
+UPDATE urls
+SET title = $1,
+    long_url = $2,
+    is_active = $3,
+    redirect_status = $4
+FROM previous
+WHERE urls.id = previous.id
+RETURNING urls.id, urls.short_url, urls.long_url, urls.author_id, urls.created_at, urls.title, urls.is_archived, urls.is_active, urls.expires_at, urls.max_clicks, urls.password_hash, urls.password_salt, urls.redirect_status, urls.forward_query, urls.sticky_variants, urls.folder_id, urls.workspace_id, urls.domain, urls.clicks
+`
+
+type UpdateDestinationParams struct {
+	Title          string      `json:"title"`
+	LongUrl        string      `json:"long_url"`
+	IsActive       bool        `json:"is_active"`
+	RedirectStatus int16       `json:"redirect_status"`
+	ShortUrl       string      `json:"short_url"`
+	Domain         string      `json:"domain"`
+	WorkspaceID    int32       `json:"workspace_id"`
+	ChangedBy      pgtype.Int4 `json:"changed_by"`
+}
+
+// Saves the current destination in url_destination_history before replacing
+// it, in a single statement so the history can't miss a change.
+func (q *Queries) UpdateDestination(ctx context.Context, arg UpdateDestinationParams) (Url, error) {
+	row := q.db.QueryRow(ctx, updateDestination,
+		arg.Title,
+		arg.LongUrl,
+		arg.IsActive,
+		arg.RedirectStatus,
+		arg.ShortUrl,
+		arg.Domain,
+		arg.WorkspaceID,
+		arg.ChangedBy,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortUrl,
+		&i.LongUrl,
+		&i.AuthorID,
+		&i.CreatedAt,
+		&i.Title,
+		&i.IsArchived,
+		&i.IsActive,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.PasswordHash,
+		&i.PasswordSalt,
+		&i.RedirectStatus,
+		&i.ForwardQuery,
+		&i.StickyVariants,
+		&i.FolderID,
+		&i.WorkspaceID,
+		&i.Domain,
+		&i.Clicks,
+	)
+	return i, err
+}
+
+const updateExpiration = `-- name: UpdateExpiration :one
+UPDATE urls
+SET expires_at = $1,
+    max_clicks = $2
+WHERE urls.short_url = $3
+AND urls.domain = $4
+AND urls.workspace_id = $5
+RETURNING id, short_url, long_url, author_id, created_at, title, is_archived, is_active, expires_at, max_clicks, password_hash, password_salt, redirect_status, forward_query, sticky_variants, folder_id, workspace_id, domain, clicks
+`
+
+type UpdateExpirationParams struct {
+	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
+	MaxClicks   pgtype.Int4      `json:"max_clicks"`
+	ShortUrl    string           `json:"short_url"`
+	Domain      string           `json:"domain"`
+	WorkspaceID int32            `json:"workspace_id"`
+}
+
+func (q *Queries) UpdateExpiration(ctx context.Context, arg UpdateExpirationParams) (Url, error) {
+	row := q.db.QueryRow(ctx, updateExpiration,
+		arg.ExpiresAt,
+		arg.MaxClicks,
+		arg.ShortUrl,
+		arg.Domain,
+		arg.WorkspaceID,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortUrl,
+		&i.LongUrl,
+		&i.AuthorID,
+		&i.CreatedAt,
+		&i.Title,
+		&i.IsArchived,
+		&i.IsActive,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.PasswordHash,
+		&i.PasswordSalt,
+		&i.RedirectStatus,
+		&i.ForwardQuery,
+		&i.StickyVariants,
+		&i.FolderID,
+		&i.WorkspaceID,
+		&i.Domain,
+		&i.Clicks,
+	)
+	return i, err
+}
+
+const updateForwardQuery = `-- name: UpdateForwardQuery :one
+UPDATE urls
+SET forward_query = $1
+WHERE urls.short_url = $2
+AND urls.domain = $3
+AND urls.workspace_id = $4
+RETURNING id, short_url, long_url, author_id, created_at, title, is_archived, is_active, expires_at, max_clicks, password_hash, password_salt, redirect_status, forward_query, sticky_variants, folder_id, workspace_id, domain, clicks
+`
+
+type UpdateForwardQueryParams struct {
+	ForwardQuery bool   `json:"forward_query"`
+	ShortUrl     string `json:"short_url"`
+	Domain       string `json:"domain"`
+	WorkspaceID  int32  `json:"workspace_id"`
+}
+
+func (q *Queries) UpdateForwardQuery(ctx context.Context, arg UpdateForwardQueryParams) (Url, error) {
+	row := q.db.QueryRow(ctx, updateForwardQuery,
+		arg.ForwardQuery,
+		arg.ShortUrl,
+		arg.Domain,
+		arg.WorkspaceID,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortUrl,
+		&i.LongUrl,
+		&i.AuthorID,
+		&i.CreatedAt,
+		&i.Title,
+		&i.IsArchived,
+		&i.IsActive,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.PasswordHash,
+		&i.PasswordSalt,
+		&i.RedirectStatus,
+		&i.ForwardQuery,
+		&i.StickyVariants,
+		&i.FolderID,
+		&i.WorkspaceID,
+		&i.Domain,
+		&i.Clicks,
+	)
+	return i, err
+}
+
+const updatePassword = `-- name: UpdatePassword :one
+UPDATE urls
+SET password_hash = $1,
+    password_salt = $2
+WHERE urls.short_url = $3
+AND urls.domain = $4
+AND urls.workspace_id = $5
+RETURNING id, short_url, long_url, author_id, created_at, title, is_archived, is_active, expires_at, max_clicks, password_hash, password_salt, redirect_status, forward_query, sticky_variants, folder_id, workspace_id, domain, clicks
+`
+
+type UpdatePasswordParams struct {
+	PasswordHash []byte `json:"password_hash"`
+	PasswordSalt []byte `json:"password_salt"`
+	ShortUrl     string `json:"short_url"`
+	Domain       string `json:"domain"`
+	WorkspaceID  int32  `json:"workspace_id"`
+}
+
+func (q *Queries) UpdatePassword(ctx context.Context, arg UpdatePasswordParams) (Url, error) {
+	row := q.db.QueryRow(ctx, updatePassword,
+		arg.PasswordHash,
+		arg.PasswordSalt,
+		arg.ShortUrl,
+		arg.Domain,
+		arg.WorkspaceID,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortUrl,
+		&i.LongUrl,
+		&i.AuthorID,
+		&i.CreatedAt,
+		&i.Title,
+		&i.IsArchived,
+		&i.IsActive,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.PasswordHash,
+		&i.PasswordSalt,
+		&i.RedirectStatus,
+		&i.ForwardQuery,
+		&i.StickyVariants,
+		&i.FolderID,
+		&i.WorkspaceID,
+		&i.Domain,
+		&i.Clicks,
+	)
+	return i, err
+}
+
+const updateStickyVariants = `-- name: UpdateStickyVariants :one
+UPDATE urls
+SET sticky_variants = $1
+WHERE urls.short_url = $2
+AND urls.domain = $3
+AND urls.workspace_id = $4
+RETURNING id, short_url, long_url, author_id, created_at, title, is_archived, is_active, expires_at, max_clicks, password_hash, password_salt, redirect_status, forward_query, sticky_variants, folder_id, workspace_id, domain, clicks
+`
+
+type UpdateStickyVariantsParams struct {
+	StickyVariants bool   `json:"sticky_variants"`
+	ShortUrl       string `json:"short_url"`
+	Domain         string `json:"domain"`
+	WorkspaceID    int32  `json:"workspace_id"`
+}
+
+func (q *Queries) UpdateStickyVariants(ctx context.Context, arg UpdateStickyVariantsParams) (Url, error) {
+	row := q.db.QueryRow(ctx, updateStickyVariants,
+		arg.StickyVariants,
+		arg.ShortUrl,
+		arg.Domain,
+		arg.WorkspaceID,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.ShortUrl,
+		&i.LongUrl,
+		&i.AuthorID,
+		&i.CreatedAt,
+		&i.Title,
+		&i.IsArchived,
+		&i.IsActive,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.PasswordHash,
+		&i.PasswordSalt,
+		&i.RedirectStatus,
+		&i.ForwardQuery,
+		&i.StickyVariants,
+		&i.FolderID,
+		&i.WorkspaceID,
+		&i.Domain,
+		&i.Clicks,
+	)
+	return i, err
+}
+
 const updateTitle = `-- name: UpdateTitle :one
 UPDATE urls
 SET title = $1
 WHERE urls.short_url = $2
-AND urls.author_id = $3
-RETURNING id, short_url, long_url, author_id, created_at, title, is_archived, is_active
+AND urls.domain = $3
+AND urls.workspace_id = $4
+RETURNING id, short_url, long_url, author_id, created_at, title, is_archived, is_active, expires_at, max_clicks, password_hash, password_salt, redirect_status, forward_query, sticky_variants, folder_id, workspace_id, domain, clicks
 `
 
 type UpdateTitleParams struct {
-	Title    string `json:"title"`
-	ShortUrl string `json:"short_url"`
-	AuthorID int32  `json:"author_id"`
+	Title       string `json:"title"`
+	ShortUrl    string `json:"short_url"`
+	Domain      string `json:"domain"`
+	WorkspaceID int32  `json:"workspace_id"`
 }
 
 func (q *Queries) UpdateTitle(ctx context.Context, arg UpdateTitleParams) (Url, error) {
-	row := q.db.QueryRow(ctx, updateTitle, arg.Title, arg.ShortUrl, arg.AuthorID)
+	row := q.db.QueryRow(ctx, updateTitle,
+		arg.Title,
+		arg.ShortUrl,
+		arg.Domain,
+		arg.WorkspaceID,
+	)
 	var i Url
 	err := row.Scan(
 		&i.ID,
@@ -209,6 +700,17 @@ func (q *Queries) UpdateTitle(ctx context.Context, arg UpdateTitleParams) (Url,
 		&i.Title,
 		&i.IsArchived,
 		&i.IsActive,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.PasswordHash,
+		&i.PasswordSalt,
+		&i.RedirectStatus,
+		&i.ForwardQuery,
+		&i.StickyVariants,
+		&i.FolderID,
+		&i.WorkspaceID,
+		&i.Domain,
+		&i.Clicks,
 	)
 	return i, err
 }
@@ -216,15 +718,15 @@ func (q *Queries) UpdateTitle(ctx context.Context, arg UpdateTitleParams) (Url,
 const updateURLStatus = `-- name: UpdateURLStatus :exec
 UPDATE urls
 SET is_active = $1
-WHERE urls.short_url = $2
+WHERE urls.id = $2
 `
 
 type UpdateURLStatusParams struct {
-	IsActive bool   `json:"is_active"`
-	ShortUrl string `json:"short_url"`
+	IsActive bool  `json:"is_active"`
+	ID       int32 `json:"id"`
 }
 
 func (q *Queries) UpdateURLStatus(ctx context.Context, arg UpdateURLStatusParams) error {
-	_, err := q.db.Exec(ctx, updateURLStatus, arg.IsActive, arg.ShortUrl)
+	_, err := q.db.Exec(ctx, updateURLStatus, arg.IsActive, arg.ID)
 	return err
 }
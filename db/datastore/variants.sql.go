@@ -0,0 +1,168 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: variants.sql
+
+package datastore
+
+import (
+	"context"
+)
+
+const countVariantVisits = `-- name: CountVariantVisits :many
+SELECT
+    v.variant_id::INTEGER AS variant_id,
+    COUNT(*)::INTEGER AS visits,
+    COUNT(DISTINCT v.ip_address)::INTEGER AS unique_visitors
+FROM visits v
+JOIN url_variants uv ON uv.id = v.variant_id
+WHERE uv.url_id = $1
+AND NOT v.is_bot
+GROUP BY v.variant_id
+`
+
+type CountVariantVisitsRow struct {
+	VariantID      int32 `json:"variant_id"`
+	Visits         int32 `json:"visits"`
+	UniqueVisitors int32 `json:"unique_visitors"`
+}
+
+func (q *Queries) CountVariantVisits(ctx context.Context, urlID int32) ([]CountVariantVisitsRow, error) {
+	rows, err := q.db.Query(ctx, countVariantVisits, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountVariantVisitsRow{}
+	for rows.Next() {
+		var i CountVariantVisitsRow
+		if err := rows.Scan(&i.VariantID, &i.Visits, &i.UniqueVisitors); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteVariantsExcept = `-- name: DeleteVariantsExcept :exec
+DELETE FROM url_variants
+WHERE url_id = $1
+AND NOT (id = ANY($2::INTEGER[]))
+`
+
+type DeleteVariantsExceptParams struct {
+	UrlID int32   `json:"url_id"`
+	Keep  []int32 `json:"keep"`
+}
+
+func (q *Queries) DeleteVariantsExcept(ctx context.Context, arg DeleteVariantsExceptParams) error {
+	_, err := q.db.Exec(ctx, deleteVariantsExcept, arg.UrlID, arg.Keep)
+	return err
+}
+
+const insertVariant = `-- name: InsertVariant :one
+INSERT INTO url_variants (url_id, position, long_url, weight)
+VALUES ($1, $2, $3, $4)
+RETURNING id, url_id, position, long_url, weight, created_at
+`
+
+type InsertVariantParams struct {
+	UrlID    int32  `json:"url_id"`
+	Position int32  `json:"position"`
+	LongUrl  string `json:"long_url"`
+	Weight   int32  `json:"weight"`
+}
+
+func (q *Queries) InsertVariant(ctx context.Context, arg InsertVariantParams) (UrlVariant, error) {
+	row := q.db.QueryRow(ctx, insertVariant,
+		arg.UrlID,
+		arg.Position,
+		arg.LongUrl,
+		arg.Weight,
+	)
+	var i UrlVariant
+	err := row.Scan(
+		&i.ID,
+		&i.UrlID,
+		&i.Position,
+		&i.LongUrl,
+		&i.Weight,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listVariants = `-- name: ListVariants :many
+SELECT id, url_id, position, long_url, weight, created_at
+FROM url_variants
+WHERE url_id = $1
+ORDER BY position, id
+`
+
+func (q *Queries) ListVariants(ctx context.Context, urlID int32) ([]UrlVariant, error) {
+	rows, err := q.db.Query(ctx, listVariants, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UrlVariant{}
+	for rows.Next() {
+		var i UrlVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.UrlID,
+			&i.Position,
+			&i.LongUrl,
+			&i.Weight,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateVariant = `-- name: UpdateVariant :one
+UPDATE url_variants
+SET position = $1,
+    long_url = $2,
+    weight = $3
+WHERE id = $4
+AND url_id = $5
+RETURNING id, url_id, position, long_url, weight, created_at
+`
+
+type UpdateVariantParams struct {
+	Position int32  `json:"position"`
+	LongUrl  string `json:"long_url"`
+	Weight   int32  `json:"weight"`
+	ID       int32  `json:"id"`
+	UrlID    int32  `json:"url_id"`
+}
+
+func (q *Queries) UpdateVariant(ctx context.Context, arg UpdateVariantParams) (UrlVariant, error) {
+	row := q.db.QueryRow(ctx, updateVariant,
+		arg.Position,
+		arg.LongUrl,
+		arg.Weight,
+		arg.ID,
+		arg.UrlID,
+	)
+	var i UrlVariant
+	err := row.Scan(
+		&i.ID,
+		&i.UrlID,
+		&i.Position,
+		&i.LongUrl,
+		&i.Weight,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,485 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: webhooks.sql
+
+package datastore
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimWebhookDeliveries = `-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET next_attempt_at = $1::TIMESTAMP
+FROM webhook_endpoints e
+WHERE e.id = d.endpoint_id
+AND d.id IN (
+    SELECT due.id
+    FROM webhook_deliveries due
+    WHERE due.status = 'pending'
+    AND due.next_attempt_at <= $2::TIMESTAMP
+    ORDER BY due.next_attempt_at
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING d.id, d.event, d.payload, d.attempts, e.url, e.secret
+`
+
+type ClaimWebhookDeliveriesParams struct {
+	LeaseUntil pgtype.Timestamp `json:"lease_until"`
+	Now        pgtype.Timestamp `json:"now"`
+	BatchSize  int32            `json:"batch_size"`
+}
+
+type ClaimWebhookDeliveriesRow struct {
+	ID       int64  `json:"id"`
+	Event    string `json:"event"`
+	Payload  []byte `json:"payload"`
+	Attempts int32  `json:"attempts"`
+	Url      string `json:"url"`
+	Secret   string `json:"secret"`
+}
+
+// Due deliveries are leased until lease_until so that a dispatcher dying
+// midway doesn't lose them, and skipped by the other dispatchers meanwhile.
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, claimWebhookDeliveries, arg.LeaseUntil, arg.Now, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ClaimWebhookDeliveriesRow{}
+	for rows.Next() {
+		var i ClaimWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Event,
+			&i.Payload,
+			&i.Attempts,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countLinkVisits = `-- name: CountLinkVisits :many
+SELECT url_id, count(*) AS visits
+FROM visits
+WHERE url_id = ANY($1::INTEGER[])
+AND NOT is_bot
+GROUP BY url_id
+`
+
+type CountLinkVisitsRow struct {
+	UrlID  int32 `json:"url_id"`
+	Visits int64 `json:"visits"`
+}
+
+func (q *Queries) CountLinkVisits(ctx context.Context, urlIds []int32) ([]CountLinkVisitsRow, error) {
+	rows, err := q.db.Query(ctx, countLinkVisits, urlIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountLinkVisitsRow{}
+	for rows.Next() {
+		var i CountLinkVisitsRow
+		if err := rows.Scan(&i.UrlID, &i.Visits); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteWebhookDeliveriesBefore = `-- name: DeleteWebhookDeliveriesBefore :execrows
+DELETE FROM webhook_deliveries
+WHERE status != 'pending'
+AND created_at < $1
+`
+
+func (q *Queries) DeleteWebhookDeliveriesBefore(ctx context.Context, before pgtype.Timestamp) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhookDeliveriesBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteWebhookEndpoint = `-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = $1
+AND workspace_id = $2
+`
+
+type DeleteWebhookEndpointParams struct {
+	ID          int32 `json:"id"`
+	WorkspaceID int32 `json:"workspace_id"`
+}
+
+func (q *Queries) DeleteWebhookEndpoint(ctx context.Context, arg DeleteWebhookEndpointParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhookEndpoint, arg.ID, arg.WorkspaceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+type InsertWebhookDeliveriesParams struct {
+	EndpointID    int32            `json:"endpoint_id"`
+	Event         string           `json:"event"`
+	Payload       []byte           `json:"payload"`
+	NextAttemptAt pgtype.Timestamp `json:"next_attempt_at"`
+}
+
+const insertWebhookEndpoint = `-- name: InsertWebhookEndpoint :one
+INSERT INTO webhook_endpoints (workspace_id, url, secret, events)
+VALUES ($1, $2, $3, $4::TEXT[])
+RETURNING id, url, secret, events, created_at, workspace_id
+`
+
+type InsertWebhookEndpointParams struct {
+	WorkspaceID int32    `json:"workspace_id"`
+	Url         string   `json:"url"`
+	Secret      string   `json:"secret"`
+	Events      []string `json:"events"`
+}
+
+func (q *Queries) InsertWebhookEndpoint(ctx context.Context, arg InsertWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, insertWebhookEndpoint,
+		arg.WorkspaceID,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+	)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+	)
+	return i, err
+}
+
+const listLinkWebhookEndpoints = `-- name: ListLinkWebhookEndpoints :many
+SELECT
+    u.id AS url_id,
+    u.short_url,
+    u.domain,
+    u.long_url,
+    u.title,
+    u.created_at AS url_created_at,
+    e.id AS endpoint_id,
+    e.events
+FROM urls u
+JOIN webhook_endpoints e ON e.workspace_id = u.workspace_id
+WHERE u.id = ANY($1::INTEGER[])
+AND e.events && $2::TEXT[]
+`
+
+type ListLinkWebhookEndpointsParams struct {
+	UrlIds []int32  `json:"url_ids"`
+	Events []string `json:"events"`
+}
+
+type ListLinkWebhookEndpointsRow struct {
+	UrlID        int32            `json:"url_id"`
+	ShortUrl     string           `json:"short_url"`
+	Domain       string           `json:"domain"`
+	LongUrl      string           `json:"long_url"`
+	Title        string           `json:"title"`
+	UrlCreatedAt pgtype.Timestamp `json:"url_created_at"`
+	EndpointID   int32            `json:"endpoint_id"`
+	Events       []string         `json:"events"`
+}
+
+// Endpoints subscribed to any of the events of the links of their workspace,
+// used to notify the visits of a batch.
+func (q *Queries) ListLinkWebhookEndpoints(ctx context.Context, arg ListLinkWebhookEndpointsParams) ([]ListLinkWebhookEndpointsRow, error) {
+	rows, err := q.db.Query(ctx, listLinkWebhookEndpoints, arg.UrlIds, arg.Events)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLinkWebhookEndpointsRow{}
+	for rows.Next() {
+		var i ListLinkWebhookEndpointsRow
+		if err := rows.Scan(
+			&i.UrlID,
+			&i.ShortUrl,
+			&i.Domain,
+			&i.LongUrl,
+			&i.Title,
+			&i.UrlCreatedAt,
+			&i.EndpointID,
+			&i.Events,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscribedWebhookEndpoints = `-- name: ListSubscribedWebhookEndpoints :many
+SELECT id
+FROM webhook_endpoints
+WHERE workspace_id = $1
+AND $2::TEXT = ANY(events)
+`
+
+type ListSubscribedWebhookEndpointsParams struct {
+	WorkspaceID int32  `json:"workspace_id"`
+	Event       string `json:"event"`
+}
+
+func (q *Queries) ListSubscribedWebhookEndpoints(ctx context.Context, arg ListSubscribedWebhookEndpointsParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listSubscribedWebhookEndpoints, arg.WorkspaceID, arg.Event)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT
+    d.id,
+    d.endpoint_id,
+    e.url AS endpoint_url,
+    d.event,
+    d.status,
+    d.attempts,
+    d.next_attempt_at,
+    d.last_attempt_at,
+    d.response_status,
+    d.last_error,
+    d.created_at
+FROM webhook_deliveries d
+JOIN webhook_endpoints e ON e.id = d.endpoint_id
+WHERE e.workspace_id = $1
+ORDER BY d.id DESC
+LIMIT $2
+`
+
+type ListWebhookDeliveriesParams struct {
+	WorkspaceID int32 `json:"workspace_id"`
+	MaxResults  int32 `json:"max_results"`
+}
+
+type ListWebhookDeliveriesRow struct {
+	ID             int64            `json:"id"`
+	EndpointID     int32            `json:"endpoint_id"`
+	EndpointUrl    string           `json:"endpoint_url"`
+	Event          string           `json:"event"`
+	Status         string           `json:"status"`
+	Attempts       int32            `json:"attempts"`
+	NextAttemptAt  pgtype.Timestamp `json:"next_attempt_at"`
+	LastAttemptAt  pgtype.Timestamp `json:"last_attempt_at"`
+	ResponseStatus pgtype.Int4      `json:"response_status"`
+	LastError      string           `json:"last_error"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]ListWebhookDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries, arg.WorkspaceID, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListWebhookDeliveriesRow{}
+	for rows.Next() {
+		var i ListWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EndpointID,
+			&i.EndpointUrl,
+			&i.Event,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastAttemptAt,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookEndpoints = `-- name: ListWebhookEndpoints :many
+SELECT id, url, secret, events, created_at, workspace_id
+FROM webhook_endpoints
+WHERE workspace_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListWebhookEndpoints(ctx context.Context, workspaceID int32) ([]WebhookEndpoint, error) {
+	rows, err := q.db.Query(ctx, listWebhookEndpoints, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEndpoint{}
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const raiseWebhookThreshold = `-- name: RaiseWebhookThreshold :execrows
+INSERT INTO webhook_thresholds (endpoint_id, url_id, threshold)
+VALUES ($1, $2, $3)
+ON CONFLICT (endpoint_id, url_id) DO UPDATE
+SET threshold = EXCLUDED.threshold
+WHERE webhook_thresholds.threshold < EXCLUDED.threshold
+`
+
+type RaiseWebhookThresholdParams struct {
+	EndpointID int32 `json:"endpoint_id"`
+	UrlID      int32 `json:"url_id"`
+	Threshold  int64 `json:"threshold"`
+}
+
+func (q *Queries) RaiseWebhookThreshold(ctx context.Context, arg RaiseWebhookThresholdParams) (int64, error) {
+	result, err := q.db.Exec(ctx, raiseWebhookThreshold, arg.EndpointID, arg.UrlID, arg.Threshold)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordWebhookAttempt = `-- name: RecordWebhookAttempt :exec
+UPDATE webhook_deliveries
+SET
+    status = $1,
+    attempts = attempts + 1,
+    last_attempt_at = $2,
+    next_attempt_at = $3,
+    response_status = $4,
+    last_error = $5
+WHERE id = $6
+`
+
+type RecordWebhookAttemptParams struct {
+	Status         string           `json:"status"`
+	AttemptedAt    pgtype.Timestamp `json:"attempted_at"`
+	NextAttemptAt  pgtype.Timestamp `json:"next_attempt_at"`
+	ResponseStatus pgtype.Int4      `json:"response_status"`
+	LastError      string           `json:"last_error"`
+	ID             int64            `json:"id"`
+}
+
+func (q *Queries) RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) error {
+	_, err := q.db.Exec(ctx, recordWebhookAttempt,
+		arg.Status,
+		arg.AttemptedAt,
+		arg.NextAttemptAt,
+		arg.ResponseStatus,
+		arg.LastError,
+		arg.ID,
+	)
+	return err
+}
+
+const redeliverWebhook = `-- name: RedeliverWebhook :one
+INSERT INTO webhook_deliveries (endpoint_id, event, payload, next_attempt_at)
+SELECT d.endpoint_id, d.event, d.payload, $1
+FROM webhook_deliveries d
+JOIN webhook_endpoints e ON e.id = d.endpoint_id
+WHERE d.id = $2
+AND e.workspace_id = $3
+RETURNING id
+`
+
+type RedeliverWebhookParams struct {
+	Now         pgtype.Timestamp `json:"now"`
+	ID          int64            `json:"id"`
+	WorkspaceID int32            `json:"workspace_id"`
+}
+
+// Redelivering queues a copy of the delivery, the original stays in the log.
+func (q *Queries) RedeliverWebhook(ctx context.Context, arg RedeliverWebhookParams) (int64, error) {
+	row := q.db.QueryRow(ctx, redeliverWebhook, arg.Now, arg.ID, arg.WorkspaceID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const seedWebhookThresholds = `-- name: SeedWebhookThresholds :exec
+INSERT INTO webhook_thresholds (endpoint_id, url_id, threshold)
+SELECT $1::INTEGER, clicks.url_id, max(t.threshold)
+FROM (
+    SELECT visits.url_id, count(*) AS total
+    FROM visits
+    JOIN urls ON urls.id = visits.url_id
+    WHERE urls.workspace_id = $2::INTEGER
+    AND NOT visits.is_bot
+    GROUP BY visits.url_id
+) clicks
+JOIN unnest($3::BIGINT[]) AS t(threshold) ON t.threshold <= clicks.total
+GROUP BY clicks.url_id
+`
+
+type SeedWebhookThresholdsParams struct {
+	EndpointID  int32   `json:"endpoint_id"`
+	WorkspaceID int32   `json:"workspace_id"`
+	Thresholds  []int64 `json:"thresholds"`
+}
+
+// Endpoints start from the thresholds the links of their workspace already
+// reached, only the next ones are notified.
+func (q *Queries) SeedWebhookThresholds(ctx context.Context, arg SeedWebhookThresholdsParams) error {
+	_, err := q.db.Exec(ctx, seedWebhookThresholds, arg.EndpointID, arg.WorkspaceID, arg.Thresholds)
+	return err
+}
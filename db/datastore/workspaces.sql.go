@@ -0,0 +1,499 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: workspaces.sql
+
+package datastore
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const acceptInvitation = `-- name: AcceptInvitation :exec
+WITH invitation AS (
+    DELETE FROM workspace_invitations
+    WHERE id = $2
+    RETURNING workspace_id, role
+)
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT workspace_id, $1, role FROM invitation
+ON CONFLICT (workspace_id, user_id) DO NOTHING
+`
+
+type AcceptInvitationParams struct {
+	UserID int32 `json:"user_id"`
+	ID     int32 `json:"id"`
+}
+
+// Members keep their role when they accept another invitation to the same
+// workspace.
+func (q *Queries) AcceptInvitation(ctx context.Context, arg AcceptInvitationParams) error {
+	_, err := q.db.Exec(ctx, acceptInvitation, arg.UserID, arg.ID)
+	return err
+}
+
+const countOwnedWorkspaces = `-- name: CountOwnedWorkspaces :one
+SELECT COUNT(*)::INTEGER
+FROM workspace_members m
+JOIN workspaces w ON w.id = m.workspace_id
+WHERE m.user_id = $1
+AND m.role = 'owner'
+AND w.personal_of IS NULL
+`
+
+func (q *Queries) CountOwnedWorkspaces(ctx context.Context, userID int32) (int32, error) {
+	row := q.db.QueryRow(ctx, countOwnedWorkspaces, userID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const deleteInvitation = `-- name: DeleteInvitation :execrows
+DELETE FROM workspace_invitations
+WHERE id = $1
+AND workspace_id = $2
+`
+
+type DeleteInvitationParams struct {
+	ID          int32 `json:"id"`
+	WorkspaceID int32 `json:"workspace_id"`
+}
+
+func (q *Queries) DeleteInvitation(ctx context.Context, arg DeleteInvitationParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteInvitation, arg.ID, arg.WorkspaceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteMember = `-- name: DeleteMember :execrows
+DELETE FROM workspace_members
+WHERE workspace_id = $1
+AND user_id = $2
+`
+
+type DeleteMemberParams struct {
+	WorkspaceID int32 `json:"workspace_id"`
+	UserID      int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteMember(ctx context.Context, arg DeleteMemberParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteMember, arg.WorkspaceID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const ensurePersonalWorkspace = `-- name: EnsurePersonalWorkspace :exec
+WITH workspace AS (
+    INSERT INTO workspaces (guid, name, personal_of)
+    VALUES ($2, 'Personal', $1)
+    ON CONFLICT (personal_of) DO NOTHING
+    RETURNING id
+)
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT id, $1, 'owner' FROM workspace
+`
+
+type EnsurePersonalWorkspaceParams struct {
+	UserID int32       `json:"user_id"`
+	Guid   pgtype.UUID `json:"guid"`
+}
+
+func (q *Queries) EnsurePersonalWorkspace(ctx context.Context, arg EnsurePersonalWorkspaceParams) error {
+	_, err := q.db.Exec(ctx, ensurePersonalWorkspace, arg.UserID, arg.Guid)
+	return err
+}
+
+const getInvitationByToken = `-- name: GetInvitationByToken :one
+SELECT
+    i.id, i.workspace_id, i.email, i.role, i.token_hash, i.invited_by, i.created_at, i.expires_at,
+    w.name AS workspace_name
+FROM workspace_invitations i
+JOIN workspaces w ON w.id = i.workspace_id
+WHERE i.token_hash = $1
+AND i.expires_at > CURRENT_TIMESTAMP
+`
+
+type GetInvitationByTokenRow struct {
+	ID            int32            `json:"id"`
+	WorkspaceID   int32            `json:"workspace_id"`
+	Email         string           `json:"email"`
+	Role          string           `json:"role"`
+	TokenHash     []byte           `json:"token_hash"`
+	InvitedBy     pgtype.Int4      `json:"invited_by"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	ExpiresAt     pgtype.Timestamp `json:"expires_at"`
+	WorkspaceName string           `json:"workspace_name"`
+}
+
+func (q *Queries) GetInvitationByToken(ctx context.Context, tokenHash []byte) (GetInvitationByTokenRow, error) {
+	row := q.db.QueryRow(ctx, getInvitationByToken, tokenHash)
+	var i GetInvitationByTokenRow
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Email,
+		&i.Role,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.WorkspaceName,
+	)
+	return i, err
+}
+
+const getMemberWorkspace = `-- name: GetMemberWorkspace :one
+SELECT
+    w.id, w.guid, w.name, w.personal_of, w.created_at,
+    m.role
+FROM workspaces w
+JOIN workspace_members m ON m.workspace_id = w.id
+WHERE w.id = $1
+AND m.user_id = $2
+`
+
+type GetMemberWorkspaceParams struct {
+	WorkspaceID int32 `json:"workspace_id"`
+	UserID      int32 `json:"user_id"`
+}
+
+type GetMemberWorkspaceRow struct {
+	ID         int32            `json:"id"`
+	Guid       pgtype.UUID      `json:"guid"`
+	Name       string           `json:"name"`
+	PersonalOf pgtype.Int4      `json:"personal_of"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	Role       string           `json:"role"`
+}
+
+func (q *Queries) GetMemberWorkspace(ctx context.Context, arg GetMemberWorkspaceParams) (GetMemberWorkspaceRow, error) {
+	row := q.db.QueryRow(ctx, getMemberWorkspace, arg.WorkspaceID, arg.UserID)
+	var i GetMemberWorkspaceRow
+	err := row.Scan(
+		&i.ID,
+		&i.Guid,
+		&i.Name,
+		&i.PersonalOf,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getPersonalWorkspace = `-- name: GetPersonalWorkspace :one
+SELECT
+    w.id, w.guid, w.name, w.personal_of, w.created_at,
+    m.role
+FROM workspaces w
+JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = w.personal_of
+WHERE w.personal_of = $1::INTEGER
+`
+
+type GetPersonalWorkspaceRow struct {
+	ID         int32            `json:"id"`
+	Guid       pgtype.UUID      `json:"guid"`
+	Name       string           `json:"name"`
+	PersonalOf pgtype.Int4      `json:"personal_of"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	Role       string           `json:"role"`
+}
+
+func (q *Queries) GetPersonalWorkspace(ctx context.Context, userID int32) (GetPersonalWorkspaceRow, error) {
+	row := q.db.QueryRow(ctx, getPersonalWorkspace, userID)
+	var i GetPersonalWorkspaceRow
+	err := row.Scan(
+		&i.ID,
+		&i.Guid,
+		&i.Name,
+		&i.PersonalOf,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const insertWorkspace = `-- name: InsertWorkspace :one
+WITH workspace AS (
+    INSERT INTO workspaces (name)
+    VALUES ($1)
+    RETURNING id, guid, name, personal_of, created_at
+), member AS (
+    INSERT INTO workspace_members (workspace_id, user_id, role)
+    SELECT id, $2, 'owner' FROM workspace
+)
+SELECT id, guid, name, personal_of, created_at FROM workspace
+`
+
+type InsertWorkspaceParams struct {
+	Name   string `json:"name"`
+	UserID int32  `json:"user_id"`
+}
+
+type InsertWorkspaceRow struct {
+	ID         int32            `json:"id"`
+	Guid       pgtype.UUID      `json:"guid"`
+	Name       string           `json:"name"`
+	PersonalOf pgtype.Int4      `json:"personal_of"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) InsertWorkspace(ctx context.Context, arg InsertWorkspaceParams) (InsertWorkspaceRow, error) {
+	row := q.db.QueryRow(ctx, insertWorkspace, arg.Name, arg.UserID)
+	var i InsertWorkspaceRow
+	err := row.Scan(
+		&i.ID,
+		&i.Guid,
+		&i.Name,
+		&i.PersonalOf,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listInvitations = `-- name: ListInvitations :many
+SELECT
+    i.id,
+    i.email,
+    i.role,
+    i.created_at,
+    i.expires_at,
+    COALESCE(u.username, '')::TEXT AS invited_by
+FROM workspace_invitations i
+LEFT JOIN users u ON u.id = i.invited_by
+WHERE i.workspace_id = $1
+AND i.expires_at > CURRENT_TIMESTAMP
+ORDER BY i.created_at DESC
+`
+
+type ListInvitationsRow struct {
+	ID        int32            `json:"id"`
+	Email     string           `json:"email"`
+	Role      string           `json:"role"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	InvitedBy string           `json:"invited_by"`
+}
+
+func (q *Queries) ListInvitations(ctx context.Context, workspaceID int32) ([]ListInvitationsRow, error) {
+	rows, err := q.db.Query(ctx, listInvitations, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListInvitationsRow{}
+	for rows.Next() {
+		var i ListInvitationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Role,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.InvitedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMemberWorkspaces = `-- name: ListMemberWorkspaces :many
+SELECT
+    w.id, w.guid, w.name, w.personal_of, w.created_at,
+    m.role
+FROM workspaces w
+JOIN workspace_members m ON m.workspace_id = w.id
+WHERE m.user_id = $1
+ORDER BY w.personal_of IS NULL, w.name, w.id
+`
+
+type ListMemberWorkspacesRow struct {
+	ID         int32            `json:"id"`
+	Guid       pgtype.UUID      `json:"guid"`
+	Name       string           `json:"name"`
+	PersonalOf pgtype.Int4      `json:"personal_of"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	Role       string           `json:"role"`
+}
+
+// The personal workspace comes first.
+func (q *Queries) ListMemberWorkspaces(ctx context.Context, userID int32) ([]ListMemberWorkspacesRow, error) {
+	rows, err := q.db.Query(ctx, listMemberWorkspaces, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMemberWorkspacesRow{}
+	for rows.Next() {
+		var i ListMemberWorkspacesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Guid,
+			&i.Name,
+			&i.PersonalOf,
+			&i.CreatedAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWorkspaceMembers = `-- name: ListWorkspaceMembers :many
+SELECT
+    u.id,
+    u.username,
+    u.email,
+    m.role,
+    m.created_at
+FROM workspace_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.workspace_id = $1
+ORDER BY m.created_at, u.id
+`
+
+type ListWorkspaceMembersRow struct {
+	ID        int32            `json:"id"`
+	Username  string           `json:"username"`
+	Email     string           `json:"email"`
+	Role      string           `json:"role"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) ListWorkspaceMembers(ctx context.Context, workspaceID int32) ([]ListWorkspaceMembersRow, error) {
+	rows, err := q.db.Query(ctx, listWorkspaceMembers, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListWorkspaceMembersRow{}
+	for rows.Next() {
+		var i ListWorkspaceMembersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.Role,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockWorkspaceOwners = `-- name: LockWorkspaceOwners :many
+SELECT user_id
+FROM workspace_members
+WHERE workspace_id = $1
+AND role = 'owner'
+ORDER BY user_id
+FOR UPDATE
+`
+
+// Locks the owners of a workspace until the end of the transaction, so that
+// concurrent changes of its members can't leave it without any.
+func (q *Queries) LockWorkspaceOwners(ctx context.Context, workspaceID int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, lockWorkspaceOwners, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateMemberRole = `-- name: UpdateMemberRole :execrows
+UPDATE workspace_members
+SET role = $1
+WHERE workspace_id = $2
+AND user_id = $3
+`
+
+type UpdateMemberRoleParams struct {
+	Role        string `json:"role"`
+	WorkspaceID int32  `json:"workspace_id"`
+	UserID      int32  `json:"user_id"`
+}
+
+func (q *Queries) UpdateMemberRole(ctx context.Context, arg UpdateMemberRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateMemberRole, arg.Role, arg.WorkspaceID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const upsertInvitation = `-- name: UpsertInvitation :one
+INSERT INTO workspace_invitations (workspace_id, email, role, token_hash, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (workspace_id, email) DO UPDATE SET
+    role = EXCLUDED.role,
+    token_hash = EXCLUDED.token_hash,
+    invited_by = EXCLUDED.invited_by,
+    created_at = CURRENT_TIMESTAMP,
+    expires_at = EXCLUDED.expires_at
+RETURNING id, workspace_id, email, role, token_hash, invited_by, created_at, expires_at
+`
+
+type UpsertInvitationParams struct {
+	WorkspaceID int32            `json:"workspace_id"`
+	Email       string           `json:"email"`
+	Role        string           `json:"role"`
+	TokenHash   []byte           `json:"token_hash"`
+	InvitedBy   pgtype.Int4      `json:"invited_by"`
+	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
+}
+
+// Inviting an email again replaces its previous invitation.
+func (q *Queries) UpsertInvitation(ctx context.Context, arg UpsertInvitationParams) (WorkspaceInvitation, error) {
+	row := q.db.QueryRow(ctx, upsertInvitation,
+		arg.WorkspaceID,
+		arg.Email,
+		arg.Role,
+		arg.TokenHash,
+		arg.InvitedBy,
+		arg.ExpiresAt,
+	)
+	var i WorkspaceInvitation
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Email,
+		&i.Role,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
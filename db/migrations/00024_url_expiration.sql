@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls
+    ADD COLUMN expires_at TIMESTAMP,
+    ADD COLUMN max_clicks INTEGER CHECK (max_clicks > 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS max_clicks;
+-- +goose StatementEnd
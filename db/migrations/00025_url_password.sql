@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls
+    ADD COLUMN password_hash BYTEA,
+    ADD COLUMN password_salt BYTEA;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS password_hash,
+    DROP COLUMN IF EXISTS password_salt;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls
+    ADD COLUMN redirect_status SMALLINT NOT NULL DEFAULT 302
+        CONSTRAINT urls_redirect_status_check CHECK (redirect_status IN (301, 302, 307, 308));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS redirect_status;
+-- +goose StatementEnd
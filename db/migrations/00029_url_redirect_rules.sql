@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS url_redirect_rules (
+    id SERIAL PRIMARY KEY,
+    url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    long_url TEXT NOT NULL,
+    platforms TEXT[] NOT NULL DEFAULT '{}',
+    mobile BOOLEAN,
+    countries TEXT[] NOT NULL DEFAULT '{}',
+    languages TEXT[] NOT NULL DEFAULT '{}',
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX ON url_redirect_rules(url_id, position);
+
+ALTER TABLE visits
+    ADD COLUMN redirect_rule_id INTEGER REFERENCES url_redirect_rules(id) ON DELETE SET NULL;
+CREATE INDEX ON visits(redirect_rule_id) WHERE redirect_rule_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE visits
+    DROP COLUMN IF EXISTS redirect_rule_id;
+DROP TABLE IF EXISTS url_redirect_rules;
+-- +goose StatementEnd
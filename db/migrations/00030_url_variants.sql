@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS url_variants (
+    id SERIAL PRIMARY KEY,
+    url_id INTEGER NOT NULL REFERENCES urls(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    long_url TEXT NOT NULL,
+    weight INTEGER NOT NULL CHECK (weight > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX ON url_variants(url_id, position);
+
+ALTER TABLE urls
+    ADD COLUMN sticky_variants BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE visits
+    ADD COLUMN variant_id INTEGER REFERENCES url_variants(id) ON DELETE SET NULL;
+CREATE INDEX ON visits(variant_id) WHERE variant_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE visits
+    DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS sticky_variants;
+DROP TABLE IF EXISTS url_variants;
+-- +goose StatementEnd
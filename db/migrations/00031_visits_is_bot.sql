@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE visits
+    ADD COLUMN is_bot BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE visits
+    DROP COLUMN IF EXISTS is_bot;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- source tells how the visitor got to the link, 'qr' for the scans of its QR
+-- code, NULL when the short URL was followed directly.
+ALTER TABLE visits
+    ADD COLUMN source TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE visits
+    DROP COLUMN IF EXISTS source;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Backs the full-text search of the links list, the expression must stay the
+-- same as in ListStatisticsPerAuthor and CountLinksPerAuthor.
+CREATE INDEX IF NOT EXISTS idx_urls_search ON urls USING GIN (
+    to_tsvector('simple', regexp_replace(title || ' ' || short_url || ' ' || long_url, '[^[:alnum:]]+', ' ', 'g'))
+);
+CREATE INDEX IF NOT EXISTS idx_urls_author_created_at ON urls(author_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_urls_author_created_at;
+DROP INDEX IF EXISTS idx_urls_search;
+-- +goose StatementEnd
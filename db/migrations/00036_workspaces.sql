@@ -0,0 +1,124 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workspaces (
+    id SERIAL PRIMARY KEY,
+    guid UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    -- every user has a personal workspace, it can't be shared
+    personal_of INTEGER UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id)
+);
+CREATE INDEX ON workspace_members(user_id);
+
+-- only the hash of the token sent in the invitation link is kept, email is
+-- lowercase
+CREATE TABLE IF NOT EXISTS workspace_invitations (
+    id SERIAL PRIMARY KEY,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    token_hash BYTEA NOT NULL UNIQUE,
+    invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    UNIQUE (workspace_id, email)
+);
+
+-- personal workspaces take the guid of their user so that the Stripe
+-- customers of the users become the ones of their workspace
+INSERT INTO workspaces (guid, name, personal_of)
+SELECT guid, 'Personal', id FROM users;
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT id, personal_of, 'owner' FROM workspaces;
+
+-- links belong to a workspace, author_id is who created them and outlives
+-- its user
+ALTER TABLE urls ADD COLUMN workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE;
+UPDATE urls SET workspace_id = w.id FROM workspaces w WHERE w.personal_of = urls.author_id;
+ALTER TABLE urls ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE urls ALTER COLUMN author_id DROP NOT NULL;
+ALTER TABLE urls DROP CONSTRAINT urls_author_id_fkey;
+ALTER TABLE urls ADD CONSTRAINT urls_author_id_fkey
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE;
+DROP INDEX IF EXISTS idx_urls_author_created_at;
+CREATE INDEX IF NOT EXISTS idx_urls_workspace_created_at ON urls(workspace_id, created_at DESC);
+
+ALTER TABLE folders ADD COLUMN workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE;
+UPDATE folders SET workspace_id = w.id FROM workspaces w WHERE w.personal_of = folders.user_id;
+ALTER TABLE folders ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE folders DROP COLUMN user_id;
+ALTER TABLE folders ADD CONSTRAINT folders_workspace_id_name_key UNIQUE (workspace_id, name);
+
+ALTER TABLE tags ADD COLUMN workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE;
+UPDATE tags SET workspace_id = w.id FROM workspaces w WHERE w.personal_of = tags.user_id;
+ALTER TABLE tags ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE tags DROP COLUMN user_id;
+ALTER TABLE tags ADD CONSTRAINT tags_workspace_id_name_key UNIQUE (workspace_id, name);
+
+-- subscriptions are paid for a workspace
+ALTER TABLE customer DROP CONSTRAINT customer_user_id_fkey;
+ALTER TABLE customer RENAME COLUMN user_id TO workspace_guid;
+ALTER TABLE customer ADD CONSTRAINT customer_workspace_guid_fkey
+    FOREIGN KEY (workspace_guid) REFERENCES workspaces(guid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- folders and tags of shared workspaces go to their oldest owner, as do the
+-- links whose author was deleted
+ALTER TABLE customer DROP CONSTRAINT customer_workspace_guid_fkey;
+DELETE FROM subscription WHERE customer_id NOT IN (SELECT guid FROM users);
+DELETE FROM customer WHERE workspace_guid NOT IN (SELECT guid FROM users);
+ALTER TABLE customer RENAME COLUMN workspace_guid TO user_id;
+ALTER TABLE customer ADD CONSTRAINT customer_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(guid);
+
+CREATE TEMPORARY TABLE workspace_owners AS
+SELECT DISTINCT ON (workspace_id) workspace_id, user_id
+FROM workspace_members
+WHERE role = 'owner'
+ORDER BY workspace_id, created_at;
+
+ALTER TABLE tags ADD COLUMN user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+UPDATE tags SET user_id = o.user_id FROM workspace_owners o WHERE o.workspace_id = tags.workspace_id;
+DELETE FROM tags t USING tags other
+WHERE t.user_id = other.user_id AND t.name = other.name AND t.id > other.id;
+ALTER TABLE tags ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE tags DROP COLUMN workspace_id;
+ALTER TABLE tags ADD CONSTRAINT tags_user_id_name_key UNIQUE (user_id, name);
+
+ALTER TABLE folders ADD COLUMN user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+UPDATE folders SET user_id = o.user_id FROM workspace_owners o WHERE o.workspace_id = folders.workspace_id;
+UPDATE folders f SET name = f.name || ' (' || f.id || ')'
+FROM folders other
+WHERE f.user_id = other.user_id AND f.name = other.name AND f.id > other.id;
+ALTER TABLE folders ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE folders DROP COLUMN workspace_id;
+ALTER TABLE folders ADD CONSTRAINT folders_user_id_name_key UNIQUE (user_id, name);
+
+UPDATE urls SET author_id = o.user_id
+FROM workspace_owners o
+WHERE o.workspace_id = urls.workspace_id AND urls.author_id IS NULL;
+DELETE FROM urls WHERE author_id IS NULL;
+ALTER TABLE urls DROP CONSTRAINT urls_author_id_fkey;
+ALTER TABLE urls ADD CONSTRAINT urls_author_id_fkey
+    FOREIGN KEY (author_id) REFERENCES users(id)
+    ON DELETE CASCADE ON UPDATE CASCADE DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE urls ALTER COLUMN author_id SET NOT NULL;
+DROP INDEX IF EXISTS idx_urls_workspace_created_at;
+CREATE INDEX IF NOT EXISTS idx_urls_author_created_at ON urls(author_id, created_at DESC);
+ALTER TABLE urls DROP COLUMN workspace_id;
+
+DROP TABLE workspace_owners;
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
+-- +goose StatementEnd
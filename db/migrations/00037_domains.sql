@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- custom domains serve the links of their workspace once the TXT record
+-- proving their ownership was found, hostname is lowercase
+CREATE TABLE IF NOT EXISTS domains (
+    id SERIAL PRIMARY KEY,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    hostname TEXT NOT NULL,
+    verification_token TEXT NOT NULL,
+    verified_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (workspace_id, hostname)
+);
+-- several workspaces can claim a hostname, only one can verify it
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_verified_hostname ON domains(hostname)
+WHERE verified_at IS NOT NULL;
+
+-- links are unique per domain, '' being the default one. A slug is still
+-- unique in its workspace so that the dashboard can keep addressing links by
+-- slug.
+ALTER TABLE urls ADD COLUMN domain TEXT NOT NULL DEFAULT '';
+ALTER TABLE urls DROP CONSTRAINT urls_short_url_key;
+ALTER TABLE urls ADD CONSTRAINT urls_domain_short_url_key UNIQUE (domain, short_url);
+ALTER TABLE urls ADD CONSTRAINT urls_workspace_id_short_url_key UNIQUE (workspace_id, short_url);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- links of custom domains whose slug is taken on the default one are lost
+DELETE FROM urls u USING urls other
+WHERE u.short_url = other.short_url AND u.domain <> '' AND (other.domain = '' OR u.id > other.id);
+ALTER TABLE urls DROP CONSTRAINT urls_workspace_id_short_url_key;
+ALTER TABLE urls DROP CONSTRAINT urls_domain_short_url_key;
+ALTER TABLE urls ADD CONSTRAINT urls_short_url_key UNIQUE (short_url);
+ALTER TABLE urls DROP COLUMN domain;
+
+DROP TABLE IF EXISTS domains;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- endpoints belong to a workspace and are told about the links of all its
+-- members, the existing ones go to the personal workspace of their user
+ALTER TABLE webhook_endpoints ADD COLUMN workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE;
+UPDATE webhook_endpoints e SET workspace_id = w.id FROM workspaces w WHERE w.personal_of = e.user_id;
+ALTER TABLE webhook_endpoints ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE webhook_endpoints DROP COLUMN user_id;
+CREATE INDEX ON webhook_endpoints(workspace_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- endpoints of shared workspaces go to their oldest owner
+ALTER TABLE webhook_endpoints ADD COLUMN user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+UPDATE webhook_endpoints e SET user_id = o.user_id
+FROM (
+    SELECT DISTINCT ON (workspace_id) workspace_id, user_id
+    FROM workspace_members
+    WHERE role = 'owner'
+    ORDER BY workspace_id, created_at
+) o
+WHERE o.workspace_id = e.workspace_id;
+DELETE FROM webhook_endpoints WHERE user_id IS NULL;
+ALTER TABLE webhook_endpoints ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE webhook_endpoints DROP COLUMN workspace_id;
+CREATE INDEX ON webhook_endpoints(user_id);
+-- +goose StatementEnd
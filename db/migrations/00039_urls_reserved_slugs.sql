@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- links whose slug became a page of the application can't be followed
+-- anymore, they are renamed after their id so their owners can find them. A
+-- counter is appended when that slug is taken too.
+DO $$
+DECLARE
+    link RECORD;
+    candidate TEXT;
+    n INTEGER;
+BEGIN
+    FOR link IN
+        SELECT id, short_url, domain, workspace_id
+        FROM urls
+        WHERE short_url IN ('folders', 'tags', 'workspace', 'workspaces', 'invitations', 'urls-sort')
+        ORDER BY id
+    LOOP
+        candidate := link.short_url || '-' || link.id;
+        n := 1;
+        WHILE EXISTS (
+            SELECT 1 FROM urls taken
+            WHERE taken.short_url = candidate
+            AND (taken.domain = link.domain OR taken.workspace_id = link.workspace_id)
+        ) LOOP
+            n := n + 1;
+            candidate := link.short_url || '-' || link.id || '-' || n;
+        END LOOP;
+        UPDATE urls SET short_url = candidate WHERE id = link.id;
+    END LOOP;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- the renamed links keep their new slug, the old one is still shadowed
+SELECT 1;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a workspace can use the same slug on several of its domains, the dashboard
+-- addresses links by domain and slug
+ALTER TABLE urls DROP CONSTRAINT urls_workspace_id_short_url_key;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- links sharing their slug with an older link of their workspace are renamed
+UPDATE urls u
+SET short_url = u.short_url || '-' || u.id
+WHERE EXISTS (
+    SELECT 1 FROM urls other
+    WHERE other.workspace_id = u.workspace_id
+    AND other.short_url = u.short_url
+    AND other.id < u.id
+);
+ALTER TABLE urls ADD CONSTRAINT urls_workspace_id_short_url_key UNIQUE (workspace_id, short_url);
+-- +goose StatementEnd
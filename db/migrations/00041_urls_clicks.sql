@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls
+    ADD COLUMN clicks INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE urls
+SET clicks = counts.clicks
+FROM (
+    SELECT url_id, count(*) AS clicks
+    FROM visits
+    WHERE NOT is_bot
+    GROUP BY url_id
+) counts
+WHERE counts.url_id = urls.id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS clicks;
+-- +goose StatementEnd
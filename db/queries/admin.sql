@@ -8,6 +8,8 @@ SELECT
         WHERE guid = @guid AND role = 'admin'
     ) AS is_admin;
 
+-- The plan of a user is the one of their personal workspace, which has the
+-- guid of its user.
 -- name: AdminListUsers :many
 SELECT
     u.*,
@@ -21,9 +23,9 @@ SELECT
 FROM
     users u
 LEFT JOIN
-    customer c ON u.guid = c.user_id
+    customer c ON u.guid = c.workspace_guid
 LEFT JOIN
-    subscription s ON c.user_id = s.customer_id
+    subscription s ON c.workspace_guid = s.customer_id
 LEFT JOIN
     urls ON u.id = urls.author_id
 LEFT JOIN
@@ -51,9 +53,9 @@ SELECT
 FROM
     users u
 LEFT JOIN
-    customer c ON u.guid = c.user_id
+    customer c ON u.guid = c.workspace_guid
 LEFT JOIN
-    subscription s ON c.user_id = s.customer_id
+    subscription s ON c.workspace_guid = s.customer_id
 LEFT JOIN
     urls ON u.id = urls.author_id
 LEFT JOIN
@@ -192,9 +194,9 @@ JOIN
 LEFT JOIN
     visits ON urls.id = visits.url_id
 LEFT JOIN
-    customer c ON users.guid = c.user_id
+    customer c ON users.guid = c.workspace_guid
 LEFT JOIN
-    subscription s ON c.user_id = s.customer_id
+    subscription s ON c.workspace_guid = s.customer_id
 WHERE
     (sqlc.narg('search')::text IS NULL OR urls.title ILIKE '%' || sqlc.narg('search') || '%' OR urls.short_url ILIKE '%' || sqlc.narg('search') || '%' OR urls.long_url ILIKE '%' || sqlc.narg('search') || '%')
     AND (sqlc.narg('is_active')::boolean IS NULL OR urls.is_active = sqlc.narg('is_active'))
@@ -262,6 +264,7 @@ LIMIT 5;
 -- name: AdminGetTopURLs :many
 SELECT
     u.short_url,
+    u.domain,
     u.long_url,
     COUNT(v.id)::bigint AS clicks
 FROM
@@ -305,11 +308,23 @@ FROM users
 WHERE urls.author_id = users.id AND users.guid = @guid;
 
 -- name: AdminUpdateURL :one
+WITH previous AS (
+    SELECT id, long_url
+    FROM urls
+    WHERE urls.id = @id
+    FOR UPDATE
+), history AS (
+    INSERT INTO url_destination_history (url_id, long_url, changed_by)
+    SELECT previous.id, previous.long_url, @changed_by
+    FROM previous
+    WHERE previous.long_url <> @long_url
+)
 UPDATE urls
 SET title = @title,
     long_url = @long_url
-WHERE id = @id
-RETURNING *;
+FROM previous
+WHERE urls.id = previous.id
+RETURNING urls.*;
 
 -- name: AdminGetRecentActivity :many
 SELECT
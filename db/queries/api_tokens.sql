@@ -0,0 +1,36 @@
+-- name: InsertAPIToken :one
+INSERT INTO api_tokens (user_id, name, token_prefix, token_hash)
+VALUES (@user_id, @name, @token_prefix, @token_hash)
+RETURNING *;
+
+-- name: ListAPITokens :many
+SELECT *
+FROM api_tokens
+WHERE user_id = @user_id
+AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: RevokeAPIToken :execrows
+UPDATE api_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = @id
+AND user_id = @user_id
+AND revoked_at IS NULL;
+
+-- name: GetUserByAPIToken :one
+SELECT
+    api_tokens.id AS token_id,
+    sqlc.embed(users)
+FROM api_tokens
+JOIN users ON users.id = api_tokens.user_id
+WHERE api_tokens.token_hash = @token_hash
+AND api_tokens.revoked_at IS NULL
+LIMIT 1;
+
+-- Only write when the previous timestamp is stale so that busy tokens don't
+-- turn every API call into an UPDATE.
+-- name: TouchAPIToken :exec
+UPDATE api_tokens
+SET last_used_at = CURRENT_TIMESTAMP
+WHERE id = @id
+AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute');
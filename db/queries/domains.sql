@@ -0,0 +1,39 @@
+-- name: ListDomains :many
+SELECT *
+FROM domains
+WHERE workspace_id = @workspace_id
+ORDER BY hostname;
+
+-- name: GetDomain :one
+SELECT *
+FROM domains
+WHERE id = @id
+AND workspace_id = @workspace_id;
+
+-- name: CountDomains :one
+SELECT count(*)::INTEGER
+FROM domains
+WHERE workspace_id = @workspace_id;
+
+-- name: InsertDomain :one
+INSERT INTO domains (workspace_id, hostname, verification_token)
+VALUES (@workspace_id, @hostname, @verification_token)
+RETURNING *;
+
+-- name: VerifyDomain :one
+UPDATE domains
+SET verified_at = CURRENT_TIMESTAMP
+WHERE id = @id
+AND workspace_id = @workspace_id
+RETURNING *;
+
+-- name: DeleteDomain :execrows
+DELETE FROM domains
+WHERE id = @id
+AND workspace_id = @workspace_id;
+
+-- name: CountDomainURLs :one
+SELECT count(*)::INTEGER
+FROM urls
+WHERE workspace_id = @workspace_id
+AND domain = @domain;
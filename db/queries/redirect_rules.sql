@@ -0,0 +1,39 @@
+-- name: ListRedirectRules :many
+SELECT *
+FROM url_redirect_rules
+WHERE url_id = @url_id
+ORDER BY position, id;
+
+-- name: InsertRedirectRule :one
+INSERT INTO url_redirect_rules (url_id, position, long_url, platforms, mobile, countries, languages, starts_at, ends_at)
+VALUES (@url_id, @position, @long_url, @platforms, @mobile, @countries, @languages, @starts_at, @ends_at)
+RETURNING *;
+
+-- name: UpdateRedirectRule :one
+UPDATE url_redirect_rules
+SET position = @position,
+    long_url = @long_url,
+    platforms = @platforms,
+    mobile = @mobile,
+    countries = @countries,
+    languages = @languages,
+    starts_at = @starts_at,
+    ends_at = @ends_at
+WHERE id = @id
+AND url_id = @url_id
+RETURNING *;
+
+-- name: DeleteRedirectRulesExcept :exec
+DELETE FROM url_redirect_rules
+WHERE url_id = @url_id
+AND NOT (id = ANY(@keep::INTEGER[]));
+
+-- name: CountRedirectRuleVisits :many
+SELECT
+    v.redirect_rule_id::INTEGER AS rule_id,
+    COUNT(*)::INTEGER AS visits
+FROM visits v
+JOIN url_redirect_rules r ON r.id = v.redirect_rule_id
+WHERE r.url_id = @url_id
+AND NOT v.is_bot
+GROUP BY v.redirect_rule_id;
@@ -4,12 +4,50 @@ VALUES (@url_id, @ip_address, @user_agent, @browser_id, @referrer)
 RETURNING *;
 
 
--- name: UpsertBrowser :one
+-- Ids are reserved up front so visits written with COPY can be referenced by
+-- their locations in the same batch.
+-- name: ReserveVisitIDs :many
+SELECT nextval('visits_id_seq')::INTEGER AS id
+FROM generate_series(1, @count::INTEGER);
+
+-- name: InsertVisits :copyfrom
+INSERT INTO visits (id, url_id, visited_at, ip_address, user_agent, browser_id, referrer, redirect_rule_id, variant_id, is_bot, source)
+VALUES (@id, @url_id, @visited_at, @ip_address, @user_agent, @browser_id, @referrer, @redirect_rule_id, @variant_id, @is_bot, @source);
+
+-- name: InsertVisitLocations :copyfrom
+INSERT INTO visit_locations (visit_id, address, country_code, country_name, subdivision, continent, city_name, latitude, longitude, source)
+VALUES (@visit_id, @address, @country_code, @country_name, @subdivision, @continent, @city_name, @latitude, @longitude, @source);
+
+-- Browsers are inserted outside of the visits transaction and in a stable
+-- order, so concurrent batches neither hold nor wait on each other's rows.
+-- name: InsertBrowsers :exec
 INSERT INTO browsers (name, version, platform, mobile)
-VALUES (@name, @version, @platform, @mobile)
-ON CONFLICT (name, version, platform, mobile) 
-DO UPDATE SET name = EXCLUDED.name
-RETURNING *;
+SELECT
+    unnest(@names::TEXT[]) AS name,
+    unnest(@versions::TEXT[]) AS version,
+    unnest(@platforms::TEXT[]) AS platform,
+    unnest(@mobiles::BOOLEAN[]) AS mobile
+ORDER BY 1, 2, 3, 4
+ON CONFLICT (name, version, platform, mobile) DO NOTHING;
+
+-- name: ListBrowsers :many
+SELECT b.id, b.name, b.version, b.platform, b.mobile
+FROM browsers b
+JOIN (
+    SELECT
+        unnest(@names::TEXT[]) AS name,
+        unnest(@versions::TEXT[]) AS version,
+        unnest(@platforms::TEXT[]) AS platform,
+        unnest(@mobiles::BOOLEAN[]) AS mobile
+) k ON b.name = k.name AND b.version = k.version AND b.platform = k.platform AND b.mobile = k.mobile;
+
+-- Links deleted while their visits were queued are left out of the batch,
+-- the ones returned can't be deleted until the transaction ends.
+-- name: LockVisitedURLs :many
+SELECT id FROM urls
+WHERE id = ANY(@ids::INTEGER[])
+ORDER BY id
+FOR KEY SHARE;
 
 -- name: InsertVisitLocation :one
 INSERT INTO visit_locations (
@@ -38,56 +76,143 @@ VALUES (
 )
 RETURNING *;
 
+-- search_query is a tsquery, an empty one matches every link. Without a
+-- page_size all the links are returned.
 -- name: ListStatisticsPerAuthor :many
 SELECT
 	count(v.id) as nr_visits,
 	MIN(u.id)::INTEGER as id,
 	u.short_url as short_url,
+	MIN(u.domain)::TEXT as domain,
 	MIN(u.title):: TEXT as title,
 	MIN(u.long_url):: TEXT as long_url,
-	MIN(u.created_at)::TIMESTAMP as created_at
+	MIN(u.created_at)::TIMESTAMP as created_at,
+	BOOL_OR(COALESCE(u.is_archived, false))::BOOLEAN as is_archived,
+	COALESCE(MIN(u.folder_id), 0)::INTEGER as folder_id,
+	COALESCE(MIN(f.name), '')::TEXT as folder_name,
+	COALESCE((
+		SELECT array_agg(t.name ORDER BY t.name)
+		FROM url_tags ut
+		JOIN tags t ON t.id = ut.tag_id
+		WHERE ut.url_id = u.id
+	), '{}')::TEXT[] as tags
 FROM
 	urls u
-LEFT JOIN visits v ON u.id = v.url_id
+LEFT JOIN visits v ON u.id = v.url_id AND NOT v.is_bot
+LEFT JOIN folders f ON f.id = u.folder_id
 WHERE
-	u.author_id = @author_id
+	u.workspace_id = @workspace_id
+	AND COALESCE(u.is_archived, false) = @is_archived::BOOLEAN
+	AND (
+		@search_query::TEXT = ''
+		OR to_tsvector('simple', regexp_replace(u.title || ' ' || u.short_url || ' ' || u.long_url, '[^[:alnum:]]+', ' ', 'g')) @@ to_tsquery('simple', @search_query::TEXT)
+	)
+	AND (sqlc.narg('folder_id')::INTEGER IS NULL OR u.folder_id = sqlc.narg('folder_id'))
 	AND (
-		@search = ''
-		-- OR to_tsvector(regexp_replace(long_url, '^(https?://)?(www\.)?', '', 'i')) @@ to_tsquery(@search)
-		OR long_url ILIKE '%' || @search || '%'
+		sqlc.narg('tag')::TEXT IS NULL
+		OR EXISTS (
+			SELECT 1
+			FROM url_tags ut
+			JOIN tags t ON t.id = ut.tag_id
+			WHERE ut.url_id = u.id AND t.name = sqlc.narg('tag')
+		)
 	)
 GROUP BY
 	u.short_url, u.id
 ORDER BY
-	u.id DESC;
+	CASE WHEN @sort::TEXT = 'oldest' THEN MIN(u.created_at) END ASC,
+	CASE WHEN @sort::TEXT = 'most-clicked' THEN count(v.id) END DESC,
+	CASE WHEN @sort::TEXT = 'least-clicked' THEN count(v.id) END ASC,
+	CASE WHEN @sort::TEXT = 'folder' THEN MIN(f.name) END ASC NULLS LAST,
+	MIN(u.created_at) DESC,
+	u.id DESC
+LIMIT sqlc.narg('page_size')::INTEGER
+OFFSET @page_offset::INTEGER;
+
+-- name: CountLinksPerAuthor :one
+SELECT count(*)::INTEGER
+FROM urls u
+WHERE
+	u.workspace_id = @workspace_id
+	AND COALESCE(u.is_archived, false) = @is_archived::BOOLEAN
+	AND (
+		@search_query::TEXT = ''
+		OR to_tsvector('simple', regexp_replace(u.title || ' ' || u.short_url || ' ' || u.long_url, '[^[:alnum:]]+', ' ', 'g')) @@ to_tsquery('simple', @search_query::TEXT)
+	)
+	AND (sqlc.narg('folder_id')::INTEGER IS NULL OR u.folder_id = sqlc.narg('folder_id'))
+	AND (
+		sqlc.narg('tag')::TEXT IS NULL
+		OR EXISTS (
+			SELECT 1
+			FROM url_tags ut
+			JOIN tags t ON t.id = ut.tag_id
+			WHERE ut.url_id = u.id AND t.name = sqlc.narg('tag')
+		)
+	);
 
 -- name: StatisticPerURL :one
 SELECT
 	count(v.id) as nr_visits,
 	MIN(u.id)::INTEGER as id,
 	u.short_url as short_url,
+	MIN(u.domain)::TEXT as domain,
 	MIN(u.title):: TEXT as title,
 	MIN(u.long_url):: TEXT as long_url,
 	MIN(u.created_at)::TIMESTAMP as created_at
 FROM
 	urls u
-LEFT JOIN visits v ON u.id = v.url_id
+LEFT JOIN visits v ON u.id = v.url_id AND NOT v.is_bot
 WHERE
 	u.short_url = @short_url
-	AND u.author_id = @author_id
+	AND u.domain = @domain
+	AND u.workspace_id = @workspace_id
 GROUP BY
 	u.short_url, u.id
 LIMIT 1;
 
+-- Exports read visits a page at a time, oldest first, starting after the
+-- last visit of the previous page. A NULL url_id lists the visits of all the
+-- links of the workspace.
 -- name: ListVisits :many
-SELECT *
-FROM visits
-ORDER BY id DESC;
+SELECT
+	v.id,
+	v.visited_at,
+	u.short_url,
+	COALESCE(vl.country_code, '')::TEXT AS country_code,
+	COALESCE(vl.country_name, '')::TEXT AS country_name,
+	COALESCE(vl.city_name, '')::TEXT AS city_name,
+	COALESCE(b.name, '')::TEXT AS browser,
+	COALESCE(b.platform, '')::TEXT AS platform,
+	COALESCE(b.mobile, false)::BOOLEAN AS mobile,
+	COALESCE(v.referrer, '')::TEXT AS referrer,
+	v.is_bot
+FROM
+	visits v
+JOIN
+	urls u ON u.id = v.url_id
+LEFT JOIN
+	browsers b ON b.id = v.browser_id
+LEFT JOIN LATERAL (
+	SELECT country_code, country_name, city_name
+	FROM visit_locations
+	WHERE visit_locations.visit_id = v.id
+	LIMIT 1
+) vl ON true
+WHERE
+	u.workspace_id = @workspace_id
+	AND (sqlc.narg('url_id')::INTEGER IS NULL OR u.id = sqlc.narg('url_id'))
+	AND v.visited_at >= @start_date
+	AND v.visited_at < @end_date
+	AND (@include_bots::BOOLEAN OR NOT v.is_bot)
+	AND v.id > @after_id
+ORDER BY
+	v.id
+LIMIT @page_size;
 
 -- name: CountURLThisMonth :one
 SELECT count(*)
 FROM urls
-WHERE author_id = @author_id
+WHERE workspace_id = @workspace_id
 AND created_at BETWEEN date_trunc('month', CURRENT_DATE) AND date_trunc('month', CURRENT_DATE) + INTERVAL '1 month';
 
 
@@ -95,7 +220,7 @@ AND created_at BETWEEN date_trunc('month', CURRENT_DATE) AND date_trunc('month',
 SELECT count(visits.*)
 FROM visits
 JOIN urls ON urls.id = visits.url_id
-WHERE urls.author_id = @author_id
+WHERE urls.workspace_id = @workspace_id
 AND created_at BETWEEN date_trunc('month', CURRENT_DATE) AND date_trunc('month', CURRENT_DATE) + INTERVAL '1 month';
 
 -- SQL query to get the location distribution data for a specific URL
@@ -111,11 +236,24 @@ JOIN
     visits v ON vl.visit_id = v.id
 JOIN
     urls u ON v.url_id = u.id
-CROSS JOIN (SELECT count(*) as total FROM visits WHERE visits.url_id = @url_id) AS total_visits
+CROSS JOIN (
+    SELECT count(*) as total
+    FROM visits
+    WHERE visits.url_id = @url_id
+    AND (@include_bots::BOOLEAN OR NOT visits.is_bot)
+    AND (sqlc.narg('start_date')::TIMESTAMP IS NULL OR visits.visited_at >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::TIMESTAMP IS NULL OR visits.visited_at < sqlc.narg('end_date'))
+) AS total_visits
 WHERE
-	u.author_id = @author_id
+	u.workspace_id = @workspace_id
 AND
     u.id = @url_id -- Replace 'your_short_url' with the actual short URL
+AND
+    (@include_bots::BOOLEAN OR NOT v.is_bot)
+AND
+    (sqlc.narg('start_date')::TIMESTAMP IS NULL OR v.visited_at >= sqlc.narg('start_date'))
+AND
+    (sqlc.narg('end_date')::TIMESTAMP IS NULL OR v.visited_at < sqlc.narg('end_date'))
 GROUP BY
     vl.country_code, vl.country_name, total_visits.total
 ORDER BY
@@ -139,11 +277,20 @@ CROSS JOIN (
   FROM visits
   JOIN urls ON urls.id = visits.url_id
   WHERE urls.id = @url_id
+  AND (@include_bots::BOOLEAN OR NOT visits.is_bot)
+    AND (sqlc.narg('start_date')::TIMESTAMP IS NULL OR visits.visited_at >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::TIMESTAMP IS NULL OR visits.visited_at < sqlc.narg('end_date'))
 ) AS total_visits
 WHERE
-	u.author_id = @author_id
+	u.workspace_id = @workspace_id
 AND
     u.id = @url_id
+AND
+    (@include_bots::BOOLEAN OR NOT v.is_bot)
+AND
+    (sqlc.narg('start_date')::TIMESTAMP IS NULL OR v.visited_at >= sqlc.narg('start_date'))
+AND
+    (sqlc.narg('end_date')::TIMESTAMP IS NULL OR v.visited_at < sqlc.narg('end_date'))
 GROUP BY
     browsers.name, 
     total_visits.total
@@ -166,24 +313,34 @@ CROSS JOIN (
   FROM visits
   JOIN urls ON urls.id = visits.url_id
   WHERE urls.id = @url_id
+  AND (@include_bots::BOOLEAN OR NOT visits.is_bot)
+    AND (sqlc.narg('start_date')::TIMESTAMP IS NULL OR visits.visited_at >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::TIMESTAMP IS NULL OR visits.visited_at < sqlc.narg('end_date'))
 ) AS total_visits
 WHERE
-	u.author_id = @author_id
+	u.workspace_id = @workspace_id
 AND
     u.id = @url_id
+AND
+    (@include_bots::BOOLEAN OR NOT v.is_bot)
+AND
+    (sqlc.narg('start_date')::TIMESTAMP IS NULL OR v.visited_at >= sqlc.narg('start_date'))
+AND
+    (sqlc.narg('end_date')::TIMESTAMP IS NULL OR v.visited_at < sqlc.narg('end_date'))
 GROUP BY
     browsers.mobile, 
     total_visits.total;
 
 -- name: UniqueVisitCount :one
-SELECT 
-	count(*)
+SELECT
+	count(DISTINCT ip_address)
 FROM
 	visits
 WHERE
 	url_id = @url_id
-GROUP BY 
-	url_id, ip_address;
+	AND (@include_bots::BOOLEAN OR NOT is_bot)
+	AND (sqlc.narg('start_date')::TIMESTAMP IS NULL OR visited_at >= sqlc.narg('start_date'))
+	AND (sqlc.narg('end_date')::TIMESTAMP IS NULL OR visited_at < sqlc.narg('end_date'));
 
 
 -- name: TotalVisit :one
@@ -191,18 +348,36 @@ SELECT count(*)
 FROM
 	visits
 WHERE
-	url_id = @url_id;
+	url_id = @url_id
+	AND (@include_bots::BOOLEAN OR NOT is_bot)
+	AND (sqlc.narg('start_date')::TIMESTAMP IS NULL OR visited_at >= sqlc.narg('start_date'))
+	AND (sqlc.narg('end_date')::TIMESTAMP IS NULL OR visited_at < sqlc.narg('end_date'));
+
+-- name: QRScanCount :one
+SELECT count(*)
+FROM
+	visits
+WHERE
+	url_id = @url_id
+	AND source = 'qr'
+	AND (@include_bots::BOOLEAN OR NOT is_bot)
+	AND (sqlc.narg('start_date')::TIMESTAMP IS NULL OR visited_at >= sqlc.narg('start_date'))
+	AND (sqlc.narg('end_date')::TIMESTAMP IS NULL OR visited_at < sqlc.narg('end_date'));
 
 
+-- Visits are stored in UTC, buckets start at midnight, or the top of the
+-- hour, in the timezone of the viewer.
 -- name: VisitOverTime :many
 SELECT
-    date_trunc(@time_trunc, visited_at)::timestamp AS visit_date,
+    date_trunc(@time_trunc::TEXT, visited_at AT TIME ZONE 'UTC', @timezone::TEXT)::TIMESTAMPTZ AS visit_date,
     COUNT(*) AS visit_count
 FROM
     visits
 WHERE
     url_id = @url_id
-    AND visited_at BETWEEN @start_date AND @end_date
+    AND visited_at >= @start_date
+    AND visited_at < @end_date
+    AND (@include_bots::BOOLEAN OR NOT is_bot)
 GROUP BY
     visit_date
 ORDER BY
@@ -221,11 +396,20 @@ CROSS JOIN (
     SELECT count(*) AS total
     FROM visits
     WHERE visits.url_id = @url_id
+    AND (@include_bots::BOOLEAN OR NOT visits.is_bot)
+    AND (sqlc.narg('start_date')::TIMESTAMP IS NULL OR visits.visited_at >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::TIMESTAMP IS NULL OR visits.visited_at < sqlc.narg('end_date'))
 ) AS total_visits
 WHERE
-    u.author_id = @author_id
+    u.workspace_id = @workspace_id
 AND
     u.id = @url_id
+AND
+    (@include_bots::BOOLEAN OR NOT v.is_bot)
+AND
+    (sqlc.narg('start_date')::TIMESTAMP IS NULL OR v.visited_at >= sqlc.narg('start_date'))
+AND
+    (sqlc.narg('end_date')::TIMESTAMP IS NULL OR v.visited_at < sqlc.narg('end_date'))
 AND 
     v.referrer IS NOT NULL AND v.referrer != '' -- Exclude empty or null referrers
 GROUP BY
@@ -8,11 +8,11 @@ LIMIT 1;
 -- name: GetCustomer :one
 SELECT *
 FROM customer
-WHERE user_id = $1
+WHERE workspace_guid = $1
 LIMIT 1;
 
 -- name: InsertCustomer :one
-INSERT INTO customer (user_id, stripe_id)
+INSERT INTO customer (workspace_guid, stripe_id)
 VALUES ($1, $2)
 RETURNING *;
 
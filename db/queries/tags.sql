@@ -0,0 +1,80 @@
+-- name: ListFolders :many
+SELECT
+    f.id,
+    f.name,
+    COUNT(u.id)::INTEGER AS links
+FROM folders f
+LEFT JOIN urls u ON u.folder_id = f.id AND NOT COALESCE(u.is_archived, false)
+WHERE f.workspace_id = @workspace_id
+GROUP BY f.id
+ORDER BY f.name;
+
+-- name: GetFolder :one
+SELECT *
+FROM folders
+WHERE id = @id
+AND workspace_id = @workspace_id;
+
+-- name: CountFolders :one
+SELECT COUNT(*)::INTEGER
+FROM folders
+WHERE workspace_id = @workspace_id;
+
+-- name: InsertFolder :one
+INSERT INTO folders (workspace_id, name)
+VALUES (@workspace_id, @name)
+RETURNING *;
+
+-- name: DeleteFolder :execrows
+DELETE FROM folders
+WHERE id = @id
+AND workspace_id = @workspace_id;
+
+-- name: SetURLFolder :exec
+UPDATE urls
+SET folder_id = sqlc.narg('folder_id')
+WHERE id = @url_id;
+
+-- name: ListURLTags :many
+SELECT t.name
+FROM url_tags ut
+JOIN tags t ON t.id = ut.tag_id
+WHERE ut.url_id = @url_id
+ORDER BY t.name;
+
+-- Conflicting names are updated so that their ids are returned as well.
+-- name: UpsertTags :many
+INSERT INTO tags (workspace_id, name)
+SELECT @workspace_id, unnest(@names::TEXT[])
+ON CONFLICT (workspace_id, name)
+DO UPDATE SET name = EXCLUDED.name
+RETURNING id;
+
+-- name: DeleteURLTags :exec
+DELETE FROM url_tags
+WHERE url_id = @url_id;
+
+-- name: InsertURLTags :exec
+INSERT INTO url_tags (url_id, tag_id)
+SELECT @url_id, unnest(@tag_ids::INTEGER[]);
+
+-- name: DeleteUnusedTags :exec
+DELETE FROM tags t
+WHERE t.workspace_id = @workspace_id
+AND NOT EXISTS (SELECT 1 FROM url_tags ut WHERE ut.tag_id = t.id);
+
+-- name: TagStatistics :many
+SELECT
+    t.name,
+    COUNT(DISTINCT ut.url_id)::INTEGER AS links,
+    COUNT(v.id)::INTEGER AS clicks,
+    COUNT(DISTINCT v.ip_address)::INTEGER AS unique_visitors
+FROM tags t
+JOIN url_tags ut ON ut.tag_id = t.id
+LEFT JOIN visits v ON v.url_id = ut.url_id
+    AND (@include_bots::BOOLEAN OR NOT v.is_bot)
+    AND (sqlc.narg('start_date')::TIMESTAMP IS NULL OR v.visited_at >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::TIMESTAMP IS NULL OR v.visited_at < sqlc.narg('end_date'))
+WHERE t.workspace_id = @workspace_id
+GROUP BY t.id
+ORDER BY clicks DESC, t.name;
@@ -1,19 +1,34 @@
 -- name: AddShortURL :one
-INSERT INTO urls (title, short_url, long_url, author_id, is_active) 
-VALUES (@title, @short_url, @long_url, @author_id, @is_active)
+INSERT INTO urls (title, short_url, domain, long_url, author_id, workspace_id, is_active, expires_at, max_clicks, password_hash, password_salt, redirect_status, forward_query)
+VALUES (@title, @short_url, @domain, @long_url, @author_id, @workspace_id, @is_active, @expires_at, @max_clicks, @password_hash, @password_salt, @redirect_status, @forward_query)
 RETURNING *;
 
 -- name: ListShortURLs :many
 SELECT *
 FROM urls
-WHERE urls.author_id = @author_id
+WHERE urls.workspace_id = @workspace_id
 AND urls.is_archived = @is_archived
 ORDER BY id DESC;
 
 -- name: GetShortURL :one
 SELECT *
 FROM urls
-WHERE urls.short_url = @short_url;
+WHERE urls.short_url = @short_url
+AND urls.domain = @domain
+AND urls.workspace_id = @workspace_id;
+
+-- Redirects of a verified custom domain only follow its links, any other
+-- host follows the links of the default domain.
+-- name: GetRedirectURL :one
+SELECT urls.*
+FROM urls
+WHERE urls.short_url = @short_url
+AND urls.domain = COALESCE((
+    SELECT domains.hostname
+    FROM domains
+    WHERE domains.hostname = @host
+    AND domains.verified_at IS NOT NULL
+), '');
 
 -- name: GetByID :one
 SELECT *
@@ -23,28 +38,127 @@ WHERE urls.id = @id;
 -- name: DeleteURL :exec
 DELETE FROM urls
 WHERE id = @id
-AND urls.author_id = @author_id;
+AND urls.workspace_id = @workspace_id;
 
 -- name: UpdateTitle :one
 UPDATE urls
 SET title = @title
 WHERE urls.short_url = @short_url
-AND urls.author_id = @author_id
+AND urls.domain = @domain
+AND urls.workspace_id = @workspace_id
+RETURNING *;
+
+-- Saves the current destination in url_destination_history before replacing
+-- it, in a single statement so the history can't miss a change.
+-- name: UpdateDestination :one
+WITH previous AS (
+    SELECT id, long_url
+    FROM urls
+    WHERE urls.short_url = @short_url
+    AND urls.domain = @domain
+    AND urls.workspace_id = @workspace_id
+    FOR UPDATE
+), history AS (
+    INSERT INTO url_destination_history (url_id, long_url, changed_by)
+    SELECT previous.id, previous.long_url, @changed_by
+    FROM previous
+    WHERE previous.long_url <> @long_url
+)
+UPDATE urls
+SET title = @title,
+    long_url = @long_url,
+    is_active = @is_active,
+    redirect_status = @redirect_status
+FROM previous
+WHERE urls.id = previous.id
+RETURNING urls.*;
+
+-- name: ListDestinationHistory :many
+SELECT
+    h.long_url,
+    h.changed_at,
+    COALESCE(users.username, '')::TEXT AS changed_by
+FROM url_destination_history h
+LEFT JOIN users ON users.id = h.changed_by
+WHERE h.url_id = @url_id
+ORDER BY h.changed_at DESC, h.id DESC;
+
+-- name: UpdateExpiration :one
+UPDATE urls
+SET expires_at = @expires_at,
+    max_clicks = @max_clicks
+WHERE urls.short_url = @short_url
+AND urls.domain = @domain
+AND urls.workspace_id = @workspace_id
+RETURNING *;
+
+-- Counts a redirect toward the click limit of a link. No row is returned once
+-- the limit is reached.
+-- name: CountClick :one
+UPDATE urls
+SET clicks = clicks + 1
+WHERE id = @id
+AND (max_clicks IS NULL OR clicks < max_clicks)
+RETURNING clicks;
+
+-- name: UpdatePassword :one
+UPDATE urls
+SET password_hash = @password_hash,
+    password_salt = @password_salt
+WHERE urls.short_url = @short_url
+AND urls.domain = @domain
+AND urls.workspace_id = @workspace_id
+RETURNING *;
+
+-- name: UpdateForwardQuery :one
+UPDATE urls
+SET forward_query = @forward_query
+WHERE urls.short_url = @short_url
+AND urls.domain = @domain
+AND urls.workspace_id = @workspace_id
+RETURNING *;
+
+-- name: UpdateStickyVariants :one
+UPDATE urls
+SET sticky_variants = @sticky_variants
+WHERE urls.short_url = @short_url
+AND urls.domain = @domain
+AND urls.workspace_id = @workspace_id
 RETURNING *;
 
 -- name: ArchiveURL :exec
 UPDATE urls
 SET is_archived = true
 WHERE urls.short_url = @short_url
-AND urls.author_id = @author_id;
+AND urls.domain = @domain
+AND urls.workspace_id = @workspace_id;
 
 -- name: UnarchiveURL :exec
 UPDATE urls
 SET is_archived = false
 WHERE urls.short_url = @short_url
-AND urls.author_id = @author_id;
+AND urls.domain = @domain
+AND urls.workspace_id = @workspace_id;
+
+-- The links are given as two arrays of the same length, slugs[i] being
+-- served on domains[i].
+-- name: UnarchiveURLs :execrows
+UPDATE urls
+SET is_archived = false
+FROM (
+    SELECT unnest(@domains::TEXT[]) AS domain, unnest(@slugs::TEXT[]) AS short_url
+) refs
+WHERE urls.domain = refs.domain
+AND urls.short_url = refs.short_url
+AND urls.workspace_id = @workspace_id;
 
 -- name: UpdateURLStatus :exec
 UPDATE urls
 SET is_active = @is_active
-WHERE urls.short_url = @short_url;
\ No newline at end of file
+WHERE urls.id = @id;
+
+-- The highest id is a cheap, slightly pessimistic, stand-in for count(*): it
+-- uses the primary key index and also counts deleted links.
+-- name: EstimateURLCount :one
+SELECT COALESCE(MAX(id), 0)::BIGINT AS count
+FROM urls;
@@ -0,0 +1,35 @@
+-- name: ListVariants :many
+SELECT *
+FROM url_variants
+WHERE url_id = @url_id
+ORDER BY position, id;
+
+-- name: InsertVariant :one
+INSERT INTO url_variants (url_id, position, long_url, weight)
+VALUES (@url_id, @position, @long_url, @weight)
+RETURNING *;
+
+-- name: UpdateVariant :one
+UPDATE url_variants
+SET position = @position,
+    long_url = @long_url,
+    weight = @weight
+WHERE id = @id
+AND url_id = @url_id
+RETURNING *;
+
+-- name: DeleteVariantsExcept :exec
+DELETE FROM url_variants
+WHERE url_id = @url_id
+AND NOT (id = ANY(@keep::INTEGER[]));
+
+-- name: CountVariantVisits :many
+SELECT
+    v.variant_id::INTEGER AS variant_id,
+    COUNT(*)::INTEGER AS visits,
+    COUNT(DISTINCT v.ip_address)::INTEGER AS unique_visitors
+FROM visits v
+JOIN url_variants uv ON uv.id = v.variant_id
+WHERE uv.url_id = @url_id
+AND NOT v.is_bot
+GROUP BY v.variant_id;
@@ -0,0 +1,135 @@
+-- name: InsertWebhookEndpoint :one
+INSERT INTO webhook_endpoints (workspace_id, url, secret, events)
+VALUES (@workspace_id, @url, @secret, @events::TEXT[])
+RETURNING *;
+
+-- name: ListWebhookEndpoints :many
+SELECT *
+FROM webhook_endpoints
+WHERE workspace_id = @workspace_id
+ORDER BY id;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = @id
+AND workspace_id = @workspace_id;
+
+-- name: ListSubscribedWebhookEndpoints :many
+SELECT id
+FROM webhook_endpoints
+WHERE workspace_id = @workspace_id
+AND @event::TEXT = ANY(events);
+
+-- Endpoints subscribed to any of the events of the links of their workspace,
+-- used to notify the visits of a batch.
+-- name: ListLinkWebhookEndpoints :many
+SELECT
+    u.id AS url_id,
+    u.short_url,
+    u.domain,
+    u.long_url,
+    u.title,
+    u.created_at AS url_created_at,
+    e.id AS endpoint_id,
+    e.events
+FROM urls u
+JOIN webhook_endpoints e ON e.workspace_id = u.workspace_id
+WHERE u.id = ANY(@url_ids::INTEGER[])
+AND e.events && @events::TEXT[];
+
+-- name: CountLinkVisits :many
+SELECT url_id, count(*) AS visits
+FROM visits
+WHERE url_id = ANY(@url_ids::INTEGER[])
+AND NOT is_bot
+GROUP BY url_id;
+
+-- Endpoints start from the thresholds the links of their workspace already
+-- reached, only the next ones are notified.
+-- name: SeedWebhookThresholds :exec
+INSERT INTO webhook_thresholds (endpoint_id, url_id, threshold)
+SELECT @endpoint_id::INTEGER, clicks.url_id, max(t.threshold)
+FROM (
+    SELECT visits.url_id, count(*) AS total
+    FROM visits
+    JOIN urls ON urls.id = visits.url_id
+    WHERE urls.workspace_id = @workspace_id::INTEGER
+    AND NOT visits.is_bot
+    GROUP BY visits.url_id
+) clicks
+JOIN unnest(@thresholds::BIGINT[]) AS t(threshold) ON t.threshold <= clicks.total
+GROUP BY clicks.url_id;
+
+-- name: RaiseWebhookThreshold :execrows
+INSERT INTO webhook_thresholds (endpoint_id, url_id, threshold)
+VALUES (@endpoint_id, @url_id, @threshold)
+ON CONFLICT (endpoint_id, url_id) DO UPDATE
+SET threshold = EXCLUDED.threshold
+WHERE webhook_thresholds.threshold < EXCLUDED.threshold;
+
+-- name: InsertWebhookDeliveries :copyfrom
+INSERT INTO webhook_deliveries (endpoint_id, event, payload, next_attempt_at)
+VALUES (@endpoint_id, @event, @payload, @next_attempt_at);
+
+-- Due deliveries are leased until lease_until so that a dispatcher dying
+-- midway doesn't lose them, and skipped by the other dispatchers meanwhile.
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET next_attempt_at = @lease_until::TIMESTAMP
+FROM webhook_endpoints e
+WHERE e.id = d.endpoint_id
+AND d.id IN (
+    SELECT due.id
+    FROM webhook_deliveries due
+    WHERE due.status = 'pending'
+    AND due.next_attempt_at <= @now::TIMESTAMP
+    ORDER BY due.next_attempt_at
+    LIMIT @batch_size
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING d.id, d.event, d.payload, d.attempts, e.url, e.secret;
+
+-- name: RecordWebhookAttempt :exec
+UPDATE webhook_deliveries
+SET
+    status = @status,
+    attempts = attempts + 1,
+    last_attempt_at = @attempted_at,
+    next_attempt_at = @next_attempt_at,
+    response_status = sqlc.narg('response_status'),
+    last_error = @last_error
+WHERE id = @id;
+
+-- name: ListWebhookDeliveries :many
+SELECT
+    d.id,
+    d.endpoint_id,
+    e.url AS endpoint_url,
+    d.event,
+    d.status,
+    d.attempts,
+    d.next_attempt_at,
+    d.last_attempt_at,
+    d.response_status,
+    d.last_error,
+    d.created_at
+FROM webhook_deliveries d
+JOIN webhook_endpoints e ON e.id = d.endpoint_id
+WHERE e.workspace_id = @workspace_id
+ORDER BY d.id DESC
+LIMIT @max_results;
+
+-- Redelivering queues a copy of the delivery, the original stays in the log.
+-- name: RedeliverWebhook :one
+INSERT INTO webhook_deliveries (endpoint_id, event, payload, next_attempt_at)
+SELECT d.endpoint_id, d.event, d.payload, @now
+FROM webhook_deliveries d
+JOIN webhook_endpoints e ON e.id = d.endpoint_id
+WHERE d.id = @id
+AND e.workspace_id = @workspace_id
+RETURNING id;
+
+-- name: DeleteWebhookDeliveriesBefore :execrows
+DELETE FROM webhook_deliveries
+WHERE status != 'pending'
+AND created_at < @before;
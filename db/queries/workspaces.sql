@@ -0,0 +1,140 @@
+-- name: EnsurePersonalWorkspace :exec
+WITH workspace AS (
+    INSERT INTO workspaces (guid, name, personal_of)
+    VALUES (@guid, 'Personal', @user_id)
+    ON CONFLICT (personal_of) DO NOTHING
+    RETURNING id
+)
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT id, @user_id, 'owner' FROM workspace;
+
+-- name: GetPersonalWorkspace :one
+SELECT
+    w.*,
+    m.role
+FROM workspaces w
+JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = w.personal_of
+WHERE w.personal_of = @user_id::INTEGER;
+
+-- name: GetMemberWorkspace :one
+SELECT
+    w.*,
+    m.role
+FROM workspaces w
+JOIN workspace_members m ON m.workspace_id = w.id
+WHERE w.id = @workspace_id
+AND m.user_id = @user_id;
+
+-- The personal workspace comes first.
+-- name: ListMemberWorkspaces :many
+SELECT
+    w.*,
+    m.role
+FROM workspaces w
+JOIN workspace_members m ON m.workspace_id = w.id
+WHERE m.user_id = @user_id
+ORDER BY w.personal_of IS NULL, w.name, w.id;
+
+-- name: CountOwnedWorkspaces :one
+SELECT COUNT(*)::INTEGER
+FROM workspace_members m
+JOIN workspaces w ON w.id = m.workspace_id
+WHERE m.user_id = @user_id
+AND m.role = 'owner'
+AND w.personal_of IS NULL;
+
+-- name: InsertWorkspace :one
+WITH workspace AS (
+    INSERT INTO workspaces (name)
+    VALUES (@name)
+    RETURNING *
+), member AS (
+    INSERT INTO workspace_members (workspace_id, user_id, role)
+    SELECT id, @user_id, 'owner' FROM workspace
+)
+SELECT * FROM workspace;
+
+-- name: ListWorkspaceMembers :many
+SELECT
+    u.id,
+    u.username,
+    u.email,
+    m.role,
+    m.created_at
+FROM workspace_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.workspace_id = @workspace_id
+ORDER BY m.created_at, u.id;
+
+-- Locks the owners of a workspace until the end of the transaction, so that
+-- concurrent changes of its members can't leave it without any.
+-- name: LockWorkspaceOwners :many
+SELECT user_id
+FROM workspace_members
+WHERE workspace_id = @workspace_id
+AND role = 'owner'
+ORDER BY user_id
+FOR UPDATE;
+
+-- name: UpdateMemberRole :execrows
+UPDATE workspace_members
+SET role = @role
+WHERE workspace_id = @workspace_id
+AND user_id = @user_id;
+
+-- name: DeleteMember :execrows
+DELETE FROM workspace_members
+WHERE workspace_id = @workspace_id
+AND user_id = @user_id;
+
+-- Inviting an email again replaces its previous invitation.
+-- name: UpsertInvitation :one
+INSERT INTO workspace_invitations (workspace_id, email, role, token_hash, invited_by, expires_at)
+VALUES (@workspace_id, @email, @role, @token_hash, @invited_by, @expires_at)
+ON CONFLICT (workspace_id, email) DO UPDATE SET
+    role = EXCLUDED.role,
+    token_hash = EXCLUDED.token_hash,
+    invited_by = EXCLUDED.invited_by,
+    created_at = CURRENT_TIMESTAMP,
+    expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: ListInvitations :many
+SELECT
+    i.id,
+    i.email,
+    i.role,
+    i.created_at,
+    i.expires_at,
+    COALESCE(u.username, '')::TEXT AS invited_by
+FROM workspace_invitations i
+LEFT JOIN users u ON u.id = i.invited_by
+WHERE i.workspace_id = @workspace_id
+AND i.expires_at > CURRENT_TIMESTAMP
+ORDER BY i.created_at DESC;
+
+-- name: GetInvitationByToken :one
+SELECT
+    i.*,
+    w.name AS workspace_name
+FROM workspace_invitations i
+JOIN workspaces w ON w.id = i.workspace_id
+WHERE i.token_hash = @token_hash
+AND i.expires_at > CURRENT_TIMESTAMP;
+
+-- name: DeleteInvitation :execrows
+DELETE FROM workspace_invitations
+WHERE id = @id
+AND workspace_id = @workspace_id;
+
+-- Members keep their role when they accept another invitation to the same
+-- workspace.
+-- name: AcceptInvitation :exec
+WITH invitation AS (
+    DELETE FROM workspace_invitations
+    WHERE id = @id
+    RETURNING workspace_id, role
+)
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT workspace_id, @user_id, role FROM invitation
+ON CONFLICT (workspace_id, user_id) DO NOTHING;
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+type apiTokenStore struct {
+	db datastore.Querier
+}
+
+func NewAPITokenStore(db datastore.DBTX) *apiTokenStore {
+	return &apiTokenStore{
+		db: datastore.New(db),
+	}
+}
+
+func (s *apiTokenStore) Insert(ctx context.Context, userID domain.ID, name, prefix string, hash []byte) (datastore.ApiToken, error) {
+	token, err := s.db.InsertAPIToken(ctx, datastore.InsertAPITokenParams{
+		UserID:      int32(userID),
+		Name:        name,
+		TokenPrefix: prefix,
+		TokenHash:   hash,
+	})
+	if err != nil {
+		return datastore.ApiToken{}, fmt.Errorf("failed to insert api token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *apiTokenStore) List(ctx context.Context, userID domain.ID) ([]datastore.ApiToken, error) {
+	tokens, err := s.db.ListAPITokens(ctx, int32(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (s *apiTokenStore) Revoke(ctx context.Context, userID, tokenID domain.ID) (bool, error) {
+	n, err := s.db.RevokeAPIToken(ctx, datastore.RevokeAPITokenParams{
+		ID:     int32(tokenID),
+		UserID: int32(userID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api token: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *apiTokenStore) GetUser(ctx context.Context, hash []byte) (datastore.GetUserByAPITokenRow, error) {
+	row, err := s.db.GetUserByAPIToken(ctx, hash)
+	if err != nil {
+		return datastore.GetUserByAPITokenRow{}, fmt.Errorf("failed to get user by api token: %w", err)
+	}
+	return row, nil
+}
+
+func (s *apiTokenStore) Touch(ctx context.Context, tokenID domain.ID) error {
+	if err := s.db.TouchAPIToken(ctx, int32(tokenID)); err != nil {
+		return fmt.Errorf("failed to update api token last use: %w", err)
+	}
+	return nil
+}
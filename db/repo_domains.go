@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+type domainStore struct {
+	db *datastore.Queries
+}
+
+func NewDomainStore(pool *pgxpool.Pool) *domainStore {
+	return &domainStore{
+		db: datastore.New(pool),
+	}
+}
+
+func toCustomDomain(row datastore.Domain) domain.CustomDomain {
+	return domain.CustomDomain{
+		ID:                domain.ID(row.ID),
+		WorkspaceID:       domain.ID(row.WorkspaceID),
+		Hostname:          row.Hostname,
+		VerificationToken: row.VerificationToken,
+		VerifiedAt:        row.VerifiedAt.Time,
+		CreatedAt:         row.CreatedAt.Time,
+	}
+}
+
+// ListDomains returns the custom domains of workspaceID, sorted by hostname.
+func (s *domainStore) ListDomains(ctx context.Context, workspaceID domain.ID) ([]domain.CustomDomain, error) {
+	rows, err := s.db.ListDomains(ctx, int32(workspaceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	domains := make([]domain.CustomDomain, len(rows))
+	for i, row := range rows {
+		domains[i] = toCustomDomain(row)
+	}
+	return domains, nil
+}
+
+// GetDomain returns the domain domainID of workspaceID, pgx.ErrNoRows when
+// it belongs to another workspace.
+func (s *domainStore) GetDomain(ctx context.Context, workspaceID, domainID domain.ID) (domain.CustomDomain, error) {
+	row, err := s.db.GetDomain(ctx, datastore.GetDomainParams{
+		ID:          int32(domainID),
+		WorkspaceID: int32(workspaceID),
+	})
+	if err != nil {
+		return domain.CustomDomain{}, err
+	}
+	return toCustomDomain(row), nil
+}
+
+func (s *domainStore) CountDomains(ctx context.Context, workspaceID domain.ID) (int, error) {
+	n, err := s.db.CountDomains(ctx, int32(workspaceID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count domains: %w", err)
+	}
+	return int(n), nil
+}
+
+func (s *domainStore) InsertDomain(ctx context.Context, workspaceID domain.ID, hostname, token string) (domain.CustomDomain, error) {
+	row, err := s.db.InsertDomain(ctx, datastore.InsertDomainParams{
+		WorkspaceID:       int32(workspaceID),
+		Hostname:          hostname,
+		VerificationToken: token,
+	})
+	if err != nil {
+		return domain.CustomDomain{}, fmt.Errorf("failed to add domain: %w", err)
+	}
+	return toCustomDomain(row), nil
+}
+
+// VerifyDomain marks domainID as verified, which fails on a unique violation
+// when another workspace already verified the hostname.
+func (s *domainStore) VerifyDomain(ctx context.Context, workspaceID, domainID domain.ID) (domain.CustomDomain, error) {
+	row, err := s.db.VerifyDomain(ctx, datastore.VerifyDomainParams{
+		ID:          int32(domainID),
+		WorkspaceID: int32(workspaceID),
+	})
+	if err != nil {
+		return domain.CustomDomain{}, fmt.Errorf("failed to verify domain: %w", err)
+	}
+	return toCustomDomain(row), nil
+}
+
+// DeleteDomain reports whether domainID was a domain of workspaceID.
+func (s *domainStore) DeleteDomain(ctx context.Context, workspaceID, domainID domain.ID) (bool, error) {
+	n, err := s.db.DeleteDomain(ctx, datastore.DeleteDomainParams{
+		ID:          int32(domainID),
+		WorkspaceID: int32(workspaceID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete domain: %w", err)
+	}
+	return n > 0, nil
+}
+
+// CountDomainURLs counts the links of workspaceID served on hostname.
+func (s *domainStore) CountDomainURLs(ctx context.Context, workspaceID domain.ID, hostname string) (int, error) {
+	n, err := s.db.CountDomainURLs(ctx, datastore.CountDomainURLsParams{
+		WorkspaceID: int32(workspaceID),
+		Domain:      hostname,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count domain links: %w", err)
+	}
+	return int(n), nil
+}
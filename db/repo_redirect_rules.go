@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+type redirectRuleStore struct {
+	pool *pgxpool.Pool
+	db   *datastore.Queries
+}
+
+func NewRedirectRuleStore(pool *pgxpool.Pool) *redirectRuleStore {
+	return &redirectRuleStore{
+		pool: pool,
+		db:   datastore.New(pool),
+	}
+}
+
+func (s *redirectRuleStore) ListRedirectRules(ctx context.Context, urlID domain.ID) ([]datastore.UrlRedirectRule, error) {
+	rows, err := s.db.ListRedirectRules(ctx, int32(urlID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list redirect rules: %w", err)
+	}
+	return rows, nil
+}
+
+// CountRedirectRuleVisits returns the number of visits redirected by each rule
+// of a link, by rule ID.
+func (s *redirectRuleStore) CountRedirectRuleVisits(ctx context.Context, urlID domain.ID) (map[domain.ID]int, error) {
+	rows, err := s.db.CountRedirectRuleVisits(ctx, int32(urlID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count redirect rule visits: %w", err)
+	}
+	counts := make(map[domain.ID]int, len(rows))
+	for _, row := range rows {
+		counts[domain.ID(row.RuleID)] = int(row.Visits)
+	}
+	return counts, nil
+}
+
+// ReplaceRedirectRules makes rules, in order, the redirect rules of a link in
+// a single transaction. Rules with an ID are updated and keep it so that the
+// visits they redirected stay attached to them, the others are inserted and
+// the rules left out are deleted.
+func (s *redirectRuleStore) ReplaceRedirectRules(ctx context.Context, urlID domain.ID, rules []domain.RedirectRule) ([]datastore.UrlRedirectRule, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		// no-op if the tx already committed
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("error rolling back transaction", "err", err)
+		}
+	}()
+	q := s.db.WithTx(tx)
+
+	keep := make([]int32, 0, len(rules))
+	for _, rule := range rules {
+		if rule.ID != 0 {
+			keep = append(keep, int32(rule.ID))
+		}
+	}
+	if err := q.DeleteRedirectRulesExcept(ctx, datastore.DeleteRedirectRulesExceptParams{
+		UrlID: int32(urlID),
+		Keep:  keep,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to delete redirect rules: %w", err)
+	}
+
+	rows := make([]datastore.UrlRedirectRule, 0, len(rules))
+	for i, rule := range rules {
+		var (
+			row datastore.UrlRedirectRule
+			err error
+		)
+		if rule.ID == 0 {
+			row, err = q.InsertRedirectRule(ctx, datastore.InsertRedirectRuleParams{
+				UrlID:     int32(urlID),
+				Position:  int32(i),
+				LongUrl:   rule.LongURL,
+				Platforms: platforms(rule.Platforms),
+				Mobile:    mobile(rule.Mobile),
+				Countries: nonNil(rule.Countries),
+				Languages: nonNil(rule.Languages),
+				StartsAt:  timestamp(rule.StartsAt),
+				EndsAt:    timestamp(rule.EndsAt),
+			})
+		} else {
+			row, err = q.UpdateRedirectRule(ctx, datastore.UpdateRedirectRuleParams{
+				ID:        int32(rule.ID),
+				UrlID:     int32(urlID),
+				Position:  int32(i),
+				LongUrl:   rule.LongURL,
+				Platforms: platforms(rule.Platforms),
+				Mobile:    mobile(rule.Mobile),
+				Countries: nonNil(rule.Countries),
+				Languages: nonNil(rule.Languages),
+				StartsAt:  timestamp(rule.StartsAt),
+				EndsAt:    timestamp(rule.EndsAt),
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to save redirect rule %d: %w", i, err)
+		}
+		rows = append(rows, row)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return rows, nil
+}
+
+func platforms(p []domain.Platform) []string {
+	s := make([]string, len(p))
+	for i, platform := range p {
+		s[i] = string(platform)
+	}
+	return s
+}
+
+func mobile(b *bool) pgtype.Bool {
+	if b == nil {
+		return pgtype.Bool{}
+	}
+	return pgtype.Bool{Bool: *b, Valid: true}
+}
+
+// nonNil keeps NOT NULL array columns from receiving a NULL.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func timestamp(t *time.Time) pgtype.Timestamp {
+	if t == nil {
+		return pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: t.UTC(), Valid: true}
+}
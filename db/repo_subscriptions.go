@@ -59,10 +59,11 @@ func (r *repoSubscriptions) Tx(ctx context.Context, fn func(context.Context) err
 	return nil
 }
 
-func (r *repoSubscriptions) GetCustomer(ctx context.Context, user *domain.User) (datastore.Customer, error) {
-	row, err := r.q(ctx).GetCustomer(ctx, user.GUID.PgType())
+// GetCustomer returns the Stripe customer paying for the workspace workspaceGUID.
+func (r *repoSubscriptions) GetCustomer(ctx context.Context, workspaceGUID domain.GUID) (datastore.Customer, error) {
+	row, err := r.q(ctx).GetCustomer(ctx, workspaceGUID.PgType())
 	if err != nil {
-		return datastore.Customer{}, fmt.Errorf("error getting customer with id %s: %w", user.GUID, err)
+		return datastore.Customer{}, fmt.Errorf("error getting customer with id %s: %w", workspaceGUID, err)
 	}
 	return row, nil
 }
@@ -99,9 +100,9 @@ func (r repoSubscriptions) UpdateSubscription(ctx context.Context, subscription
 	return nil
 }
 
-func (r *repoSubscriptions) ListSubscriptions(ctx context.Context, user *domain.User, status string) ([]datastore.Subscription, error) {
+func (r *repoSubscriptions) ListSubscriptions(ctx context.Context, workspaceGUID domain.GUID, status string) ([]datastore.Subscription, error) {
 	row, err := r.q(ctx).ListCustomerSubscription(ctx, datastore.ListCustomerSubscriptionParams{
-		CustomerID: user.GUID.PgType(),
+		CustomerID: workspaceGUID.PgType(),
 		Status: pgtype.Text{
 			String: status,
 			Valid:  status != "",
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+type tagStore struct {
+	pool *pgxpool.Pool
+	db   *datastore.Queries
+}
+
+func NewTagStore(pool *pgxpool.Pool) *tagStore {
+	return &tagStore{
+		pool: pool,
+		db:   datastore.New(pool),
+	}
+}
+
+// ListFolders returns the folders of a workspace by name, with the number of links
+// filed in each.
+func (s *tagStore) ListFolders(ctx context.Context, workspaceID domain.ID) ([]datastore.ListFoldersRow, error) {
+	rows, err := s.db.ListFolders(ctx, int32(workspaceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	return rows, nil
+}
+
+func (s *tagStore) GetFolder(ctx context.Context, workspaceID, folderID domain.ID) (datastore.Folder, error) {
+	return s.db.GetFolder(ctx, datastore.GetFolderParams{
+		ID:          int32(folderID),
+		WorkspaceID: int32(workspaceID),
+	})
+}
+
+func (s *tagStore) CountFolders(ctx context.Context, workspaceID domain.ID) (int, error) {
+	n, err := s.db.CountFolders(ctx, int32(workspaceID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count folders: %w", err)
+	}
+	return int(n), nil
+}
+
+func (s *tagStore) InsertFolder(ctx context.Context, workspaceID domain.ID, name string) (datastore.Folder, error) {
+	return s.db.InsertFolder(ctx, datastore.InsertFolderParams{
+		WorkspaceID: int32(workspaceID),
+		Name:        name,
+	})
+}
+
+// DeleteFolder deletes a folder of a workspace, its links are left out of any
+// folder. It reports whether the folder existed.
+func (s *tagStore) DeleteFolder(ctx context.Context, workspaceID, folderID domain.ID) (bool, error) {
+	n, err := s.db.DeleteFolder(ctx, datastore.DeleteFolderParams{
+		ID:          int32(folderID),
+		WorkspaceID: int32(workspaceID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete folder: %w", err)
+	}
+	return n > 0, nil
+}
+
+// SetURLFolder files a link in a folder, or in none when folderID is 0.
+func (s *tagStore) SetURLFolder(ctx context.Context, urlID, folderID domain.ID) error {
+	return s.db.SetURLFolder(ctx, datastore.SetURLFolderParams{
+		UrlID:    int32(urlID),
+		FolderID: pgtype.Int4{Int32: int32(folderID), Valid: folderID != 0},
+	})
+}
+
+func (s *tagStore) ListURLTags(ctx context.Context, urlID domain.ID) ([]string, error) {
+	tags, err := s.db.ListURLTags(ctx, int32(urlID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	return tags, nil
+}
+
+// ReplaceTags makes names the tags of a link of workspaceID in a single
+// transaction. The tags no link wears anymore are deleted.
+func (s *tagStore) ReplaceTags(ctx context.Context, workspaceID, urlID domain.ID, names []string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		// no-op if the tx already committed
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("error rolling back transaction", "err", err)
+		}
+	}()
+	q := s.db.WithTx(tx)
+
+	if err := q.DeleteURLTags(ctx, int32(urlID)); err != nil {
+		return fmt.Errorf("failed to delete tags: %w", err)
+	}
+	if len(names) > 0 {
+		ids, err := q.UpsertTags(ctx, datastore.UpsertTagsParams{
+			WorkspaceID: int32(workspaceID),
+			Names:       names,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save tags: %w", err)
+		}
+		if err := q.InsertURLTags(ctx, datastore.InsertURLTagsParams{
+			UrlID:  int32(urlID),
+			TagIds: ids,
+		}); err != nil {
+			return fmt.Errorf("failed to tag url: %w", err)
+		}
+	}
+	if err := q.DeleteUnusedTags(ctx, int32(workspaceID)); err != nil {
+		return fmt.Errorf("failed to delete unused tags: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// TagStatistics counts the links, visits and unique visitors of each tag of a
+// workspace in filter.Period, the most visited first.
+func (s *tagStore) TagStatistics(ctx context.Context, workspaceID domain.ID, filter domain.StatsFilter) ([]datastore.TagStatisticsRow, error) {
+	since, until := periodBounds(filter.Period)
+	rows, err := s.db.TagStatistics(ctx, datastore.TagStatisticsParams{
+		WorkspaceID: int32(workspaceID),
+		IncludeBots: filter.IncludeBots,
+		StartDate:   since,
+		EndDate:     until,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag statistics: %w", err)
+	}
+	return rows, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"unicode"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -22,24 +24,153 @@ func NewURLStore(db datastore.DBTX) *urlStore {
 	}
 }
 
-func (s *urlStore) Add(ctx context.Context, title, shortURL, longURL string, authorID domain.ID, isActive bool) (domain.ID, error) {
-	url, err := s.db.AddShortURL(ctx, datastore.AddShortURLParams{
-		Title:    title,
-		ShortUrl: shortURL,
-		LongUrl:  longURL,
-		AuthorID: int32(authorID),
-		IsActive: isActive,
-	})
+func (s *urlStore) Add(ctx context.Context, params domain.AddURLParams) (domain.ID, error) {
+	args := datastore.AddShortURLParams{
+		Title:          params.Title,
+		ShortUrl:       params.Slug,
+		Domain:         params.Domain,
+		LongUrl:        params.Long,
+		AuthorID:       pgtype.Int4{Int32: int32(params.AuthorID), Valid: params.AuthorID != 0},
+		WorkspaceID:    int32(params.WorkspaceID),
+		IsActive:       params.IsActive,
+		ExpiresAt:      expiresAt(params.Expiration),
+		MaxClicks:      maxClicks(params.Expiration),
+		RedirectStatus: int16(params.RedirectStatus),
+		ForwardQuery:   params.ForwardQuery,
+	}
+	if params.Password != nil {
+		args.PasswordHash = params.Password.Hash
+		args.PasswordSalt = params.Password.Salt
+	}
+
+	url, err := s.db.AddShortURL(ctx, args)
 	if err != nil {
 		return 0, fmt.Errorf("failed to add shorten url: %w", err)
 	}
 	return domain.ID(url.ID), nil
 }
 
-func (s *urlStore) List(ctx context.Context, authorID domain.ID, search string) ([]datastore.ListStatisticsPerAuthorRow, error) {
+func (s *urlStore) UpdateExpiration(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, exp domain.Expiration) (datastore.Url, error) {
+	url, err := s.db.UpdateExpiration(ctx, datastore.UpdateExpirationParams{
+		ExpiresAt:   expiresAt(exp),
+		MaxClicks:   maxClicks(exp),
+		ShortUrl:    ref.Slug,
+		Domain:      ref.Domain,
+		WorkspaceID: int32(workspaceID),
+	})
+	if err != nil {
+		return datastore.Url{}, fmt.Errorf("failed to update url expiration: %w", err)
+	}
+	return url, nil
+}
+
+// CountClick counts a redirect of a link and returns its clicks, pgx.ErrNoRows
+// when it already reached its click limit.
+func (s *urlStore) CountClick(ctx context.Context, urlID domain.ID) (int, error) {
+	clicks, err := s.db.CountClick(ctx, int32(urlID))
+	return int(clicks), err
+}
+
+// UpdateDestination changes the title, destination and redirect status of a
+// link and records the previous destination in its history.
+func (s *urlStore) UpdateDestination(ctx context.Context, workspaceID, changedBy domain.ID, ref domain.LinkRef, title, longURL string, redirectStatus int, isActive bool) (datastore.Url, error) {
+	url, err := s.db.UpdateDestination(ctx, datastore.UpdateDestinationParams{
+		Title:          title,
+		LongUrl:        longURL,
+		IsActive:       isActive,
+		RedirectStatus: int16(redirectStatus),
+		ShortUrl:       ref.Slug,
+		Domain:         ref.Domain,
+		WorkspaceID:    int32(workspaceID),
+		ChangedBy:      pgtype.Int4{Int32: int32(changedBy), Valid: changedBy != 0},
+	})
+	if err != nil {
+		return datastore.Url{}, fmt.Errorf("failed to update url destination: %w", err)
+	}
+	return url, nil
+}
+
+func (s *urlStore) ListDestinationHistory(ctx context.Context, urlID domain.ID) ([]datastore.ListDestinationHistoryRow, error) {
+	rows, err := s.db.ListDestinationHistory(ctx, int32(urlID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list destination history: %w", err)
+	}
+	return rows, nil
+}
+
+// UpdatePassword sets the password protecting a link, a nil password removes it.
+func (s *urlStore) UpdatePassword(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, password *domain.PasswordHash) (datastore.Url, error) {
+	args := datastore.UpdatePasswordParams{
+		ShortUrl:    ref.Slug,
+		Domain:      ref.Domain,
+		WorkspaceID: int32(workspaceID),
+	}
+	if password != nil {
+		args.PasswordHash = password.Hash
+		args.PasswordSalt = password.Salt
+	}
+
+	url, err := s.db.UpdatePassword(ctx, args)
+	if err != nil {
+		return datastore.Url{}, fmt.Errorf("failed to update url password: %w", err)
+	}
+	return url, nil
+}
+
+// UpdateForwardQuery sets whether visits pass their query string on to the
+// destination of a link.
+func (s *urlStore) UpdateForwardQuery(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, forward bool) (datastore.Url, error) {
+	url, err := s.db.UpdateForwardQuery(ctx, datastore.UpdateForwardQueryParams{
+		ForwardQuery: forward,
+		ShortUrl:     ref.Slug,
+		Domain:       ref.Domain,
+		WorkspaceID:  int32(workspaceID),
+	})
+	if err != nil {
+		return datastore.Url{}, fmt.Errorf("failed to update url query forwarding: %w", err)
+	}
+	return url, nil
+}
+
+// UpdateStickyVariants sets whether returning visitors of a link keep being
+// sent to the same variant.
+func (s *urlStore) UpdateStickyVariants(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, sticky bool) (datastore.Url, error) {
+	url, err := s.db.UpdateStickyVariants(ctx, datastore.UpdateStickyVariantsParams{
+		StickyVariants: sticky,
+		ShortUrl:       ref.Slug,
+		Domain:         ref.Domain,
+		WorkspaceID:    int32(workspaceID),
+	})
+	if err != nil {
+		return datastore.Url{}, fmt.Errorf("failed to update url sticky variants: %w", err)
+	}
+	return url, nil
+}
+
+func expiresAt(exp domain.Expiration) pgtype.Timestamp {
+	if exp.ExpiresAt == nil {
+		return pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: exp.ExpiresAt.UTC(), Valid: true}
+}
+
+func maxClicks(exp domain.Expiration) pgtype.Int4 {
+	if exp.MaxClicks == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: int32(*exp.MaxClicks), Valid: true}
+}
+
+func (s *urlStore) List(ctx context.Context, workspaceID domain.ID, filter domain.LinkFilter, page domain.Page) ([]datastore.ListStatisticsPerAuthorRow, error) {
 	rows, err := s.db.ListStatisticsPerAuthor(ctx, datastore.ListStatisticsPerAuthorParams{
-		AuthorID: int32(authorID),
-		Search:   search,
+		WorkspaceID: int32(workspaceID),
+		IsArchived:  filter.Archived,
+		SearchQuery: searchQuery(filter.Search),
+		FolderID:    pgtype.Int4{Int32: int32(filter.FolderID), Valid: filter.FolderID != 0},
+		Tag:         pgtype.Text{String: filter.Tag, Valid: filter.Tag != ""},
+		Sort:        string(filter.Sort),
+		PageOffset:  int32(page.Offset),
+		PageSize:    pgtype.Int4{Int32: int32(page.Limit), Valid: page.Limit > 0},
 	})
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return nil, fmt.Errorf("failed to list shorten urls: %w", err)
@@ -47,15 +178,53 @@ func (s *urlStore) List(ctx context.Context, authorID domain.ID, search string)
 	return rows, nil
 }
 
-func (s *urlStore) Delete(ctx context.Context, urlID, authorID domain.ID) error {
+// CountLinks counts the links of workspaceID matching filter, its sort aside.
+func (s *urlStore) CountLinks(ctx context.Context, workspaceID domain.ID, filter domain.LinkFilter) (int, error) {
+	n, err := s.db.CountLinksPerAuthor(ctx, datastore.CountLinksPerAuthorParams{
+		WorkspaceID: int32(workspaceID),
+		IsArchived:  filter.Archived,
+		SearchQuery: searchQuery(filter.Search),
+		FolderID:    pgtype.Int4{Int32: int32(filter.FolderID), Valid: filter.FolderID != 0},
+		Tag:         pgtype.Text{String: filter.Tag, Valid: filter.Tag != ""},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count shorten urls: %w", err)
+	}
+	return int(n), nil
+}
+
+// maxSearchWords bounds the size of the full-text query of a search.
+const maxSearchWords = 8
+
+// searchQuery turns a search typed by a user into a tsquery matching the
+// links with a word starting with each of its words. Only letters and digits
+// are kept, as in the indexed text, so the query is always valid.
+func searchQuery(search string) string {
+	words := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxSearchWords {
+		words = words[:maxSearchWords]
+	}
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+func (s *urlStore) Delete(ctx context.Context, urlID, workspaceID domain.ID) error {
 	return s.db.DeleteURL(ctx, datastore.DeleteURLParams{
-		ID:       int32(urlID),
-		AuthorID: int32(authorID),
+		ID:          int32(urlID),
+		WorkspaceID: int32(workspaceID),
 	})
 }
 
-func (s *urlStore) Get(ctx context.Context, slug string) (datastore.Url, error) {
-	url, err := s.db.GetShortURL(ctx, slug)
+func (s *urlStore) Get(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) (datastore.Url, error) {
+	url, err := s.db.GetShortURL(ctx, datastore.GetShortURLParams{
+		ShortUrl:    ref.Slug,
+		Domain:      ref.Domain,
+		WorkspaceID: int32(workspaceID),
+	})
 	if err != nil {
 		return datastore.Url{}, fmt.Errorf("failed to get shorten url: %w", err)
 	}
@@ -63,8 +232,13 @@ func (s *urlStore) Get(ctx context.Context, slug string) (datastore.Url, error)
 	return url, nil
 }
 
-func (s *urlStore) GetByID(ctx context.Context, urlID domain.ID) (datastore.Url, error) {
-	url, err := s.db.GetByID(ctx, int32(urlID))
+// GetRedirect returns the link slug followed by the requests of host, see
+// GetRedirectURL.
+func (s *urlStore) GetRedirect(ctx context.Context, host, slug string) (datastore.Url, error) {
+	url, err := s.db.GetRedirectURL(ctx, datastore.GetRedirectURLParams{
+		ShortUrl: slug,
+		Host:     host,
+	})
 	if err != nil {
 		return datastore.Url{}, fmt.Errorf("failed to get shorten url: %w", err)
 	}
@@ -72,81 +246,26 @@ func (s *urlStore) GetByID(ctx context.Context, urlID domain.ID) (datastore.Url,
 	return url, nil
 }
 
-func (s urlStore) TrackRedirect(ctx context.Context, urlID domain.ID, request domain.RequestInfo) (datastore.Visit, error) {
-
-	browser, err := s.UpsertBrowser(ctx, request.Browser())
+func (s *urlStore) EstimateURLCount(ctx context.Context) (int64, error) {
+	count, err := s.db.EstimateURLCount(ctx)
 	if err != nil {
-		return datastore.Visit{}, fmt.Errorf("upsert browser: %w", err)
+		return 0, fmt.Errorf("failed to estimate url count: %w", err)
 	}
+	return count, nil
+}
 
-	visit, err := s.db.TrackRedirect(ctx, datastore.TrackRedirectParams{
-		UrlID: int32(urlID),
-		IpAddress: pgtype.Text{
-			String: request.IpAddress(),
-			Valid:  request.IpAddress() != "",
-		},
-		UserAgent: pgtype.Text{
-			String: request.UserAgent(),
-			Valid:  request.UserAgent() != "",
-		},
-		BrowserID: pgtype.UUID{
-			Bytes: browser.ID,
-			Valid: !browser.ID.IsNil(),
-		},
-		Referrer: pgtype.Text{
-			String: request.Referer(),
-			Valid:  request.Referer() != "",
-		},
-	})
+func (s *urlStore) GetByID(ctx context.Context, urlID domain.ID) (datastore.Url, error) {
+	url, err := s.db.GetByID(ctx, int32(urlID))
 	if err != nil {
-		return datastore.Visit{}, fmt.Errorf("failed to track redirect: %v", err)
+		return datastore.Url{}, fmt.Errorf("failed to get shorten url: %w", err)
 	}
-	return visit, nil
-}
 
-func (s urlStore) InsertVisitLocation(ctx context.Context, visitID domain.ID, loc domain.IPLocation) error {
-	_, err := s.db.InsertVisitLocation(ctx, datastore.InsertVisitLocationParams{
-		VisitID: int32(visitID),
-		Address: pgtype.Text{
-			String: loc.IP,
-			Valid:  loc.IP != "",
-		},
-		CountryCode: pgtype.Text{
-			String: loc.CountryCode,
-			Valid:  loc.CountryCode != "",
-		},
-		CountryName: pgtype.Text{
-			String: loc.Country,
-			Valid:  loc.Country != "",
-		},
-		Subdivision: pgtype.Text{
-			String: loc.State,
-			Valid:  loc.State != "",
-		},
-		CityName: pgtype.Text{
-			String: loc.City,
-			Valid:  loc.City != "",
-		},
-		Latitude: pgtype.Float8{
-			Float64: loc.Latitude,
-			Valid:   loc.Latitude != 0,
-		},
-		Longitude: pgtype.Float8{
-			Float64: loc.Longitude,
-			Valid:   loc.Longitude != 0,
-		},
-		Source: pgtype.Text{
-			String: "ipquery",
-			Valid:  true,
-		},
-	})
-	return err
+	return url, nil
 }
 
-func (s urlStore) Statistics(ctx context.Context, authorID domain.ID) ([]datastore.ListStatisticsPerAuthorRow, error) {
+func (s urlStore) Statistics(ctx context.Context, workspaceID domain.ID) ([]datastore.ListStatisticsPerAuthorRow, error) {
 	rows, err := s.db.ListStatisticsPerAuthor(ctx, datastore.ListStatisticsPerAuthorParams{
-		AuthorID: int32(authorID),
-		Search:   "",
+		WorkspaceID: int32(workspaceID),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list statistics: %w", err)
@@ -155,10 +274,11 @@ func (s urlStore) Statistics(ctx context.Context, authorID domain.ID) ([]datasto
 	return rows, err
 }
 
-func (s urlStore) StatisticsDetail(ctx context.Context, authorID domain.ID, slug string) (datastore.StatisticPerURLRow, error) {
+func (s urlStore) StatisticsDetail(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) (datastore.StatisticPerURLRow, error) {
 	row, err := s.db.StatisticPerURL(ctx, datastore.StatisticPerURLParams{
-		ShortUrl: slug,
-		AuthorID: int32(authorID),
+		ShortUrl:    ref.Slug,
+		Domain:      ref.Domain,
+		WorkspaceID: int32(workspaceID),
 	})
 	if err != nil {
 		return datastore.StatisticPerURLRow{}, fmt.Errorf("failed to get statistics: %w", err)
@@ -167,113 +287,187 @@ func (s urlStore) StatisticsDetail(ctx context.Context, authorID domain.ID, slug
 	return row, nil
 }
 
-func (s urlStore) UpdateTitle(ctx context.Context, authorID domain.ID, slug, title string) (datastore.Url, error) {
+func (s urlStore) UpdateTitle(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, title string) (datastore.Url, error) {
 	return s.db.UpdateTitle(ctx, datastore.UpdateTitleParams{
-		Title:    title,
-		ShortUrl: slug,
-		AuthorID: int32(authorID),
+		Title:       title,
+		ShortUrl:    ref.Slug,
+		Domain:      ref.Domain,
+		WorkspaceID: int32(workspaceID),
 	})
 }
 
-func (s urlStore) UpdateURLStatus(ctx context.Context, shortURL string, isActive bool) error {
+func (s urlStore) UpdateURLStatus(ctx context.Context, urlID domain.ID, isActive bool) error {
 	return s.db.UpdateURLStatus(ctx, datastore.UpdateURLStatusParams{
-		ShortUrl: shortURL,
+		ID:       int32(urlID),
 		IsActive: isActive,
 	})
 }
 
-func (s urlStore) ArchiveURL(ctx context.Context, authorID domain.ID, slug string) error {
+func (s urlStore) ArchiveURL(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) error {
 	return s.db.ArchiveURL(ctx, datastore.ArchiveURLParams{
-		ShortUrl: slug,
-		AuthorID: int32(authorID),
+		ShortUrl:    ref.Slug,
+		Domain:      ref.Domain,
+		WorkspaceID: int32(workspaceID),
 	})
 }
 
-func (s urlStore) UnarchiveURL(ctx context.Context, authorID domain.ID, slug string) error {
+func (s urlStore) UnarchiveURL(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) error {
 	return s.db.UnarchiveURL(ctx, datastore.UnarchiveURLParams{
-		ShortUrl: slug,
-		AuthorID: int32(authorID),
+		ShortUrl:    ref.Slug,
+		Domain:      ref.Domain,
+		WorkspaceID: int32(workspaceID),
 	})
 }
 
-func (a urlStore) CountMonthlyURL(ctx context.Context, authorID domain.ID) (int64, error) {
-	return a.db.CountURLThisMonth(ctx, int32(authorID))
+func (s urlStore) UnarchiveURLs(ctx context.Context, workspaceID domain.ID, refs []domain.LinkRef) (int64, error) {
+	args := datastore.UnarchiveURLsParams{
+		Domains:     make([]string, len(refs)),
+		Slugs:       make([]string, len(refs)),
+		WorkspaceID: int32(workspaceID),
+	}
+	for i, ref := range refs {
+		args.Domains[i] = ref.Domain
+		args.Slugs[i] = ref.Slug
+	}
+
+	n, err := s.db.UnarchiveURLs(ctx, args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unarchive urls: %w", err)
+	}
+	return n, nil
 }
 
-func (a urlStore) CountMonthlyVisit(ctx context.Context, authorID domain.ID) (int64, error) {
-	return a.db.CountTotalVisitThisMonth(ctx, int32(authorID))
+func (a urlStore) CountMonthlyURL(ctx context.Context, workspaceID domain.ID) (int64, error) {
+	return a.db.CountURLThisMonth(ctx, int32(workspaceID))
 }
 
-func (a urlStore) LocationDistribution(ctx context.Context, authorID, urlID domain.ID) ([]datastore.LocationDistributionRow, error) {
+func (a urlStore) CountMonthlyVisit(ctx context.Context, workspaceID domain.ID) (int64, error) {
+	return a.db.CountTotalVisitThisMonth(ctx, int32(workspaceID))
+}
+
+func (a urlStore) LocationDistribution(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter) ([]datastore.LocationDistributionRow, error) {
+	since, until := periodBounds(filter.Period)
 	return a.db.LocationDistribution(ctx, datastore.LocationDistributionParams{
-		UrlID:    int32(urlID),
-		AuthorID: int32(authorID),
+		UrlID:       int32(urlID),
+		WorkspaceID: int32(workspaceID),
+		IncludeBots: filter.IncludeBots,
+		StartDate:   since,
+		EndDate:     until,
 	})
 }
 
-func (a urlStore) BrowserDistribution(ctx context.Context, authorID, urlID domain.ID) ([]datastore.BrowserDistributionRow, error) {
+func (a urlStore) BrowserDistribution(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter) ([]datastore.BrowserDistributionRow, error) {
+	since, until := periodBounds(filter.Period)
 	return a.db.BrowserDistribution(ctx, datastore.BrowserDistributionParams{
-		UrlID:    int32(urlID),
-		AuthorID: int32(authorID),
+		UrlID:       int32(urlID),
+		WorkspaceID: int32(workspaceID),
+		IncludeBots: filter.IncludeBots,
+		StartDate:   since,
+		EndDate:     until,
 	})
 }
 
-func (a urlStore) DeviceDistribution(ctx context.Context, authorID, urlID domain.ID) ([]datastore.DeviceDistributionRow, error) {
+func (a urlStore) DeviceDistribution(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter) ([]datastore.DeviceDistributionRow, error) {
+	since, until := periodBounds(filter.Period)
 	return a.db.DeviceDistribution(ctx, datastore.DeviceDistributionParams{
-		UrlID:    int32(urlID),
-		AuthorID: int32(authorID),
+		UrlID:       int32(urlID),
+		WorkspaceID: int32(workspaceID),
+		IncludeBots: filter.IncludeBots,
+		StartDate:   since,
+		EndDate:     until,
 	})
 }
 
-func (a urlStore) RefererDistribution(ctx context.Context, authorID, urlID domain.ID) ([]datastore.ReferrerDistributionRow, error) {
+func (a urlStore) RefererDistribution(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter) ([]datastore.ReferrerDistributionRow, error) {
+	since, until := periodBounds(filter.Period)
 	return a.db.ReferrerDistribution(ctx, datastore.ReferrerDistributionParams{
-		UrlID:    int32(urlID),
-		AuthorID: int32(authorID),
+		UrlID:       int32(urlID),
+		WorkspaceID: int32(workspaceID),
+		IncludeBots: filter.IncludeBots,
+		StartDate:   since,
+		EndDate:     until,
 	})
 }
 
-func (a urlStore) UniqueVisitCount(ctx context.Context, urlID domain.ID) (int64, error) {
-	return a.db.UniqueVisitCount(ctx, int32(urlID))
+// ListVisits returns up to limit visits after the visit afterID, of the link
+// urlID or of all the links of workspaceID when urlID is 0, in filter.Period.
+func (a urlStore) ListVisits(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter, afterID domain.ID, limit int) ([]datastore.ListVisitsRow, error) {
+	since, until := periodBounds(filter.Period)
+	return a.db.ListVisits(ctx, datastore.ListVisitsParams{
+		WorkspaceID: int32(workspaceID),
+		UrlID:       pgtype.Int4{Int32: int32(urlID), Valid: urlID != 0},
+		StartDate:   since,
+		EndDate:     until,
+		IncludeBots: filter.IncludeBots,
+		AfterID:     int32(afterID),
+		PageSize:    int32(limit),
+	})
 }
 
-func (a urlStore) TotalVisit(ctx context.Context, urlID domain.ID) (int64, error) {
-	return a.db.TotalVisit(ctx, int32(urlID))
+// periodBounds returns the bounds of p, NULL when p is zero so every visit is
+// counted.
+func periodBounds(p domain.Period) (since, until pgtype.Timestamp) {
+	if p == (domain.Period{}) {
+		return pgtype.Timestamp{}, pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: p.Since.UTC(), Valid: true},
+		pgtype.Timestamp{Time: p.Until.UTC(), Valid: true}
 }
 
-func (a urlStore) UpsertBrowser(ctx context.Context, browser domain.Browser) (domain.Browser, error) {
-	row, err := a.db.UpsertBrowser(ctx, datastore.UpsertBrowserParams{
-		Name:     browser.Name,
-		Version:  browser.Version,
-		Platform: browser.Platform,
-		Mobile:   browser.IsMobile,
+func (a urlStore) UniqueVisitCount(ctx context.Context, urlID domain.ID, filter domain.StatsFilter) (int64, error) {
+	since, until := periodBounds(filter.Period)
+	return a.db.UniqueVisitCount(ctx, datastore.UniqueVisitCountParams{
+		UrlID:       int32(urlID),
+		IncludeBots: filter.IncludeBots,
+		StartDate:   since,
+		EndDate:     until,
 	})
-	if err != nil {
-		return domain.Browser{}, fmt.Errorf("failed to upsert browser: %w", err)
-	}
+}
 
-	return domain.Browser{
-		ID:       row.ID.Bytes,
-		Name:     row.Name,
-		Version:  row.Version,
-		Platform: row.Platform,
-		IsMobile: row.Mobile,
-	}, nil
+func (a urlStore) TotalVisit(ctx context.Context, urlID domain.ID, filter domain.StatsFilter) (int64, error) {
+	since, until := periodBounds(filter.Period)
+	return a.db.TotalVisit(ctx, datastore.TotalVisitParams{
+		UrlID:       int32(urlID),
+		IncludeBots: filter.IncludeBots,
+		StartDate:   since,
+		EndDate:     until,
+	})
+}
+
+// QRScanCount counts the visits of a link that scanned its QR code.
+func (a urlStore) QRScanCount(ctx context.Context, urlID domain.ID, filter domain.StatsFilter) (int64, error) {
+	since, until := periodBounds(filter.Period)
+	return a.db.QRScanCount(ctx, datastore.QRScanCountParams{
+		UrlID:       int32(urlID),
+		IncludeBots: filter.IncludeBots,
+		StartDate:   since,
+		EndDate:     until,
+	})
 }
 
-func (a urlStore) VisitOverTime(ctx context.Context, urlID domain.ID, period domain.Period, timeTrunc string) ([]domain.TimeSeriesData, error) {
+// VisitOverTime counts the visits of a link in period by bucket. Buckets start
+// in the timezone of the filter.
+func (a urlStore) VisitOverTime(ctx context.Context, urlID domain.ID, period domain.Period, bucket domain.Bucket, filter domain.StatsFilter) ([]domain.TimeSeriesData, error) {
+	timezone := "UTC"
+	if filter.Location != nil {
+		timezone = filter.Location.String()
+	}
+
 	row, err := a.db.VisitOverTime(ctx, datastore.VisitOverTimeParams{
-		UrlID: int32(urlID),
+		UrlID:       int32(urlID),
+		IncludeBots: filter.IncludeBots,
 		StartDate: pgtype.Timestamp{
-			Time:             period.Since,
+			Time:             period.Since.UTC(),
 			InfinityModifier: pgtype.Finite,
 			Valid:            true,
 		},
 		EndDate: pgtype.Timestamp{
-			Time:             period.Until,
+			Time:             period.Until.UTC(),
 			InfinityModifier: pgtype.Finite,
 			Valid:            true,
 		},
-		TimeTrunc: timeTrunc,
+		TimeTrunc: string(bucket),
+		Timezone:  timezone,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get visit per day: %w", err)
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		search string
+		want   string
+	}{
+		{"", ""},
+		{"  ", ""},
+		{"Blog", "blog:*"},
+		{"example.com/blog", "example:* & com:* & blog:*"},
+		{"it's & | ! (x):*", "it:* & s:* & x:*"},
+		{"café 2024", "café:* & 2024:*"},
+		{"a b c d e f g h i j", "a:* & b:* & c:* & d:* & e:* & f:* & g:* & h:*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, searchQuery(tt.search))
+		})
+	}
+}
+
+// visitsQuerier counts the visits made at the times it holds, within the
+// bounds of the query.
+type visitsQuerier struct {
+	datastore.Querier
+	visits []time.Time
+}
+
+func (q visitsQuerier) TotalVisit(_ context.Context, arg datastore.TotalVisitParams) (int64, error) {
+	var n int64
+	for _, at := range q.visits {
+		if (!arg.StartDate.Valid || !at.Before(arg.StartDate.Time)) && (!arg.EndDate.Valid || at.Before(arg.EndDate.Time)) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestTotalVisitPeriod(t *testing.T) {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	store := urlStore{db: visitsQuerier{visits: []time.Time{
+		day.Add(-time.Hour),
+		day,
+		day.Add(12 * time.Hour),
+		day.Add(24 * time.Hour),
+	}}}
+	paris := time.FixedZone("CEST", 2*60*60)
+
+	tests := []struct {
+		name   string
+		period domain.Period
+		want   int64
+	}{
+		{"every visit", domain.Period{}, 4},
+		{"one day", domain.Period{Since: day, Until: day.Add(24 * time.Hour)}, 2},
+		{"bounds in another timezone", domain.Period{Since: day.In(paris), Until: day.Add(time.Hour).In(paris)}, 1},
+		{"before the first visit", domain.Period{Since: day.Add(-48 * time.Hour), Until: day.Add(-24 * time.Hour)}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := store.TotalVisit(context.Background(), 1, domain.StatsFilter{Period: tt.period})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+type variantStore struct {
+	pool *pgxpool.Pool
+	db   *datastore.Queries
+}
+
+func NewVariantStore(pool *pgxpool.Pool) *variantStore {
+	return &variantStore{
+		pool: pool,
+		db:   datastore.New(pool),
+	}
+}
+
+func (s *variantStore) ListVariants(ctx context.Context, urlID domain.ID) ([]datastore.UrlVariant, error) {
+	rows, err := s.db.ListVariants(ctx, int32(urlID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list variants: %w", err)
+	}
+	return rows, nil
+}
+
+// CountVariantVisits returns the visits and unique visitors sent to each
+// variant of a link.
+func (s *variantStore) CountVariantVisits(ctx context.Context, urlID domain.ID) ([]datastore.CountVariantVisitsRow, error) {
+	rows, err := s.db.CountVariantVisits(ctx, int32(urlID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count variant visits: %w", err)
+	}
+	return rows, nil
+}
+
+// ReplaceVariants makes variants, in order, the variants of a link in a single
+// transaction. Variants with an ID are updated so that their visits stay
+// attached to them, the others are inserted and the variants left out are
+// deleted.
+func (s *variantStore) ReplaceVariants(ctx context.Context, urlID domain.ID, variants []domain.Variant) ([]datastore.UrlVariant, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		// no-op if the tx already committed
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("error rolling back transaction", "err", err)
+		}
+	}()
+	q := s.db.WithTx(tx)
+
+	keep := make([]int32, 0, len(variants))
+	for _, v := range variants {
+		if v.ID != 0 {
+			keep = append(keep, int32(v.ID))
+		}
+	}
+	if err := q.DeleteVariantsExcept(ctx, datastore.DeleteVariantsExceptParams{
+		UrlID: int32(urlID),
+		Keep:  keep,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to delete variants: %w", err)
+	}
+
+	rows := make([]datastore.UrlVariant, 0, len(variants))
+	for i, v := range variants {
+		var (
+			row datastore.UrlVariant
+			err error
+		)
+		if v.ID == 0 {
+			row, err = q.InsertVariant(ctx, datastore.InsertVariantParams{
+				UrlID:    int32(urlID),
+				Position: int32(i),
+				LongUrl:  v.LongURL,
+				Weight:   int32(v.Weight),
+			})
+		} else {
+			row, err = q.UpdateVariant(ctx, datastore.UpdateVariantParams{
+				ID:       int32(v.ID),
+				UrlID:    int32(urlID),
+				Position: int32(i),
+				LongUrl:  v.LongURL,
+				Weight:   int32(v.Weight),
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to save variant %d: %w", i, err)
+		}
+		rows = append(rows, row)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+type visitStore struct {
+	pool *pgxpool.Pool
+	db   *datastore.Queries
+}
+
+func NewVisitStore(pool *pgxpool.Pool) *visitStore {
+	return &visitStore{
+		pool: pool,
+		db:   datastore.New(pool),
+	}
+}
+
+// InsertVisits records a batch of visits and their locations in a single
+// transaction, using COPY for both tables. Visits of links deleted since they
+// were queued are dropped.
+func (s *visitStore) InsertVisits(ctx context.Context, visits []domain.Visit) error {
+	if len(visits) == 0 {
+		return nil
+	}
+
+	browsers, err := s.browserIDs(ctx, visits)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		// no-op if the tx already committed
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("error rolling back transaction", "err", err)
+		}
+	}()
+	q := s.db.WithTx(tx)
+
+	urlIDs, err := q.LockVisitedURLs(ctx, visitedURLIDs(visits))
+	if err != nil {
+		return fmt.Errorf("failed to lock visited urls: %w", err)
+	}
+	if kept := visitsOf(visits, urlIDs); len(kept) < len(visits) {
+		log.Info("dropping visits of deleted links", "count", len(visits)-len(kept))
+		visits = kept
+	}
+	if len(visits) == 0 {
+		return nil
+	}
+
+	ids, err := q.ReserveVisitIDs(ctx, int32(len(visits)))
+	if err != nil {
+		return fmt.Errorf("failed to reserve visit ids: %w", err)
+	}
+
+	rows := make([]datastore.InsertVisitsParams, len(visits))
+	var locations []datastore.InsertVisitLocationsParams
+	for i, v := range visits {
+		rows[i] = datastore.InsertVisitsParams{
+			ID:             ids[i],
+			UrlID:          int32(v.URLID),
+			VisitedAt:      pgtype.Timestamp{Time: v.VisitedAt.UTC(), Valid: true},
+			IpAddress:      text(v.Request.IpAddress()),
+			UserAgent:      text(v.Request.UserAgent()),
+			BrowserID:      browsers[v.Request.Browser()],
+			Referrer:       text(v.Request.Referer()),
+			RedirectRuleID: pgtype.Int4{Int32: int32(v.RuleID), Valid: v.RuleID != 0},
+			VariantID:      pgtype.Int4{Int32: int32(v.VariantID), Valid: v.VariantID != 0},
+			IsBot:          v.IsBot,
+			Source:         text(string(v.Source)),
+		}
+		if v.Location != nil {
+			locations = append(locations, visitLocation(ids[i], *v.Location))
+		}
+	}
+
+	if _, err := q.InsertVisits(ctx, rows); err != nil {
+		return fmt.Errorf("failed to copy visits: %w", err)
+	}
+	if len(locations) > 0 {
+		if _, err := q.InsertVisitLocations(ctx, locations); err != nil {
+			return fmt.Errorf("failed to copy visit locations: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// browserIDs returns the id of the browser of every visit, inserting the
+// unknown ones. It runs outside of the visits transaction so the browser rows
+// are only locked for the duration of the insert.
+func (s *visitStore) browserIDs(ctx context.Context, visits []domain.Visit) (map[domain.Browser]pgtype.UUID, error) {
+	// most visits of a batch share a handful of browsers
+	browsers := visitBrowsers(visits)
+	params := datastore.InsertBrowsersParams{
+		Names:     make([]string, len(browsers)),
+		Versions:  make([]string, len(browsers)),
+		Platforms: make([]string, len(browsers)),
+		Mobiles:   make([]bool, len(browsers)),
+	}
+	for i, b := range browsers {
+		params.Names[i], params.Versions[i], params.Platforms[i], params.Mobiles[i] = b.Name, b.Version, b.Platform, b.IsMobile
+	}
+
+	if err := s.db.InsertBrowsers(ctx, params); err != nil {
+		return nil, fmt.Errorf("failed to insert browsers: %w", err)
+	}
+	rows, err := s.db.ListBrowsers(ctx, datastore.ListBrowsersParams(params))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list browsers: %w", err)
+	}
+
+	ids := make(map[domain.Browser]pgtype.UUID, len(rows))
+	for _, row := range rows {
+		ids[domain.Browser{Name: row.Name, Version: row.Version, Platform: row.Platform, IsMobile: row.Mobile}] = row.ID
+	}
+	return ids, nil
+}
+
+// visitBrowsers returns the distinct browsers of visits. InsertBrowsers sorts
+// them.
+func visitBrowsers(visits []domain.Visit) []domain.Browser {
+	var browsers []domain.Browser
+	seen := make(map[domain.Browser]struct{})
+	for _, v := range visits {
+		b := v.Request.Browser()
+		if _, ok := seen[b]; ok {
+			continue
+		}
+		seen[b] = struct{}{}
+		browsers = append(browsers, b)
+	}
+	return browsers
+}
+
+// visitedURLIDs returns the distinct links of visits.
+func visitedURLIDs(visits []domain.Visit) []int32 {
+	ids := make([]int32, 0, len(visits))
+	for _, v := range visits {
+		ids = append(ids, int32(v.URLID))
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// visitsOf keeps the visits of the links urlIDs.
+func visitsOf(visits []domain.Visit, urlIDs []int32) []domain.Visit {
+	kept := make([]domain.Visit, 0, len(visits))
+	for _, v := range visits {
+		if slices.Contains(urlIDs, int32(v.URLID)) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func visitLocation(visitID int32, loc domain.IPLocation) datastore.InsertVisitLocationsParams {
+	return datastore.InsertVisitLocationsParams{
+		VisitID:     visitID,
+		Address:     text(loc.IP),
+		CountryCode: text(loc.CountryCode),
+		CountryName: text(loc.Country),
+		Subdivision: text(loc.State),
+		Continent:   text(loc.Continent),
+		CityName:    text(loc.City),
+		Latitude: pgtype.Float8{
+			Float64: loc.Latitude,
+			Valid:   loc.Latitude != 0,
+		},
+		Longitude: pgtype.Float8{
+			Float64: loc.Longitude,
+			Valid:   loc.Longitude != 0,
+		},
+		Source: text(loc.Source),
+	}
+}
+
+// text maps the empty string to NULL.
+func text(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+func TestVisitsOfDeletedLinks(t *testing.T) {
+	visits := []domain.Visit{
+		{URLID: 3, Request: *domain.NewRequestInfo("203.0.113.7", "", "", "", "")},
+		{URLID: 1, Request: *domain.NewRequestInfo("203.0.113.7", "", "", "", "")},
+		{URLID: 2, Request: *domain.NewRequestInfo("203.0.113.7", "", "", "", "")},
+		{URLID: 3, Request: *domain.NewRequestInfo("198.51.100.1", "", "", "", "")},
+	}
+	assert.Equal(t, []int32{1, 2, 3}, visitedURLIDs(visits))
+
+	// link 2 was deleted while its visit was queued
+	kept := visitsOf(visits, []int32{1, 3})
+	assert.Equal(t, []domain.Visit{visits[0], visits[1], visits[3]}, kept)
+	assert.Empty(t, visitsOf(visits, nil))
+}
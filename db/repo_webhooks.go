@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+type webhookStore struct {
+	pool *pgxpool.Pool
+	db   *datastore.Queries
+}
+
+func NewWebhookStore(pool *pgxpool.Pool) *webhookStore {
+	return &webhookStore{
+		pool: pool,
+		db:   datastore.New(pool),
+	}
+}
+
+// InsertEndpoint creates an endpoint of workspaceID. When it subscribes to
+// click thresholds, the ones the links of the workspace already reached are
+// recorded as notified in the same transaction, so that only the next ones
+// are sent.
+func (s *webhookStore) InsertEndpoint(ctx context.Context, workspaceID domain.ID, url, secret string, events []domain.WebhookEvent) (datastore.WebhookEndpoint, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return datastore.WebhookEndpoint{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		// no-op if the tx already committed
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("error rolling back transaction", "err", err)
+		}
+	}()
+	q := s.db.WithTx(tx)
+
+	endpoint, err := q.InsertWebhookEndpoint(ctx, datastore.InsertWebhookEndpointParams{
+		WorkspaceID: int32(workspaceID),
+		Url:         url,
+		Secret:      secret,
+		Events:      eventNames(events),
+	})
+	if err != nil {
+		return datastore.WebhookEndpoint{}, fmt.Errorf("failed to insert webhook endpoint: %w", err)
+	}
+
+	if slices.Contains(events, domain.WebhookLinkClickThreshold) {
+		err := q.SeedWebhookThresholds(ctx, datastore.SeedWebhookThresholdsParams{
+			EndpointID:  endpoint.ID,
+			WorkspaceID: int32(workspaceID),
+			Thresholds:  domain.ClickThresholds,
+		})
+		if err != nil {
+			return datastore.WebhookEndpoint{}, fmt.Errorf("failed to seed webhook thresholds: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return datastore.WebhookEndpoint{}, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return endpoint, nil
+}
+
+func (s *webhookStore) ListEndpoints(ctx context.Context, workspaceID domain.ID) ([]datastore.WebhookEndpoint, error) {
+	endpoints, err := s.db.ListWebhookEndpoints(ctx, int32(workspaceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+func (s *webhookStore) DeleteEndpoint(ctx context.Context, workspaceID, endpointID domain.ID) (bool, error) {
+	n, err := s.db.DeleteWebhookEndpoint(ctx, datastore.DeleteWebhookEndpointParams{
+		ID:          int32(endpointID),
+		WorkspaceID: int32(workspaceID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *webhookStore) SubscribedEndpoints(ctx context.Context, workspaceID domain.ID, event domain.WebhookEvent) ([]int32, error) {
+	ids, err := s.db.ListSubscribedWebhookEndpoints(ctx, datastore.ListSubscribedWebhookEndpointsParams{
+		WorkspaceID: int32(workspaceID),
+		Event:       string(event),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscribed webhook endpoints: %w", err)
+	}
+	return ids, nil
+}
+
+func (s *webhookStore) LinkEndpoints(ctx context.Context, urlIDs []domain.ID, events []domain.WebhookEvent) ([]datastore.ListLinkWebhookEndpointsRow, error) {
+	rows, err := s.db.ListLinkWebhookEndpoints(ctx, datastore.ListLinkWebhookEndpointsParams{
+		UrlIds: int32IDs(urlIDs),
+		Events: eventNames(events),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list link webhook endpoints: %w", err)
+	}
+	return rows, nil
+}
+
+// CountVisits returns the number of visits by people of each link.
+func (s *webhookStore) CountVisits(ctx context.Context, urlIDs []domain.ID) (map[domain.ID]int64, error) {
+	rows, err := s.db.CountLinkVisits(ctx, int32IDs(urlIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count link visits: %w", err)
+	}
+	counts := make(map[domain.ID]int64, len(rows))
+	for _, row := range rows {
+		counts[domain.ID(row.UrlID)] = row.Visits
+	}
+	return counts, nil
+}
+
+// RaiseThreshold records that the endpoint was told urlID reached threshold.
+// It reports false when it already was told about this one or a higher one.
+func (s *webhookStore) RaiseThreshold(ctx context.Context, endpointID, urlID domain.ID, threshold int64) (bool, error) {
+	n, err := s.db.RaiseWebhookThreshold(ctx, datastore.RaiseWebhookThresholdParams{
+		EndpointID: int32(endpointID),
+		UrlID:      int32(urlID),
+		Threshold:  threshold,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to raise webhook threshold: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *webhookStore) InsertDeliveries(ctx context.Context, deliveries []datastore.InsertWebhookDeliveriesParams) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if _, err := s.db.InsertWebhookDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("failed to insert webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ClaimDeliveries leases up to limit deliveries due at now until leaseUntil.
+func (s *webhookStore) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]datastore.ClaimWebhookDeliveriesRow, error) {
+	rows, err := s.db.ClaimWebhookDeliveries(ctx, datastore.ClaimWebhookDeliveriesParams{
+		Now:        pgtype.Timestamp{Time: now.UTC(), Valid: true},
+		LeaseUntil: pgtype.Timestamp{Time: leaseUntil.UTC(), Valid: true},
+		BatchSize:  int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	return rows, nil
+}
+
+func (s *webhookStore) RecordAttempt(ctx context.Context, deliveryID int64, status domain.WebhookDeliveryStatus, attemptedAt, nextAttemptAt time.Time, responseStatus int, lastError string) error {
+	err := s.db.RecordWebhookAttempt(ctx, datastore.RecordWebhookAttemptParams{
+		ID:             deliveryID,
+		Status:         string(status),
+		AttemptedAt:    pgtype.Timestamp{Time: attemptedAt.UTC(), Valid: true},
+		NextAttemptAt:  pgtype.Timestamp{Time: nextAttemptAt.UTC(), Valid: true},
+		ResponseStatus: pgtype.Int4{Int32: int32(responseStatus), Valid: responseStatus != 0},
+		LastError:      lastError,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+func (s *webhookStore) ListDeliveries(ctx context.Context, workspaceID domain.ID, limit int) ([]datastore.ListWebhookDeliveriesRow, error) {
+	rows, err := s.db.ListWebhookDeliveries(ctx, datastore.ListWebhookDeliveriesParams{
+		WorkspaceID: int32(workspaceID),
+		MaxResults:  int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return rows, nil
+}
+
+// Redeliver queues a copy of a delivery of workspaceID, due at now.
+func (s *webhookStore) Redeliver(ctx context.Context, workspaceID domain.ID, deliveryID int64, now time.Time) (int64, error) {
+	return s.db.RedeliverWebhook(ctx, datastore.RedeliverWebhookParams{
+		ID:          deliveryID,
+		WorkspaceID: int32(workspaceID),
+		Now:         pgtype.Timestamp{Time: now.UTC(), Valid: true},
+	})
+}
+
+// DeleteDeliveriesBefore removes the deliveries done before t from the log.
+func (s *webhookStore) DeleteDeliveriesBefore(ctx context.Context, t time.Time) (int64, error) {
+	n, err := s.db.DeleteWebhookDeliveriesBefore(ctx, pgtype.Timestamp{Time: t.UTC(), Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	return n, nil
+}
+
+func eventNames(events []domain.WebhookEvent) []string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = string(e)
+	}
+	return names
+}
+
+func int32IDs(ids []domain.ID) []int32 {
+	s := make([]int32, len(ids))
+	for i, id := range ids {
+		s[i] = int32(id)
+	}
+	return s
+}
@@ -0,0 +1,281 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+type workspaceStore struct {
+	pool *pgxpool.Pool
+	db   *datastore.Queries
+}
+
+func NewWorkspaceStore(pool *pgxpool.Pool) *workspaceStore {
+	return &workspaceStore{
+		pool: pool,
+		db:   datastore.New(pool),
+	}
+}
+
+// q returns the transaction-bound querier when called inside Tx, otherwise the
+// pool-bound one.
+func (s *workspaceStore) q(ctx context.Context) *datastore.Queries {
+	if q, ok := ctx.Value(txKey{}).(*datastore.Queries); ok {
+		return q
+	}
+	return s.db
+}
+
+func (s *workspaceStore) Tx(ctx context.Context, fn func(context.Context) error, opts pgx.TxOptions) error {
+	tx, err := s.pool.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		// no-op if the tx already committed
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error("error rolling back transaction", "err", err)
+		}
+	}()
+
+	ctx = context.WithValue(ctx, txKey{}, s.db.WithTx(tx))
+	if err := fn(ctx); err != nil {
+		return fmt.Errorf("error in transaction: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+func toWorkspace(id int32, guid pgtype.UUID, name string, personalOf pgtype.Int4, role string) domain.Workspace {
+	return domain.Workspace{
+		ID:       domain.ID(id),
+		GUID:     domain.GUID(uuid.UUID(guid.Bytes)),
+		Name:     name,
+		Personal: personalOf.Valid,
+		Role:     domain.Role(role),
+	}
+}
+
+// PersonalWorkspace returns the personal workspace of user, creating it the
+// first time.
+func (s *workspaceStore) PersonalWorkspace(ctx context.Context, user domain.User) (domain.Workspace, error) {
+	row, err := s.db.GetPersonalWorkspace(ctx, int32(user.ID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		// personal workspaces have the guid of their user, see
+		// 00036_workspaces.sql
+		err = s.db.EnsurePersonalWorkspace(ctx, datastore.EnsurePersonalWorkspaceParams{
+			UserID: int32(user.ID),
+			Guid:   user.GUID.PgType(),
+		})
+		if err != nil {
+			return domain.Workspace{}, fmt.Errorf("failed to create personal workspace: %w", err)
+		}
+		row, err = s.db.GetPersonalWorkspace(ctx, int32(user.ID))
+	}
+	if err != nil {
+		return domain.Workspace{}, fmt.Errorf("failed to get personal workspace: %w", err)
+	}
+	return toWorkspace(row.ID, row.Guid, row.Name, row.PersonalOf, row.Role), nil
+}
+
+// MemberWorkspace returns the workspace workspaceID with the role userID has
+// in it, pgx.ErrNoRows when they aren't a member.
+func (s *workspaceStore) MemberWorkspace(ctx context.Context, userID, workspaceID domain.ID) (domain.Workspace, error) {
+	row, err := s.db.GetMemberWorkspace(ctx, datastore.GetMemberWorkspaceParams{
+		WorkspaceID: int32(workspaceID),
+		UserID:      int32(userID),
+	})
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+	return toWorkspace(row.ID, row.Guid, row.Name, row.PersonalOf, row.Role), nil
+}
+
+// ListWorkspaces returns the workspaces userID is a member of, their personal
+// one first.
+func (s *workspaceStore) ListWorkspaces(ctx context.Context, userID domain.ID) ([]domain.Workspace, error) {
+	rows, err := s.db.ListMemberWorkspaces(ctx, int32(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	workspaces := make([]domain.Workspace, len(rows))
+	for i, row := range rows {
+		workspaces[i] = toWorkspace(row.ID, row.Guid, row.Name, row.PersonalOf, row.Role)
+	}
+	return workspaces, nil
+}
+
+// CountOwnedWorkspaces counts the shared workspaces userID owns.
+func (s *workspaceStore) CountOwnedWorkspaces(ctx context.Context, userID domain.ID) (int, error) {
+	n, err := s.db.CountOwnedWorkspaces(ctx, int32(userID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count workspaces: %w", err)
+	}
+	return int(n), nil
+}
+
+// InsertWorkspace creates a shared workspace owned by userID.
+func (s *workspaceStore) InsertWorkspace(ctx context.Context, userID domain.ID, name string) (domain.Workspace, error) {
+	row, err := s.db.InsertWorkspace(ctx, datastore.InsertWorkspaceParams{
+		Name:   name,
+		UserID: int32(userID),
+	})
+	if err != nil {
+		return domain.Workspace{}, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return toWorkspace(row.ID, row.Guid, row.Name, row.PersonalOf, string(domain.RoleOwner)), nil
+}
+
+func (s *workspaceStore) ListMembers(ctx context.Context, workspaceID domain.ID) ([]domain.WorkspaceMember, error) {
+	rows, err := s.db.ListWorkspaceMembers(ctx, int32(workspaceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspace members: %w", err)
+	}
+	members := make([]domain.WorkspaceMember, len(rows))
+	for i, row := range rows {
+		members[i] = domain.WorkspaceMember{
+			UserID:   domain.ID(row.ID),
+			Name:     row.Username,
+			Email:    row.Email,
+			Role:     domain.Role(row.Role),
+			JoinedAt: row.CreatedAt.Time,
+		}
+	}
+	return members, nil
+}
+
+// LockOwners returns the owners of workspaceID, locked until the end of the
+// transaction.
+func (s *workspaceStore) LockOwners(ctx context.Context, workspaceID domain.ID) ([]domain.ID, error) {
+	rows, err := s.q(ctx).LockWorkspaceOwners(ctx, int32(workspaceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock workspace owners: %w", err)
+	}
+	owners := make([]domain.ID, len(rows))
+	for i, id := range rows {
+		owners[i] = domain.ID(id)
+	}
+	return owners, nil
+}
+
+// UpdateMemberRole reports whether userID is a member of workspaceID.
+func (s *workspaceStore) UpdateMemberRole(ctx context.Context, workspaceID, userID domain.ID, role domain.Role) (bool, error) {
+	n, err := s.q(ctx).UpdateMemberRole(ctx, datastore.UpdateMemberRoleParams{
+		Role:        string(role),
+		WorkspaceID: int32(workspaceID),
+		UserID:      int32(userID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update member role: %w", err)
+	}
+	return n > 0, nil
+}
+
+// DeleteMember reports whether userID was a member of workspaceID.
+func (s *workspaceStore) DeleteMember(ctx context.Context, workspaceID, userID domain.ID) (bool, error) {
+	n, err := s.q(ctx).DeleteMember(ctx, datastore.DeleteMemberParams{
+		WorkspaceID: int32(workspaceID),
+		UserID:      int32(userID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete member: %w", err)
+	}
+	return n > 0, nil
+}
+
+// UpsertInvitation invites email in workspaceID, replacing any pending
+// invitation of the same email.
+func (s *workspaceStore) UpsertInvitation(ctx context.Context, inv domain.WorkspaceInvitation, invitedBy domain.ID, tokenHash []byte) (domain.WorkspaceInvitation, error) {
+	row, err := s.db.UpsertInvitation(ctx, datastore.UpsertInvitationParams{
+		WorkspaceID: int32(inv.WorkspaceID),
+		Email:       inv.Email,
+		Role:        string(inv.Role),
+		TokenHash:   tokenHash,
+		InvitedBy:   pgtype.Int4{Int32: int32(invitedBy), Valid: invitedBy != 0},
+		ExpiresAt:   pgtype.Timestamp{Time: inv.ExpiresAt.UTC(), Valid: true},
+	})
+	if err != nil {
+		return domain.WorkspaceInvitation{}, fmt.Errorf("failed to save invitation: %w", err)
+	}
+	inv.ID = domain.ID(row.ID)
+	inv.CreatedAt = row.CreatedAt.Time
+	return inv, nil
+}
+
+// ListInvitations returns the pending invitations of workspaceID, the latest
+// first.
+func (s *workspaceStore) ListInvitations(ctx context.Context, workspaceID domain.ID) ([]domain.WorkspaceInvitation, error) {
+	rows, err := s.db.ListInvitations(ctx, int32(workspaceID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	invitations := make([]domain.WorkspaceInvitation, len(rows))
+	for i, row := range rows {
+		invitations[i] = domain.WorkspaceInvitation{
+			ID:          domain.ID(row.ID),
+			WorkspaceID: workspaceID,
+			Email:       row.Email,
+			Role:        domain.Role(row.Role),
+			InvitedBy:   row.InvitedBy,
+			CreatedAt:   row.CreatedAt.Time,
+			ExpiresAt:   row.ExpiresAt.Time,
+		}
+	}
+	return invitations, nil
+}
+
+// GetInvitation returns the pending invitation whose token hashes to
+// tokenHash, pgx.ErrNoRows when there is none or it expired.
+func (s *workspaceStore) GetInvitation(ctx context.Context, tokenHash []byte) (domain.WorkspaceInvitation, error) {
+	row, err := s.db.GetInvitationByToken(ctx, tokenHash)
+	if err != nil {
+		return domain.WorkspaceInvitation{}, err
+	}
+	return domain.WorkspaceInvitation{
+		ID:          domain.ID(row.ID),
+		WorkspaceID: domain.ID(row.WorkspaceID),
+		Workspace:   row.WorkspaceName,
+		Email:       row.Email,
+		Role:        domain.Role(row.Role),
+		CreatedAt:   row.CreatedAt.Time,
+		ExpiresAt:   row.ExpiresAt.Time,
+	}, nil
+}
+
+// DeleteInvitation reports whether invitationID was an invitation of
+// workspaceID.
+func (s *workspaceStore) DeleteInvitation(ctx context.Context, workspaceID, invitationID domain.ID) (bool, error) {
+	n, err := s.db.DeleteInvitation(ctx, datastore.DeleteInvitationParams{
+		ID:          int32(invitationID),
+		WorkspaceID: int32(workspaceID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete invitation: %w", err)
+	}
+	return n > 0, nil
+}
+
+// AcceptInvitation makes userID a member with the role of the invitation and
+// deletes it.
+func (s *workspaceStore) AcceptInvitation(ctx context.Context, invitationID, userID domain.ID) error {
+	err := s.db.AcceptInvitation(ctx, datastore.AcceptInvitationParams{
+		ID:     int32(invitationID),
+		UserID: int32(userID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// APITokenPrefix marks personal API tokens so they are easy to recognise in
+// logs and secret scanners.
+const APITokenPrefix = "sct_"
+
+type APIToken struct {
+	ID         ID
+	Name       string
+	Prefix     string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
+// CreatedAPIToken holds a freshly created token together with its plain text
+// value, which is only ever available right after creation.
+type CreatedAPIToken struct {
+	APIToken
+	Token string
+}
@@ -7,6 +7,14 @@ type Period struct {
 	Until time.Time
 }
 
+// Previous is the period of the same length ending when p starts.
+func (p Period) Previous() Period {
+	return Period{
+		Since: p.Since.Add(-p.Until.Sub(p.Since)),
+		Until: p.Since,
+	}
+}
+
 type TimeSeriesData struct {
 	Time  time.Time
 	Count int64
@@ -16,3 +24,100 @@ type TwoDimension struct {
 	Label string
 	Value int
 }
+
+// Bucket is the interval visits are counted by in a time series.
+type Bucket string
+
+const (
+	BucketHour  Bucket = "hour"
+	BucketDay   Bucket = "day"
+	BucketWeek  Bucket = "week"
+	BucketMonth Bucket = "month"
+)
+
+// BucketFor picks the bucket that keeps a time series over p readable: hours
+// up to two days, days up to three months, weeks up to a year and months
+// beyond.
+func BucketFor(p Period) Bucket {
+	d := p.Until.Sub(p.Since)
+	switch {
+	case d <= 48*time.Hour:
+		return BucketHour
+	case d <= 92*24*time.Hour:
+		return BucketDay
+	case d <= 366*24*time.Hour:
+		return BucketWeek
+	default:
+		return BucketMonth
+	}
+}
+
+// Truncate returns the start, in loc, of the bucket t falls in. Weeks start
+// on Monday.
+func (b Bucket) Truncate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	y, m, d := t.Date()
+	switch b {
+	case BucketHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case BucketWeek:
+		monday := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-monday, 0, 0, 0, 0, loc)
+	case BucketMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+}
+
+// Next returns the start of the bucket following the one starting at t.
+func (b Bucket) Next(t time.Time) time.Time {
+	switch b {
+	case BucketHour:
+		return t.Add(time.Hour)
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// VisitSeries counts the visits of a link by bucket over a period, along with
+// the period just before it for comparison.
+type VisitSeries struct {
+	Period   Period
+	Bucket   Bucket
+	Location *time.Location
+	Current  []TimeSeriesData
+	// Previous has a point for each point of Current, counting the visits of
+	// the matching bucket of the previous period.
+	Previous []TimeSeriesData
+}
+
+func (s VisitSeries) Total() int64 {
+	return sum(s.Current)
+}
+
+func (s VisitSeries) PreviousTotal() int64 {
+	return sum(s.Previous)
+}
+
+// Change is the variation of the number of visits from the previous period,
+// in percent. ok is false when the previous period had no visit.
+func (s VisitSeries) Change() (change float64, ok bool) {
+	previous := s.PreviousTotal()
+	if previous == 0 {
+		return 0, false
+	}
+	return float64(s.Total()-previous) * 100 / float64(previous), true
+}
+
+func sum(data []TimeSeriesData) int64 {
+	var total int64
+	for _, d := range data {
+		total += d.Count
+	}
+	return total
+}
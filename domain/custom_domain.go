@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// CustomDomain is a hostname a workspace serves its links on instead of the
+// default domain. It is only used once the workspace proved it owns it.
+type CustomDomain struct {
+	ID          ID
+	WorkspaceID ID
+	// Hostname is lowercase, without scheme nor port.
+	Hostname string
+	// VerificationToken is the value of the TXT record proving ownership.
+	VerificationToken string
+	VerifiedAt        time.Time
+	CreatedAt         time.Time
+}
+
+// Verified reports whether the ownership of the domain was proven.
+func (d CustomDomain) Verified() bool {
+	return !d.VerifiedAt.IsZero()
+}
+
+// VerificationName is the name of the TXT record proving ownership of the
+// domain, a subdomain so that it doesn't clash with the records of the apex.
+func (d CustomDomain) VerificationName() string {
+	return "_shortcut." + d.Hostname
+}
+
+// VerificationValue is the value of the TXT record proving ownership of the
+// domain.
+func (d CustomDomain) VerificationValue() string {
+	return "shortcut-verify=" + d.VerificationToken
+}
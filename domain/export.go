@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// ExportedVisit is a visit of a link as found in analytics exports.
+type ExportedVisit struct {
+	ID          ID
+	Slug        string
+	VisitedAt   time.Time
+	Country     string
+	CountryCode string
+	City        string
+	Browser     string
+	Platform    string
+	Device      DeviceKind
+	Referrer    string
+	IsBot       bool
+}
+
+// StatMetric is what the visits of an ExportedStat are grouped by.
+type StatMetric string
+
+const (
+	StatMetricTime     StatMetric = "time"
+	StatMetricCountry  StatMetric = "country"
+	StatMetricBrowser  StatMetric = "browser"
+	StatMetricDevice   StatMetric = "device"
+	StatMetricReferrer StatMetric = "referrer"
+)
+
+// ExportedStat counts the visits of a link sharing the same value of a
+// metric: a bucket of time, a country, a browser, a device or a referrer.
+type ExportedStat struct {
+	Slug   string
+	Metric StatMetric
+	// Label is the value of the metric, the start of the bucket for time.
+	Label      string
+	Visits     int64
+	Percentage float32
+}
@@ -2,6 +2,7 @@ package domain
 
 type IPLocation struct {
 	IP          string
+	Continent   string
 	Country     string
 	CountryCode string
 	City        string
@@ -11,4 +12,12 @@ type IPLocation struct {
 	Longitude   float64
 	Timezone    string
 	Localtime   string
+	// Source names the provider the location comes from.
+	Source string
+
+	// The address is known to belong to a hosting provider, a proxy or the
+	// Tor network. Only ipquery reports these.
+	IsDatacenter bool
+	IsProxy      bool
+	IsTor        bool
 }
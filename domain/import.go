@@ -0,0 +1,49 @@
+package domain
+
+// ImportRow is a link to create in a bulk import.
+type ImportRow struct {
+	// Line is the line of the row in the imported file, or its position in
+	// an API batch, counted from 1.
+	Line int
+	URL  string
+	// Title is extracted from the page when empty.
+	Title string
+}
+
+// ImportStatus is what became of a row of a bulk import.
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	// ImportInvalid rows were not a valid URL.
+	ImportInvalid ImportStatus = "invalid"
+	// ImportFlagged rows were found dangerous by the safety scanner, their
+	// link is created disabled, pending moderation.
+	ImportFlagged ImportStatus = "flagged"
+	// ImportSkipped rows were left out, because the monthly quota was reached
+	// or the account was suspended by a flagged row.
+	ImportSkipped ImportStatus = "skipped"
+	ImportFailed  ImportStatus = "failed"
+)
+
+// ImportResult reports on a row of a bulk import.
+type ImportResult struct {
+	Row    ImportRow
+	Status ImportStatus
+	// Short is the short URL of the created link.
+	Short string
+	Slug  string
+	// Error explains why the row was not created.
+	Error string
+}
+
+// ImportSummary counts the rows of a bulk import by status.
+type ImportSummary map[ImportStatus]int
+
+func SummarizeImport(results []ImportResult) ImportSummary {
+	summary := make(ImportSummary)
+	for _, r := range results {
+		summary[r.Status]++
+	}
+	return summary
+}
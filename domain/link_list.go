@@ -0,0 +1,44 @@
+package domain
+
+// LinkFilter narrows down the links listed on the dashboard.
+type LinkFilter struct {
+	// Search keeps the links with, for every word of it, a word starting
+	// with it in their title, slug or destination.
+	Search   string
+	Archived bool
+	// Tag, when set, only keeps the links wearing it.
+	Tag string
+	// FolderID, when set, only keeps the links filed in it.
+	FolderID ID
+	Sort     LinkSort
+}
+
+// LinkSort is the order links are listed in.
+type LinkSort string
+
+const (
+	LinkSortNewest       LinkSort = "newest"
+	LinkSortOldest       LinkSort = "oldest"
+	LinkSortMostClicked  LinkSort = "most-clicked"
+	LinkSortLeastClicked LinkSort = "least-clicked"
+	// LinkSortFolder groups the links by folder name, the ones outside of any
+	// folder last, the newest first within a folder.
+	LinkSortFolder LinkSort = "folder"
+)
+
+// ParseLinkSort returns the sort named v, the newest links first when v is
+// unknown.
+func ParseLinkSort(v string) LinkSort {
+	switch s := LinkSort(v); s {
+	case LinkSortOldest, LinkSortMostClicked, LinkSortLeastClicked, LinkSortFolder:
+		return s
+	default:
+		return LinkSortNewest
+	}
+}
+
+// Page is the slice of a list to return. A zero Limit returns the whole list.
+type Page struct {
+	Offset int
+	Limit  int
+}
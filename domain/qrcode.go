@@ -0,0 +1,53 @@
+package domain
+
+import "image/color"
+
+// QRFormat is the image format a QR code is rendered in.
+type QRFormat string
+
+const (
+	QRFormatPNG QRFormat = "png"
+	QRFormatSVG QRFormat = "svg"
+)
+
+// QRLevel is the error correction level of a QR code, the share of the code
+// that can be damaged, or hidden by a logo, while it still scans.
+type QRLevel string
+
+const (
+	// QRLevelLow recovers 7% of the code.
+	QRLevelLow QRLevel = "L"
+	// QRLevelMedium recovers 15% of the code.
+	QRLevelMedium QRLevel = "M"
+	// QRLevelQuartile recovers 25% of the code.
+	QRLevelQuartile QRLevel = "Q"
+	// QRLevelHigh recovers 30% of the code.
+	QRLevelHigh QRLevel = "H"
+)
+
+const (
+	QRMinSize     = 128
+	QRMaxSize     = 2048
+	QRDefaultSize = 256
+)
+
+// QROptions are the styling options of a QR code.
+type QROptions struct {
+	Format QRFormat
+	// Size is the width and height of the image in pixels.
+	Size       int
+	Level      QRLevel
+	Foreground color.RGBA
+	Background color.RGBA
+	// Logo draws the logo of Shortcut in the middle of the code.
+	Logo bool
+}
+
+// DefaultQROptions renders black on white PNG codes.
+var DefaultQROptions = QROptions{
+	Format:     QRFormatPNG,
+	Size:       QRDefaultSize,
+	Level:      QRLevelMedium,
+	Foreground: color.RGBA{A: 0xff},
+	Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+}
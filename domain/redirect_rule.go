@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Platform is an operating system family a redirect rule can target.
+type Platform string
+
+const (
+	PlatformIOS     Platform = "ios"
+	PlatformAndroid Platform = "android"
+	PlatformWindows Platform = "windows"
+	PlatformMacOS   Platform = "macos"
+	PlatformLinux   Platform = "linux"
+	PlatformOther   Platform = "other"
+)
+
+// Platforms are the platforms redirect rules can target.
+var Platforms = []Platform{PlatformIOS, PlatformAndroid, PlatformWindows, PlatformMacOS, PlatformLinux}
+
+// RedirectRule sends the visitors matching all of its conditions to LongURL
+// instead of the destination of the link. Empty conditions match everyone.
+type RedirectRule struct {
+	ID      ID
+	LongURL string
+
+	Platforms []Platform
+	// Mobile restricts the rule to mobile devices when true, to the others
+	// when false.
+	Mobile *bool
+	// Countries are ISO 3166-1 alpha-2 codes, in upper case.
+	Countries []string
+	// Languages are language tags in lower case. "fr" matches every regional
+	// variant like "fr-ca", "fr-ca" only itself.
+	Languages []string
+	StartsAt  *time.Time
+	EndsAt    *time.Time
+
+	// Clicks is the number of visits sent to LongURL by this rule.
+	Clicks int
+}
+
+// HasConditions reports whether the rule restricts who it applies to.
+func (r RedirectRule) HasConditions() bool {
+	return len(r.Platforms) > 0 || r.Mobile != nil || len(r.Countries) > 0 || len(r.Languages) > 0 ||
+		r.StartsAt != nil || r.EndsAt != nil
+}
+
+// Matches reports whether a visit described by info at time now satisfies all
+// the conditions of the rule.
+func (r RedirectRule) Matches(info *RequestInfo, now time.Time) bool {
+	if r.StartsAt != nil && now.Before(*r.StartsAt) {
+		return false
+	}
+	if r.EndsAt != nil && !now.Before(*r.EndsAt) {
+		return false
+	}
+	if len(r.Platforms) > 0 && !slices.Contains(r.Platforms, info.Platform()) {
+		return false
+	}
+	if r.Mobile != nil && *r.Mobile != info.Browser().IsMobile {
+		return false
+	}
+	if len(r.Countries) > 0 && !slices.Contains(r.Countries, strings.ToUpper(info.Country())) {
+		return false
+	}
+	if len(r.Languages) > 0 && !matchLanguage(r.Languages, info.Language()) {
+		return false
+	}
+	return true
+}
+
+func matchLanguage(languages []string, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	primary, _, _ := strings.Cut(tag, "-")
+	for _, lang := range languages {
+		if lang == tag || lang == primary {
+			return true
+		}
+	}
+	return false
+}
+
+// Destination returns where a visit described by info at time now is
+// redirected: the destination of the first matching rule, or the destination
+// of the link. The matched rule is nil when no rule matched.
+func (u URL) Destination(info *RequestInfo, now time.Time) (string, *RedirectRule) {
+	for i, rule := range u.Rules {
+		if rule.Matches(info, now) {
+			return rule.LongURL, &u.Rules[i]
+		}
+	}
+	return u.Long, nil
+}
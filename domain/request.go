@@ -7,18 +7,20 @@ import (
 )
 
 type RequestInfo struct {
-	ipAddress string
-	userAgent string
-	referer   string
-	country   string
+	ipAddress      string
+	userAgent      string
+	referer        string
+	country        string
+	acceptLanguage string
 }
 
-func NewRequestInfo(ipAddress, userAgent, referer, country string) *RequestInfo {
+func NewRequestInfo(ipAddress, userAgent, referer, country, acceptLanguage string) *RequestInfo {
 	return &RequestInfo{
-		ipAddress: ipAddress,
-		userAgent: userAgent,
-		referer:   referer,
-		country:   country,
+		ipAddress:      ipAddress,
+		userAgent:      userAgent,
+		referer:        referer,
+		country:        country,
+		acceptLanguage: acceptLanguage,
 	}
 }
 
@@ -38,6 +40,38 @@ func (r *RequestInfo) Referer() string {
 func (r *RequestInfo) Country() string {
 	return r.country
 }
+
+// Language is the preferred language of the visitor, the first tag of the
+// Accept-Language header in lower case, e.g. "fr-ca".
+func (r *RequestInfo) Language() string {
+	tag, _, _ := strings.Cut(r.acceptLanguage, ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "*" {
+		return ""
+	}
+	return tag
+}
+
+// Platform is the operating system family of the visitor.
+func (r *RequestInfo) Platform() Platform {
+	ua := user_agent.New(r.userAgent)
+	system := ua.OS()
+	switch {
+	case strings.HasPrefix(system, "Android"):
+		return PlatformAndroid
+	case strings.Contains(system, "iPhone OS"), strings.HasPrefix(ua.Platform(), "iP"):
+		return PlatformIOS
+	case strings.HasPrefix(system, "Windows"):
+		return PlatformWindows
+	case strings.Contains(system, "Mac OS X"):
+		return PlatformMacOS
+	case strings.Contains(system, "Linux"), strings.HasPrefix(system, "CrOS"):
+		return PlatformLinux
+	default:
+		return PlatformOther
+	}
+}
 func (r *RequestInfo) Browser() Browser {
 	ua := user_agent.New(r.userAgent)
 	name, version := ua.Browser()
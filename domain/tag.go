@@ -0,0 +1,18 @@
+package domain
+
+// Folder groups links of a workspace. A link is in at most one folder.
+type Folder struct {
+	ID   ID
+	Name string
+
+	// Links is the number of links, not archived, in the folder.
+	Links int
+}
+
+// TagStat aggregates the visits of the links wearing a tag.
+type TagStat struct {
+	Name           string
+	Links          int
+	Clicks         int
+	UniqueVisitors int
+}
@@ -1,20 +1,194 @@
 package domain
 
-import "time"
+import (
+	"net/http"
+	"strings"
+	"time"
+)
 
 type URL struct {
-	ID         ID
-	Title      string
-	Long       string
-	Short      string
-	Slug       string
+	ID    ID
+	Title string
+	Long  string
+	Short string
+	Slug  string
+	// Domain is the custom domain the link is served on, empty for the
+	// default one.
+	Domain     string
 	IsArchived bool
 	IsActive   bool
 	CreatedAt  time.Time
+	Expiration
+	// HasPassword is set when visitors must enter a password to be redirected.
+	HasPassword bool
+	// RedirectStatus is the HTTP status code visitors are redirected with.
+	RedirectStatus int
+	// ForwardQuery is set when the query string of a visit is passed on to
+	// the destination.
+	ForwardQuery bool
+	// Rules pick another destination depending on the visitor, in order.
+	Rules []RedirectRule
+	// Variants split the visitors no rule matched between several
+	// destinations. Long is only used when there are none.
+	Variants []Variant
+	// StickyVariants keeps sending a returning visitor to the variant they
+	// were first sent to.
+	StickyVariants bool
+	// Folder is the folder the link is filed in, its ID is zero when it
+	// isn't in any.
+	Folder Folder
+	// Tags are the lowercase labels of the link, sorted.
+	Tags []string
+	// Clicks is the number of redirects served to people, counted toward
+	// MaxClicks.
+	Clicks int
 
 	NrVisited int
 }
 
+// Ref is the reference the dashboard and the API address the link by.
+func (u URL) Ref() LinkRef {
+	return LinkRef{Domain: u.Domain, Slug: u.Slug}
+}
+
+// LinkRef identifies a link of a workspace: slugs are only unique per domain.
+type LinkRef struct {
+	// Domain is the custom domain the link is served on, empty for the
+	// default one.
+	Domain string
+	Slug   string
+}
+
+// ParseLinkRef parses the form returned by LinkRef.String.
+func ParseLinkRef(s string) LinkRef {
+	slug, host, _ := strings.Cut(s, "@")
+	return LinkRef{Domain: strings.ToLower(host), Slug: slug}
+}
+
+// String returns the slug, followed by @ and the domain for links of a custom
+// domain. Slugs can't contain @, so it can be used as a path segment.
+func (r LinkRef) String() string {
+	if r.Domain == "" {
+		return r.Slug
+	}
+	return r.Slug + "@" + r.Domain
+}
+
+// DefaultRedirectStatus is the status code of links created without an
+// explicit redirect type.
+const DefaultRedirectStatus = http.StatusFound
+
+// RedirectStatuses are the status codes a link can redirect with: permanent
+// redirects are cached by browsers and search engines, 307 and 308 keep the
+// method and body of the request.
+var RedirectStatuses = []int{
+	http.StatusFound,
+	http.StatusMovedPermanently,
+	http.StatusTemporaryRedirect,
+	http.StatusPermanentRedirect,
+}
+
+// DestinationChange records a destination a link pointed to before being edited.
+type DestinationChange struct {
+	LongURL   string
+	ChangedBy string
+	ChangedAt time.Time
+}
+
+// AddURLParams holds the columns of a link being inserted.
+type AddURLParams struct {
+	Title string
+	Slug  string
+	// Domain is the custom domain the link is served on, empty for the
+	// default one.
+	Domain   string
+	Long     string
+	IsActive bool
+	Expiration
+	// WorkspaceID owns the link, AuthorID is the member who created it.
+	WorkspaceID    ID
+	AuthorID       ID
+	Password       *PasswordHash
+	RedirectStatus int
+	ForwardQuery   bool
+}
+
+// PasswordHash is the hashed password protecting a link, along with its salt.
+type PasswordHash struct {
+	Hash []byte
+	Salt []byte
+}
+
+// Expiration limits the lifetime of a link. A nil field means no limit.
+type Expiration struct {
+	ExpiresAt *time.Time
+	MaxClicks *int
+}
+
+// IsZero reports whether the link never expires.
+func (e Expiration) IsZero() bool {
+	return e.ExpiresAt == nil && e.MaxClicks == nil
+}
+
+// Expired reports whether a link that has been clicked clicks times is past
+// its expiry date or click limit.
+func (e Expiration) Expired(now time.Time, clicks int) bool {
+	if e.ExpiresAt != nil && !now.Before(*e.ExpiresAt) {
+		return true
+	}
+	return e.MaxClicks != nil && clicks >= *e.MaxClicks
+}
+
+// ShortenOptions holds the optional settings of a link being created.
+type ShortenOptions struct {
+	// Slug is a custom alias. A random one is generated when empty.
+	Slug string
+	Expiration
+	// Password protects the link when not empty.
+	Password string
+	// RedirectStatus is the redirect status code, DefaultRedirectStatus when 0.
+	RedirectStatus int
+	// UTM parameters are merged into the destination.
+	UTM UTM
+	// ForwardQuery passes the query string of visits on to the destination.
+	ForwardQuery bool
+	// Domain is a verified custom domain of the workspace to serve the link
+	// on, the default domain when empty.
+	Domain string
+}
+
+// UTM holds the campaign parameters added to a destination for analytics
+// tools. Empty fields are left out.
+type UTM struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
+
+// IsZero reports whether no parameter is set.
+func (u UTM) IsZero() bool {
+	return u == UTM{}
+}
+
+// Params returns the parameters by query string key, skipping empty ones.
+func (u UTM) Params() map[string]string {
+	params := make(map[string]string, 5)
+	for key, value := range map[string]string{
+		"utm_source":   u.Source,
+		"utm_medium":   u.Medium,
+		"utm_campaign": u.Campaign,
+		"utm_term":     u.Term,
+		"utm_content":  u.Content,
+	} {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	return params
+}
+
 type AdminURL struct {
 	URL
 	Author     string
@@ -23,8 +197,12 @@ type AdminURL struct {
 
 type URLStat struct {
 	URL
+	// Filter is the filter the statistics were computed with.
+	Filter StatsFilter
 
-	UniqueVisitors       int
+	UniqueVisitors int
+	// QRScans is the number of visits that scanned the QR code of the link.
+	QRScans              int
 	LocationDistribution []LocationDistribution
 	Referrers            []Referrer
 	ReferrersChart       []TwoDimension
@@ -32,8 +210,22 @@ type URLStat struct {
 	DeviceChart          []TwoDimension
 	Browsers             []BrowserStats
 	BrowserChart         []TwoDimension
-	VisitPerDay          []TimeSeriesData
+	VisitsOverTime       VisitSeries
+}
+
+// StatsFilter narrows down the visits statistics are computed from.
+type StatsFilter struct {
+	// IncludeBots counts the visits of crawlers, link previews and uptime
+	// monitors, which are left out by default.
+	IncludeBots bool
+	// Period is the span of the visits counted. When zero, distributions
+	// count every visit and time series the last 24 hours.
+	Period Period
+	// Location is the timezone the buckets of time series start in, UTC when
+	// nil.
+	Location *time.Location
 }
+
 type DeviceKind string
 
 var (
@@ -0,0 +1,54 @@
+package domain
+
+// Variant is one of the destinations a link splits its visitors between, in
+// proportion to its weight.
+type Variant struct {
+	ID      ID
+	LongURL string
+	Weight  int
+
+	// Clicks is the number of visits sent to this variant.
+	Clicks int
+	// UniqueVisitors is the number of distinct IP addresses among Clicks.
+	UniqueVisitors int
+}
+
+// TotalWeight is the sum of the weights of variants.
+func TotalWeight(variants []Variant) int {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	return total
+}
+
+// Share is the fraction of visitors the variant receives, in percent.
+func (v Variant) Share(variants []Variant) float32 {
+	total := TotalWeight(variants)
+	if total == 0 {
+		return 0
+	}
+	return float32(v.Weight) * 100 / float32(total)
+}
+
+// PickVariant returns the variant whose slice of the total weight contains
+// roll, a number in [0, TotalWeight(variants)). Variants must not be empty.
+func PickVariant(variants []Variant, roll int) Variant {
+	for _, v := range variants {
+		if roll < v.Weight {
+			return v
+		}
+		roll -= v.Weight
+	}
+	return variants[len(variants)-1]
+}
+
+// Variant returns the variant of the link with the given ID.
+func (u URL) Variant(id ID) (Variant, bool) {
+	for _, v := range u.Variants {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// Visit is a click on a short link waiting to be recorded.
+type Visit struct {
+	URLID ID
+	Route
+	VisitedAt time.Time
+	Request   RequestInfo
+	// Location is nil when the visitor could not be located.
+	Location *IPLocation
+	// IsBot is set when the visit comes from a crawler, a link preview or a
+	// monitoring service rather than a person.
+	IsBot bool
+	// Source tells how the visitor got to the short link.
+	Source VisitSource
+}
+
+// VisitSource tells how a visitor got to a short link.
+type VisitSource string
+
+const (
+	// VisitSourceDirect visits followed the short URL itself.
+	VisitSourceDirect VisitSource = ""
+	// VisitSourceQR visits scanned the QR code of the link.
+	VisitSourceQR VisitSource = "qr"
+)
+
+// Route records what picked the destination of a visit.
+type Route struct {
+	// RuleID is the redirect rule that matched, 0 when none did.
+	RuleID ID
+	// VariantID is the variant the visitor was sent to, 0 when the link has
+	// no variants or a rule matched.
+	VariantID ID
+}
@@ -0,0 +1,87 @@
+package domain
+
+import "time"
+
+// WebhookEvent is a kind of event webhook endpoints subscribe to.
+type WebhookEvent string
+
+const (
+	// WebhookLinkCreated is sent when a link is shortened.
+	WebhookLinkCreated WebhookEvent = "link.created"
+	// WebhookLinkClicked is sent for every visit of a link by a person.
+	WebhookLinkClicked WebhookEvent = "link.clicked"
+	// WebhookLinkDisabled is sent when moderation disables a link.
+	WebhookLinkDisabled WebhookEvent = "link.disabled"
+	// WebhookLinkClickThreshold is sent when the clicks of a link reach one
+	// of the ClickThresholds.
+	WebhookLinkClickThreshold WebhookEvent = "link.click_threshold"
+)
+
+// WebhookEvents are the events endpoints can subscribe to, in the order they
+// are shown.
+var WebhookEvents = []WebhookEvent{
+	WebhookLinkCreated,
+	WebhookLinkClicked,
+	WebhookLinkDisabled,
+	WebhookLinkClickThreshold,
+}
+
+// ClickThresholds are the numbers of clicks link.click_threshold is sent at.
+var ClickThresholds = []int64{100, 1_000, 10_000, 100_000, 1_000_000}
+
+// ReachedThreshold returns the highest of the ClickThresholds a link with
+// clicks visits reached.
+func ReachedThreshold(clicks int64) (int64, bool) {
+	for i := len(ClickThresholds) - 1; i >= 0; i-- {
+		if ClickThresholds[i] <= clicks {
+			return ClickThresholds[i], true
+		}
+	}
+	return 0, false
+}
+
+// WebhookEndpoint is a URL of a user that events are posted to.
+type WebhookEndpoint struct {
+	ID  ID
+	URL string
+	// Secret signs the payloads posted to the endpoint.
+	Secret    string
+	Events    []WebhookEvent
+	CreatedAt time.Time
+}
+
+// Subscribed reports whether the endpoint receives event.
+func (e WebhookEndpoint) Subscribed(event WebhookEvent) bool {
+	for _, ev := range e.Events {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is where a delivery stands: pending deliveries are
+// still being tried, failed ones ran out of attempts.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is an event posted, or to post, to an endpoint.
+type WebhookDelivery struct {
+	// ID is wider than the other ids, every click can be delivered.
+	ID             int64
+	EndpointID     ID
+	EndpointURL    string
+	Event          WebhookEvent
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastAttemptAt  *time.Time
+	ResponseStatus int
+	LastError      string
+	CreatedAt      time.Time
+}
@@ -0,0 +1,82 @@
+package domain
+
+import "time"
+
+// Role is what a member is allowed to do in a workspace. Each role can do
+// everything the ones below it can.
+type Role string
+
+const (
+	// RoleViewer sees the links of a workspace and their statistics.
+	RoleViewer Role = "viewer"
+	// RoleEditor also creates, edits and archives links.
+	RoleEditor Role = "editor"
+	// RoleOwner also manages the members and the subscription.
+	RoleOwner Role = "owner"
+)
+
+// Roles lists the roles from the most to the least privileged.
+var Roles = []Role{RoleOwner, RoleEditor, RoleViewer}
+
+func (r Role) rank() int {
+	switch r {
+	case RoleOwner:
+		return 3
+	case RoleEditor:
+		return 2
+	case RoleViewer:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Valid reports whether r is one of the Roles.
+func (r Role) Valid() bool {
+	return r.rank() > 0
+}
+
+// Can reports whether r grants at least the rights of required.
+func (r Role) Can(required Role) bool {
+	return r.Valid() && r.rank() >= required.rank()
+}
+
+// Workspace owns links, folders, tags and a subscription, shared by its
+// members. Every user has a personal workspace nobody else can join.
+type Workspace struct {
+	ID       ID
+	GUID     GUID
+	Name     string
+	Personal bool
+
+	// Role is the one of the user the workspace was loaded for.
+	Role Role
+}
+
+type WorkspaceMember struct {
+	UserID   ID
+	Name     string
+	Email    string
+	Role     Role
+	JoinedAt time.Time
+}
+
+type WorkspaceInvitation struct {
+	ID          ID
+	WorkspaceID ID
+	Workspace   string
+	Email       string
+	Role        Role
+	InvitedBy   string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// CreatedInvitation holds a freshly created invitation together with the link
+// accepting it, which is only ever available right after creation.
+type CreatedInvitation struct {
+	WorkspaceInvitation
+	Link string
+	// Sent reports whether the link was emailed to the invitee.
+	Sent bool
+}
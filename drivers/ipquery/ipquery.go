@@ -1,9 +1,12 @@
 package ipquery
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type Response struct {
@@ -36,9 +39,16 @@ type Response struct {
 
 const baseURL = "https://api.ipquery.io"
 
-func QueryIP(ip string) (*Response, error) {
+var client = &http.Client{Timeout: 5 * time.Second}
+
+func QueryIP(ctx context.Context, ip string) (*Response, error) {
 	url := fmt.Sprintf("%s/%s?format=json", baseURL, ip)
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -58,3 +68,43 @@ func QueryIP(ip string) (*Response, error) {
 
 	return r, nil
 }
+
+// QueryIPs looks up several IP addresses in a single request.
+func QueryIPs(ctx context.Context, ips []string) ([]Response, error) {
+	switch len(ips) {
+	case 0:
+		return nil, nil
+	case 1:
+		r, err := QueryIP(ctx, ips[0])
+		if err != nil {
+			return nil, err
+		}
+		return []Response{*r}, nil
+	}
+
+	url := fmt.Sprintf("%s/%s?format=json", baseURL, strings.Join(ips, ","))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query IPs: %s", resp.Status)
+	}
+
+	var r []Response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"expvar"
 	"net/http"
 	"strconv"
 	"time"
@@ -41,11 +42,12 @@ func (h *AdministrationHandlers) Routes(r chi.Router, pool *pgxpool.Pool) {
 		r.Get("/admin/users", h.users)
 		r.Get("/admin/users/{guid}", h.userDetail)
 		r.Get("/admin/urls", h.urls)
-		r.Get("/admin/urls/{slug}", h.urlDetail)
+		r.Get("/admin/urls/{id}", h.urlDetail)
 		r.Get("/admin/urls/{id}/edit", h.editURL)
 		r.Post("/admin/urls/{id}", h.updateURL)
 		r.Delete("/admin/urls/{id}", h.deleteURL)
 		r.Patch("/admin/urls/{id}/status", h.toggleURLStatus)
+		r.Get("/admin/debug/vars", expvar.Handler().ServeHTTP)
 		r.Patch("/admin/users/{guid}/status", h.toggleUserSuspension)
 		r.Patch("/admin/users/{guid}/urls/status", h.toggleUserURLsStatus)
 		r.Get("/admin/moderation", h.moderation)
@@ -119,7 +121,8 @@ func (h *AdministrationHandlers) updateURL(w http.ResponseWriter, r *http.Reques
 	title := r.FormValue("title")
 	longURL := r.FormValue("long_url")
 
-	if err := h.service.UpdateURL(r.Context(), domain.ID(id), title, longURL); err != nil {
+	admin := middleware.UserFromContext(r.Context())
+	if err := h.service.UpdateURL(r.Context(), domain.ID(id), admin.ID, title, longURL); err != nil {
 		http.Error(w, "Failed to update URL", http.StatusInternalServerError)
 		return
 	}
@@ -128,8 +131,12 @@ func (h *AdministrationHandlers) updateURL(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *AdministrationHandlers) urlDetail(w http.ResponseWriter, r *http.Request) {
-	slug := chi.URLParam(r, "slug")
-	stats, err := h.service.GetURLStats(r.Context(), slug)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid URL ID", http.StatusBadRequest)
+		return
+	}
+	stats, err := h.service.GetURLStats(r.Context(), domain.ID(id))
 	if err != nil {
 		http.Error(w, "Failed to retrieve URL stats", http.StatusInternalServerError)
 		return
@@ -190,7 +197,7 @@ func (h *AdministrationHandlers) toggleURLStatus(w http.ResponseWriter, r *http.
 			http.Error(w, "Failed to fetch updated URL", http.StatusInternalServerError)
 			return
 		}
-		stats, err := h.service.GetURLStats(r.Context(), url.Slug)
+		stats, err := h.service.GetURLStats(r.Context(), url.ID)
 		if err != nil {
 			http.Error(w, "Failed to fetch updated URL stats", http.StatusInternalServerError)
 			return
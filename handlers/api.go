@@ -0,0 +1,888 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/services"
+)
+
+// maxAPIBodySize caps the size of JSON payloads accepted by the API.
+const maxAPIBodySize = 1 << 20
+
+// workspaceGetter looks up a workspace of a user, the API acts on the one
+// named by the X-Workspace-ID header.
+type workspaceGetter interface {
+	Get(ctx context.Context, userID, workspaceID domain.ID) (domain.Workspace, error)
+}
+
+// APIHandlers exposes links and their analytics as JSON under /api/v1.
+type APIHandlers struct {
+	svc        URLService
+	stripe     stripeService
+	qr         QRCodeRenderer
+	workspaces workspaceGetter
+}
+
+func NewAPIHandlers(svc URLService, stripe stripeService, qr QRCodeRenderer, workspaces workspaceGetter) *APIHandlers {
+	return &APIHandlers{
+		svc:        svc,
+		stripe:     stripe,
+		qr:         qr,
+		workspaces: workspaces,
+	}
+}
+
+func (h *APIHandlers) Routes(r chi.Router) {
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(apiAuthenticated)
+		r.Use(h.apiWorkspace)
+
+		r.With(middleware.PaginateParams).Get("/links", h.listLinks)
+		r.Get("/links/export", h.exportLinks)
+		r.Get("/links/{ref}", h.getLink)
+		r.Get("/links/{ref}/stats", h.linkStats)
+		r.Get("/links/{ref}/history", h.linkHistory)
+		r.Get("/links/{ref}/rules", h.linkRules)
+		r.Get("/links/{ref}/variants", h.linkVariants)
+		r.Get("/links/{ref}/export", h.exportLink)
+		r.Get("/links/{ref}/qr", h.linkQRCode)
+		r.Get("/tags", h.listTags)
+		r.Get("/folders", h.listFolders)
+
+		r.Group(func(r chi.Router) {
+			r.Use(apiRequireRole(domain.RoleEditor))
+
+			r.Post("/links", h.createLink)
+			r.Post("/links/batch", h.importLinks)
+			r.Patch("/links/{ref}", h.updateLink)
+			r.Delete("/links/{ref}", h.deleteLink)
+			r.Put("/links/{ref}/rules", h.setLinkRules)
+			r.Put("/links/{ref}/variants", h.setLinkVariants)
+			r.Post("/folders", h.createFolder)
+			r.Delete("/folders/{id}", h.deleteFolder)
+		})
+	})
+}
+
+// workspaceHeader picks the workspace an API request works in, the personal
+// workspace of the user when it isn't set.
+const workspaceHeader = "X-Workspace-ID"
+
+// apiWorkspace switches to the workspace of the X-Workspace-ID header. It must
+// come after apiAuthenticated.
+func (h *APIHandlers) apiWorkspace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(workspaceHeader)
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id, err := strconv.ParseInt(header, 10, 32)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "", workspaceHeader+" must be a workspace id")
+			return
+		}
+
+		user := middleware.UserFromContext(r.Context())
+		ws, err := h.workspaces.Get(r.Context(), user.ID, domain.ID(id))
+		if err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(middleware.WithWorkspace(r.Context(), ws)))
+	})
+}
+
+// apiRequireRole is the JSON counterpart of requireRole.
+func apiRequireRole(role domain.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ws := middleware.WorkspaceFromContext(r.Context())
+			if ws == nil || !ws.Role.Can(role) {
+				writeAPIServiceError(w, services.ErrWorkspaceForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// apiAuthenticated is the JSON counterpart of middleware.Authenticated: it
+// answers with a 401/403 error body instead of redirecting to the login page.
+func apiAuthenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := middleware.UserFromContext(r.Context())
+		if user == nil {
+			writeAPIError(w, http.StatusUnauthorized, "", "authentication required")
+			return
+		}
+
+		if user.IsSuspended {
+			writeAPIError(w, http.StatusForbidden, "account_suspended", "your account has been suspended")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type apiLink struct {
+	ID             domain.ID  `json:"id"`
+	Slug           string     `json:"slug"`
+	ShortURL       string     `json:"short_url"`
+	Domain         string     `json:"domain"`
+	Ref            string     `json:"ref"`
+	LongURL        string     `json:"long_url"`
+	Title          string     `json:"title"`
+	IsActive       bool       `json:"is_active"`
+	IsArchived     bool       `json:"is_archived"`
+	Clicks         int        `json:"clicks"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	MaxClicks      *int       `json:"max_clicks"`
+	Protected      bool       `json:"password_protected"`
+	RedirectStatus int        `json:"redirect_status"`
+	ForwardQuery   bool       `json:"forward_query"`
+	StickyVariants bool       `json:"sticky_variants"`
+	Tags           []string   `json:"tags"`
+	Folder         *apiFolder `json:"folder"`
+}
+
+type apiPagination struct {
+	Page       int `json:"page"`
+	PageSize   int `json:"page_size"`
+	TotalItems int `json:"total_items"`
+}
+
+type apiLinkList struct {
+	Data       []apiLink     `json:"data"`
+	Pagination apiPagination `json:"pagination"`
+}
+
+type apiLinkStats struct {
+	apiLink
+	// IncludeBots tells whether the visits of bots were counted, which the
+	// include_bots query parameter asks for.
+	IncludeBots    bool               `json:"include_bots"`
+	UniqueVisitors int                `json:"unique_visitors"`
+	QRScans        int                `json:"qr_scans"`
+	Locations      []apiLocationStat  `json:"locations"`
+	Referrers      []apiReferrerStat  `json:"referrers"`
+	Devices        map[string]float32 `json:"devices"`
+	Browsers       []apiBrowserStat   `json:"browsers"`
+	// From, To and Timezone are the period of visits_over_time, chosen with
+	// the range or from and to query parameters, and the timezone of its
+	// buckets, chosen with tz.
+	From                   time.Time           `json:"from"`
+	To                     time.Time           `json:"to"`
+	Timezone               string              `json:"timezone"`
+	Bucket                 domain.Bucket       `json:"bucket"`
+	VisitsOverTime         []apiTimeSeriesData `json:"visits_over_time"`
+	PreviousVisitsOverTime []apiTimeSeriesData `json:"previous_visits_over_time"`
+	Variants               []apiVariant        `json:"variants"`
+}
+
+type apiLocationStat struct {
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"`
+	Visits      int     `json:"visits"`
+	Percentage  float32 `json:"percentage"`
+}
+
+type apiReferrerStat struct {
+	Source     string  `json:"source"`
+	Clicks     int     `json:"clicks"`
+	Percentage float32 `json:"percentage"`
+}
+
+type apiBrowserStat struct {
+	Name       string  `json:"name"`
+	Percentage float32 `json:"percentage"`
+}
+
+type apiTimeSeriesData struct {
+	Time  time.Time `json:"time"`
+	Count int64     `json:"count"`
+}
+
+// apiDestinationChange is a previous destination of a link.
+type apiDestinationChange struct {
+	URL       string    `json:"url"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type apiDestinationHistory struct {
+	Data []apiDestinationChange `json:"data"`
+}
+
+// apiRedirectRule sends the visitors matching all of its conditions to URL.
+// Clicks is ignored in requests.
+type apiRedirectRule struct {
+	ID        domain.ID         `json:"id,omitempty"`
+	URL       string            `json:"url"`
+	Platforms []domain.Platform `json:"platforms"`
+	Mobile    *bool             `json:"mobile"`
+	Countries []string          `json:"countries"`
+	Languages []string          `json:"languages"`
+	StartsAt  *time.Time        `json:"starts_at"`
+	EndsAt    *time.Time        `json:"ends_at"`
+	Clicks    int               `json:"clicks"`
+}
+
+type apiRedirectRules struct {
+	Data []apiRedirectRule `json:"data"`
+}
+
+// setRedirectRulesRequest replaces the rules of a link. Rules are evaluated
+// in order; the ones with an id update an existing rule, the others are new.
+type setRedirectRulesRequest struct {
+	Rules []apiRedirectRule `json:"rules"`
+}
+
+// apiVariant is one of the destinations a link splits its visitors between.
+// Share, clicks and unique visitors are ignored in requests.
+type apiVariant struct {
+	ID             domain.ID `json:"id,omitempty"`
+	URL            string    `json:"url"`
+	Weight         int       `json:"weight"`
+	Share          float32   `json:"share"`
+	Clicks         int       `json:"clicks"`
+	UniqueVisitors int       `json:"unique_visitors"`
+}
+
+type apiVariants struct {
+	Data []apiVariant `json:"data"`
+}
+
+// setVariantsRequest replaces the variants of a link; the ones with an id
+// update an existing variant, the others are new. An empty list stops the
+// split.
+type setVariantsRequest struct {
+	Variants []apiVariant `json:"variants"`
+}
+
+type createLinkRequest struct {
+	URL       string     `json:"url"`
+	Title     string     `json:"title"`
+	Slug      string     `json:"slug"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxClicks *int       `json:"max_clicks"`
+	Password  string     `json:"password"`
+	// RedirectStatus is one of 301, 302, 307 or 308, 302 when omitted.
+	RedirectStatus int    `json:"redirect_status"`
+	UTM            apiUTM `json:"utm"`
+	ForwardQuery   bool   `json:"forward_query"`
+	// Domain is a verified custom domain of the workspace, the default domain
+	// when omitted.
+	Domain string `json:"domain"`
+}
+
+// apiUTM holds the campaign parameters merged into the destination of a new
+// link.
+type apiUTM struct {
+	Source   string `json:"source"`
+	Medium   string `json:"medium"`
+	Campaign string `json:"campaign"`
+	Term     string `json:"term"`
+	Content  string `json:"content"`
+}
+
+type updateLinkRequest struct {
+	Title     *string             `json:"title"`
+	URL       *string             `json:"url"`
+	Archived  *bool               `json:"archived"`
+	ExpiresAt nullable[time.Time] `json:"expires_at"`
+	MaxClicks nullable[int]       `json:"max_clicks"`
+	// Password sets the link password, null or "" removes it.
+	Password nullable[string] `json:"password"`
+	// RedirectStatus is one of 301, 302, 307 or 308.
+	RedirectStatus *int  `json:"redirect_status"`
+	ForwardQuery   *bool `json:"forward_query"`
+	StickyVariants *bool `json:"sticky_variants"`
+	// Tags replaces the tags of the link, [] removes them all.
+	Tags *[]string `json:"tags"`
+	// FolderID moves the link to one of the folders, null takes it out of
+	// its folder.
+	FolderID nullable[domain.ID] `json:"folder_id"`
+}
+
+// nullable tells an absent JSON field apart from one explicitly set to null,
+// which PATCH requests use to clear a value.
+type nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (n *nullable[T]) UnmarshalJSON(b []byte) error {
+	n.Set = true
+	if string(b) == "null" {
+		n.Value = nil
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	n.Value = &v
+	return nil
+}
+
+func toAPILink(u domain.URL) apiLink {
+	tags := u.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return apiLink{
+		ID:             u.ID,
+		Slug:           u.Slug,
+		ShortURL:       u.Short,
+		Domain:         u.Domain,
+		Ref:            u.Ref().String(),
+		LongURL:        u.Long,
+		Title:          u.Title,
+		IsActive:       u.IsActive,
+		IsArchived:     u.IsArchived,
+		Clicks:         u.NrVisited,
+		CreatedAt:      u.CreatedAt,
+		ExpiresAt:      u.ExpiresAt,
+		MaxClicks:      u.MaxClicks,
+		Protected:      u.HasPassword,
+		RedirectStatus: u.RedirectStatus,
+		ForwardQuery:   u.ForwardQuery,
+		StickyVariants: u.StickyVariants,
+		Tags:           tags,
+		Folder:         toAPIFolder(u.Folder),
+	}
+}
+
+func toAPILinkStats(s domain.URLStat) apiLinkStats {
+	stats := apiLinkStats{
+		apiLink:        toAPILink(s.URL),
+		IncludeBots:    s.Filter.IncludeBots,
+		UniqueVisitors: s.UniqueVisitors,
+		QRScans:        s.QRScans,
+		Locations:      make([]apiLocationStat, 0, len(s.LocationDistribution)),
+		Referrers:      make([]apiReferrerStat, 0, len(s.Referrers)),
+		Devices:        make(map[string]float32, len(s.Devices)),
+		Browsers:       make([]apiBrowserStat, 0, len(s.Browsers)),
+		From:           s.VisitsOverTime.Period.Since,
+		To:             s.VisitsOverTime.Period.Until,
+		Bucket:         s.VisitsOverTime.Bucket,
+		VisitsOverTime: toAPITimeSeries(s.VisitsOverTime.Current),
+		Variants:       toAPIVariants(s.Variants).Data,
+
+		PreviousVisitsOverTime: toAPITimeSeries(s.VisitsOverTime.Previous),
+	}
+	if s.VisitsOverTime.Location != nil {
+		stats.Timezone = s.VisitsOverTime.Location.String()
+	}
+	for _, l := range s.LocationDistribution {
+		stats.Locations = append(stats.Locations, apiLocationStat{
+			Country:     l.Country,
+			CountryCode: l.CountryCode,
+			Visits:      l.VisitCount,
+			Percentage:  l.Percentage,
+		})
+	}
+	for _, r := range s.Referrers {
+		stats.Referrers = append(stats.Referrers, apiReferrerStat{
+			Source:     r.Source,
+			Clicks:     r.ClickCount,
+			Percentage: r.Percentage,
+		})
+	}
+	for kind, d := range s.Devices {
+		stats.Devices[string(kind)] = d.Percentage
+	}
+	for _, b := range s.Browsers {
+		stats.Browsers = append(stats.Browsers, apiBrowserStat{
+			Name:       b.Browser.Name,
+			Percentage: b.Percentage,
+		})
+	}
+	return stats
+}
+
+func toAPITimeSeries(data []domain.TimeSeriesData) []apiTimeSeriesData {
+	series := make([]apiTimeSeriesData, 0, len(data))
+	for _, d := range data {
+		series = append(series, apiTimeSeriesData{
+			Time:  d.Time,
+			Count: d.Count,
+		})
+	}
+	return series
+}
+
+// decodeJSON reads a JSON body into v and writes an error response if it can't.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if ct := r.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "application/json") {
+		writeAPIError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", "content type must be application/json")
+		return false
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBodySize))
+	if err := dec.Decode(v); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeAPIError(w, http.StatusRequestEntityTooLarge, "", "request body too large")
+			return false
+		}
+		writeAPIError(w, http.StatusBadRequest, "", "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+func (h *APIHandlers) listLinks(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	filter, err := parseLinkFilter(r)
+	if err != nil {
+		writeAPIValidationError(w, map[string]error{"folder": err})
+		return
+	}
+	pagination := middleware.GetPaginationParams(r.Context())
+	page := domain.Page{Offset: pagination.Offset(), Limit: pagination.Limit()}
+	urls, total, err := h.svc.List(r.Context(), ws.ID, filter, page)
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	resp := apiLinkList{
+		Data: make([]apiLink, 0, len(urls)),
+		Pagination: apiPagination{
+			Page:       pagination.Page,
+			PageSize:   pagination.Limit(),
+			TotalItems: total,
+		},
+	}
+	for _, u := range urls {
+		resp.Data = append(resp.Data, toAPILink(u.URL))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *APIHandlers) createLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := middleware.UserFromContext(ctx)
+	ws := middleware.WorkspaceFromContext(ctx)
+
+	var req createLinkRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	req.Slug = strings.TrimSpace(req.Slug)
+	errs := validateShorten(req.URL, req.Slug)
+	if req.RedirectStatus != 0 {
+		if err := services.ValidateRedirectStatus(req.RedirectStatus); err != nil {
+			errs["redirect_status"] = err
+		}
+	}
+	if len(errs) > 0 {
+		writeAPIValidationError(w, errs)
+		return
+	}
+
+	reached, err := quotaReached(ctx, h.svc, h.stripe, *ws)
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+	if reached {
+		writeAPIError(w, http.StatusForbidden, "quota_exceeded", "you have reached your monthly limit of URLs")
+		return
+	}
+
+	url, err := h.svc.Shorten(ctx, req.URL, req.Title, ws.ID, user.ID, domain.ShortenOptions{
+		Slug: req.Slug,
+		Expiration: domain.Expiration{
+			ExpiresAt: req.ExpiresAt,
+			MaxClicks: req.MaxClicks,
+		},
+		Password:       req.Password,
+		RedirectStatus: req.RedirectStatus,
+		UTM:            domain.UTM(req.UTM),
+		ForwardQuery:   req.ForwardQuery,
+		Domain:         req.Domain,
+	})
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/links/"+url.Ref().String())
+	writeJSON(w, http.StatusCreated, toAPILink(url))
+}
+
+func (h *APIHandlers) getLink(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	url, err := h.svc.Get(r.Context(), ws.ID, linkRef(r))
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+	if url, err = h.svc.WithOrganization(r.Context(), ws.ID, url); err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAPILink(url))
+}
+
+func (h *APIHandlers) updateLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := middleware.UserFromContext(ctx)
+	ws := middleware.WorkspaceFromContext(ctx)
+	ref := linkRef(r)
+
+	var req updateLinkRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	url, err := h.svc.Get(ctx, ws.ID, ref)
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	// validate everything before writing anything so a PATCH is all or nothing
+	if req.URL != nil {
+		*req.URL = strings.TrimSpace(*req.URL)
+		if errs := validateURL(*req.URL); len(errs) > 0 {
+			writeAPIValidationError(w, map[string]error{"url": errs["long_url"]})
+			return
+		}
+	}
+	if req.RedirectStatus != nil {
+		if err := services.ValidateRedirectStatus(*req.RedirectStatus); err != nil {
+			writeAPIValidationError(w, map[string]error{"redirect_status": err})
+			return
+		}
+	}
+	updateExp := req.ExpiresAt.Set || req.MaxClicks.Set
+	exp := url.Expiration
+	if req.ExpiresAt.Set {
+		exp.ExpiresAt = req.ExpiresAt.Value
+	}
+	if req.MaxClicks.Set {
+		exp.MaxClicks = req.MaxClicks.Value
+	}
+	if updateExp {
+		if err := services.ValidateExpiration(exp, time.Now()); err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+	}
+	var password string
+	if req.Password.Value != nil {
+		password = *req.Password.Value
+	}
+	if password != "" {
+		if err := services.ValidateLinkPassword(password); err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+	}
+	var tags []string
+	if req.Tags != nil {
+		if tags, err = services.NormalizeTags(*req.Tags); err != nil {
+			writeAPIValidationError(w, map[string]error{"tags": err})
+			return
+		}
+	}
+	var folderID domain.ID
+	if req.FolderID.Value != nil {
+		folderID = *req.FolderID.Value
+		if err := checkFolder(ctx, h.svc, ws.ID, folderID); err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+	}
+
+	if req.Title != nil || req.URL != nil || req.RedirectStatus != nil {
+		title, longURL, redirectStatus := url.Title, url.Long, url.RedirectStatus
+		if req.Title != nil {
+			title = strings.TrimSpace(*req.Title)
+		}
+		if req.URL != nil {
+			longURL = *req.URL
+		}
+		if req.RedirectStatus != nil {
+			redirectStatus = *req.RedirectStatus
+		}
+		url, err = h.svc.Edit(ctx, ws.ID, user.ID, ref, title, longURL, redirectStatus)
+		if err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+	}
+
+	if updateExp {
+		url, err = h.svc.UpdateExpiration(ctx, ws.ID, ref, exp)
+		if err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+	}
+
+	if req.Password.Set {
+		url, err = h.svc.UpdatePassword(ctx, ws.ID, ref, password)
+		if err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+	}
+
+	if req.ForwardQuery != nil && *req.ForwardQuery != url.ForwardQuery {
+		url, err = h.svc.UpdateForwardQuery(ctx, ws.ID, ref, *req.ForwardQuery)
+		if err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+	}
+
+	if req.StickyVariants != nil && *req.StickyVariants != url.StickyVariants {
+		url, err = h.svc.UpdateStickyVariants(ctx, ws.ID, ref, *req.StickyVariants)
+		if err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+	}
+
+	if req.Archived != nil && *req.Archived != url.IsArchived {
+		if *req.Archived {
+			err = h.svc.Archive(ctx, ws.ID, ref)
+		} else {
+			_, err = h.svc.Unarchive(ctx, ws.ID, ref)
+		}
+		if err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+		url.IsArchived = *req.Archived
+	}
+
+	if req.Tags != nil {
+		if _, err := h.svc.SetTags(ctx, ws.ID, ref, tags); err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+	}
+
+	if req.FolderID.Set {
+		if _, err := h.svc.SetFolder(ctx, ws.ID, ref, folderID); err != nil {
+			writeAPIServiceError(w, err)
+			return
+		}
+		url.Folder.ID = folderID
+	}
+
+	if url, err = h.svc.WithOrganization(ctx, ws.ID, url); err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAPILink(url))
+}
+
+func (h *APIHandlers) deleteLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ws := middleware.WorkspaceFromContext(ctx)
+
+	url, err := h.svc.Get(ctx, ws.ID, linkRef(r))
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	if err := h.svc.Delete(ctx, url.ID, ws.ID); err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *APIHandlers) linkStats(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	filter, err := parseStatsFilter(r, time.Now())
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	stats, err := h.svc.StatisticsDetail(r.Context(), ws.ID, linkRef(r), filter)
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAPILinkStats(stats))
+}
+
+func (h *APIHandlers) linkHistory(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	history, err := h.svc.DestinationHistory(r.Context(), ws.ID, linkRef(r))
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	changes := make([]apiDestinationChange, 0, len(history))
+	for _, c := range history {
+		changes = append(changes, apiDestinationChange{
+			URL:       c.LongURL,
+			ChangedBy: c.ChangedBy,
+			ChangedAt: c.ChangedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, apiDestinationHistory{Data: changes})
+}
+
+func toAPIRedirectRules(rules []domain.RedirectRule) apiRedirectRules {
+	data := make([]apiRedirectRule, 0, len(rules))
+	for _, rule := range rules {
+		data = append(data, apiRedirectRule{
+			ID:        rule.ID,
+			URL:       rule.LongURL,
+			Platforms: rule.Platforms,
+			Mobile:    rule.Mobile,
+			Countries: rule.Countries,
+			Languages: rule.Languages,
+			StartsAt:  rule.StartsAt,
+			EndsAt:    rule.EndsAt,
+			Clicks:    rule.Clicks,
+		})
+	}
+	return apiRedirectRules{Data: data}
+}
+
+func (h *APIHandlers) linkRules(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	rules, err := h.svc.RedirectRules(r.Context(), ws.ID, linkRef(r))
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAPIRedirectRules(rules))
+}
+
+func (h *APIHandlers) setLinkRules(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	var req setRedirectRulesRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	rules := make([]domain.RedirectRule, 0, len(req.Rules))
+	for i, rule := range req.Rules {
+		rule.URL = strings.TrimSpace(rule.URL)
+		if errs := validateURL(rule.URL); len(errs) > 0 {
+			writeAPIValidationError(w, map[string]error{fmt.Sprintf("rules[%d].url", i): errs["long_url"]})
+			return
+		}
+		rules = append(rules, domain.RedirectRule{
+			ID:        rule.ID,
+			LongURL:   rule.URL,
+			Platforms: rule.Platforms,
+			Mobile:    rule.Mobile,
+			Countries: rule.Countries,
+			Languages: rule.Languages,
+			StartsAt:  rule.StartsAt,
+			EndsAt:    rule.EndsAt,
+		})
+	}
+
+	rules, err := h.svc.SetRedirectRules(r.Context(), ws.ID, user.ID, linkRef(r), rules)
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAPIRedirectRules(rules))
+}
+
+func toAPIVariants(variants []domain.Variant) apiVariants {
+	data := make([]apiVariant, 0, len(variants))
+	for _, v := range variants {
+		data = append(data, apiVariant{
+			ID:             v.ID,
+			URL:            v.LongURL,
+			Weight:         v.Weight,
+			Share:          v.Share(variants),
+			Clicks:         v.Clicks,
+			UniqueVisitors: v.UniqueVisitors,
+		})
+	}
+	return apiVariants{Data: data}
+}
+
+func (h *APIHandlers) linkVariants(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	variants, err := h.svc.Variants(r.Context(), ws.ID, linkRef(r))
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAPIVariants(variants))
+}
+
+func (h *APIHandlers) setLinkVariants(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	var req setVariantsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	variants := make([]domain.Variant, 0, len(req.Variants))
+	for i, v := range req.Variants {
+		v.URL = strings.TrimSpace(v.URL)
+		if errs := validateURL(v.URL); len(errs) > 0 {
+			writeAPIValidationError(w, map[string]error{fmt.Sprintf("variants[%d].url", i): errs["long_url"]})
+			return
+		}
+		variants = append(variants, domain.Variant{
+			ID:      v.ID,
+			LongURL: v.URL,
+			Weight:  v.Weight,
+		})
+	}
+
+	variants, err := h.svc.SetVariants(r.Context(), ws.ID, user.ID, linkRef(r), variants)
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toAPIVariants(variants))
+}
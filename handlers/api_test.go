@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/services"
+)
+
+func TestAPIAuthenticated(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	tests := []struct {
+		name     string
+		user     *domain.User
+		wantCode int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"suspended", &domain.User{ID: 1, IsSuspended: true}, http.StatusForbidden},
+		{"authenticated", &domain.User{ID: 1}, http.StatusTeapot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/links", nil)
+			if tt.user != nil {
+				r = r.WithContext(middleware.WithUser(r.Context(), *tt.user))
+			}
+			w := httptest.NewRecorder()
+
+			apiAuthenticated(next).ServeHTTP(w, r)
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+// stubWorkspaces makes user 1 a viewer of workspace 2.
+type stubWorkspaces struct{}
+
+func (stubWorkspaces) Get(_ context.Context, userID, workspaceID domain.ID) (domain.Workspace, error) {
+	if userID != 1 || workspaceID != 2 {
+		return domain.Workspace{}, services.ErrWorkspaceNotFound
+	}
+	return domain.Workspace{ID: 2, Role: domain.RoleViewer}, nil
+}
+
+func TestAPIWorkspace(t *testing.T) {
+	h := &APIHandlers{workspaces: stubWorkspaces{}}
+	personal := domain.Workspace{ID: 1, Personal: true, Role: domain.RoleOwner}
+
+	tests := []struct {
+		name     string
+		header   string
+		method   string
+		wantCode int
+	}{
+		{"personal workspace by default", "", http.MethodPost, http.StatusTeapot},
+		{"member", "2", http.MethodGet, http.StatusTeapot},
+		{"viewers can't write", "2", http.MethodPost, http.StatusForbidden},
+		{"not a member", "3", http.MethodGet, http.StatusNotFound},
+		{"invalid id", "marketing", http.MethodGet, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			})
+			if tt.method != http.MethodGet {
+				next = apiRequireRole(domain.RoleEditor)(next).ServeHTTP
+			}
+
+			r := httptest.NewRequest(tt.method, "/api/v1/links", nil)
+			r.Header.Set(workspaceHeader, tt.header)
+			ctx := middleware.WithUser(r.Context(), domain.User{ID: 1})
+			r = r.WithContext(middleware.WithWorkspace(ctx, personal))
+			w := httptest.NewRecorder()
+
+			h.apiWorkspace(next).ServeHTTP(w, r)
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestWriteAPIServiceError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{"not found", fmt.Errorf("wrapped: %w", services.ErrURLNotFound), http.StatusNotFound, "not_found", "wrapped: url not found"},
+		{"slug taken", services.ErrSlugTaken, http.StatusConflict, "conflict", services.ErrSlugTaken.Error()},
+		{"suspicious", services.ErrSuspiciousURL, http.StatusForbidden, "forbidden", services.ErrSuspiciousURL.Error()},
+		{"unexpected", fmt.Errorf("connection reset"), http.StatusInternalServerError, "internal_error", "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			writeAPIServiceError(w, tt.err)
+
+			var body apiError
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantStatus, body.Error.Status)
+			assert.Equal(t, tt.wantCode, body.Error.Code)
+			assert.Equal(t, tt.wantMessage, body.Error.Message)
+		})
+	}
+}
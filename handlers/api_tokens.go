@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/services"
+	"github.com/zaibon/shortcut/templates/components"
+)
+
+type APITokenService interface {
+	Create(ctx context.Context, userID domain.ID, name string) (domain.CreatedAPIToken, error)
+	List(ctx context.Context, userID domain.ID) ([]domain.APIToken, error)
+	Revoke(ctx context.Context, userID, tokenID domain.ID) error
+}
+
+// APITokenHandlers lets users manage their personal API tokens from the
+// account page.
+type APITokenHandlers struct {
+	svc APITokenService
+}
+
+func NewAPITokenHandlers(svc APITokenService) *APITokenHandlers {
+	return &APITokenHandlers{
+		svc: svc,
+	}
+}
+
+func (h *APITokenHandlers) Routes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Authenticated)
+		r.Get("/account/tokens", h.listTokens)
+		r.Post("/account/tokens", h.createToken)
+		r.Delete("/account/tokens/{id}", h.revokeToken)
+	})
+}
+
+func (h *APITokenHandlers) listTokens(w http.ResponseWriter, r *http.Request) {
+	h.renderTokens(w, r, nil)
+}
+
+func (h *APITokenHandlers) createToken(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.svc.Create(r.Context(), user.ID, r.PostForm.Get("name"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPITokenName) {
+			addFlash(w, r, err.Error(), flashTypeError)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		slog.Error("failed to create api token", "error", err)
+		http.Error(w, "failed to create api token", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderTokens(w, r, &created)
+}
+
+func (h *APITokenHandlers) revokeToken(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid token id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.Revoke(r.Context(), user.ID, domain.ID(id)); err != nil {
+		if errors.Is(err, services.ErrAPITokenNotFound) {
+			http.Error(w, "api token not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("failed to revoke api token", "error", err)
+		http.Error(w, "failed to revoke api token", http.StatusInternalServerError)
+		return
+	}
+
+	addFlash(w, r, "API token revoked", flashTypeInfo)
+	h.renderTokens(w, r, nil)
+}
+
+func (h *APITokenHandlers) renderTokens(w http.ResponseWriter, r *http.Request, created *domain.CreatedAPIToken) {
+	user := middleware.UserFromContext(r.Context())
+
+	tokens, err := h.svc.List(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list api tokens", "error", err)
+		http.Error(w, "failed to list api tokens", http.StatusInternalServerError)
+		return
+	}
+
+	components.APITokensCard(tokens, created).Render(r.Context(), w)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/templates"
+)
+
+// archivedFilter reports whether the request is for the "Archived" tab of the
+// dashboard.
+func archivedFilter(r *http.Request) bool {
+	return r.FormValue("archived") == "true"
+}
+
+func (h *Handler) archiveURL(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	if err := h.svc.Archive(r.Context(), ws.ID, linkRef(r)); err != nil {
+		log.Error("failed to archive url", slog.Any("error", err))
+		http.Error(w, "failed to archive url", ErrorStatus(err))
+		return
+	}
+
+	addFlash(w, r, "Link archived", flashTypeInfo)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) unarchiveURL(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	if _, err := h.svc.Unarchive(r.Context(), ws.ID, linkRef(r)); err != nil {
+		log.Error("failed to unarchive url", slog.Any("error", err))
+		http.Error(w, "failed to unarchive url", ErrorStatus(err))
+		return
+	}
+
+	addFlash(w, r, "Link restored", flashTypeInfo)
+	w.WriteHeader(http.StatusOK)
+}
+
+// unarchiveURLs restores the links selected in the "Archived" tab and renders
+// what is left in it.
+func (h *Handler) unarchiveURLs(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		log.Error("failed to parse form", slog.Any("error", err))
+		http.Error(w, "failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	refs := make([]domain.LinkRef, len(r.Form["ref"]))
+	for i, ref := range r.Form["ref"] {
+		refs[i] = domain.ParseLinkRef(ref)
+	}
+	if len(refs) == 0 {
+		addFlash(w, r, "Select the links to restore", flashTypeError)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.svc.Unarchive(r.Context(), ws.ID, refs...)
+	if err != nil {
+		log.Error("failed to unarchive urls", slog.Any("error", err))
+		http.Error(w, "failed to unarchive urls", http.StatusInternalServerError)
+		return
+	}
+
+	filter := domain.LinkFilter{Archived: true, Sort: domain.LinkSortNewest}
+	urls, paginationLinks, err := h.listLinks(r, ws.ID, filter)
+	if err != nil {
+		log.Error("failed to get statistics", slog.Any("error", err))
+		http.Error(w, "failed to get statistics", http.StatusInternalServerError)
+		return
+	}
+
+	if n == 1 {
+		addFlash(w, r, "1 link restored", flashTypeInfo)
+	} else {
+		addFlash(w, r, fmt.Sprintf("%d links restored", n), flashTypeInfo)
+	}
+	if err := templates.URLList(urls, paginationLinks, filter).
+		Render(r.Context(), w); err != nil {
+		log.Error("failed to render page", slog.Any("error", err))
+	}
+}
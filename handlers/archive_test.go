@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+func TestKeepListFilter(t *testing.T) {
+	links := func() domain.PaginationLinks {
+		return domain.PaginationLinks{
+			Previous: &domain.Link{Href: "?page=1&page_size=10"},
+			Pages:    []domain.Link{{Href: "?page=1&page_size=10"}, {Href: "?page=2&page_size=10"}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter domain.LinkFilter
+		want   []string
+	}{
+		{"active", domain.LinkFilter{}, []string{"?page=1&page_size=10", "?page=2&page_size=10"}},
+		{"archived", domain.LinkFilter{Archived: true}, []string{"?page=1&page_size=10&archived=true", "?page=2&page_size=10&archived=true"}},
+		{"tag and folder", domain.LinkFilter{Tag: "black friday", FolderID: 3}, []string{"?page=1&page_size=10&folder=3&tag=black+friday", "?page=2&page_size=10&folder=3&tag=black+friday"}},
+		{"newest", domain.LinkFilter{Sort: domain.LinkSortNewest}, []string{"?page=1&page_size=10", "?page=2&page_size=10"}},
+		{"search and sort", domain.LinkFilter{Search: "my blog", Sort: domain.LinkSortOldest}, []string{"?page=1&page_size=10&search=my+blog&sort=oldest", "?page=2&page_size=10&search=my+blog&sort=oldest"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := keepListFilter(links(), tt.filter)
+			assert.Equal(t, tt.want[0], got.Previous.Href)
+			assert.Nil(t, got.Next)
+			for i, page := range got.Pages {
+				assert.Equal(t, tt.want[i], page.Href)
+			}
+		})
+	}
+}
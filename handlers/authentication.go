@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/donseba/go-htmx"
@@ -24,7 +25,7 @@ type AuthService interface {
 }
 
 type URLUsageService interface {
-	CountMonthlyURL(ctx context.Context, authorID domain.ID) (int64, error)
+	CountMonthlyURL(ctx context.Context, workspaceID domain.ID) (int64, error)
 }
 
 type UsersHandler struct {
@@ -75,7 +76,8 @@ func (h *UsersHandler) myAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	count, err := h.urlService.CountMonthlyURL(r.Context(), user.ID)
+	ws := middleware.WorkspaceFromContext(r.Context())
+	count, err := h.urlService.CountMonthlyURL(r.Context(), ws.ID)
 	if err != nil {
 		slog.Error("failed to get url count", "error", err)
 	}
@@ -83,7 +85,7 @@ func (h *UsersHandler) myAccount(w http.ResponseWriter, r *http.Request) {
 	limit := domain.FreePlanLimit
 	planName := "Free"
 
-	sub, err := h.stripe.GetSubscription(r.Context(), user)
+	sub, err := h.stripe.GetSubscription(r.Context(), *ws)
 	if err == nil && sub != nil {
 		planName = sub.Product().Name
 		if sub.Features.LinksNumber > 0 {
@@ -189,5 +191,10 @@ func (h *UsersHandler) oauthCallback(w http.ResponseWriter, r *http.Request) {
 	}
 	h.sessionManager.Put(r.Context(), "user_id", user.GUID.String())
 
-	http.Redirect(w, r, "/", http.StatusFound)
+	// like an invitation opened before logging in
+	next := h.sessionManager.PopString(r.Context(), sessionRedirectKey)
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+		next = "/"
+	}
+	http.Redirect(w, r, next, http.StatusFound)
 }
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/templates/components"
+)
+
+type DomainService interface {
+	List(ctx context.Context, ws domain.Workspace) ([]domain.CustomDomain, error)
+	Add(ctx context.Context, ws domain.Workspace, hostname string) (domain.CustomDomain, error)
+	Verify(ctx context.Context, ws domain.Workspace, domainID domain.ID) (domain.CustomDomain, error)
+	Remove(ctx context.Context, ws domain.Workspace, domainID domain.ID) error
+}
+
+// DomainHandlers lets the owners of a workspace serve its links on their own
+// domains.
+type DomainHandlers struct {
+	svc DomainService
+	// defaultHost is the domain links are served on unless told otherwise
+	defaultHost string
+}
+
+func NewDomainHandlers(svc DomainService, defaultHost string) *DomainHandlers {
+	return &DomainHandlers{
+		svc:         svc,
+		defaultHost: defaultHost,
+	}
+}
+
+func (h *DomainHandlers) Routes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Authenticated)
+		r.Get("/workspace/domains/select", h.domainSelect)
+
+		r.Group(func(r chi.Router) {
+			r.Use(requireRole(domain.RoleOwner))
+			r.Get("/workspace/domains", h.domains)
+			r.Post("/workspace/domains", h.addDomain)
+			r.Post("/workspace/domains/{id}/verify", h.verifyDomain)
+			r.Delete("/workspace/domains/{id}", h.removeDomain)
+		})
+	})
+}
+
+// domainSelect renders the domain picker of the shorten form, nothing when
+// the workspace has no verified domain.
+func (h *DomainHandlers) domainSelect(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	domains, err := h.svc.List(r.Context(), *ws)
+	if err != nil {
+		domainsError(w, r, err)
+		return
+	}
+	for _, d := range domains {
+		if d.Verified() {
+			if err := components.DomainSelect(h.defaultHost, domains).Render(r.Context(), w); err != nil {
+				log.Error("failed to render domain select", slog.Any("error", err))
+			}
+			return
+		}
+	}
+}
+
+func (h *DomainHandlers) domains(w http.ResponseWriter, r *http.Request) {
+	h.renderDomains(w, r)
+}
+
+func (h *DomainHandlers) addDomain(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	if _, err := h.svc.Add(r.Context(), *ws, r.FormValue("hostname")); err != nil {
+		domainsError(w, r, err)
+		return
+	}
+
+	h.renderDomains(w, r)
+}
+
+func (h *DomainHandlers) verifyDomain(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid domain id", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.svc.Verify(r.Context(), *ws, domain.ID(id))
+	if err != nil {
+		domainsError(w, r, err)
+		return
+	}
+
+	addFlash(w, r, d.Hostname+" is verified, new links can now use it", flashTypeInfo)
+	h.renderDomains(w, r)
+}
+
+func (h *DomainHandlers) removeDomain(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid domain id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.Remove(r.Context(), *ws, domain.ID(id)); err != nil {
+		domainsError(w, r, err)
+		return
+	}
+
+	addFlash(w, r, "Domain removed", flashTypeInfo)
+	h.renderDomains(w, r)
+}
+
+func (h *DomainHandlers) renderDomains(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	domains, err := h.svc.List(r.Context(), *ws)
+	if err != nil {
+		domainsError(w, r, err)
+		return
+	}
+
+	if err := components.CustomDomainsCard(domains).Render(r.Context(), w); err != nil {
+		log.Error("failed to render custom domains card", slog.Any("error", err))
+	}
+}
+
+func domainsError(w http.ResponseWriter, r *http.Request, err error) {
+	status := ErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Error("failed to manage domains", slog.Any("error", err))
+		http.Error(w, "failed to manage domains", status)
+		return
+	}
+	addFlash(w, r, err.Error(), flashTypeError)
+	w.WriteHeader(status)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/templates/components"
+)
+
+// datetimeLocalLayout is the value format of an <input type="datetime-local">.
+const datetimeLocalLayout = "2006-01-02T15:04"
+
+// parseFormTime reads the optional datetime-local field name. It has no
+// timezone, it is interpreted in the IANA zone sent in the timezone field, UTC
+// by default. An empty field returns nil.
+func parseFormTime(r *http.Request, name string) (*time.Time, error) {
+	v := strings.TrimSpace(r.FormValue(name))
+	if v == "" {
+		return nil, nil
+	}
+
+	loc, err := time.LoadLocation(r.FormValue("timezone"))
+	if err != nil {
+		loc = time.UTC
+	}
+	t, err := time.ParseInLocation(datetimeLocalLayout, v, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseExpirationForm reads the optional expires_at and max_clicks fields.
+// expires_at has no timezone, it is interpreted in the IANA zone sent in the
+// timezone field, UTC by default.
+func parseExpirationForm(r *http.Request) (domain.Expiration, map[string]error) {
+	var (
+		exp  domain.Expiration
+		errs = make(map[string]error)
+	)
+
+	if t, err := parseFormTime(r, "expires_at"); err != nil {
+		errs["expires_at"] = fmt.Errorf("invalid expiration date")
+	} else {
+		exp.ExpiresAt = t
+	}
+
+	if v := strings.TrimSpace(r.FormValue("max_clicks")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs["max_clicks"] = fmt.Errorf("click limit must be a number")
+		} else {
+			exp.MaxClicks = &n
+		}
+	}
+
+	return exp, errs
+}
+
+func (h *Handler) updateExpiration(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	ref := linkRef(r)
+
+	exp, errs := parseExpirationForm(r)
+	if len(errs) > 0 {
+		addFlash(w, r, firstError(errs, "expires_at", "max_clicks").Error(), flashTypeError)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	h.setExpiration(w, r, ws.ID, ref, exp)
+}
+
+func (h *Handler) clearExpiration(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	h.setExpiration(w, r, ws.ID, linkRef(r), domain.Expiration{})
+}
+
+func (h *Handler) setExpiration(w http.ResponseWriter, r *http.Request, workspaceID domain.ID, ref domain.LinkRef, exp domain.Expiration) {
+	url, err := h.svc.UpdateExpiration(r.Context(), workspaceID, ref, exp)
+	if err != nil {
+		status := ErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			log.Error("failed to update url expiration", slog.Any("error", err))
+			http.Error(w, "failed to update expiration", status)
+			return
+		}
+		addFlash(w, r, err.Error(), flashTypeError)
+		w.WriteHeader(status)
+		return
+	}
+
+	addFlash(w, r, "Expiration updated", flashTypeInfo)
+	if err := components.ExpirationCard(url).Render(r.Context(), w); err != nil {
+		log.Error("failed to render expiration card", slog.Any("error", err))
+	}
+}
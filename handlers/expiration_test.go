@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExpirationForm(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("timezone database not available")
+	}
+
+	tests := []struct {
+		name          string
+		form          url.Values
+		wantExpiresAt *time.Time
+		wantMaxClicks *int
+		wantErrFields []string
+	}{
+		{
+			name: "empty",
+			form: url.Values{},
+		},
+		{
+			name:          "utc by default",
+			form:          url.Values{"expires_at": {"2026-03-01T10:30"}},
+			wantExpiresAt: ptr(time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)),
+		},
+		{
+			name:          "viewer timezone",
+			form:          url.Values{"expires_at": {"2026-03-01T10:30"}, "timezone": {"Europe/Paris"}},
+			wantExpiresAt: ptr(time.Date(2026, 3, 1, 10, 30, 0, 0, paris)),
+		},
+		{
+			name:          "unknown timezone falls back to utc",
+			form:          url.Values{"expires_at": {"2026-03-01T10:30"}, "timezone": {"Mars/Olympus"}},
+			wantExpiresAt: ptr(time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)),
+		},
+		{
+			name:          "max clicks",
+			form:          url.Values{"max_clicks": {"100"}},
+			wantMaxClicks: ptr(100),
+		},
+		{
+			name:          "invalid values",
+			form:          url.Values{"expires_at": {"tomorrow"}, "max_clicks": {"lots"}},
+			wantErrFields: []string{"expires_at", "max_clicks"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPut, "/urls/abc/expiration", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			exp, errs := parseExpirationForm(r)
+			for _, field := range tt.wantErrFields {
+				assert.Contains(t, errs, field)
+			}
+			if len(tt.wantErrFields) > 0 {
+				return
+			}
+
+			assert.Empty(t, errs)
+			if tt.wantExpiresAt == nil {
+				assert.Nil(t, exp.ExpiresAt)
+			} else if assert.NotNil(t, exp.ExpiresAt) {
+				assert.True(t, tt.wantExpiresAt.Equal(*exp.ExpiresAt), "got %s, want %s", exp.ExpiresAt, tt.wantExpiresAt)
+			}
+			assert.Equal(t, tt.wantMaxClicks, exp.MaxClicks)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+)
+
+// exportFlushEvery is the number of records written between two flushes of an
+// export to the client.
+const exportFlushEvery = 500
+
+// exportedVisit is a row of a visits export.
+type exportedVisit struct {
+	Link        string    `json:"link"`
+	VisitedAt   time.Time `json:"visited_at"`
+	Country     string    `json:"country"`
+	CountryCode string    `json:"country_code"`
+	City        string    `json:"city"`
+	Browser     string    `json:"browser"`
+	Platform    string    `json:"platform"`
+	Device      string    `json:"device"`
+	Referrer    string    `json:"referrer"`
+	Bot         bool      `json:"bot"`
+}
+
+var exportedVisitColumns = []string{"link", "visited_at", "country", "country_code", "city", "browser", "platform", "device", "referrer", "bot"}
+
+func (v exportedVisit) fields() []string {
+	return []string{
+		v.Link,
+		v.VisitedAt.Format(time.RFC3339),
+		v.Country,
+		v.CountryCode,
+		v.City,
+		v.Browser,
+		v.Platform,
+		v.Device,
+		v.Referrer,
+		strconv.FormatBool(v.Bot),
+	}
+}
+
+// exportedStat is a row of an aggregated statistics export.
+type exportedStat struct {
+	Link       string  `json:"link"`
+	Metric     string  `json:"metric"`
+	Label      string  `json:"label"`
+	Visits     int64   `json:"visits"`
+	Percentage float32 `json:"percentage"`
+}
+
+var exportedStatColumns = []string{"link", "metric", "label", "visits", "percentage"}
+
+func (s exportedStat) fields() []string {
+	return []string{
+		s.Link,
+		s.Metric,
+		s.Label,
+		strconv.FormatInt(s.Visits, 10),
+		strconv.FormatFloat(float64(s.Percentage), 'f', 2, 32),
+	}
+}
+
+// exportWriter streams the records of an export as CSV or NDJSON. Nothing is
+// sent before the first record, so errors found before it can still be
+// reported with a proper status.
+type exportWriter struct {
+	w        http.ResponseWriter
+	format   string
+	filename string
+	columns  []string
+	csv      *csv.Writer
+	json     *json.Encoder
+	started  bool
+	written  int
+}
+
+func newExportWriter(w http.ResponseWriter, format, filename string, columns []string) *exportWriter {
+	return &exportWriter{
+		w:        w,
+		format:   format,
+		filename: filename,
+		columns:  columns,
+		csv:      csv.NewWriter(w),
+		json:     json.NewEncoder(w),
+	}
+}
+
+func (e *exportWriter) start() error {
+	e.started = true
+	if e.format == "ndjson" {
+		e.w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		e.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	}
+	e.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.filename+"."+e.format))
+	e.w.WriteHeader(http.StatusOK)
+
+	if e.format == "csv" {
+		return e.csv.Write(e.columns)
+	}
+	return nil
+}
+
+func (e *exportWriter) write(fields []string, record any) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	if e.format == "ndjson" {
+		if err := e.json.Encode(record); err != nil {
+			return err
+		}
+	} else {
+		for i, f := range fields {
+			fields[i] = csvSafe(f)
+		}
+		if err := e.csv.Write(fields); err != nil {
+			return err
+		}
+	}
+
+	e.written++
+	if e.written%exportFlushEvery == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *exportWriter) flush() error {
+	e.csv.Flush()
+	if err := e.csv.Error(); err != nil {
+		return err
+	}
+	// not every writer can flush, the response is then sent when complete
+	if err := http.NewResponseController(e.w).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// close sends what is left of the export, and its header when empty.
+func (e *exportWriter) close() error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	return e.flush()
+}
+
+// csvSafe keeps spreadsheets from evaluating fields coming from visitors, like
+// referrers, as formulas.
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// exportAnalytics streams the visits, or with data=stats the aggregated
+// statistics, of the link ref, or of all the links of the workspace when ref
+// is empty, as CSV or, with format=ndjson, as NDJSON. The period and timezone
+// are read like the statistics of a link, the last 30 days by default. fail
+// reports the errors found before anything is sent.
+func exportAnalytics(w http.ResponseWriter, r *http.Request, svc URLService, ref domain.LinkRef, fail func(w http.ResponseWriter, status int, msg string)) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		fail(w, http.StatusBadRequest, "format must be csv or ndjson")
+		return
+	}
+	data := query.Get("data")
+	if data == "" {
+		data = "visits"
+	}
+	if data != "visits" && data != "stats" {
+		fail(w, http.StatusBadRequest, "data must be visits or stats")
+		return
+	}
+
+	now := time.Now()
+	filter, err := parseStatsFilter(r, now)
+	if err != nil {
+		fail(w, ErrorStatus(err), err.Error())
+		return
+	}
+	if filter.Period == (domain.Period{}) {
+		filter.Period = domain.Period{Since: now.Add(-statsRanges["month"]), Until: now}
+	}
+
+	name := ref.String()
+	if name == "" {
+		name = "links"
+	}
+	filename := fmt.Sprintf("shortcut-%s-%s-%s-%s", name, data,
+		filter.Period.Since.In(filter.Location).Format(time.DateOnly),
+		// the period ends at the start of the day after its last one
+		filter.Period.Until.Add(-time.Nanosecond).In(filter.Location).Format(time.DateOnly))
+
+	var export *exportWriter
+	if data == "stats" {
+		export = newExportWriter(w, format, filename, exportedStatColumns)
+		err = svc.ExportStats(r.Context(), ws.ID, ref, filter, func(s domain.ExportedStat) error {
+			row := exportedStat{
+				Link:       s.Slug,
+				Metric:     string(s.Metric),
+				Label:      s.Label,
+				Visits:     s.Visits,
+				Percentage: s.Percentage,
+			}
+			return export.write(row.fields(), row)
+		})
+	} else {
+		export = newExportWriter(w, format, filename, exportedVisitColumns)
+		err = svc.ExportVisits(r.Context(), ws.ID, ref, filter, func(v domain.ExportedVisit) error {
+			row := exportedVisit{
+				Link:        v.Slug,
+				VisitedAt:   v.VisitedAt,
+				Country:     v.Country,
+				CountryCode: v.CountryCode,
+				City:        v.City,
+				Browser:     v.Browser,
+				Platform:    v.Platform,
+				Device:      string(v.Device),
+				Referrer:    v.Referrer,
+				Bot:         v.IsBot,
+			}
+			return export.write(row.fields(), row)
+		})
+	}
+
+	if err != nil {
+		if !export.started {
+			status := ErrorStatus(err)
+			if status == http.StatusInternalServerError {
+				log.Error("failed to export analytics", slog.Any("error", err))
+				fail(w, status, "failed to export analytics")
+				return
+			}
+			fail(w, status, err.Error())
+			return
+		}
+		// the status is already sent, the export ends up truncated
+		log.Error("failed to export analytics", slog.Any("error", err))
+		return
+	}
+	if err := export.close(); err != nil {
+		log.Error("failed to export analytics", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) exportLink(w http.ResponseWriter, r *http.Request) {
+	exportAnalytics(w, r, h.svc, linkRef(r), plainExportError)
+}
+
+func (h *Handler) exportLinks(w http.ResponseWriter, r *http.Request) {
+	exportAnalytics(w, r, h.svc, domain.LinkRef{}, plainExportError)
+}
+
+func (h *APIHandlers) exportLink(w http.ResponseWriter, r *http.Request) {
+	exportAnalytics(w, r, h.svc, linkRef(r), apiExportError)
+}
+
+func (h *APIHandlers) exportLinks(w http.ResponseWriter, r *http.Request) {
+	exportAnalytics(w, r, h.svc, domain.LinkRef{}, apiExportError)
+}
+
+func plainExportError(w http.ResponseWriter, status int, msg string) {
+	http.Error(w, msg, status)
+}
+
+func apiExportError(w http.ResponseWriter, status int, msg string) {
+	writeAPIError(w, status, "", msg)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/services"
+)
+
+// exportURLService exports the visits it holds for any link but "unknown".
+type exportURLService struct {
+	URLService
+	visits []domain.ExportedVisit
+}
+
+func (s exportURLService) ExportVisits(_ context.Context, _ domain.ID, ref domain.LinkRef, _ domain.StatsFilter, fn func(domain.ExportedVisit) error) error {
+	if ref.Slug == "unknown" {
+		return services.ErrURLNotFound
+	}
+	for _, v := range s.visits {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportAnalytics(t *testing.T) {
+	svc := exportURLService{visits: []domain.ExportedVisit{
+		{
+			Slug:        "promo",
+			VisitedAt:   time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC),
+			Country:     "France",
+			CountryCode: "FR",
+			City:        "Paris",
+			Browser:     "Firefox",
+			Platform:    "Linux",
+			Device:      domain.DeviceKindDesktop,
+			Referrer:    "=HYPERLINK(\"https://evil.test\")",
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		target   string
+		wantCode int
+		wantType string
+		wantBody string
+	}{
+		{
+			name:     "csv",
+			target:   "/urls/promo/export?from=2026-09-01&to=2026-09-30",
+			wantCode: http.StatusOK,
+			wantType: "text/csv; charset=utf-8",
+			wantBody: "link,visited_at,country,country_code,city,browser,platform,device,referrer,bot\n" +
+				"promo,2026-09-01T10:00:00Z,France,FR,Paris,Firefox,Linux,desktop,\"'=HYPERLINK(\"\"https://evil.test\"\")\",false\n",
+		},
+		{
+			name:     "ndjson",
+			target:   "/urls/promo/export?format=ndjson&from=2026-09-01&to=2026-09-30",
+			wantCode: http.StatusOK,
+			wantType: "application/x-ndjson",
+			wantBody: `{"link":"promo","visited_at":"2026-09-01T10:00:00Z","country":"France","country_code":"FR","city":"Paris","browser":"Firefox","platform":"Linux","device":"desktop","referrer":"=HYPERLINK(\"https://evil.test\")","bot":false}` + "\n",
+		},
+		{name: "unknown link", target: "/urls/unknown/export", wantCode: http.StatusNotFound},
+		{name: "unknown format", target: "/urls/promo/export?format=xlsx", wantCode: http.StatusBadRequest},
+		{name: "unknown data", target: "/urls/promo/export?data=everything", wantCode: http.StatusBadRequest},
+		{name: "invalid period", target: "/urls/promo/export?from=2026-09-30&to=2026-09-01", wantCode: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			r = r.WithContext(middleware.WithWorkspace(r.Context(), domain.Workspace{ID: 1, Role: domain.RoleViewer}))
+			w := httptest.NewRecorder()
+
+			ref := domain.ParseLinkRef(strings.Split(tt.target, "/")[2])
+			exportAnalytics(w, r, svc, ref, plainExportError)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.wantType, w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Header().Get("Content-Disposition"), "shortcut-promo-visits-2026-09-01-2026-09-30")
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
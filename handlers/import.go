@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/services"
+	"github.com/zaibon/shortcut/templates/components"
+)
+
+// maxImportFileSize caps the size of the CSV files uploaded for a bulk
+// import, about a thousand rows of long URLs.
+const maxImportFileSize = 2 << 20
+
+var (
+	errImportNoFile    = errors.New("pick a CSV file to import")
+	errImportTooLarge  = fmt.Errorf("the file is larger than %d MB", maxImportFileSize>>20)
+	errImportEmpty     = errors.New("the file has no URL to shorten")
+	errImportNoURL     = errors.New(`the header of the file has no "url" column`)
+	errImportMalformed = errors.New("the file is not a valid CSV file")
+)
+
+var importReportColumns = []string{"line", "url", "title", "status", "short_url", "error"}
+
+// parseImportCSV reads the rows of a bulk import. The first line is a header
+// when one of its fields is "url" or "title", the columns are then picked by
+// name: url and the optional title. Otherwise the URL is the first field of
+// each line and the title the second one.
+func parseImportCSV(r io.Reader) ([]domain.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// blank lines are skipped by the reader, the line of each record is kept
+	// so the report points at the right line of the file
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return nil, err
+			}
+			return nil, errImportMalformed
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	if len(records) == 0 {
+		return nil, errImportEmpty
+	}
+	// spreadsheets like to start their exports with a byte order mark
+	records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+
+	urlCol, titleCol, first := 0, 1, 0
+	if header := importHeader(records[0]); header != nil {
+		col, ok := header["url"]
+		if !ok {
+			return nil, errImportNoURL
+		}
+		urlCol, titleCol, first = col, -1, 1
+		if col, ok := header["title"]; ok {
+			titleCol = col
+		}
+	}
+
+	rows := make([]domain.ImportRow, 0, len(records)-first)
+	for i, record := range records[first:] {
+		row := domain.ImportRow{Line: lines[first+i]}
+		if urlCol < len(record) {
+			row.URL = strings.TrimSpace(record[urlCol])
+		}
+		if titleCol >= 0 && titleCol < len(record) {
+			row.Title = strings.TrimSpace(record[titleCol])
+		}
+		if row.URL == "" && row.Title == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errImportEmpty
+	}
+	if len(rows) > services.ImportMaxRows {
+		return nil, services.ErrTooManyImportRows
+	}
+	return rows, nil
+}
+
+// importHeader returns the columns of a header line by name, or nil when the
+// line is not a header.
+func importHeader(record []string) map[string]int {
+	columns := make(map[string]int, len(record))
+	for i, field := range record {
+		columns[strings.ToLower(strings.TrimSpace(field))] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		if _, ok := columns["title"]; !ok {
+			return nil
+		}
+	}
+	return columns
+}
+
+// importLinks validates the rows of a bulk import and creates the valid ones,
+// up to quota of them.
+func importLinks(ctx context.Context, svc URLService, workspaceID, userID domain.ID, rows []domain.ImportRow, quota int64) ([]domain.ImportResult, error) {
+	results := make([]domain.ImportResult, len(rows))
+	valid := make([]domain.ImportRow, 0, len(rows))
+	positions := make([]int, 0, len(rows))
+	for i, row := range rows {
+		if errs := validateURL(row.URL); len(errs) > 0 {
+			results[i] = domain.ImportResult{Row: row, Status: domain.ImportInvalid, Error: errs["long_url"].Error()}
+			continue
+		}
+		valid = append(valid, row)
+		positions = append(positions, i)
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	created, err := svc.ShortenBatch(ctx, workspaceID, userID, valid, quota)
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range created {
+		results[positions[i]] = result
+	}
+	return results, nil
+}
+
+// writeImportReport writes the results of a bulk import as CSV.
+func writeImportReport(w io.Writer, results []domain.ImportResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(importReportColumns); err != nil {
+		return err
+	}
+	for _, r := range results {
+		fields := []string{
+			strconv.Itoa(r.Row.Line),
+			r.Row.URL,
+			r.Row.Title,
+			string(r.Status),
+			r.Short,
+			r.Error,
+		}
+		for i, f := range fields {
+			fields[i] = csvSafe(f)
+		}
+		if err := cw.Write(fields); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (h *Handler) importURLs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := middleware.UserFromContext(ctx)
+	ws := middleware.WorkspaceFromContext(ctx)
+
+	if user.IsSuspended {
+		addFlash(w, r, "Your account is suspended. You cannot create new links.", flashTypeError)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize+64<<10)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			importError(w, r, errImportTooLarge)
+			return
+		}
+		importError(w, r, errImportNoFile)
+		return
+	}
+	defer file.Close()
+
+	rows, err := parseImportCSV(file)
+	if err != nil {
+		importError(w, r, err)
+		return
+	}
+
+	quota, err := quotaLeft(ctx, h.svc, h.stripe, *ws)
+	if err != nil {
+		importError(w, r, fmt.Errorf("failed to count monthly url: %w", err))
+		return
+	}
+	if quota <= 0 {
+		addFlash(w, r, "You have reached your monthly limit of URLs", flashTypeError)
+		return
+	}
+
+	results, err := importLinks(ctx, h.svc, ws.ID, user.ID, rows, quota)
+	if err != nil {
+		importError(w, r, err)
+		return
+	}
+
+	var report bytes.Buffer
+	if err := writeImportReport(&report, results); err != nil {
+		log.Error("failed to write import report", slog.Any("error", err))
+	}
+
+	summary := domain.SummarizeImport(results)
+	if summary[domain.ImportCreated] > 0 {
+		addFlash(w, r, fmt.Sprintf("%d links created", summary[domain.ImportCreated]), flashTypeInfo)
+	}
+	reportURL := "data:text/csv;charset=utf-8;base64," + base64.StdEncoding.EncodeToString(report.Bytes())
+	if err := components.ImportReport(results, summary, reportURL).Render(ctx, w); err != nil {
+		log.Error("failed to render import report", slog.Any("error", err))
+	}
+}
+
+func importError(w http.ResponseWriter, r *http.Request, err error) {
+	status := ErrorStatus(err)
+	switch {
+	case errors.Is(err, errImportTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, errImportNoFile), errors.Is(err, errImportEmpty), errors.Is(err, errImportNoURL), errors.Is(err, errImportMalformed):
+		status = http.StatusBadRequest
+	case status == http.StatusInternalServerError:
+		log.Error("failed to import links", slog.Any("error", err))
+		err = errors.New("failed to import links, try again")
+	}
+	addFlash(w, r, err.Error(), flashTypeError)
+	w.WriteHeader(status)
+}
+
+type importLinksRequest struct {
+	Links []struct {
+		URL   string `json:"url"`
+		Title string `json:"title"`
+	} `json:"links"`
+}
+
+type apiImportResult struct {
+	Line     int    `json:"line"`
+	URL      string `json:"url"`
+	Title    string `json:"title,omitempty"`
+	Status   string `json:"status"`
+	ShortURL string `json:"short_url,omitempty"`
+	Slug     string `json:"slug,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type apiImportResponse struct {
+	Data    []apiImportResult `json:"data"`
+	Summary map[string]int    `json:"summary"`
+}
+
+// importLinks creates links in bulk from a JSON list of links, or from a CSV
+// file read like the ones uploaded in the dashboard when the body is sent as
+// text/csv. The report is returned as JSON, or as CSV with format=csv.
+func (h *APIHandlers) importLinks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := middleware.UserFromContext(ctx)
+	ws := middleware.WorkspaceFromContext(ctx)
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeAPIError(w, http.StatusBadRequest, "", "format must be json or csv")
+		return
+	}
+
+	var rows []domain.ImportRow
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		var err error
+		rows, err = parseImportCSV(http.MaxBytesReader(w, r.Body, maxImportFileSize))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			switch {
+			case errors.As(err, &maxErr):
+				writeAPIError(w, http.StatusRequestEntityTooLarge, "", "request body too large")
+			case errors.Is(err, services.ErrTooManyImportRows):
+				writeAPIServiceError(w, err)
+			default:
+				writeAPIError(w, http.StatusBadRequest, "", err.Error())
+			}
+			return
+		}
+	} else {
+		var req importLinksRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if len(req.Links) == 0 {
+			writeAPIValidationError(w, map[string]error{"links": errImportEmpty})
+			return
+		}
+		if len(req.Links) > services.ImportMaxRows {
+			writeAPIServiceError(w, services.ErrTooManyImportRows)
+			return
+		}
+		for i, l := range req.Links {
+			rows = append(rows, domain.ImportRow{
+				Line:  i + 1,
+				URL:   strings.TrimSpace(l.URL),
+				Title: strings.TrimSpace(l.Title),
+			})
+		}
+	}
+
+	quota, err := quotaLeft(ctx, h.svc, h.stripe, *ws)
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+	if quota <= 0 {
+		writeAPIError(w, http.StatusForbidden, "quota_exceeded", "you have reached your monthly limit of URLs")
+		return
+	}
+
+	results, err := importLinks(ctx, h.svc, ws.ID, user.ID, rows, quota)
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="shortcut-import-report.csv"`)
+		if err := writeImportReport(w, results); err != nil {
+			log.Error("failed to write import report", slog.Any("error", err))
+		}
+		return
+	}
+
+	resp := apiImportResponse{
+		Data:    make([]apiImportResult, 0, len(results)),
+		Summary: make(map[string]int),
+	}
+	for _, res := range results {
+		resp.Data = append(resp.Data, apiImportResult{
+			Line:     res.Row.Line,
+			URL:      res.Row.URL,
+			Title:    res.Row.Title,
+			Status:   string(res.Status),
+			ShortURL: res.Short,
+			Slug:     res.Slug,
+			Error:    res.Error,
+		})
+	}
+	for status, n := range domain.SummarizeImport(results) {
+		resp.Summary[string(status)] = n
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/services"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []domain.ImportRow
+		wantErr error
+	}{
+		{
+			name: "no header",
+			csv:  "https://example.com/a,Product A\nhttps://example.com/b\n",
+			want: []domain.ImportRow{
+				{Line: 1, URL: "https://example.com/a", Title: "Product A"},
+				{Line: 2, URL: "https://example.com/b"},
+			},
+		},
+		{
+			name: "header",
+			csv:  "\ufeffsku,Title,URL\n42, Product A , https://example.com/a\n\n43,,https://example.com/b\n",
+			want: []domain.ImportRow{
+				{Line: 2, URL: "https://example.com/a", Title: "Product A"},
+				{Line: 4, URL: "https://example.com/b"},
+			},
+		},
+		{name: "header without url", csv: "title,link\nA,https://example.com/a\n", wantErr: errImportNoURL},
+		{name: "only a header", csv: "url,title\n", wantErr: errImportEmpty},
+		{name: "empty", csv: "", wantErr: errImportEmpty},
+		{name: "malformed", csv: "url\n\"https://example.com/a\n", wantErr: errImportMalformed},
+		{name: "too many rows", csv: strings.Repeat("https://example.com\n", services.ImportMaxRows+1), wantErr: services.ErrTooManyImportRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rows, err := parseImportCSV(strings.NewReader(tt.csv))
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, rows)
+		})
+	}
+}
+
+// importURLService keeps the rows it is given and creates all of them, using
+// their title as slug.
+type importURLService struct {
+	URLService
+	given []domain.ImportRow
+}
+
+func (s *importURLService) ShortenBatch(_ context.Context, _, _ domain.ID, rows []domain.ImportRow, _ int64) ([]domain.ImportResult, error) {
+	s.given = rows
+	results := make([]domain.ImportResult, len(rows))
+	for i, row := range rows {
+		results[i] = domain.ImportResult{Row: row, Status: domain.ImportCreated, Short: "https://sho.rt/" + row.Title, Slug: row.Title}
+	}
+	return results, nil
+}
+
+func TestImportLinks(t *testing.T) {
+	t.Parallel()
+
+	svc := &importURLService{}
+	rows := []domain.ImportRow{
+		{Line: 1, URL: "https://example.com/a", Title: "a"},
+		{Line: 2, URL: "not a url"},
+		{Line: 3, URL: "https://example.com/b", Title: "b"},
+	}
+
+	results, err := importLinks(context.Background(), svc, 1, 5, rows, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ImportRow{rows[0], rows[2]}, svc.given, "invalid rows are not created")
+	assert.Equal(t, domain.ImportCreated, results[0].Status)
+	assert.Equal(t, domain.ImportInvalid, results[1].Status)
+	assert.Equal(t, "invalid URL", results[1].Error)
+	assert.Equal(t, "https://sho.rt/b", results[2].Short)
+
+	var report bytes.Buffer
+	assert.NoError(t, writeImportReport(&report, []domain.ImportResult{
+		results[0],
+		{Row: domain.ImportRow{Line: 4, URL: "=cmd|' /C calc'!A0"}, Status: domain.ImportInvalid, Error: "invalid URL"},
+	}))
+	assert.Equal(t, "line,url,title,status,short_url,error\n"+
+		"1,https://example.com/a,a,created,https://sho.rt/a,\n"+
+		"4,'=cmd|' /C calc'!A0,,invalid,,invalid URL\n", report.String())
+}
@@ -5,7 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net"
 	"net/http"
+	"strings"
 
 	"github.com/donseba/go-htmx"
 	"github.com/go-chi/chi/v5"
@@ -20,35 +23,68 @@ import (
 )
 
 type URLService interface {
-	Shorten(ctx context.Context, url string, title string, userID domain.ID) (string, error)
-	List(ctx context.Context, authorID domain.ID, search string) ([]domain.URLStat, error)
-	Delete(ctx context.Context, urlID, authorID domain.ID) error
-
-	Expand(ctx context.Context, short string) (domain.URL, error)
+	Shorten(ctx context.Context, url string, title string, workspaceID, userID domain.ID, opts domain.ShortenOptions) (domain.URL, error)
+	ShortenBatch(ctx context.Context, workspaceID, userID domain.ID, rows []domain.ImportRow, quota int64) ([]domain.ImportResult, error)
+	List(ctx context.Context, workspaceID domain.ID, filter domain.LinkFilter, page domain.Page) ([]domain.URLStat, int, error)
+	Delete(ctx context.Context, urlID, workspaceID domain.ID) error
+	Archive(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) error
+	Unarchive(ctx context.Context, workspaceID domain.ID, refs ...domain.LinkRef) (int64, error)
+
+	Expand(ctx context.Context, host, short string) (domain.URL, error)
+	IsExpired(ctx context.Context, url domain.URL) (bool, error)
+	CountClick(ctx context.Context, url domain.URL, r *http.Request) (bool, error)
 	ExtractTitle(url string) string
 
-	Get(ctx context.Context, authorID domain.ID, slug string) (domain.URL, error)
-	StatisticsDetail(ctx context.Context, authorID domain.ID, slug string) (domain.URLStat, error)
-
-	ClickOverTime(ctx context.Context, urlID domain.ID, period domain.Period, timeRange string) ([]domain.TimeSeriesData, error)
-
-	TrackRedirect(ctx context.Context, urlID domain.ID, r *http.Request) error
+	Get(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) (domain.URL, error)
+	Edit(ctx context.Context, workspaceID, userID domain.ID, ref domain.LinkRef, title, longURL string, redirectStatus int) (domain.URL, error)
+	DestinationHistory(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) ([]domain.DestinationChange, error)
+	UpdateExpiration(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, exp domain.Expiration) (domain.URL, error)
+	UpdatePassword(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, password string) (domain.URL, error)
+	UpdateForwardQuery(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, forward bool) (domain.URL, error)
+	RedirectRules(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) ([]domain.RedirectRule, error)
+	SetRedirectRules(ctx context.Context, workspaceID, userID domain.ID, ref domain.LinkRef, rules []domain.RedirectRule) ([]domain.RedirectRule, error)
+	Variants(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) ([]domain.Variant, error)
+	SetVariants(ctx context.Context, workspaceID, userID domain.ID, ref domain.LinkRef, variants []domain.Variant) ([]domain.Variant, error)
+	UpdateStickyVariants(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, sticky bool) (domain.URL, error)
+	VerifyPassword(ctx context.Context, urlID domain.ID, ip, password string) error
+	StatisticsDetail(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, filter domain.StatsFilter) (domain.URLStat, error)
+
+	WithOrganization(ctx context.Context, workspaceID domain.ID, url domain.URL) (domain.URL, error)
+	SetTags(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, tags []string) ([]string, error)
+	SetFolder(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, folderID domain.ID) (domain.Folder, error)
+	Folders(ctx context.Context, workspaceID domain.ID) ([]domain.Folder, error)
+	CreateFolder(ctx context.Context, workspaceID domain.ID, name string) (domain.Folder, error)
+	DeleteFolder(ctx context.Context, workspaceID, folderID domain.ID) error
+	TagStats(ctx context.Context, workspaceID domain.ID, filter domain.StatsFilter) ([]domain.TagStat, error)
+
+	ClickOverTime(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter) (domain.VisitSeries, error)
+	ExportVisits(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, filter domain.StatsFilter, fn func(domain.ExportedVisit) error) error
+	ExportStats(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, filter domain.StatsFilter, fn func(domain.ExportedStat) error) error
+
+	CountMonthlyURL(ctx context.Context, workspaceID domain.ID) (int64, error)
+	CountMonthlyVisit(ctx context.Context, workspaceID domain.ID) (int64, error)
+}
 
-	CountMonthlyURL(ctx context.Context, authorID domain.ID) (int64, error)
-	CountMonthlyVisit(ctx context.Context, authorID domain.ID) (int64, error)
+// VisitTracker records visits of short links in the background.
+type VisitTracker interface {
+	Track(urlID domain.ID, route domain.Route, r *http.Request)
 }
 
 type Handler struct {
 	htmx   *htmx.HTMX
 	svc    URLService
+	visits VisitTracker
 	stripe stripeService
+	qr     QRCodeRenderer
 }
 
-func NewURLHandlers(shortURL URLService, stripe stripeService) *Handler {
+func NewURLHandlers(shortURL URLService, visits VisitTracker, stripe stripeService, qr QRCodeRenderer) *Handler {
 	return &Handler{
 		htmx:   htmx.New(),
 		svc:    shortURL,
+		visits: visits,
 		stripe: stripe,
+		qr:     qr,
 	}
 }
 
@@ -58,21 +94,54 @@ func (h *Handler) Routes(r chi.Router) {
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.Authenticated)
 
-		r.Post("/shorten", h.shorten)
-
 		r.With(middleware.PaginateParams).Get("/urls", h.myLinks)
-		r.With(middleware.PaginateParams).Get("/urls-sort", h.urlSort)
-		r.With(middleware.PaginateParams).Get("/urls-search", h.urlSearch)
-
-		r.Get("/urls/{slug}", h.linkDetail)
+		r.With(middleware.PaginateParams).Get("/urls-sort", h.urlList)
+		r.With(middleware.PaginateParams).Get("/urls-search", h.urlList)
+		r.Get("/urls/export", h.exportLinks)
+		r.Get("/urls/{ref}", h.linkDetail)
+		r.Get("/urls/{ref}/export", h.exportLink)
+		r.Get("/urls/{ref}/qr", h.linkQRCode)
 		r.Get("/urls/{id}/clicks", h.clickChart)
-		r.Delete("/urls/{id}", h.deleteURL)
+
+		// viewers only look at the links of their workspace
+		r.Group(func(r chi.Router) {
+			r.Use(requireRole(domain.RoleEditor))
+
+			r.Post("/shorten", h.shorten)
+
+			r.With(middleware.PaginateParams).Post("/urls/unarchive", h.unarchiveURLs)
+			r.Post("/urls/import", h.importURLs)
+
+			r.Put("/urls/{ref}", h.editURL)
+			r.Post("/urls/{ref}/archive", h.archiveURL)
+			r.Delete("/urls/{ref}/archive", h.unarchiveURL)
+			r.Put("/urls/{ref}/expiration", h.updateExpiration)
+			r.Delete("/urls/{ref}/expiration", h.clearExpiration)
+			r.Put("/urls/{ref}/password", h.updatePassword)
+			r.Delete("/urls/{ref}/password", h.clearPassword)
+			r.Post("/urls/{ref}/forward-query", h.enableForwardQuery)
+			r.Delete("/urls/{ref}/forward-query", h.disableForwardQuery)
+			r.Post("/urls/{ref}/rules", h.addRedirectRule)
+			r.Delete("/urls/{ref}/rules/{id}", h.deleteRedirectRule)
+			r.Post("/urls/{ref}/rules/{id}/up", h.moveRedirectRule)
+			r.Post("/urls/{ref}/variants", h.addVariant)
+			r.Put("/urls/{ref}/variants/{id}", h.updateVariant)
+			r.Delete("/urls/{ref}/variants/{id}", h.deleteVariant)
+			r.Post("/urls/{ref}/sticky-variants", h.enableStickyVariants)
+			r.Delete("/urls/{ref}/sticky-variants", h.disableStickyVariants)
+			r.Put("/urls/{ref}/tags", h.setLinkTags)
+			r.Put("/urls/{ref}/folder", h.setLinkFolder)
+			r.Post("/folders", h.createFolder)
+			r.Delete("/folders/{id}", h.deleteFolder)
+			r.Delete("/urls/{id}", h.deleteURL)
+		})
 	})
 }
 
 // RedirectRoute registers only the redirect hot path, intentionally without session middleware.
+// Every method is redirected so 307 and 308 links keep the method of the request.
 func (h *Handler) RedirectRoute(r chi.Router) {
-	r.Get("/{shortID}", h.redirect)
+	r.HandleFunc("/{shortID}", h.redirect)
 }
 
 func (h *Handler) index(w http.ResponseWriter, r *http.Request) {
@@ -84,9 +153,17 @@ func (h *Handler) shorten(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	url := r.FormValue("url")
 	title := r.FormValue("title")
+	slug := strings.TrimSpace(r.FormValue("slug"))
 
-	if errs := validateURL(url); len(errs) > 0 {
-		addFlash(w, r, errs["long_url"].Error(), flashTypeError)
+	exp, errs := parseExpirationForm(r)
+	maps.Copy(errs, validateShorten(url, slug))
+	password := r.FormValue("password")
+	redirectStatus, err := parseRedirectStatus(r.FormValue("redirect_status"))
+	if err != nil {
+		errs["redirect_status"] = err
+	}
+	if len(errs) > 0 {
+		addFlash(w, r, firstError(errs, "long_url", "slug", "expires_at", "max_clicks", "redirect_status").Error(), flashTypeError)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -103,24 +180,15 @@ func (h *Handler) shorten(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	count, err := h.svc.CountMonthlyURL(ctx, user.ID)
+	ws := middleware.WorkspaceFromContext(ctx)
+	reached, err := quotaReached(ctx, h.svc, h.stripe, *ws)
 	if err != nil {
 		log.Error("failed to count monthly url", slog.Any("error", err))
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	limit := domain.FreePlanLimit
-	sub, err := h.stripe.GetSubscription(ctx, user)
-	if err == nil && sub != nil {
-		if sub.Features.LinksNumber > 0 {
-			limit = sub.Features.LinksNumber
-		} else if sub.Product().Name == "Pro" || sub.Product().Name == "Business" {
-			limit = 1000000
-		}
-	}
-
-	if count >= int64(limit) {
+	if reached {
 		addFlash(w, r, "You have reached your monthly limit of URLs", flashTypeError)
 		// We return 200 OK so HTMX swaps the content (which will be empty/hidden or we could render a specific error state)
 		// Or we can just return and let the flash message speak.
@@ -128,8 +196,21 @@ func (h *Handler) shorten(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	short, err := h.svc.Shorten(ctx, url, title, user.ID)
+	shortened, err := h.svc.Shorten(ctx, url, title, ws.ID, user.ID, domain.ShortenOptions{
+		Slug:           slug,
+		Expiration:     exp,
+		Password:       password,
+		RedirectStatus: redirectStatus,
+		UTM:            parseUTMForm(r),
+		ForwardQuery:   r.FormValue("forward_query") == "on",
+		Domain:         r.FormValue("domain"),
+	})
 	if err != nil {
+		if status := ErrorStatus(err); status == http.StatusConflict || status == http.StatusUnprocessableEntity {
+			addFlash(w, r, err.Error(), flashTypeError)
+			w.WriteHeader(status)
+			return
+		}
 		if errors.Is(err, services.ErrSuspiciousURL) {
 			addFlash(w, r, err.Error(), flashTypeError)
 			return
@@ -140,45 +221,170 @@ func (h *Handler) shorten(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	addFlash(w, r, fmt.Sprintf("URL shortened to %s", short), flashTypeInfo)
+	addFlash(w, r, fmt.Sprintf("URL shortened to %s", shortened.Short), flashTypeInfo)
 
-	components.ShortenURL(short, url).Render(ctx, w)
+	components.ShortenURL(shortened.Short, url).Render(ctx, w)
+}
+
+// quotaReached reports whether the workspace already created as many links
+// this month as its plan allows.
+func quotaReached(ctx context.Context, usage URLUsageService, stripe stripeService, ws domain.Workspace) (bool, error) {
+	left, err := quotaLeft(ctx, usage, stripe, ws)
+	return left <= 0, err
+}
+
+// quotaLeft returns how many links the workspace can still create this month.
+func quotaLeft(ctx context.Context, usage URLUsageService, stripe stripeService, ws domain.Workspace) (int64, error) {
+	count, err := usage.CountMonthlyURL(ctx, ws.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	limit := domain.FreePlanLimit
+	sub, err := stripe.GetSubscription(ctx, ws)
+	if err == nil && sub != nil {
+		if sub.Features.LinksNumber > 0 {
+			limit = sub.Features.LinksNumber
+		} else if sub.Product().Name == "Pro" || sub.Product().Name == "Business" {
+			limit = domain.UnlimitedPlanLimit
+		}
+	}
+
+	return int64(limit) - count, nil
 }
 
 func (h *Handler) redirect(w http.ResponseWriter, r *http.Request) {
+	url, ok := h.resolve(w, r)
+	if !ok {
+		return
+	}
+
+	if url.HasPassword {
+		if r.Method == http.MethodPost {
+			h.unlock(w, r, url)
+			return
+		}
+		w.Header().Set("X-Robots-Tag", "noindex")
+		templates.PasswordPage(r.URL.RequestURI(), "").Render(r.Context(), w)
+		return
+	}
+
+	status := url.RedirectStatus
+	if status == 0 {
+		status = domain.DefaultRedirectStatus
+	}
+	h.follow(w, r, url, status)
+}
+
+// unlock redirects to a password protected link once the visitor entered the
+// right password in the form posted by the password page.
+func (h *Handler) unlock(w http.ResponseWriter, r *http.Request, url domain.URL) {
+	err := h.svc.VerifyPassword(r.Context(), url.ID, remoteIP(r), r.PostFormValue("password"))
+	if errors.Is(err, services.ErrWrongLinkPassword) {
+		w.Header().Set("X-Robots-Tag", "noindex")
+		w.WriteHeader(http.StatusUnauthorized)
+		templates.PasswordPage(r.URL.RequestURI(), "Wrong password, please try again.").Render(r.Context(), w)
+		return
+	}
+	if errors.Is(err, services.ErrTooManyPasswordAttempts) {
+		w.Header().Set("X-Robots-Tag", "noindex")
+		w.Header().Set("Retry-After", "900")
+		w.WriteHeader(http.StatusTooManyRequests)
+		templates.PasswordPage(r.URL.RequestURI(), "Too many attempts, please try again in a few minutes.").Render(r.Context(), w)
+		return
+	}
+	if err != nil {
+		log.Error("failed to verify link password", slog.Any("error", err))
+		http.Error(w, "failed to verify password", http.StatusInternalServerError)
+		return
+	}
+
+	h.follow(w, r, url, http.StatusSeeOther)
+}
+
+// remoteIP is the address of the client, without its port. The RealIP
+// middleware already replaced it with the one forwarded by a trusted proxy.
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// resolve looks up the link of the request, on the domain of its Host
+// header, and renders the not found or expired page when it can't be
+// followed.
+func (h *Handler) resolve(w http.ResponseWriter, r *http.Request) (domain.URL, bool) {
 	id := chi.URLParam(r, "shortID")
 	if id == "" {
 		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return domain.URL{}, false
 	}
 
-	url, err := h.svc.Expand(r.Context(), id)
+	url, err := h.svc.Expand(r.Context(), r.Host, id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			w.WriteHeader(http.StatusNotFound)
 			templates.NotFoundPage().Render(r.Context(), w)
-			return
+			return domain.URL{}, false
 		}
 
 		log.Error("failed to expand url", slog.Any("error", err))
 		http.Error(w, "failed to expand url", http.StatusInternalServerError)
-		return
+		return domain.URL{}, false
 	}
 
 	if !url.IsActive {
 		w.WriteHeader(http.StatusNotFound)
 		templates.NotFoundPage().Render(r.Context(), w)
+		return domain.URL{}, false
+	}
+
+	expired, err := h.svc.IsExpired(r.Context(), url)
+	if err != nil {
+		log.Error("failed to check url expiration", slog.Any("error", err))
+	}
+	if expired {
+		gone(w, r)
+		return domain.URL{}, false
+	}
+
+	return url, true
+}
+
+// gone renders the page of expired links.
+func gone(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.WriteHeader(http.StatusGone)
+	templates.ExpiredPage().Render(r.Context(), w)
+}
+
+// follow records the visit and redirects to the destination of url picked by
+// its redirect rules or variants, along with the query string of the request
+// when the link forwards it. Links that used up their clicks are gone.
+func (h *Handler) follow(w http.ResponseWriter, r *http.Request, url domain.URL, status int) {
+	expired, err := h.svc.CountClick(r.Context(), url, r)
+	if err != nil {
+		log.Error("failed to count click", slog.Any("error", err))
+	}
+	if expired {
+		gone(w, r)
 		return
 	}
 
-	go func() {
-		if err := h.svc.TrackRedirect(context.Background(), url.ID, r); err != nil {
-			log.Error("failed to track redirect", slog.Any("error", err))
-		}
-	}()
+	target, route := services.Destination(url, r)
+	h.visits.Track(url.ID, route, r)
+
+	if url.StickyVariants && route.VariantID != 0 {
+		http.SetCookie(w, services.VariantCookie(url.Slug, route.VariantID))
+	}
+
+	if url.ForwardQuery && r.URL.RawQuery != "" {
+		target = services.ForwardQuery(target, r.URL.RawQuery)
+	}
 
 	w.Header().Set("X-Robots-Tag", "noindex")
-	http.Redirect(w, r, url.Long, http.StatusFound)
+	http.Redirect(w, r, target, status)
 }
 
 func validateURL(url string) map[string]error {
@@ -196,3 +402,14 @@ func validateURL(url string) map[string]error {
 
 	return errs
 }
+
+// validateShorten validates the fields of a shorten request. slug is optional.
+func validateShorten(url, slug string) map[string]error {
+	errs := validateURL(url)
+	if slug != "" {
+		if err := services.ValidateSlug(slug); err != nil {
+			errs["slug"] = err
+		}
+	}
+	return errs
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/services"
+)
+
+// redirectURLService serves the link "api", redirecting with a 307, and the
+// link "private", protected by the password "secret".
+type redirectURLService struct {
+	URLService
+}
+
+func (redirectURLService) Expand(_ context.Context, _, slug string) (domain.URL, error) {
+	switch slug {
+	case "api":
+		return domain.URL{ID: 1, Slug: slug, Long: "https://example.com/api", IsActive: true, RedirectStatus: http.StatusTemporaryRedirect}, nil
+	case "private":
+		return domain.URL{ID: 2, Slug: slug, Long: "https://example.com/private", IsActive: true, HasPassword: true}, nil
+	}
+	return domain.URL{}, pgx.ErrNoRows
+}
+
+func (redirectURLService) IsExpired(context.Context, domain.URL) (bool, error) {
+	return false, nil
+}
+
+func (redirectURLService) CountClick(context.Context, domain.URL, *http.Request) (bool, error) {
+	return false, nil
+}
+
+func (redirectURLService) VerifyPassword(_ context.Context, _ domain.ID, _, password string) error {
+	if password != "secret" {
+		return services.ErrWrongLinkPassword
+	}
+	return nil
+}
+
+// countingTracker counts the visits tracked.
+type countingTracker struct {
+	visits int
+}
+
+func (t *countingTracker) Track(domain.ID, domain.Route, *http.Request) {
+	t.visits++
+}
+
+func TestRedirectMethods(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		slug         string
+		password     string
+		wantStatus   int
+		wantLocation string
+	}{
+		{"get", http.MethodGet, "api", "", http.StatusTemporaryRedirect, "https://example.com/api"},
+		{"post keeps the method", http.MethodPost, "api", "", http.StatusTemporaryRedirect, "https://example.com/api"},
+		{"put", http.MethodPut, "api", "", http.StatusTemporaryRedirect, "https://example.com/api"},
+		{"delete", http.MethodDelete, "api", "", http.StatusTemporaryRedirect, "https://example.com/api"},
+		{"password page", http.MethodGet, "private", "", http.StatusOK, ""},
+		{"wrong password", http.MethodPost, "private", "guess", http.StatusUnauthorized, ""},
+		{"unlock", http.MethodPost, "private", "secret", http.StatusSeeOther, "https://example.com/private"},
+		{"unknown link", http.MethodPost, "missing", "", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tracker := &countingTracker{}
+			r := chi.NewRouter()
+			NewURLHandlers(redirectURLService{}, tracker, nil, nil).RedirectRoute(r)
+
+			form := url.Values{"password": {tt.password}}
+			req := httptest.NewRequest(tt.method, "/"+tt.slug, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantLocation, rec.Header().Get("Location"))
+			if tt.wantLocation != "" {
+				assert.Equal(t, 1, tracker.visits)
+			} else {
+				assert.Zero(t, tracker.visits)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/templates/components"
+)
+
+func (h *Handler) updatePassword(w http.ResponseWriter, r *http.Request) {
+	password := r.FormValue("password")
+	if password == "" {
+		addFlash(w, r, "Password is required", flashTypeError)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	h.setPassword(w, r, password, "Password updated")
+}
+
+func (h *Handler) clearPassword(w http.ResponseWriter, r *http.Request) {
+	h.setPassword(w, r, "", "Password removed")
+}
+
+func (h *Handler) setPassword(w http.ResponseWriter, r *http.Request, password, message string) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	url, err := h.svc.UpdatePassword(r.Context(), ws.ID, linkRef(r), password)
+	if err != nil {
+		status := ErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			log.Error("failed to update url password", slog.Any("error", err))
+			http.Error(w, "failed to update password", status)
+			return
+		}
+		addFlash(w, r, err.Error(), flashTypeError)
+		w.WriteHeader(status)
+		return
+	}
+
+	addFlash(w, r, message, flashTypeInfo)
+	if err := components.PasswordCard(url).Render(r.Context(), w); err != nil {
+		log.Error("failed to render password card", slog.Any("error", err))
+	}
+}
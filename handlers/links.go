@@ -3,8 +3,8 @@ package handlers
 import (
 	"log/slog"
 	"net/http"
-	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -12,6 +12,7 @@ import (
 	"github.com/zaibon/shortcut/domain"
 	"github.com/zaibon/shortcut/log"
 	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/services"
 	"github.com/zaibon/shortcut/templates"
 	"github.com/zaibon/shortcut/templates/components"
 )
@@ -32,104 +33,83 @@ func Paginate[T any](s []T, q *PaginationQuery) []T {
 func (h *Handler) myLinks(w http.ResponseWriter, r *http.Request) {
 	htmx := h.htmx.NewHandler(w, r)
 
-	user := middleware.UserFromContext(r.Context())
-	if user == nil {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	if ws == nil {
 		htmx.Redirect("/auth")
 		return
 	}
 
-	urls, err := h.svc.List(r.Context(), user.ID, "")
+	filter, err := parseLinkFilter(r)
 	if err != nil {
-		log.Error("failed to get statistics", slog.Any("error", err))
-		http.Error(w, "failed to get statistics", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// Get pagination parameters from the context
-	pagination := middleware.GetPaginationParams(r.Context())
-	paginationLinks := middleware.GeneratePaginationLinks(pagination, len(urls))
-
-	urls = middleware.Paginate(urls, pagination)
-	urls = sortUrls(urls, "")
-
-	if err := templates.URLSPage(urls, paginationLinks).
-		Render(r.Context(), w); err != nil {
-		log.Error("failed to render page", slog.Any("error", err))
-	}
-}
-
-func (h *Handler) urlSort(w http.ResponseWriter, r *http.Request) {
-	htmx := h.htmx.NewHandler(w, r)
-
-	user := middleware.UserFromContext(r.Context())
-	if user == nil {
-		htmx.Redirect("/auth")
+	urls, paginationLinks, err := h.listLinks(r, ws.ID, filter)
+	if err != nil {
+		log.Error("failed to get statistics", slog.Any("error", err))
+		http.Error(w, "failed to get statistics", http.StatusInternalServerError)
 		return
 	}
-
-	if err := r.ParseForm(); err != nil {
-		log.Error("failed to parse form", slog.Any("error", err))
-		http.Error(w, "failed to parse form", http.StatusBadRequest)
+	folders, err := h.svc.Folders(r.Context(), ws.ID)
+	if err != nil {
+		log.Error("failed to get folders", slog.Any("error", err))
+		http.Error(w, "failed to get folders", http.StatusInternalServerError)
 		return
 	}
-
-	sortBy := r.FormValue("sort")
-
-	urls, err := h.svc.List(r.Context(), user.ID, "")
+	tags, err := h.svc.TagStats(r.Context(), ws.ID, domain.StatsFilter{})
 	if err != nil {
-		log.Error("failed to get statistics", slog.Any("error", err))
-		http.Error(w, "failed to get statistics", http.StatusInternalServerError)
+		log.Error("failed to get tags", slog.Any("error", err))
+		http.Error(w, "failed to get tags", http.StatusInternalServerError)
 		return
 	}
 
-	// Get pagination parameters from the context
-	pagination := middleware.GetPaginationParams(r.Context())
-	paginationLinks := middleware.GeneratePaginationLinks(pagination, len(urls))
-
-	urls = middleware.Paginate(urls, pagination)
-	urls = sortUrls(urls, sortBy)
-
-	if err := templates.URLList(urls, paginationLinks).
+	if err := templates.URLSPage(urls, paginationLinks, filter, folders, tags).
 		Render(r.Context(), w); err != nil {
 		log.Error("failed to render page", slog.Any("error", err))
 	}
 }
 
-func (h *Handler) urlSearch(w http.ResponseWriter, r *http.Request) {
+// urlList renders the links list alone, as the search and the sort of the
+// dashboard change.
+func (h *Handler) urlList(w http.ResponseWriter, r *http.Request) {
 	htmx := h.htmx.NewHandler(w, r)
 
-	user := middleware.UserFromContext(r.Context())
-	if user == nil {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	if ws == nil {
 		htmx.Redirect("/auth")
 		return
 	}
 
-	if err := r.ParseForm(); err != nil {
-		log.Error("failed to parse form", slog.Any("error", err))
-		http.Error(w, "failed to parse form", http.StatusBadRequest)
+	filter, err := parseLinkFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	search := r.FormValue("search")
-
-	urls, err := h.svc.List(r.Context(), user.ID, search)
+	urls, paginationLinks, err := h.listLinks(r, ws.ID, filter)
 	if err != nil {
 		log.Error("failed to get statistics", slog.Any("error", err))
 		http.Error(w, "failed to get statistics", http.StatusInternalServerError)
 		return
 	}
 
-	// Get pagination parameters from the context
-	pagination := middleware.GetPaginationParams(r.Context())
-	paginationLinks := middleware.GeneratePaginationLinks(pagination, len(urls))
-
-	urls = middleware.Paginate(urls, pagination)
-
-	if err := templates.URLList(urls, paginationLinks).
+	if err := templates.URLList(urls, paginationLinks, filter).
 		Render(r.Context(), w); err != nil {
 		log.Error("failed to render page", slog.Any("error", err))
 	}
 }
 
+// listLinks returns the page of the links of workspaceID requested by r and
+// the links to the other pages.
+func (h *Handler) listLinks(r *http.Request, workspaceID domain.ID, filter domain.LinkFilter) ([]domain.URLStat, domain.PaginationLinks, error) {
+	pagination := middleware.GetPaginationParams(r.Context())
+	page := domain.Page{Offset: pagination.Offset(), Limit: pagination.Limit()}
+	urls, total, err := h.svc.List(r.Context(), workspaceID, filter, page)
+	if err != nil {
+		return nil, domain.PaginationLinks{}, err
+	}
+	return urls, keepListFilter(middleware.GeneratePaginationLinks(pagination, total), filter), nil
+}
+
 func (h *Handler) deleteURL(w http.ResponseWriter, r *http.Request) {
 	htmx := h.htmx.NewHandler(w, r)
 	user := middleware.UserFromContext(r.Context())
@@ -142,6 +122,7 @@ func (h *Handler) deleteURL(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Account is suspended", http.StatusForbidden)
 		return
 	}
+	ws := middleware.WorkspaceFromContext(r.Context())
 
 	sid := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(sid, 10, 32)
@@ -152,7 +133,7 @@ func (h *Handler) deleteURL(w http.ResponseWriter, r *http.Request) {
 	}
 
 	urlID := domain.ID(id)
-	if err := h.svc.Delete(r.Context(), urlID, user.ID); err != nil {
+	if err := h.svc.Delete(r.Context(), urlID, ws.ID); err != nil {
 		log.Error("failed to delete url", slog.Any("error", err))
 		http.Error(w, "failed to delete url", http.StatusInternalServerError)
 		return
@@ -161,24 +142,92 @@ func (h *Handler) deleteURL(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// linkRef returns the link named by the ref URL parameter, see
+// domain.LinkRef.String.
+func linkRef(r *http.Request) domain.LinkRef {
+	return domain.ParseLinkRef(chi.URLParam(r, "ref"))
+}
+
 func (h *Handler) linkDetail(w http.ResponseWriter, r *http.Request) {
-	slug := chi.URLParam(r, "slug")
-	user := middleware.UserFromContext(r.Context())
+	ref := linkRef(r)
+	ws := middleware.WorkspaceFromContext(r.Context())
 
-	url, err := h.svc.StatisticsDetail(r.Context(), user.ID, slug)
+	filter, err := parseStatsFilter(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), ErrorStatus(err))
+		return
+	}
+
+	url, err := h.svc.StatisticsDetail(r.Context(), ws.ID, ref, filter)
 	if err != nil {
 		log.Error("failed to get url", slog.Any("error", err))
-		http.Error(w, "failed to get url", http.StatusInternalServerError)
+		http.Error(w, "failed to get url", ErrorStatus(err))
+		return
+	}
+
+	history, err := h.svc.DestinationHistory(r.Context(), ws.ID, ref)
+	if err != nil {
+		log.Error("failed to get destination history", slog.Any("error", err))
+		http.Error(w, "failed to get url", ErrorStatus(err))
+		return
+	}
+
+	rules, err := h.svc.RedirectRules(r.Context(), ws.ID, ref)
+	if err != nil {
+		log.Error("failed to get redirect rules", slog.Any("error", err))
+		http.Error(w, "failed to get url", ErrorStatus(err))
 		return
 	}
 
-	if err := templates.URLDetail(url).
+	folders, err := h.svc.Folders(r.Context(), ws.ID)
+	if err != nil {
+		log.Error("failed to get folders", slog.Any("error", err))
+		http.Error(w, "failed to get url", ErrorStatus(err))
+		return
+	}
+
+	if err := templates.URLDetail(url, history, rules, folders).
 		Render(r.Context(), w); err != nil {
 		log.Error("failed to render page", slog.Any("error", err))
 	}
 }
 
+func (h *Handler) editURL(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	ws := middleware.WorkspaceFromContext(r.Context())
+	ref := linkRef(r)
+
+	title := strings.TrimSpace(r.FormValue("title"))
+	longURL := strings.TrimSpace(r.FormValue("long_url"))
+	errs := validateURL(longURL)
+	redirectStatus, err := parseRedirectStatus(r.FormValue("redirect_status"))
+	if err != nil {
+		errs["redirect_status"] = err
+	}
+	if len(errs) > 0 {
+		addFlash(w, r, firstError(errs, "long_url", "redirect_status").Error(), flashTypeError)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.svc.Edit(r.Context(), ws.ID, user.ID, ref, title, longURL, redirectStatus); err != nil {
+		status := ErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			log.Error("failed to edit url", slog.Any("error", err))
+			http.Error(w, "failed to edit url", status)
+			return
+		}
+		addFlash(w, r, err.Error(), flashTypeError)
+		w.WriteHeader(status)
+		return
+	}
+
+	HXRedirect(r.Context(), w, "/urls/"+ref.String())
+}
+
 func (h *Handler) clickChart(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
 	sID := chi.URLParam(r, "id")
 	urlID, err := strconv.ParseInt(sID, 10, 32)
 	if err != nil {
@@ -187,70 +236,98 @@ func (h *Handler) clickChart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	now := time.Now()
-	period := domain.Period{
-		Until: time.Now(),
-	}
-
-	timeRange := r.URL.Query().Get("range")
-
-	switch timeRange {
-	case "day":
-		period.Since = now.AddDate(0, 0, -1)
-	case "week":
-		period.Since = now.AddDate(0, 0, -7)
-	case "month":
-		period.Since = now.AddDate(0, -1, 0)
-	default:
-		period.Since = now.AddDate(0, 0, -7)
+	filter, err := parseStatsFilter(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), ErrorStatus(err))
+		return
 	}
 
-	data, err := h.svc.ClickOverTime(r.Context(), domain.ID(urlID), period, timeRange)
+	series, err := h.svc.ClickOverTime(r.Context(), ws.ID, domain.ID(urlID), filter)
 	if err != nil {
 		log.Error("failed to get click over time", slog.Any("error", err))
-		http.Error(w, "failed to get click over time", http.StatusInternalServerError)
+		http.Error(w, "failed to get click over time", ErrorStatus(err))
 		return
 	}
 
-	if err := components.ChartData("visitOverTime", data).
+	if err := components.VisitSeriesData(series).
 		Render(r.Context(), w); err != nil {
 		log.Error("failed to render page", slog.Any("error", err))
 
 	}
 }
 
-var (
-	sortByNewest = func(i, j domain.URLStat) int {
-		return j.CreatedAt.Compare(i.CreatedAt)
+// statsRanges are the periods, ending now, selected by the range query
+// parameter.
+var statsRanges = map[string]time.Duration{
+	"day":     24 * time.Hour,
+	"week":    7 * 24 * time.Hour,
+	"month":   30 * 24 * time.Hour,
+	"quarter": 90 * 24 * time.Hour,
+	"year":    365 * 24 * time.Hour,
+}
+
+// parseStatsFilter reads the statistics filter of a request. Bots are left out
+// unless include_bots is true. Buckets start in the tz timezone, UTC by
+// default. The period is either a range ending now or goes from from to to,
+// both included; range takes precedence. It is left zero when neither is
+// given.
+func parseStatsFilter(r *http.Request, now time.Time) (domain.StatsFilter, error) {
+	query := r.URL.Query()
+	includeBots, _ := strconv.ParseBool(query.Get("include_bots"))
+	filter := domain.StatsFilter{IncludeBots: includeBots, Location: time.UTC}
+
+	if tz := strings.TrimSpace(query.Get("tz")); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil || tz == "Local" {
+			return domain.StatsFilter{}, services.ErrInvalidTimezone
+		}
+		filter.Location = loc
 	}
-	sortByOldest = func(i, j domain.URLStat) int {
-		return i.CreatedAt.Compare(j.CreatedAt)
+
+	from, to := strings.TrimSpace(query.Get("from")), strings.TrimSpace(query.Get("to"))
+	switch timeRange := query.Get("range"); {
+	case timeRange != "":
+		d, ok := statsRanges[timeRange]
+		if !ok {
+			return domain.StatsFilter{}, services.ErrInvalidPeriod
+		}
+		filter.Period = domain.Period{Since: now.Add(-d), Until: now}
+	case from != "":
+		since, err := parseStatsDate(from, filter.Location, false)
+		if err != nil {
+			return domain.StatsFilter{}, err
+		}
+		until := now
+		if to != "" {
+			if until, err = parseStatsDate(to, filter.Location, true); err != nil {
+				return domain.StatsFilter{}, err
+			}
+		}
+		filter.Period = domain.Period{Since: since, Until: until}
+	case to != "":
+		return domain.StatsFilter{}, services.ErrInvalidPeriod
+	default:
+		return filter, nil
 	}
-	compareInt = func(i, j int) int {
-		return j - i
+
+	if err := services.ValidatePeriod(filter.Period); err != nil {
+		return domain.StatsFilter{}, err
 	}
-	sortByMostClicks = func(i, j domain.URLStat) int {
-		return compareInt(i.NrVisited, j.NrVisited)
+	return filter, nil
+}
 
+// parseStatsDate reads a date, midnight in loc, or an RFC 3339 time. The end
+// of a period includes its last day, it stops at the following midnight.
+func parseStatsDate(v string, loc *time.Location, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
 	}
-	sortByLeastClicks = func(i, j domain.URLStat) int {
-		return compareInt(j.NrVisited, i.NrVisited)
+	t, err := time.ParseInLocation(time.DateOnly, v, loc)
+	if err != nil {
+		return time.Time{}, services.ErrInvalidDate
 	}
-)
-
-func sortUrls(urls []domain.URLStat, filter string) []domain.URLStat {
-	switch filter {
-	case "newest":
-		slices.SortFunc(urls, sortByNewest)
-	case "oldest":
-		slices.SortFunc(urls, sortByOldest)
-	case "most-clicked":
-		slices.SortFunc(urls, sortByMostClicks)
-	case "least-clicked":
-		slices.SortFunc(urls, sortByLeastClicks)
-	default:
-		slices.SortFunc(urls, sortByNewest)
-
+	if end {
+		t = t.AddDate(0, 0, 1)
 	}
-	return urls
+	return t, nil
 }
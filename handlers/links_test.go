@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/services"
+)
+
+func TestParseStatsFilter(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("timezone database not available")
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   string
+		want    domain.StatsFilter
+		wantErr error
+	}{
+		{
+			name:  "default",
+			query: "",
+			want:  domain.StatsFilter{Location: time.UTC},
+		},
+		{
+			name:  "bots",
+			query: "include_bots=true",
+			want:  domain.StatsFilter{IncludeBots: true, Location: time.UTC},
+		},
+		{
+			name:  "range",
+			query: "range=week&tz=Europe/Paris",
+			want: domain.StatsFilter{
+				Period:   domain.Period{Since: now.AddDate(0, 0, -7), Until: now},
+				Location: paris,
+			},
+		},
+		{
+			name:  "dates include the last day",
+			query: "from=2026-09-01&to=2026-09-30&tz=Europe/Paris",
+			want: domain.StatsFilter{
+				Period: domain.Period{
+					Since: time.Date(2026, 9, 1, 0, 0, 0, 0, paris),
+					Until: time.Date(2026, 10, 1, 0, 0, 0, 0, paris),
+				},
+				Location: paris,
+			},
+		},
+		{
+			name:  "from until now",
+			query: "from=2026-10-01",
+			want: domain.StatsFilter{
+				Period:   domain.Period{Since: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), Until: now},
+				Location: time.UTC,
+			},
+		},
+		{
+			name:  "rfc 3339",
+			query: "from=2026-10-01T08:00:00Z&to=2026-10-01T20:00:00Z",
+			want: domain.StatsFilter{
+				Period: domain.Period{
+					Since: time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC),
+					Until: time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC),
+				},
+				Location: time.UTC,
+			},
+		},
+		{
+			name:  "range takes precedence",
+			query: "range=day&from=2026-01-01&to=2026-01-31",
+			want: domain.StatsFilter{
+				Period:   domain.Period{Since: now.Add(-24 * time.Hour), Until: now},
+				Location: time.UTC,
+			},
+		},
+		{name: "unknown range", query: "range=decade", wantErr: services.ErrInvalidPeriod},
+		{name: "unknown timezone", query: "tz=Mars/Olympus", wantErr: services.ErrInvalidTimezone},
+		{name: "local timezone", query: "tz=Local", wantErr: services.ErrInvalidTimezone},
+		{name: "invalid date", query: "from=01/09/2026", wantErr: services.ErrInvalidDate},
+		{name: "to without from", query: "to=2026-09-30", wantErr: services.ErrInvalidPeriod},
+		{name: "reversed", query: "from=2026-09-30&to=2026-09-01", wantErr: services.ErrInvalidPeriod},
+		{name: "too long", query: "from=2010-01-01&to=2026-01-01", wantErr: services.ErrPeriodTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest("GET", "/urls/1/clicks?"+tt.query, nil)
+			got, err := parseStatsFilter(r, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.IncludeBots, got.IncludeBots)
+			assert.Equal(t, tt.want.Location.String(), got.Location.String())
+			assert.True(t, tt.want.Period.Since.Equal(got.Period.Since), "since: got %s, want %s", got.Period.Since, tt.want.Period.Since)
+			assert.True(t, tt.want.Period.Until.Equal(got.Period.Until), "until: got %s, want %s", got.Period.Until, tt.want.Period.Until)
+		})
+	}
+}
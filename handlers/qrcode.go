@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/services"
+)
+
+// QRCodeRenderer draws QR codes.
+type QRCodeRenderer interface {
+	Render(content string, opts domain.QROptions) ([]byte, error)
+}
+
+// qrCacheControl lets browsers keep QR codes for a day, they only change with
+// their options.
+const qrCacheControl = "private, max-age=86400"
+
+var qrContentTypes = map[domain.QRFormat]string{
+	domain.QRFormatPNG: "image/png",
+	domain.QRFormatSVG: "image/svg+xml",
+}
+
+// parseQROptions reads the options of a QR code from the query string:
+// format, size in pixels, error correction level, fg and bg colors and logo.
+// Missing ones keep their default value, a logo raising the level to H.
+func parseQROptions(query url.Values) (domain.QROptions, error) {
+	opts := domain.DefaultQROptions
+
+	if v := query.Get("format"); v != "" {
+		opts.Format = domain.QRFormat(strings.ToLower(v))
+	}
+	if v := query.Get("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("size must be a number of pixels")
+		}
+		opts.Size = size
+	}
+	if v := query.Get("logo"); v != "" {
+		logo, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("logo must be true or false")
+		}
+		opts.Logo = logo
+		if logo {
+			opts.Level = domain.QRLevelHigh
+		}
+	}
+	if v := query.Get("level"); v != "" {
+		opts.Level = domain.QRLevel(strings.ToUpper(v))
+	}
+	if v := query.Get("fg"); v != "" {
+		fg, err := services.ParseHexColor(v)
+		if err != nil {
+			return opts, fmt.Errorf("fg: %w", err)
+		}
+		opts.Foreground = fg
+	}
+	if v := query.Get("bg"); v != "" {
+		bg, err := services.ParseHexColor(v)
+		if err != nil {
+			return opts, fmt.Errorf("bg: %w", err)
+		}
+		opts.Background = bg
+	}
+
+	return opts, services.ValidateQROptions(opts)
+}
+
+// qrETag identifies the QR code of content drawn with opts.
+func qrETag(content string, opts domain.QROptions) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%s|%d|%s|%s|%s|%t", content, opts.Format, opts.Size,
+		opts.Level, services.HexColor(opts.Foreground), services.HexColor(opts.Background), opts.Logo))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// serveQRCode renders the QR code of the link ref with the options of the
+// query string, as an attachment with download=true. Scans of the code are
+// recorded as such on the visits of the link. fail reports the errors.
+func serveQRCode(w http.ResponseWriter, r *http.Request, svc URLService, qr QRCodeRenderer, ref domain.LinkRef, fail func(w http.ResponseWriter, status int, msg string)) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	opts, err := parseQROptions(r.URL.Query())
+	if err != nil {
+		fail(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	download, _ := strconv.ParseBool(r.URL.Query().Get("download"))
+
+	link, err := svc.Get(r.Context(), ws.ID, ref)
+	if err != nil {
+		status := ErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			log.Error("failed to get url", slog.Any("error", err))
+			fail(w, status, "failed to render QR code")
+			return
+		}
+		fail(w, status, err.Error())
+		return
+	}
+
+	content := services.QRContent(link.Short)
+	etag := qrETag(content, opts)
+	w.Header().Set("Cache-Control", qrCacheControl)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	img, err := qr.Render(content, opts)
+	if err != nil {
+		log.Error("failed to render QR code", slog.Any("error", err))
+		fail(w, http.StatusInternalServerError, "failed to render QR code")
+		return
+	}
+
+	w.Header().Set("Content-Type", qrContentTypes[opts.Format])
+	w.Header().Set("Content-Length", strconv.Itoa(len(img)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if opts.Format == domain.QRFormatSVG {
+		// the logo is the only resource an SVG code loads
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src data:")
+	}
+	if download {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="qrcode-%s.%s"`, link.Slug, opts.Format))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(img) //nolint:errcheck
+}
+
+func (h *Handler) linkQRCode(w http.ResponseWriter, r *http.Request) {
+	serveQRCode(w, r, h.svc, h.qr, linkRef(r), plainExportError)
+}
+
+func (h *APIHandlers) linkQRCode(w http.ResponseWriter, r *http.Request) {
+	serveQRCode(w, r, h.svc, h.qr, linkRef(r), apiExportError)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/services"
+)
+
+func TestParseQROptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    func(*domain.QROptions)
+		wantErr bool
+	}{
+		{name: "default", want: func(*domain.QROptions) {}},
+		{
+			name:  "all",
+			query: "format=SVG&size=512&level=q&fg=4f46e5&bg=%23fff8e7",
+			want: func(o *domain.QROptions) {
+				o.Format, o.Size, o.Level = domain.QRFormatSVG, 512, domain.QRLevelQuartile
+				o.Foreground = color.RGBA{R: 0x4f, G: 0x46, B: 0xe5, A: 0xff}
+				o.Background = color.RGBA{R: 0xff, G: 0xf8, B: 0xe7, A: 0xff}
+			},
+		},
+		{name: "logo raises the level", query: "logo=true", want: func(o *domain.QROptions) { o.Logo, o.Level = true, domain.QRLevelHigh }},
+		{name: "logo with a low level", query: "logo=true&level=L", wantErr: true},
+		{name: "size not a number", query: "size=big", wantErr: true},
+		{name: "size too large", query: "size=4096", wantErr: true},
+		{name: "invalid color", query: "fg=red", wantErr: true},
+		{name: "invalid logo", query: "logo=maybe", wantErr: true},
+		{name: "unknown format", query: "format=gif", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			query, _ := url.ParseQuery(tt.query)
+			opts, err := parseQROptions(query)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			want := domain.DefaultQROptions
+			tt.want(&want)
+			assert.NoError(t, err)
+			assert.Equal(t, want, opts)
+		})
+	}
+}
+
+// qrURLService only knows the link "promo", of the default domain and of
+// go.example.com.
+type qrURLService struct {
+	URLService
+}
+
+func (qrURLService) Get(_ context.Context, _ domain.ID, ref domain.LinkRef) (domain.URL, error) {
+	switch ref {
+	case domain.LinkRef{Slug: "promo"}:
+		return domain.URL{Slug: ref.Slug, Short: "https://sho.rt/promo"}, nil
+	case domain.LinkRef{Domain: "go.example.com", Slug: "promo"}:
+		return domain.URL{Slug: ref.Slug, Domain: ref.Domain, Short: "https://go.example.com/promo"}, nil
+	}
+	return domain.URL{}, services.ErrURLNotFound
+}
+
+// qrRenderer writes the content and format it is asked for.
+type qrRenderer struct{}
+
+func (qrRenderer) Render(content string, opts domain.QROptions) ([]byte, error) {
+	return []byte(string(opts.Format) + ":" + content), nil
+}
+
+func TestServeQRCode(t *testing.T) {
+	etag := qrETag("https://sho.rt/promo?qr=1", domain.DefaultQROptions)
+
+	tests := []struct {
+		name        string
+		target      string
+		ifNoneMatch string
+		wantCode    int
+		wantType    string
+		wantBody    string
+		wantFile    string
+	}{
+		{name: "png", target: "/urls/promo/qr", wantCode: http.StatusOK, wantType: "image/png", wantBody: "png:https://sho.rt/promo?qr=1"},
+		{name: "svg", target: "/urls/promo/qr?format=svg", wantCode: http.StatusOK, wantType: "image/svg+xml", wantBody: "svg:https://sho.rt/promo?qr=1"},
+		{name: "download", target: "/urls/promo/qr?download=true", wantCode: http.StatusOK, wantType: "image/png", wantBody: "png:https://sho.rt/promo?qr=1", wantFile: `attachment; filename="qrcode-promo.png"`},
+		{name: "not modified", target: "/urls/promo/qr", ifNoneMatch: etag, wantCode: http.StatusNotModified},
+		{name: "other options", target: "/urls/promo/qr?size=512", ifNoneMatch: etag, wantCode: http.StatusOK, wantType: "image/png", wantBody: "png:https://sho.rt/promo?qr=1"},
+		{name: "custom domain", target: "/urls/promo@go.example.com/qr", wantCode: http.StatusOK, wantType: "image/png", wantBody: "png:https://go.example.com/promo?qr=1"},
+		{name: "unknown link", target: "/urls/unknown/qr", wantCode: http.StatusNotFound},
+		{name: "unknown domain", target: "/urls/promo@example.org/qr", wantCode: http.StatusNotFound},
+		{name: "invalid options", target: "/urls/promo/qr?size=1", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := &Handler{svc: qrURLService{}, qr: qrRenderer{}}
+			router := chi.NewRouter()
+			router.Get("/urls/{ref}/qr", h.linkQRCode)
+
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			r = r.WithContext(middleware.WithWorkspace(r.Context(), domain.Workspace{ID: 1, Role: domain.RoleViewer}))
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, tt.wantType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.wantBody, w.Body.String())
+				assert.Equal(t, tt.wantFile, w.Header().Get("Content-Disposition"))
+				assert.Equal(t, qrCacheControl, w.Header().Get("Cache-Control"))
+				assert.NotEmpty(t, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/templates/components"
+)
+
+// parseRedirectRuleForm reads a new redirect rule: its destination, the
+// platforms checkboxes, the device select, comma separated countries and
+// languages, and the optional time window.
+func parseRedirectRuleForm(r *http.Request) (domain.RedirectRule, map[string]error) {
+	rule := domain.RedirectRule{
+		LongURL:   strings.TrimSpace(r.FormValue("long_url")),
+		Countries: splitList(r.FormValue("countries")),
+		Languages: splitList(r.FormValue("languages")),
+	}
+	errs := validateURL(rule.LongURL)
+
+	for _, p := range r.Form["platforms"] {
+		rule.Platforms = append(rule.Platforms, domain.Platform(p))
+	}
+
+	if device := r.FormValue("device"); device != "" {
+		mobile := device == "mobile"
+		rule.Mobile = &mobile
+	}
+
+	var err error
+	if rule.StartsAt, err = parseFormTime(r, "starts_at"); err != nil {
+		errs["starts_at"] = errors.New("invalid start date")
+	}
+	if rule.EndsAt, err = parseFormTime(r, "ends_at"); err != nil {
+		errs["ends_at"] = errors.New("invalid end date")
+	}
+	return rule, errs
+}
+
+// splitList splits a list separated by commas or spaces.
+func splitList(v string) []string {
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+func (h *Handler) addRedirectRule(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	ref := linkRef(r)
+
+	rule, errs := parseRedirectRuleForm(r)
+	if len(errs) > 0 {
+		addFlash(w, r, firstError(errs, "long_url", "starts_at", "ends_at").Error(), flashTypeError)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rules, err := h.svc.RedirectRules(r.Context(), ws.ID, ref)
+	if err != nil {
+		redirectRulesError(w, r, err)
+		return
+	}
+
+	h.saveRedirectRules(w, r, ref, append(rules, rule), "Redirect rule added")
+}
+
+func (h *Handler) deleteRedirectRule(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	ref := linkRef(r)
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "failed to parse rule id", http.StatusBadRequest)
+		return
+	}
+
+	rules, err := h.svc.RedirectRules(r.Context(), ws.ID, ref)
+	if err != nil {
+		redirectRulesError(w, r, err)
+		return
+	}
+	rules = slices.DeleteFunc(rules, func(rule domain.RedirectRule) bool {
+		return rule.ID == domain.ID(id)
+	})
+
+	h.saveRedirectRules(w, r, ref, rules, "Redirect rule removed")
+}
+
+// moveRedirectRule moves a rule one step up, so that it is evaluated before
+// the rule preceding it.
+func (h *Handler) moveRedirectRule(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	ref := linkRef(r)
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "failed to parse rule id", http.StatusBadRequest)
+		return
+	}
+
+	rules, err := h.svc.RedirectRules(r.Context(), ws.ID, ref)
+	if err != nil {
+		redirectRulesError(w, r, err)
+		return
+	}
+	i := slices.IndexFunc(rules, func(rule domain.RedirectRule) bool {
+		return rule.ID == domain.ID(id)
+	})
+	if i > 0 {
+		rules[i-1], rules[i] = rules[i], rules[i-1]
+	}
+
+	h.saveRedirectRules(w, r, ref, rules, "Redirect rules reordered")
+}
+
+func (h *Handler) saveRedirectRules(w http.ResponseWriter, r *http.Request, ref domain.LinkRef, rules []domain.RedirectRule, message string) {
+	user := middleware.UserFromContext(r.Context())
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	rules, err := h.svc.SetRedirectRules(r.Context(), ws.ID, user.ID, ref, rules)
+	if err != nil {
+		redirectRulesError(w, r, err)
+		return
+	}
+
+	addFlash(w, r, message, flashTypeInfo)
+	if err := components.RedirectRulesCard(ref, rules).Render(r.Context(), w); err != nil {
+		log.Error("failed to render redirect rules card", slog.Any("error", err))
+	}
+}
+
+func redirectRulesError(w http.ResponseWriter, r *http.Request, err error) {
+	status := ErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Error("failed to update redirect rules", slog.Any("error", err))
+		http.Error(w, "failed to update redirect rules", status)
+		return
+	}
+	addFlash(w, r, err.Error(), flashTypeError)
+	w.WriteHeader(status)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
@@ -31,6 +32,69 @@ func ErrorStatus(err error) int {
 		http.StatusUnprocessableEntity: {
 			services.ErrInvalidCredentials,
 			services.ErrUserNotFound,
+			services.ErrInvalidSlug,
+			services.ErrReservedSlug,
+			services.ErrExpiryInPast,
+			services.ErrInvalidClickLimit,
+			services.ErrInvalidRedirectStatus,
+			services.ErrInvalidLinkPassword,
+			services.ErrTooManyRedirectRules,
+			services.ErrRuleWithoutCondition,
+			services.ErrInvalidRulePlatform,
+			services.ErrInvalidRuleCountry,
+			services.ErrInvalidRuleLanguage,
+			services.ErrInvalidRuleTimeWindow,
+			services.ErrTooManyVariants,
+			services.ErrInvalidVariantWeight,
+			services.ErrInvalidPeriod,
+			services.ErrPeriodTooLong,
+			services.ErrInvalidDate,
+			services.ErrInvalidTimezone,
+			services.ErrInvalidWebhookURL,
+			services.ErrInvalidWebhookEvents,
+			services.ErrTooManyWebhooks,
+			services.ErrTooManyImportRows,
+			services.ErrInvalidTag,
+			services.ErrTooManyTags,
+			services.ErrInvalidFolderName,
+			services.ErrTooManyFolders,
+			services.ErrInvalidWorkspaceName,
+			services.ErrTooManyWorkspaces,
+			services.ErrPersonalWorkspace,
+			services.ErrInvalidRole,
+			services.ErrInvalidEmail,
+			services.ErrInvalidDomain,
+			services.ErrTooManyDomains,
+			services.ErrDomainVerification,
+			services.ErrDomainNotVerified,
+		},
+		http.StatusConflict: {
+			services.ErrSlugTaken,
+			services.ErrFolderExists,
+			services.ErrLastOwner,
+			services.ErrDomainExists,
+			services.ErrDomainTaken,
+			services.ErrDomainInUse,
+		},
+		http.StatusNotFound: {
+			services.ErrURLNotFound,
+			services.ErrRedirectRuleNotFound,
+			services.ErrVariantNotFound,
+			services.ErrWebhookNotFound,
+			services.ErrWebhookDeliveryNotFound,
+			services.ErrFolderNotFound,
+			services.ErrWorkspaceNotFound,
+			services.ErrMemberNotFound,
+			services.ErrInvitationNotFound,
+			services.ErrDomainNotFound,
+		},
+		http.StatusForbidden: {
+			services.ErrSuspiciousURL,
+			services.ErrWorkspaceForbidden,
+			services.ErrInvitationEmail,
+		},
+		http.StatusTooManyRequests: {
+			services.ErrTooManyPasswordAttempts,
 		},
 	}
 
@@ -44,3 +108,74 @@ func ErrorStatus(err error) int {
 
 	return http.StatusInternalServerError
 }
+
+// apiError is the body returned by every failing /api endpoint.
+type apiError struct {
+	Error apiErrorDetail `json:"error"`
+}
+
+type apiErrorDetail struct {
+	Status  int               `json:"status"`
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+var apiErrorCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "payload_too_large",
+	http.StatusUnprocessableEntity:   "validation_failed",
+	http.StatusTooManyRequests:       "too_many_requests",
+	http.StatusInternalServerError:   "internal_error",
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v == nil {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("failed to encode json response", slog.Any("error", err))
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	if code == "" {
+		code = apiErrorCodes[status]
+	}
+	writeJSON(w, status, apiError{Error: apiErrorDetail{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}})
+}
+
+func writeAPIValidationError(w http.ResponseWriter, errs map[string]error) {
+	fields := make(map[string]string, len(errs))
+	for field, err := range errs {
+		fields[field] = err.Error()
+	}
+	writeJSON(w, http.StatusUnprocessableEntity, apiError{Error: apiErrorDetail{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    apiErrorCodes[http.StatusUnprocessableEntity],
+		Message: "invalid request",
+		Fields:  fields,
+	}})
+}
+
+// writeAPIServiceError maps an error returned by a service to its status code.
+// Unexpected errors are logged and their message is not leaked to the client.
+func writeAPIServiceError(w http.ResponseWriter, err error) {
+	status := ErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		slog.Error("api request failed", slog.Any("error", err))
+		writeAPIError(w, status, "", "internal server error")
+		return
+	}
+	writeAPIError(w, status, "", err.Error())
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/services"
+)
+
+// TestRoutesReserved guards against links shadowed by a page: the first
+// segment of every route must be refused as a custom slug.
+func TestRoutesReserved(t *testing.T) {
+	r := chi.NewRouter()
+	(&Handler{}).Routes(r)
+	(&UsersHandler{}).Routes(r)
+	(&HealthzHandlers{}).Routes(r)
+	(&subscriptionHandlers{}).Routes(r)
+	(&AdministrationHandlers{}).Routes(r, nil)
+	(&APITokenHandlers{}).Routes(r)
+	(&WebhookHandlers{}).Routes(r)
+	(&WorkspaceHandlers{}).Routes(r)
+	(&DomainHandlers{}).Routes(r)
+	(&APIHandlers{}).Routes(r)
+
+	segments := map[string]struct{}{}
+	err := chi.Walk(r, func(_, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+		if segment != "" && !strings.HasPrefix(segment, "{") {
+			segments[segment] = struct{}{}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, segments)
+
+	for segment := range segments {
+		assert.ErrorIs(t, services.ValidateSlug(segment), services.ErrReservedSlug, "/%s is not reserved", segment)
+	}
+}
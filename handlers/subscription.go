@@ -20,11 +20,11 @@ import (
 )
 
 type stripeService interface {
-	GetSubscription(ctx context.Context, user *domain.User) (*domain.Subscription, error)
+	GetSubscription(ctx context.Context, ws domain.Workspace) (*domain.Subscription, error)
 	HandleSessionCheckout(ctx context.Context, session *stripe.CheckoutSession) error
 	HandleSubscriptionUpdated(ctx context.Context, sub *stripe.Subscription) error
-	GenerateCustomerPortalURL(ctx context.Context, user *domain.User) (string, error)
-	CreateCheckoutSession(ctx context.Context, user *domain.User, priceID string) (string, error)
+	GenerateCustomerPortalURL(ctx context.Context, ws domain.Workspace) (string, error)
+	CreateCheckoutSession(ctx context.Context, ws domain.Workspace, email, priceID string) (string, error)
 	ListPlans(ctx context.Context) ([]domain.Plan, error)
 }
 
@@ -62,9 +62,9 @@ func (h *subscriptionHandlers) Routes(r chi.Router) {
 }
 
 func (h *subscriptionHandlers) subscription(w http.ResponseWriter, r *http.Request) {
-	user := middleware.UserFromContext(r.Context())
+	ws := middleware.WorkspaceFromContext(r.Context())
 
-	urlCount, err := h.urls.CountMonthlyURL(r.Context(), user.ID)
+	urlCount, err := h.urls.CountMonthlyURL(r.Context(), ws.ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -73,7 +73,7 @@ func (h *subscriptionHandlers) subscription(w http.ResponseWriter, r *http.Reque
 	limit := domain.FreePlanLimit
 	planName := "Free"
 
-	sub, err := h.payment.GetSubscription(r.Context(), user)
+	sub, err := h.payment.GetSubscription(r.Context(), *ws)
 	if err == nil && sub != nil {
 		planName = sub.Product().Name
 		if sub.Features.LinksNumber > 0 {
@@ -110,14 +110,21 @@ func (h *subscriptionHandlers) subscription(w http.ResponseWriter, r *http.Reque
 
 func (h *subscriptionHandlers) checkout(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
+	ws := middleware.WorkspaceFromContext(r.Context())
 	priceID := r.FormValue("price_id")
 
+	// the subscription is the one of the whole workspace
+	if !ws.Role.Can(domain.RoleOwner) {
+		http.Error(w, "only the owners of the workspace can change its plan", http.StatusForbidden)
+		return
+	}
+
 	if priceID == "" {
 		http.Error(w, "price_id is required", http.StatusBadRequest)
 		return
 	}
 
-	url, err := h.payment.CreateCheckoutSession(r.Context(), user, priceID)
+	url, err := h.payment.CreateCheckoutSession(r.Context(), *ws, user.Email, priceID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/services"
+	"github.com/zaibon/shortcut/templates/components"
+)
+
+var errInvalidFolderID = errors.New("folder must be the id of one of your folders")
+
+// parseLinkFilter reads the filter of the links listed by a request: the
+// search, the "Archived" tab, a tag, a folder id and the sort.
+func parseLinkFilter(r *http.Request) (domain.LinkFilter, error) {
+	filter := domain.LinkFilter{
+		Search:   strings.TrimSpace(r.FormValue("search")),
+		Archived: archivedFilter(r),
+		Tag:      strings.ToLower(strings.TrimSpace(r.FormValue("tag"))),
+		Sort:     domain.ParseLinkSort(r.FormValue("sort")),
+	}
+	if v := r.FormValue("folder"); v != "" {
+		id, err := parseFolderID(v)
+		if err != nil {
+			return domain.LinkFilter{}, err
+		}
+		filter.FolderID = id
+	}
+	return filter, nil
+}
+
+func parseFolderID(v string) (domain.ID, error) {
+	id, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || id < 0 {
+		return 0, errInvalidFolderID
+	}
+	return domain.ID(id), nil
+}
+
+// keepListFilter makes the pagination links stay on the "Archived" tab and
+// keep the search, tag, folder and sort of the listed links.
+func keepListFilter(links domain.PaginationLinks, filter domain.LinkFilter) domain.PaginationLinks {
+	query := url.Values{}
+	if filter.Archived {
+		query.Set("archived", "true")
+	}
+	if filter.Search != "" {
+		query.Set("search", filter.Search)
+	}
+	if filter.Sort != "" && filter.Sort != domain.LinkSortNewest {
+		query.Set("sort", string(filter.Sort))
+	}
+	if filter.Tag != "" {
+		query.Set("tag", filter.Tag)
+	}
+	if filter.FolderID != 0 {
+		query.Set("folder", strconv.Itoa(int(filter.FolderID)))
+	}
+	if len(query) == 0 {
+		return links
+	}
+
+	suffix := "&" + query.Encode()
+	for i := range links.Pages {
+		links.Pages[i].Href += suffix
+	}
+	if links.Previous != nil {
+		links.Previous.Href += suffix
+	}
+	if links.Next != nil {
+		links.Next.Href += suffix
+	}
+	return links
+}
+
+// checkFolder makes sure folderID is one of the folders of workspaceID.
+func checkFolder(ctx context.Context, svc URLService, workspaceID, folderID domain.ID) error {
+	folders, err := svc.Folders(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	for _, f := range folders {
+		if f.ID == folderID {
+			return nil
+		}
+	}
+	return services.ErrFolderNotFound
+}
+
+func (h *Handler) setLinkTags(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	ref := linkRef(r)
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "failed to parse form", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.svc.SetTags(r.Context(), ws.ID, ref, r.Form["tags"]); err != nil {
+		organizeError(w, r, err)
+		return
+	}
+
+	h.renderOrganizeCard(w, r, ws.ID, ref, "Tags saved")
+}
+
+func (h *Handler) setLinkFolder(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	ref := linkRef(r)
+
+	var folderID domain.ID
+	if v := r.FormValue("folder_id"); v != "" {
+		id, err := parseFolderID(v)
+		if err != nil {
+			addFlash(w, r, err.Error(), flashTypeError)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		folderID = id
+	}
+
+	folder, err := h.svc.SetFolder(r.Context(), ws.ID, ref, folderID)
+	if err != nil {
+		organizeError(w, r, err)
+		return
+	}
+
+	message := "Link removed from its folder"
+	if folder.ID != 0 {
+		message = fmt.Sprintf("Link moved to %s", folder.Name)
+	}
+	h.renderOrganizeCard(w, r, ws.ID, ref, message)
+}
+
+// createFolder creates a folder and, when a ref is given, moves that link to
+// it. Otherwise the dashboard is opened on the new folder.
+func (h *Handler) createFolder(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	folder, err := h.svc.CreateFolder(r.Context(), ws.ID, r.FormValue("name"))
+	if err != nil {
+		organizeError(w, r, err)
+		return
+	}
+
+	ref := domain.ParseLinkRef(r.FormValue("ref"))
+	if ref.Slug == "" {
+		HXRedirect(r.Context(), w, fmt.Sprintf("/urls?folder=%d", folder.ID))
+		return
+	}
+	if _, err := h.svc.SetFolder(r.Context(), ws.ID, ref, folder.ID); err != nil {
+		organizeError(w, r, err)
+		return
+	}
+	h.renderOrganizeCard(w, r, ws.ID, ref, fmt.Sprintf("Link moved to %s", folder.Name))
+}
+
+// deleteFolder deletes a folder, its links are kept, and goes back to the
+// dashboard.
+func (h *Handler) deleteFolder(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	id, err := parseFolderID(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "failed to parse folder id", http.StatusBadRequest)
+		return
+	}
+	if err := h.svc.DeleteFolder(r.Context(), ws.ID, id); err != nil {
+		organizeError(w, r, err)
+		return
+	}
+
+	HXRedirect(r.Context(), w, "/urls")
+}
+
+func (h *Handler) renderOrganizeCard(w http.ResponseWriter, r *http.Request, workspaceID domain.ID, ref domain.LinkRef, message string) {
+	link, err := h.svc.Get(r.Context(), workspaceID, ref)
+	if err != nil {
+		organizeError(w, r, err)
+		return
+	}
+	if link, err = h.svc.WithOrganization(r.Context(), workspaceID, link); err != nil {
+		organizeError(w, r, err)
+		return
+	}
+	folders, err := h.svc.Folders(r.Context(), workspaceID)
+	if err != nil {
+		organizeError(w, r, err)
+		return
+	}
+
+	addFlash(w, r, message, flashTypeInfo)
+	if err := components.OrganizeCard(link, folders).Render(r.Context(), w); err != nil {
+		log.Error("failed to render organize card", slog.Any("error", err))
+	}
+}
+
+func organizeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := ErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Error("failed to organize links", slog.Any("error", err))
+		http.Error(w, "failed to organize links", status)
+		return
+	}
+	addFlash(w, r, err.Error(), flashTypeError)
+	w.WriteHeader(status)
+}
+
+// apiFolder is a folder of links. Links is only set when listing folders.
+type apiFolder struct {
+	ID    domain.ID `json:"id"`
+	Name  string    `json:"name"`
+	Links *int      `json:"links,omitempty"`
+}
+
+type apiFolders struct {
+	Data []apiFolder `json:"data"`
+}
+
+type createFolderRequest struct {
+	Name string `json:"name"`
+}
+
+type apiTagStat struct {
+	Name           string `json:"name"`
+	Links          int    `json:"links"`
+	Clicks         int    `json:"clicks"`
+	UniqueVisitors int    `json:"unique_visitors"`
+}
+
+type apiTagStats struct {
+	Data []apiTagStat `json:"data"`
+}
+
+// toAPIFolder returns the folder of a link, nil when it isn't in any.
+func toAPIFolder(f domain.Folder) *apiFolder {
+	if f.ID == 0 {
+		return nil
+	}
+	return &apiFolder{ID: f.ID, Name: f.Name}
+}
+
+func (h *APIHandlers) listTags(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	filter, err := parseStatsFilter(r, time.Now())
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+	stats, err := h.svc.TagStats(r.Context(), ws.ID, filter)
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	resp := apiTagStats{Data: make([]apiTagStat, 0, len(stats))}
+	for _, s := range stats {
+		resp.Data = append(resp.Data, apiTagStat{
+			Name:           s.Name,
+			Links:          s.Links,
+			Clicks:         s.Clicks,
+			UniqueVisitors: s.UniqueVisitors,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *APIHandlers) listFolders(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	folders, err := h.svc.Folders(r.Context(), ws.ID)
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	resp := apiFolders{Data: make([]apiFolder, 0, len(folders))}
+	for _, f := range folders {
+		links := f.Links
+		resp.Data = append(resp.Data, apiFolder{ID: f.ID, Name: f.Name, Links: &links})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *APIHandlers) createFolder(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	var req createFolderRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	folder, err := h.svc.CreateFolder(r.Context(), ws.ID, req.Name)
+	if err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	links := 0
+	writeJSON(w, http.StatusCreated, apiFolder{ID: folder.ID, Name: folder.Name, Links: &links})
+}
+
+func (h *APIHandlers) deleteFolder(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	id, err := parseFolderID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "", "invalid folder id")
+		return
+	}
+	if err := h.svc.DeleteFolder(r.Context(), ws.ID, id); err != nil {
+		writeAPIServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+func TestParseLinkFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    domain.LinkFilter
+		wantErr bool
+	}{
+		{name: "none", query: "", want: domain.LinkFilter{Sort: domain.LinkSortNewest}},
+		{name: "archived", query: "archived=true", want: domain.LinkFilter{Archived: true, Sort: domain.LinkSortNewest}},
+		{
+			name:  "all",
+			query: "search=%20my%20blog%20&tag=%20Promo%20&folder=3&sort=most-clicked",
+			want:  domain.LinkFilter{Search: "my blog", Tag: "promo", FolderID: 3, Sort: domain.LinkSortMostClicked},
+		},
+		{name: "unknown sort", query: "sort=random", want: domain.LinkFilter{Sort: domain.LinkSortNewest}},
+		{name: "folder not a number", query: "folder=work", wantErr: true},
+		{name: "negative folder", query: "folder=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest("GET", "/urls?"+tt.query, nil)
+			got, err := parseLinkFilter(r)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/templates/components"
+)
+
+// parseUTMForm reads the campaign parameters of the shorten form.
+func parseUTMForm(r *http.Request) domain.UTM {
+	return domain.UTM{
+		Source:   strings.TrimSpace(r.FormValue("utm_source")),
+		Medium:   strings.TrimSpace(r.FormValue("utm_medium")),
+		Campaign: strings.TrimSpace(r.FormValue("utm_campaign")),
+		Term:     strings.TrimSpace(r.FormValue("utm_term")),
+		Content:  strings.TrimSpace(r.FormValue("utm_content")),
+	}
+}
+
+func (h *Handler) enableForwardQuery(w http.ResponseWriter, r *http.Request) {
+	h.setForwardQuery(w, r, true, "Query string forwarding enabled")
+}
+
+func (h *Handler) disableForwardQuery(w http.ResponseWriter, r *http.Request) {
+	h.setForwardQuery(w, r, false, "Query string forwarding disabled")
+}
+
+func (h *Handler) setForwardQuery(w http.ResponseWriter, r *http.Request, forward bool, message string) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	url, err := h.svc.UpdateForwardQuery(r.Context(), ws.ID, linkRef(r), forward)
+	if err != nil {
+		status := ErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			log.Error("failed to update url query forwarding", slog.Any("error", err))
+			http.Error(w, "failed to update query forwarding", status)
+			return
+		}
+		addFlash(w, r, err.Error(), flashTypeError)
+		w.WriteHeader(status)
+		return
+	}
+
+	addFlash(w, r, message, flashTypeInfo)
+	if err := components.ForwardQueryCard(url).Render(r.Context(), w); err != nil {
+		log.Error("failed to render query forwarding card", slog.Any("error", err))
+	}
+}
@@ -1,9 +1,55 @@
 package handlers
 
-import "net/url"
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/zaibon/shortcut/services"
+)
 
 // IsValidURL tests if a string is a valid URL.
 func IsValidURL(str string) bool {
 	u, err := url.Parse(str)
 	return err == nil && u.Scheme != "" && u.Host != ""
 }
+
+// firstError returns the error of the first field, in the given order, that
+// failed validation.
+func firstError(errs map[string]error, fields ...string) error {
+	for _, field := range fields {
+		if err, ok := errs[field]; ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseRedirectStatus reads the redirect status code of a link form. An empty
+// value returns 0, letting the service pick the default or keep the current one.
+func parseRedirectStatus(v string) (int, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, nil
+	}
+	status, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, services.ErrInvalidRedirectStatus
+	}
+	if err := services.ValidateRedirectStatus(status); err != nil {
+		return 0, err
+	}
+	return status, nil
+}
+
+// parseVariantWeight reads the weight of a variant form.
+func parseVariantWeight(v string) (int, error) {
+	weight, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return 0, services.ErrInvalidVariantWeight
+	}
+	if err := services.ValidateVariantWeight(weight); err != nil {
+		return 0, err
+	}
+	return weight, nil
+}
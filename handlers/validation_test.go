@@ -29,3 +29,66 @@ func TestIsValidURL(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRedirectStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"empty", "", 0, false},
+		{"found", "302", 302, false},
+		{"permanent", " 308 ", 308, false},
+		{"moved permanently", "301", 301, false},
+		{"not a redirect", "200", 0, true},
+		{"see other", "303", 0, true},
+		{"not a number", "permanent", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseRedirectStatus(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRedirectStatus(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRedirectStatus(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVariantWeight(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"one", "1", 1, false},
+		{"padded", " 50 ", 50, false},
+		{"max", "1000", 1000, false},
+		{"empty", "", 0, true},
+		{"zero", "0", 0, true},
+		{"negative", "-5", 0, true},
+		{"too heavy", "1001", 0, true},
+		{"not a number", "half", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseVariantWeight(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVariantWeight(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseVariantWeight(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/templates/components"
+)
+
+func (h *Handler) addVariant(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	ref := linkRef(r)
+
+	variant := domain.Variant{LongURL: strings.TrimSpace(r.FormValue("long_url"))}
+	errs := validateURL(variant.LongURL)
+	weight, err := parseVariantWeight(r.FormValue("weight"))
+	if err != nil {
+		errs["weight"] = err
+	}
+	if len(errs) > 0 {
+		addFlash(w, r, firstError(errs, "long_url", "weight").Error(), flashTypeError)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	variant.Weight = weight
+
+	variants, err := h.svc.Variants(r.Context(), ws.ID, ref)
+	if err != nil {
+		variantsError(w, r, err)
+		return
+	}
+
+	h.saveVariants(w, r, ref, append(variants, variant), "Variant added")
+}
+
+func (h *Handler) updateVariant(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	ref := linkRef(r)
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "failed to parse variant id", http.StatusBadRequest)
+		return
+	}
+	weight, err := parseVariantWeight(r.FormValue("weight"))
+	if err != nil {
+		addFlash(w, r, err.Error(), flashTypeError)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	variants, err := h.svc.Variants(r.Context(), ws.ID, ref)
+	if err != nil {
+		variantsError(w, r, err)
+		return
+	}
+	for i := range variants {
+		if variants[i].ID == domain.ID(id) {
+			variants[i].Weight = weight
+		}
+	}
+
+	h.saveVariants(w, r, ref, variants, "Variant weight updated")
+}
+
+func (h *Handler) deleteVariant(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	ref := linkRef(r)
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "failed to parse variant id", http.StatusBadRequest)
+		return
+	}
+
+	variants, err := h.svc.Variants(r.Context(), ws.ID, ref)
+	if err != nil {
+		variantsError(w, r, err)
+		return
+	}
+	variants = slices.DeleteFunc(variants, func(v domain.Variant) bool {
+		return v.ID == domain.ID(id)
+	})
+
+	h.saveVariants(w, r, ref, variants, "Variant removed")
+}
+
+func (h *Handler) saveVariants(w http.ResponseWriter, r *http.Request, ref domain.LinkRef, variants []domain.Variant, message string) {
+	user := middleware.UserFromContext(r.Context())
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	variants, err := h.svc.SetVariants(r.Context(), ws.ID, user.ID, ref, variants)
+	if err != nil {
+		variantsError(w, r, err)
+		return
+	}
+	url, err := h.svc.Get(r.Context(), ws.ID, ref)
+	if err != nil {
+		variantsError(w, r, err)
+		return
+	}
+
+	addFlash(w, r, message, flashTypeInfo)
+	if err := components.VariantsCard(ref, url.StickyVariants, variants).Render(r.Context(), w); err != nil {
+		log.Error("failed to render variants card", slog.Any("error", err))
+	}
+}
+
+func (h *Handler) enableStickyVariants(w http.ResponseWriter, r *http.Request) {
+	h.setStickyVariants(w, r, true, "Visitors now keep their variant")
+}
+
+func (h *Handler) disableStickyVariants(w http.ResponseWriter, r *http.Request) {
+	h.setStickyVariants(w, r, false, "Visitors now get a new variant on each visit")
+}
+
+func (h *Handler) setStickyVariants(w http.ResponseWriter, r *http.Request, sticky bool, message string) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+	ref := linkRef(r)
+
+	url, err := h.svc.UpdateStickyVariants(r.Context(), ws.ID, ref, sticky)
+	if err != nil {
+		variantsError(w, r, err)
+		return
+	}
+	variants, err := h.svc.Variants(r.Context(), ws.ID, ref)
+	if err != nil {
+		variantsError(w, r, err)
+		return
+	}
+
+	addFlash(w, r, message, flashTypeInfo)
+	if err := components.VariantsCard(ref, url.StickyVariants, variants).Render(r.Context(), w); err != nil {
+		log.Error("failed to render variants card", slog.Any("error", err))
+	}
+}
+
+func variantsError(w http.ResponseWriter, r *http.Request, err error) {
+	status := ErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Error("failed to update variants", slog.Any("error", err))
+		http.Error(w, "failed to update variants", status)
+		return
+	}
+	addFlash(w, r, err.Error(), flashTypeError)
+	w.WriteHeader(status)
+}
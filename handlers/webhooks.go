@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/templates/components"
+)
+
+type WebhookService interface {
+	CreateEndpoint(ctx context.Context, workspaceID domain.ID, url string, events []domain.WebhookEvent) (domain.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, workspaceID domain.ID) ([]domain.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, workspaceID, endpointID domain.ID) error
+	ListDeliveries(ctx context.Context, workspaceID domain.ID) ([]domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, workspaceID domain.ID, deliveryID int64) error
+}
+
+// WebhookHandlers lets the owners of a workspace manage its webhook endpoints
+// and follow their deliveries from the workspace page.
+type WebhookHandlers struct {
+	svc WebhookService
+}
+
+func NewWebhookHandlers(svc WebhookService) *WebhookHandlers {
+	return &WebhookHandlers{
+		svc: svc,
+	}
+}
+
+func (h *WebhookHandlers) Routes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Authenticated)
+		r.Use(requireRole(domain.RoleOwner))
+		r.Get("/workspace/webhooks", h.listWebhooks)
+		r.Post("/workspace/webhooks", h.createWebhook)
+		r.Delete("/workspace/webhooks/{id}", h.deleteWebhook)
+		r.Post("/workspace/webhooks/deliveries/{id}/redeliver", h.redeliver)
+	})
+}
+
+func (h *WebhookHandlers) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	h.renderWebhooks(w, r, nil)
+}
+
+func (h *WebhookHandlers) createWebhook(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	events := make([]domain.WebhookEvent, 0, len(r.PostForm["events"]))
+	for _, e := range r.PostForm["events"] {
+		events = append(events, domain.WebhookEvent(e))
+	}
+
+	created, err := h.svc.CreateEndpoint(r.Context(), ws.ID, r.PostForm.Get("url"), events)
+	if err != nil {
+		webhooksError(w, r, err)
+		return
+	}
+
+	h.renderWebhooks(w, r, &created)
+}
+
+func (h *WebhookHandlers) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.DeleteEndpoint(r.Context(), ws.ID, domain.ID(id)); err != nil {
+		webhooksError(w, r, err)
+		return
+	}
+
+	addFlash(w, r, "Webhook endpoint deleted", flashTypeInfo)
+	h.renderWebhooks(w, r, nil)
+}
+
+func (h *WebhookHandlers) redeliver(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid delivery id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.Redeliver(r.Context(), ws.ID, id); err != nil {
+		webhooksError(w, r, err)
+		return
+	}
+
+	addFlash(w, r, "Delivery queued again", flashTypeInfo)
+	h.renderWebhooks(w, r, nil)
+}
+
+func (h *WebhookHandlers) renderWebhooks(w http.ResponseWriter, r *http.Request, created *domain.WebhookEndpoint) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	endpoints, err := h.svc.ListEndpoints(r.Context(), ws.ID)
+	if err != nil {
+		webhooksError(w, r, err)
+		return
+	}
+	deliveries, err := h.svc.ListDeliveries(r.Context(), ws.ID)
+	if err != nil {
+		webhooksError(w, r, err)
+		return
+	}
+
+	if err := components.WebhooksCard(endpoints, deliveries, created).Render(r.Context(), w); err != nil {
+		log.Error("failed to render webhooks card", slog.Any("error", err))
+	}
+}
+
+func webhooksError(w http.ResponseWriter, r *http.Request, err error) {
+	status := ErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Error("failed to manage webhooks", slog.Any("error", err))
+		http.Error(w, "failed to manage webhooks", status)
+		return
+	}
+	addFlash(w, r, err.Error(), flashTypeError)
+	w.WriteHeader(status)
+}
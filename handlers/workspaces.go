@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/middleware"
+	"github.com/zaibon/shortcut/services"
+	"github.com/zaibon/shortcut/templates"
+	"github.com/zaibon/shortcut/templates/components"
+)
+
+// sessionRedirectKey is the session key of the page a visitor goes back to
+// once logged in.
+const sessionRedirectKey = "redirect_after_login"
+
+type WorkspaceService interface {
+	Get(ctx context.Context, userID, workspaceID domain.ID) (domain.Workspace, error)
+	List(ctx context.Context, userID domain.ID) ([]domain.Workspace, error)
+	Create(ctx context.Context, userID domain.ID, name string) (domain.Workspace, error)
+
+	Members(ctx context.Context, ws domain.Workspace) ([]domain.WorkspaceMember, error)
+	UpdateMemberRole(ctx context.Context, ws domain.Workspace, userID domain.ID, role domain.Role) error
+	RemoveMember(ctx context.Context, ws domain.Workspace, actorID, userID domain.ID) error
+
+	Invitations(ctx context.Context, ws domain.Workspace) ([]domain.WorkspaceInvitation, error)
+	Invite(ctx context.Context, ws domain.Workspace, inviter domain.User, email string, role domain.Role) (domain.CreatedInvitation, error)
+	RevokeInvitation(ctx context.Context, ws domain.Workspace, invitationID domain.ID) error
+	Invitation(ctx context.Context, token string) (domain.WorkspaceInvitation, error)
+	AcceptInvitation(ctx context.Context, user domain.User, token string) (domain.Workspace, error)
+}
+
+// WorkspaceHandlers lets users switch between their workspaces, share them
+// and join the ones they are invited to.
+type WorkspaceHandlers struct {
+	svc            WorkspaceService
+	sessionManager *scs.SessionManager
+}
+
+func NewWorkspaceHandlers(svc WorkspaceService, sessionManager *scs.SessionManager) *WorkspaceHandlers {
+	return &WorkspaceHandlers{
+		svc:            svc,
+		sessionManager: sessionManager,
+	}
+}
+
+func (h *WorkspaceHandlers) Routes(r chi.Router) {
+	r.Get("/invitations/{token}", h.invitation)
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Authenticated)
+		r.Post("/invitations/{token}", h.acceptInvitation)
+
+		r.Get("/workspace", h.settings)
+		r.Get("/workspace/members", h.members)
+		r.Put("/workspace/members/{id}", h.updateMemberRole)
+		r.Delete("/workspace/members/{id}", h.removeMember)
+		r.Post("/workspace/invitations", h.invite)
+		r.Delete("/workspace/invitations/{id}", h.revokeInvitation)
+
+		r.Get("/workspaces/switcher", h.switcher)
+		r.Post("/workspaces", h.createWorkspace)
+		r.Post("/workspaces/{id}/switch", h.switchWorkspace)
+	})
+}
+
+// requireRole only lets through the members whose role in the current
+// workspace is at least role.
+func requireRole(role domain.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ws := middleware.WorkspaceFromContext(r.Context())
+			if ws == nil || !ws.Role.Can(role) {
+				addFlash(w, r, services.ErrWorkspaceForbidden.Error(), flashTypeError)
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (h *WorkspaceHandlers) switcher(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	workspaces, err := h.svc.List(r.Context(), user.ID)
+	if err != nil {
+		workspacesError(w, r, err)
+		return
+	}
+
+	if err := components.WorkspaceSwitcher(*ws, workspaces).Render(r.Context(), w); err != nil {
+		log.Error("failed to render workspace switcher", slog.Any("error", err))
+	}
+}
+
+func (h *WorkspaceHandlers) createWorkspace(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	ws, err := h.svc.Create(r.Context(), user.ID, r.FormValue("name"))
+	if err != nil {
+		workspacesError(w, r, err)
+		return
+	}
+
+	h.sessionManager.Put(r.Context(), middleware.SessionWorkspaceKey, int(ws.ID))
+	HXRedirect(r.Context(), w, "/workspace")
+}
+
+func (h *WorkspaceHandlers) switchWorkspace(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid workspace id", http.StatusBadRequest)
+		return
+	}
+
+	// only switch to the workspaces the user is a member of
+	ws, err := h.svc.Get(r.Context(), user.ID, domain.ID(id))
+	if err != nil {
+		workspacesError(w, r, err)
+		return
+	}
+
+	h.sessionManager.Put(r.Context(), middleware.SessionWorkspaceKey, int(ws.ID))
+	HXRedirect(r.Context(), w, "/urls")
+}
+
+func (h *WorkspaceHandlers) settings(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	if err := templates.WorkspacePage(*ws).Render(r.Context(), w); err != nil {
+		log.Error("failed to render workspace page", slog.Any("error", err))
+	}
+}
+
+func (h *WorkspaceHandlers) members(w http.ResponseWriter, r *http.Request) {
+	h.renderMembers(w, r, nil)
+}
+
+func (h *WorkspaceHandlers) updateMemberRole(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid member id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.UpdateMemberRole(r.Context(), *ws, domain.ID(id), domain.Role(r.FormValue("role"))); err != nil {
+		workspacesError(w, r, err)
+		return
+	}
+
+	addFlash(w, r, "Role updated", flashTypeInfo)
+	h.renderMembers(w, r, nil)
+}
+
+func (h *WorkspaceHandlers) removeMember(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid member id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.RemoveMember(r.Context(), *ws, user.ID, domain.ID(id)); err != nil {
+		workspacesError(w, r, err)
+		return
+	}
+
+	if domain.ID(id) == user.ID {
+		// back to the personal workspace
+		h.sessionManager.Remove(r.Context(), middleware.SessionWorkspaceKey)
+		HXRedirect(r.Context(), w, "/urls")
+		return
+	}
+
+	addFlash(w, r, "Member removed", flashTypeInfo)
+	h.renderMembers(w, r, nil)
+}
+
+func (h *WorkspaceHandlers) invite(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	created, err := h.svc.Invite(r.Context(), *ws, *user, r.FormValue("email"), domain.Role(r.FormValue("role")))
+	if err != nil {
+		workspacesError(w, r, err)
+		return
+	}
+
+	if created.Sent {
+		addFlash(w, r, fmt.Sprintf("Invitation sent to %s", created.Email), flashTypeInfo)
+	}
+	h.renderMembers(w, r, &created)
+}
+
+func (h *WorkspaceHandlers) revokeInvitation(w http.ResponseWriter, r *http.Request) {
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid invitation id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.svc.RevokeInvitation(r.Context(), *ws, domain.ID(id)); err != nil {
+		workspacesError(w, r, err)
+		return
+	}
+
+	addFlash(w, r, "Invitation revoked", flashTypeInfo)
+	h.renderMembers(w, r, nil)
+}
+
+func (h *WorkspaceHandlers) renderMembers(w http.ResponseWriter, r *http.Request, created *domain.CreatedInvitation) {
+	user := middleware.UserFromContext(r.Context())
+	ws := middleware.WorkspaceFromContext(r.Context())
+
+	members, err := h.svc.Members(r.Context(), *ws)
+	if err != nil {
+		workspacesError(w, r, err)
+		return
+	}
+	// only the owners see the pending invitations
+	var invitations []domain.WorkspaceInvitation
+	if ws.Role.Can(domain.RoleOwner) {
+		if invitations, err = h.svc.Invitations(r.Context(), *ws); err != nil {
+			workspacesError(w, r, err)
+			return
+		}
+	}
+
+	if err := components.WorkspaceMembersCard(*ws, user.ID, members, invitations, created).Render(r.Context(), w); err != nil {
+		log.Error("failed to render workspace members card", slog.Any("error", err))
+	}
+}
+
+// invitation shows the invitation of the link token. Visitors who aren't
+// logged in come back to it after logging in.
+func (h *WorkspaceHandlers) invitation(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	inv, err := h.svc.Invitation(r.Context(), token)
+	if errors.Is(err, services.ErrInvitationNotFound) {
+		NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Error("failed to get invitation", slog.Any("error", err))
+		http.Error(w, "failed to get invitation", http.StatusInternalServerError)
+		return
+	}
+
+	user := middleware.UserFromContext(r.Context())
+	if user == nil {
+		h.sessionManager.Put(r.Context(), sessionRedirectKey, r.URL.Path)
+	}
+
+	if err := templates.InvitationPage(inv, token, user).Render(r.Context(), w); err != nil {
+		log.Error("failed to render invitation page", slog.Any("error", err))
+	}
+}
+
+func (h *WorkspaceHandlers) acceptInvitation(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	ws, err := h.svc.AcceptInvitation(r.Context(), *user, chi.URLParam(r, "token"))
+	if err != nil {
+		workspacesError(w, r, err)
+		return
+	}
+
+	h.sessionManager.Put(r.Context(), middleware.SessionWorkspaceKey, int(ws.ID))
+	HXRedirect(r.Context(), w, "/urls")
+}
+
+func workspacesError(w http.ResponseWriter, r *http.Request, err error) {
+	status := ErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Error("failed to manage workspace", slog.Any("error", err))
+		http.Error(w, "failed to manage workspace", status)
+		return
+	}
+	addFlash(w, r, err.Error(), flashTypeError)
+	w.WriteHeader(status)
+}
@@ -15,6 +15,9 @@ type ContextKey string
 // PaginationParamsKey is the context key for pagination params.
 const PaginationParamsKey ContextKey = "paginationParams"
 
+// maxPageSize is the largest page size a request can ask for.
+const maxPageSize = 100
+
 // PaginationParams holds pagination parameters.
 type PaginationParams struct {
 	Page         int
@@ -39,8 +42,8 @@ func (q *PaginationParams) Limit() int {
 
 	if limit <= 0 {
 		limit = 10
-	} else if limit > 100 {
-		limit = 100
+	} else if limit > maxPageSize {
+		limit = maxPageSize
 	}
 
 	return limit
@@ -82,7 +85,7 @@ func PaginateParams(next http.Handler) http.Handler {
 		if pageSizeStr != "" {
 			ps, err := strconv.Atoi(pageSizeStr)
 			if err == nil && ps > 0 {
-				pageSize = ps
+				pageSize = min(ps, maxPageSize)
 			}
 		}
 
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8, 192.0.2.1")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		realIP       string
+		wantRemoteIP string
+	}{
+		{"direct", "203.0.113.7:1234", nil, "", "203.0.113.7:1234"},
+		{"untrusted client", "203.0.113.7:1234", []string{"198.51.100.1"}, "198.51.100.1", "203.0.113.7:1234"},
+		{"trusted proxy", "10.0.0.2:1234", []string{"198.51.100.1"}, "", "198.51.100.1"},
+		{"spoofed hops are skipped", "10.0.0.2:1234", []string{"1.2.3.4, 198.51.100.1"}, "", "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.2:1234", []string{"1.2.3.4, 198.51.100.1, 192.0.2.1", "10.0.0.3"}, "", "198.51.100.1"},
+		{"every hop trusted", "10.0.0.2:1234", []string{"10.0.0.4, 10.0.0.3"}, "", "10.0.0.4"},
+		{"x-real-ip", "192.0.2.1:1234", nil, "198.51.100.1", "198.51.100.1"},
+		{"invalid header", "10.0.0.2:1234", []string{"unknown"}, "", "10.0.0.2:1234"},
+		{"ipv6 proxy", "[::ffff:10.0.0.2]:1234", []string{"2001:db8::1"}, "", "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got string
+			h := RealIP(trusted)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			assert.Equal(t, tt.wantRemoteIP, got)
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	prefixes, err := ParseTrustedProxies("")
+	assert.NoError(t, err)
+	assert.Empty(t, prefixes)
+
+	prefixes, err = ParseTrustedProxies("10.1.2.3/8,2001:db8::1")
+	assert.NoError(t, err)
+	if assert.Len(t, prefixes, 2) {
+		assert.Equal(t, "10.0.0.0/8", prefixes[0].String())
+		assert.Equal(t, "2001:db8::1/128", prefixes[1].String())
+	}
+
+	_, err = ParseTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies("proxy.internal")
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, token string) (*domain.User, error)
+}
+
+// TokenContext resolves an `Authorization: Bearer` API token to its owner and
+// populates the context the same way UserContext does for session cookies.
+// Requests with an invalid token continue anonymously so the authentication
+// middlewares downstream can reject them.
+func TokenContext(auth TokenAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, err := auth.AuthenticateToken(r.Context(), token)
+			if err != nil {
+				slog.Warn("failed to authenticate api token", "error", err)
+				next.ServeHTTP(w, r.WithContext(withoutUser(r.Context())))
+				return
+			}
+
+			ctx := WithUser(r.Context(), *user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// withoutUser drops any user set by a previous middleware, so that a bad token
+// never silently falls back to the session cookie.
+func withoutUser(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextUser, nil)
+}
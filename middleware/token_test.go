@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+type stubTokenAuthenticator map[string]domain.User
+
+func (s stubTokenAuthenticator) AuthenticateToken(_ context.Context, token string) (*domain.User, error) {
+	user, ok := s[token]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	return &user, nil
+}
+
+func TestTokenContext(t *testing.T) {
+	auth := stubTokenAuthenticator{"sct_valid": {ID: 42}}
+	sessionUser := &domain.User{ID: 7}
+
+	tests := []struct {
+		name        string
+		header      string
+		sessionUser *domain.User
+		wantUserID  domain.ID
+		wantUser    bool
+	}{
+		{"no header", "", nil, 0, false},
+		{"no header keeps session user", "", sessionUser, 7, true},
+		{"valid token", "Bearer sct_valid", nil, 42, true},
+		{"case insensitive scheme", "bearer sct_valid", nil, 42, true},
+		{"token wins over session", "Bearer sct_valid", sessionUser, 42, true},
+		{"invalid token", "Bearer sct_nope", nil, 0, false},
+		{"invalid token drops session user", "Bearer sct_nope", sessionUser, 0, false},
+		{"basic auth ignored", "Basic dXNlcjpwYXNz", nil, 0, false},
+		{"empty bearer", "Bearer ", nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got *domain.User
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = UserFromContext(r.Context())
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/links", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			if tt.sessionUser != nil {
+				r = r.WithContext(WithUser(r.Context(), *tt.sessionUser))
+			}
+
+			TokenContext(auth)(next).ServeHTTP(httptest.NewRecorder(), r)
+
+			if !tt.wantUser {
+				assert.Nil(t, got)
+				return
+			}
+			if assert.NotNil(t, got) {
+				assert.Equal(t, tt.wantUserID, got.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/alexedwards/scs/v2"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+var contextWorkspace = contextKey("workspace")
+
+// SessionWorkspaceKey is the session key of the workspace picked in the
+// workspace switcher.
+const SessionWorkspaceKey = "workspace_id"
+
+type WorkspaceFetcher interface {
+	Personal(ctx context.Context, user domain.User) (domain.Workspace, error)
+	Get(ctx context.Context, userID, workspaceID domain.ID) (domain.Workspace, error)
+}
+
+func WorkspaceFromContext(ctx context.Context) *domain.Workspace {
+	if ws, ok := ctx.Value(contextWorkspace).(*domain.Workspace); ok {
+		return ws
+	}
+	return nil
+}
+
+func WithWorkspace(ctx context.Context, ws domain.Workspace) context.Context {
+	return context.WithValue(ctx, contextWorkspace, &ws)
+}
+
+// WorkspaceContext puts the current workspace of the user in the context: the
+// one picked in the switcher, their personal workspace when they didn't pick
+// any or are no longer a member of it. It must come after UserContext and
+// TokenContext.
+func WorkspaceContext(sessionManager *scs.SessionManager, store WorkspaceFetcher) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			user := UserFromContext(r.Context())
+			if user == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if id := sessionManager.GetInt(r.Context(), SessionWorkspaceKey); id != 0 {
+				ws, err := store.Get(r.Context(), user.ID, domain.ID(id))
+				if err == nil {
+					next.ServeHTTP(w, r.WithContext(WithWorkspace(r.Context(), ws)))
+					return
+				}
+				slog.Warn("failed to fetch workspace from session", "workspace_id", id, "error", err)
+				sessionManager.Remove(r.Context(), SessionWorkspaceKey)
+			}
+
+			ws, err := store.Personal(r.Context(), *user)
+			if err != nil {
+				slog.Error("failed to fetch personal workspace", "user_id", user.ID, "error", err)
+				http.Error(w, "failed to load workspace", http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithWorkspace(r.Context(), ws)))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
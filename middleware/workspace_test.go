@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+type stubWorkspaceFetcher struct {
+	personal domain.Workspace
+	// member are the workspaces the user is a member of
+	member map[domain.ID]domain.Workspace
+}
+
+func (s stubWorkspaceFetcher) Personal(_ context.Context, _ domain.User) (domain.Workspace, error) {
+	return s.personal, nil
+}
+
+func (s stubWorkspaceFetcher) Get(_ context.Context, _, workspaceID domain.ID) (domain.Workspace, error) {
+	ws, ok := s.member[workspaceID]
+	if !ok {
+		return domain.Workspace{}, errors.New("workspace not found")
+	}
+	return ws, nil
+}
+
+func TestWorkspaceContext(t *testing.T) {
+	store := stubWorkspaceFetcher{
+		personal: domain.Workspace{ID: 1, Personal: true, Role: domain.RoleOwner},
+		member:   map[domain.ID]domain.Workspace{2: {ID: 2, Role: domain.RoleViewer}},
+	}
+
+	tests := []struct {
+		name            string
+		user            *domain.User
+		sessionID       int
+		wantWorkspaceID domain.ID
+		wantSessionID   int
+	}{
+		{name: "anonymous", user: nil, wantWorkspaceID: 0},
+		{name: "personal by default", user: &domain.User{ID: 7}, wantWorkspaceID: 1},
+		{name: "picked in the switcher", user: &domain.User{ID: 7}, sessionID: 2, wantWorkspaceID: 2, wantSessionID: 2},
+		{name: "no longer a member", user: &domain.User{ID: 7}, sessionID: 3, wantWorkspaceID: 1, wantSessionID: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sessionManager := scs.New()
+
+			var got *domain.Workspace
+			var gotSessionID int
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = WorkspaceFromContext(r.Context())
+				gotSessionID = sessionManager.GetInt(r.Context(), SessionWorkspaceKey)
+			})
+			setup := func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if tt.sessionID != 0 {
+						sessionManager.Put(r.Context(), SessionWorkspaceKey, tt.sessionID)
+					}
+					if tt.user != nil {
+						r = r.WithContext(WithUser(r.Context(), *tt.user))
+					}
+					next.ServeHTTP(w, r)
+				})
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/urls", nil)
+			sessionManager.LoadAndSave(setup(WorkspaceContext(sessionManager, store)(next))).
+				ServeHTTP(httptest.NewRecorder(), r)
+
+			if tt.wantWorkspaceID == 0 {
+				assert.Nil(t, got)
+				return
+			}
+			if assert.NotNil(t, got) {
+				assert.Equal(t, tt.wantWorkspaceID, got.ID)
+			}
+			assert.Equal(t, tt.wantSessionID, gotSessionID)
+		})
+	}
+}
@@ -12,16 +12,22 @@ import (
 
 	"github.com/zaibon/shortcut/db/datastore"
 	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
 )
 
 type Administration struct {
-	db     datastore.Querier
+	db    datastore.Querier
+	cache *linkCache
+	// events is told about the links disabled by moderation, it may be nil
+	events LinkEvents
 	domain string
 }
 
-func NewAdministrationService(db datastore.DBTX, domain string) *Administration {
+func NewAdministrationService(db datastore.DBTX, cache *linkCache, events LinkEvents, domain string) *Administration {
 	return &Administration{
 		db:     datastore.New(db),
+		cache:  cache,
+		events: events,
 		domain: domain,
 	}
 }
@@ -203,7 +209,7 @@ func (s *Administration) ListURLs(ctx context.Context, filter domain.AdminURLFil
 				ID:         domain.ID(row.Url.ID),
 				Title:      row.Url.Title,
 				Long:       row.Url.LongUrl,
-				Short:      toURL(s.domain, row.Url.ShortUrl),
+				Short:      toURL(s.domain, row.Url.Domain, row.Url.ShortUrl),
 				Slug:       row.Url.ShortUrl,
 				IsArchived: row.Url.IsArchived.Bool,
 				IsActive:   row.Url.IsActive,
@@ -232,7 +238,7 @@ func (s *Administration) GetUserURLs(ctx context.Context, guid domain.GUID) ([]d
 				ID:         domain.ID(row.Url.ID),
 				Title:      row.Url.Title,
 				Long:       row.Url.LongUrl,
-				Short:      toURL(s.domain, row.Url.ShortUrl),
+				Short:      toURL(s.domain, row.Url.Domain, row.Url.ShortUrl),
 				Slug:       row.Url.ShortUrl,
 				IsArchived: row.Url.IsArchived.Bool,
 				IsActive:   row.Url.IsActive,
@@ -363,7 +369,7 @@ func (s *Administration) GetAnalyticsStats(ctx context.Context) (*domain.AdminAn
 	var topURLs []domain.TopURL
 	for _, u := range topURLsRows {
 		topURLs = append(topURLs, domain.TopURL{
-			ShortURL: toURL(s.domain, u.ShortUrl),
+			ShortURL: toURL(s.domain, u.Domain, u.ShortUrl),
 			LongURL:  u.LongUrl,
 			Clicks:   int(u.Clicks),
 		})
@@ -390,10 +396,12 @@ func (s *Administration) GetAnalyticsStats(ctx context.Context) (*domain.AdminAn
 }
 
 func (s *Administration) DeleteURL(ctx context.Context, id domain.ID) error {
+	defer s.cache.InvalidateID(id)
 	return s.db.AdminDeleteURL(ctx, int32(id))
 }
 
 func (s *Administration) ToggleURLStatus(ctx context.Context, id domain.ID, isArchived, isActive bool) error {
+	defer s.cache.InvalidateID(id)
 	return s.db.AdminUpdateURLStatus(ctx, datastore.AdminUpdateURLStatusParams{
 		ID:         int32(id),
 		IsArchived: pgtype.Bool{Bool: isArchived, Valid: true},
@@ -412,6 +420,8 @@ func (s *Administration) ToggleUserSuspension(ctx context.Context, guid domain.G
 }
 
 func (s *Administration) ToggleUserURLsStatus(ctx context.Context, guid domain.GUID, isActive bool) error {
+	// the cache doesn't know who owns its links
+	defer s.cache.Purge()
 	return s.db.AdminToggleUserURLs(ctx, datastore.AdminToggleUserURLsParams{
 		Guid: pgtype.UUID{
 			Bytes: guid,
@@ -421,11 +431,15 @@ func (s *Administration) ToggleUserURLsStatus(ctx context.Context, guid domain.G
 	})
 }
 
-func (s *Administration) UpdateURL(ctx context.Context, id domain.ID, title, longURL string) error {
+// UpdateURL edits a link on behalf of the admin changedBy. The previous
+// destination is kept in the link history.
+func (s *Administration) UpdateURL(ctx context.Context, id, changedBy domain.ID, title, longURL string) error {
+	defer s.cache.InvalidateID(id)
 	_, err := s.db.AdminUpdateURL(ctx, datastore.AdminUpdateURLParams{
-		ID:      int32(id),
-		Title:   title,
-		LongUrl: longURL,
+		ID:        int32(id),
+		Title:     title,
+		LongUrl:   longURL,
+		ChangedBy: pgtype.Int4{Int32: int32(changedBy), Valid: changedBy != 0},
 	})
 	return err
 }
@@ -435,25 +449,28 @@ func (s *Administration) GetURL(ctx context.Context, id domain.ID) (domain.URL,
 	if err != nil {
 		return domain.URL{}, err
 	}
+	return s.toURL(row), nil
+}
+
+func (s *Administration) toURL(row datastore.Url) domain.URL {
 	return domain.URL{
 		ID:         domain.ID(row.ID),
 		Title:      row.Title,
 		Long:       row.LongUrl,
-		Short:      toURL(s.domain, row.ShortUrl),
+		Short:      toURL(s.domain, row.Domain, row.ShortUrl),
 		Slug:       row.ShortUrl,
 		IsArchived: row.IsArchived.Bool,
 		IsActive:   row.IsActive,
 		CreatedAt:  row.CreatedAt.Time,
-	}, nil
+	}
 }
 
-func (s *Administration) GetURLStats(ctx context.Context, slug string) (domain.URLStat, error) {
-	url, err := s.db.GetShortURL(ctx, slug)
+func (s *Administration) GetURLStats(ctx context.Context, urlID domain.ID) (domain.URLStat, error) {
+	url, err := s.db.GetByID(ctx, int32(urlID))
 	if err != nil {
 		return domain.URLStat{}, fmt.Errorf("failed to get url: %w", err)
 	}
-	urlID := domain.ID(url.ID)
-	authorID := domain.ID(url.AuthorID)
+	workspaceID := domain.ID(url.WorkspaceID)
 
 	var (
 		g, gCtx = errgroup.WithContext(ctx)
@@ -462,8 +479,8 @@ func (s *Administration) GetURLStats(ctx context.Context, slug string) (domain.U
 				ID:         domain.ID(url.ID),
 				Title:      url.Title,
 				Long:       url.LongUrl,
-				Short:      toURL(s.domain, url.ShortUrl),
-				Slug:       slug,
+				Short:      toURL(s.domain, url.Domain, url.ShortUrl),
+				Slug:       url.ShortUrl,
 				IsArchived: url.IsArchived.Bool,
 				IsActive:   url.IsActive,
 				CreatedAt:  url.CreatedAt.Time,
@@ -474,8 +491,8 @@ func (s *Administration) GetURLStats(ctx context.Context, slug string) (domain.U
 
 	g.Go(func() error {
 		ld, err := s.db.LocationDistribution(gCtx, datastore.LocationDistributionParams{
-			UrlID:    int32(urlID),
-			AuthorID: int32(authorID),
+			UrlID:       int32(urlID),
+			WorkspaceID: int32(workspaceID),
 		})
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get location distribution: %w", err)
@@ -498,8 +515,8 @@ func (s *Administration) GetURLStats(ctx context.Context, slug string) (domain.U
 
 	g.Go(func() error {
 		bd, err := s.db.BrowserDistribution(gCtx, datastore.BrowserDistributionParams{
-			UrlID:    int32(urlID),
-			AuthorID: int32(authorID),
+			UrlID:       int32(urlID),
+			WorkspaceID: int32(workspaceID),
 		})
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get browser distribution: %w", err)
@@ -516,8 +533,8 @@ func (s *Administration) GetURLStats(ctx context.Context, slug string) (domain.U
 
 	g.Go(func() error {
 		dd, err := s.db.DeviceDistribution(gCtx, datastore.DeviceDistributionParams{
-			UrlID:    int32(urlID),
-			AuthorID: int32(authorID),
+			UrlID:       int32(urlID),
+			WorkspaceID: int32(workspaceID),
 		})
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get device distribution: %w", err)
@@ -534,8 +551,8 @@ func (s *Administration) GetURLStats(ctx context.Context, slug string) (domain.U
 
 	g.Go(func() error {
 		rd, err := s.db.ReferrerDistribution(gCtx, datastore.ReferrerDistributionParams{
-			UrlID:    int32(urlID),
-			AuthorID: int32(authorID),
+			UrlID:       int32(urlID),
+			WorkspaceID: int32(workspaceID),
 		})
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get referer distribution: %w", err)
@@ -551,7 +568,7 @@ func (s *Administration) GetURLStats(ctx context.Context, slug string) (domain.U
 	})
 
 	g.Go(func() error {
-		total, err := s.db.TotalVisit(gCtx, int32(urlID))
+		total, err := s.db.TotalVisit(gCtx, datastore.TotalVisitParams{UrlID: int32(urlID)})
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get total visit: %w", err)
 		}
@@ -563,7 +580,7 @@ func (s *Administration) GetURLStats(ctx context.Context, slug string) (domain.U
 	})
 
 	g.Go(func() error {
-		unique, err := s.db.UniqueVisitCount(gCtx, int32(urlID))
+		unique, err := s.db.UniqueVisitCount(gCtx, datastore.UniqueVisitCountParams{UrlID: int32(urlID)})
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get unique visit count: %w", err)
 		}
@@ -576,34 +593,31 @@ func (s *Administration) GetURLStats(ctx context.Context, slug string) (domain.U
 	})
 
 	g.Go(func() error {
-		now := time.Now()
-		since := now.AddDate(0, 0, -1).Truncate(time.Hour)
-		until := now
-		period := domain.Period{Since: since, Until: until}
-
-		visitsPerDay, err := s.db.VisitOverTime(gCtx, datastore.VisitOverTimeParams{
-			TimeTrunc: "hour",
-			StartDate: pgtype.Timestamp{Time: since, Valid: true},
-			EndDate:   pgtype.Timestamp{Time: until, Valid: true},
-			UrlID:     int32(urlID),
-		})
-		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("failed to get unique visit count: %w", err)
-		}
-
-		if errors.Is(err, pgx.ErrNoRows) {
-			visitsPerDay = []datastore.VisitOverTimeRow{}
-		}
-
-		var domainVisits []domain.TimeSeriesData
-		for _, v := range visitsPerDay {
-			domainVisits = append(domainVisits, domain.TimeSeriesData{
-				Time:  v.VisitDate.Time,
-				Count: v.VisitCount,
+		series, err := visitSeries(domain.StatsFilter{}, time.Now(), func(period domain.Period, bucket domain.Bucket) ([]domain.TimeSeriesData, error) {
+			rows, err := s.db.VisitOverTime(gCtx, datastore.VisitOverTimeParams{
+				TimeTrunc: string(bucket),
+				Timezone:  "UTC",
+				StartDate: pgtype.Timestamp{Time: period.Since.UTC(), Valid: true},
+				EndDate:   pgtype.Timestamp{Time: period.Until.UTC(), Valid: true},
+				UrlID:     int32(urlID),
 			})
+			if err != nil {
+				return nil, err
+			}
+
+			data := make([]domain.TimeSeriesData, 0, len(rows))
+			for _, v := range rows {
+				data = append(data, domain.TimeSeriesData{
+					Time:  v.VisitDate.Time,
+					Count: v.VisitCount,
+				})
+			}
+			return data, nil
+		})
+		if err != nil {
+			return err
 		}
-		stats.VisitPerDay = visitPerDay(domainVisits, period, time.Hour)
-
+		stats.VisitsOverTime = series
 		return nil
 	})
 
@@ -677,6 +691,7 @@ func (s *Administration) ReviewModerationFlag(ctx context.Context, flagID int, s
 	}
 
 	// 4. Update URL and user suspension based on review decision
+	defer s.cache.InvalidateID(domain.ID(flag.UrlID))
 	if status == "approved" {
 		err = s.db.AdminUpdateURLStatus(ctx, datastore.AdminUpdateURLStatusParams{
 			ID:       flag.UrlID,
@@ -709,6 +724,15 @@ func (s *Administration) ReviewModerationFlag(ctx context.Context, flagID int, s
 		if err != nil {
 			return fmt.Errorf("failed to suspend user %d: %w", flag.UserID, err)
 		}
+
+		if s.events != nil {
+			// the link is disabled already, failing to notify doesn't undo it
+			if row, err := s.db.GetByID(ctx, flag.UrlID); err != nil {
+				log.Error("failed to fetch disabled URL", "url_id", flag.UrlID, "err", err)
+			} else {
+				s.events.LinkDisabled(ctx, domain.ID(row.WorkspaceID), s.toURL(row))
+			}
+		}
 	}
 
 	return nil
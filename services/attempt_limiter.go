@@ -0,0 +1,126 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// attemptLimiter counts attempts per key over fixed windows. Keys past their
+// limit are refused until their window ends, or slowed down to one attempt per
+// turn.
+//
+// A nil *attemptLimiter is a valid limiter that allows everything.
+type attemptLimiter struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	windows map[string]*attemptWindow
+	// sweepAt is the number of windows past which the ended ones are dropped
+	sweepAt int
+}
+
+type attemptWindow struct {
+	count int
+	ends  time.Time
+	// next is the start of the next free turn.
+	next time.Time
+}
+
+const minAttemptSweep = 1024
+
+func newAttemptLimiter(window time.Duration) *attemptLimiter {
+	return &attemptLimiter{
+		window:  window,
+		now:     time.Now,
+		windows: make(map[string]*attemptWindow),
+		sweepAt: minAttemptSweep,
+	}
+}
+
+// Allow records an attempt of key and reports whether it is within the limit
+// attempts of the current window.
+func (l *attemptLimiter) Allow(key string, limit int) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.exceeded(key, limit) {
+		return false
+	}
+	l.add(key)
+	return true
+}
+
+// Turn returns how long an attempt of key must wait before it runs. Once key
+// used its limit attempts in the current window, the attempts take turns every
+// interval. ok is false, and no turn is taken, when the wait would be longer
+// than maxWait.
+func (l *attemptLimiter) Turn(key string, limit int, interval, maxWait time.Duration) (wait time.Duration, ok bool) {
+	if l == nil {
+		return 0, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.exceeded(key, limit) {
+		return 0, true
+	}
+	w := l.windows[key]
+	now := l.now()
+	next := w.next
+	if next.Before(now) {
+		next = now
+	}
+	if wait = next.Sub(now); wait > maxWait {
+		return 0, false
+	}
+	w.next = next.Add(interval)
+	return wait, true
+}
+
+// Add records an attempt of key.
+func (l *attemptLimiter) Add(key string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.add(key)
+}
+
+func (l *attemptLimiter) exceeded(key string, limit int) bool {
+	w, ok := l.windows[key]
+	return ok && l.now().Before(w.ends) && w.count >= limit
+}
+
+func (l *attemptLimiter) add(key string) {
+	now := l.now()
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.ends) {
+		w = &attemptWindow{ends: now.Add(l.window)}
+		l.windows[key] = w
+		l.sweep(now)
+	}
+	w.count++
+}
+
+// sweep drops the ended windows once there are more than sweepAt of them, so
+// keys seen once don't pile up.
+func (l *attemptLimiter) sweep(now time.Time) {
+	if len(l.windows) <= l.sweepAt {
+		return
+	}
+	for key, w := range l.windows {
+		if !now.Before(w.ends) {
+			delete(l.windows, key)
+		}
+	}
+	l.sweepAt = max(minAttemptSweep, 2*len(l.windows))
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttemptLimiter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := newAttemptLimiter(time.Minute)
+	l.now = func() time.Time { return now }
+
+	assert.True(t, l.Allow("a", 2))
+	assert.True(t, l.Allow("a", 2))
+	assert.False(t, l.Allow("a", 2))
+	assert.True(t, l.Allow("b", 2), "keys are counted apart")
+
+	l.Add("c")
+	wait, ok := l.Turn("c", 2, time.Second, 2*time.Second)
+	assert.True(t, ok)
+	assert.Zero(t, wait, "attempts within the limit don't wait")
+	l.Add("c")
+	for _, want := range []time.Duration{0, time.Second, 2 * time.Second} {
+		wait, ok = l.Turn("c", 2, time.Second, 2*time.Second)
+		assert.True(t, ok)
+		assert.Equal(t, want, wait, "attempts past the limit take turns")
+	}
+	_, ok = l.Turn("c", 2, time.Second, 2*time.Second)
+	assert.False(t, ok, "turns are not given past maxWait")
+	now = now.Add(time.Second)
+	wait, ok = l.Turn("c", 2, time.Second, 2*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, wait)
+
+	now = now.Add(time.Minute)
+	assert.True(t, l.Allow("a", 2), "a new window starts")
+	wait, ok = l.Turn("c", 2, time.Second, 0)
+	assert.True(t, ok)
+	assert.Zero(t, wait)
+
+	var disabled *attemptLimiter
+	disabled.Add("a")
+	assert.True(t, disabled.Allow("a", 0))
+	wait, ok = disabled.Turn("a", 0, time.Second, 0)
+	assert.True(t, ok)
+	assert.Zero(t, wait)
+}
+
+func TestAttemptLimiterSweep(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := newAttemptLimiter(time.Minute)
+	l.now = func() time.Time { return now }
+
+	for i := range minAttemptSweep - 1 {
+		l.Add(fmt.Sprint(i))
+	}
+	now = now.Add(time.Minute)
+	l.Add("late")
+	assert.Len(t, l.windows, minAttemptSweep, "ended windows are kept below the threshold")
+
+	l.Add("later")
+	assert.Len(t, l.windows, 2, "ended windows are dropped past the threshold")
+}
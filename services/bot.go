@@ -0,0 +1,55 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/mssola/user_agent"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+// botUserAgents are fragments, in lower case, of the user agents of link
+// previews, crawlers, uptime monitors, headless browsers and HTTP libraries.
+// Most crawlers are caught by the generic "bot/" and "+http" fragments, the
+// latter being the contact URL they advertise.
+var botUserAgents = []string{
+	"bot/", "bot-", "bot;", "+http", "crawler", "spider", "slurp",
+
+	// link previews
+	"facebookexternalhit", "facebookcatalog", "meta-externalagent", "telegrambot",
+	"whatsapp/", "skypeuripreview", "slack-imgproxy", "embedly", "iframely",
+	"quora link preview", "vkshare", "pinterest/",
+
+	// uptime monitors
+	"pingdom", "uptimerobot", "statuscake", "site24x7", "uptime-kuma",
+	"betteruptime", "datadog", "newrelic",
+
+	// headless browsers
+	"headlesschrome", "phantomjs", "puppeteer", "playwright", "selenium", "lighthouse",
+
+	// http libraries
+	"curl/", "wget/", "python-requests", "python-urllib", "aiohttp", "httpx",
+	"go-http-client", "okhttp", "axios/", "node-fetch", "undici", "java/",
+	"apache-httpclient", "libwww-perl", "scrapy",
+}
+
+// IsBot reports whether a visit comes from a program rather than a person:
+// its user agent is missing or belongs to a known bot, or it comes from a
+// datacenter, a proxy or the Tor network. The network is only known when
+// loc comes from ipquery.
+func IsBot(info *domain.RequestInfo, loc *domain.IPLocation) bool {
+	if loc != nil && (loc.IsDatacenter || loc.IsProxy || loc.IsTor) {
+		return true
+	}
+
+	ua := strings.ToLower(strings.TrimSpace(info.UserAgent()))
+	if ua == "" {
+		return true
+	}
+	for _, fragment := range botUserAgents {
+		if strings.Contains(ua, fragment) {
+			return true
+		}
+	}
+	return user_agent.New(info.UserAgent()).Bot()
+}
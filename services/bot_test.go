@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+func TestIsBot(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		location  *domain.IPLocation
+		want      bool
+	}{
+		{"iphone", iphoneUA, nil, false},
+		{"android", androidUA, nil, false},
+		{"windows", windowsUA, nil, false},
+		{"mac", macUA, &domain.IPLocation{CountryCode: "US"}, false},
+		{"no user agent", "", nil, true},
+		{"slack", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", nil, true},
+		{"twitter", "Twitterbot/1.0", nil, true},
+		{"facebook", "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", nil, true},
+		{"discord", "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", nil, true},
+		{"google", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", nil, true},
+		{"telegram", "TelegramBot (like TwitterBot)", nil, true},
+		{"uptime robot", "Mozilla/5.0+(compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)", nil, true},
+		{"headless chrome", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.0.0 Safari/537.36", nil, true},
+		{"curl", "curl/8.4.0", nil, true},
+		{"go", "Go-http-client/1.1", nil, true},
+		{"datacenter", windowsUA, &domain.IPLocation{IsDatacenter: true}, true},
+		{"proxy", iphoneUA, &domain.IPLocation{IsProxy: true}, true},
+		{"tor", windowsUA, &domain.IPLocation{IsTor: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			info := domain.NewRequestInfo("203.0.113.7", tt.userAgent, "", "", "")
+			assert.Equal(t, tt.want, IsBot(info, tt.location))
+		})
+	}
+}
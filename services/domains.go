@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+// DomainStore persists the custom domains of workspaces.
+type DomainStore interface {
+	ListDomains(ctx context.Context, workspaceID domain.ID) ([]domain.CustomDomain, error)
+	GetDomain(ctx context.Context, workspaceID, domainID domain.ID) (domain.CustomDomain, error)
+	CountDomains(ctx context.Context, workspaceID domain.ID) (int, error)
+	InsertDomain(ctx context.Context, workspaceID domain.ID, hostname, token string) (domain.CustomDomain, error)
+	VerifyDomain(ctx context.Context, workspaceID, domainID domain.ID) (domain.CustomDomain, error)
+	DeleteDomain(ctx context.Context, workspaceID, domainID domain.ID) (bool, error)
+	CountDomainURLs(ctx context.Context, workspaceID domain.ID, hostname string) (int, error)
+}
+
+// TXTResolver looks up the TXT records of a name, net.DefaultResolver is
+// one.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+const (
+	// maxDomains bounds the custom domains of a workspace.
+	maxDomains       = 5
+	maxHostnameLen   = 253
+	domainTokenBytes = 16
+
+	// domainsWorkspaceHostnameKey is the unique constraint on the domains a
+	// workspace added, domainsVerifiedHostnameKey the unique index on the
+	// verified ones.
+	domainsWorkspaceHostnameKey = "domains_workspace_id_hostname_key"
+	domainsVerifiedHostnameKey  = "idx_domains_verified_hostname"
+)
+
+var (
+	ErrInvalidDomain      = errors.New("invalid domain name, enter a hostname such as go.example.com")
+	ErrTooManyDomains     = fmt.Errorf("a workspace can have at most %d custom domains", maxDomains)
+	ErrDomainNotFound     = errors.New("domain not found")
+	ErrDomainExists       = errors.New("this domain was already added to the workspace")
+	ErrDomainTaken        = errors.New("this domain is already used by another workspace")
+	ErrDomainVerification = errors.New("the TXT record proving the ownership of the domain was not found, DNS changes can take a while to propagate")
+	ErrDomainInUse        = errors.New("links are still served on this domain, delete them first")
+	ErrDomainNotVerified  = errors.New("links can only be served on a verified domain of the workspace")
+)
+
+var hostnameLabelPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// NormalizeHostname lowercases hostname and checks it is a fully qualified
+// domain name, without scheme, port nor path.
+func NormalizeHostname(hostname string) (string, error) {
+	hostname = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(hostname)), ".")
+	if hostname == "" || len(hostname) > maxHostnameLen {
+		return "", ErrInvalidDomain
+	}
+
+	labels := strings.Split(hostname, ".")
+	if len(labels) < 2 {
+		return "", ErrInvalidDomain
+	}
+	for _, label := range labels {
+		if !hostnameLabelPattern.MatchString(label) {
+			return "", ErrInvalidDomain
+		}
+	}
+	// a numeric top level domain is an IP address
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return "", ErrInvalidDomain
+	}
+	return hostname, nil
+}
+
+// requestHostname returns the lowercase hostname of the Host header host,
+// without its port.
+func requestHostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+type domainService struct {
+	store    DomainStore
+	resolver TXTResolver
+	// cache holds the redirects of each host, which verifying or removing a
+	// domain changes
+	cache *linkCache
+	// defaultHost is the hostname of the default domain, it can't be added
+	defaultHost string
+}
+
+// NewDomains manages the custom domains of workspaces, shortDomain is the
+// default domain links are served on.
+func NewDomains(store DomainStore, resolver TXTResolver, cache *linkCache, shortDomain string) *domainService {
+	s := &domainService{
+		store:    store,
+		resolver: resolver,
+		cache:    cache,
+	}
+	if u, err := url.Parse(shortDomain); err == nil {
+		s.defaultHost = requestHostname(u.Host)
+	}
+	return s
+}
+
+// List returns the custom domains of ws, sorted by hostname.
+func (s *domainService) List(ctx context.Context, ws domain.Workspace) ([]domain.CustomDomain, error) {
+	return s.store.ListDomains(ctx, ws.ID)
+}
+
+// Add registers hostname in ws. Links can only be served on it once its
+// ownership is verified, see Verify.
+func (s *domainService) Add(ctx context.Context, ws domain.Workspace, hostname string) (domain.CustomDomain, error) {
+	if !ws.Role.Can(domain.RoleOwner) {
+		return domain.CustomDomain{}, ErrWorkspaceForbidden
+	}
+	hostname, err := NormalizeHostname(hostname)
+	if err != nil {
+		return domain.CustomDomain{}, err
+	}
+	if hostname == s.defaultHost {
+		return domain.CustomDomain{}, ErrInvalidDomain
+	}
+
+	n, err := s.store.CountDomains(ctx, ws.ID)
+	if err != nil {
+		return domain.CustomDomain{}, err
+	}
+	if n >= maxDomains {
+		return domain.CustomDomain{}, ErrTooManyDomains
+	}
+
+	token, err := generateDomainToken()
+	if err != nil {
+		return domain.CustomDomain{}, err
+	}
+	d, err := s.store.InsertDomain(ctx, ws.ID, hostname, token)
+	if isUniqueViolation(err, domainsWorkspaceHostnameKey) {
+		return domain.CustomDomain{}, ErrDomainExists
+	}
+	return d, err
+}
+
+// Verify looks for the TXT record of the domain domainID of ws and marks the
+// domain as verified when it is found. ErrDomainVerification is returned
+// when it isn't.
+func (s *domainService) Verify(ctx context.Context, ws domain.Workspace, domainID domain.ID) (domain.CustomDomain, error) {
+	if !ws.Role.Can(domain.RoleOwner) {
+		return domain.CustomDomain{}, ErrWorkspaceForbidden
+	}
+	d, err := s.get(ctx, ws, domainID)
+	if err != nil {
+		return domain.CustomDomain{}, err
+	}
+	if d.Verified() {
+		return d, nil
+	}
+
+	records, err := s.resolver.LookupTXT(ctx, d.VerificationName())
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		log.Info("domain verification lookup failed", "hostname", d.Hostname, "err", err)
+		return domain.CustomDomain{}, ErrDomainVerification
+	}
+	if err != nil {
+		return domain.CustomDomain{}, fmt.Errorf("failed to look up TXT records: %w", err)
+	}
+	if !slices.Contains(records, d.VerificationValue()) {
+		return domain.CustomDomain{}, ErrDomainVerification
+	}
+
+	d, err = s.store.VerifyDomain(ctx, ws.ID, domainID)
+	if isUniqueViolation(err, domainsVerifiedHostnameKey) {
+		return domain.CustomDomain{}, ErrDomainTaken
+	}
+	if err != nil {
+		return domain.CustomDomain{}, err
+	}
+	// the host served the links of the default domain until now
+	s.cache.InvalidateHost(d.Hostname)
+	return d, nil
+}
+
+// Remove deletes the domain domainID of ws. Domains still serving links are
+// kept, deleting them would break the links.
+func (s *domainService) Remove(ctx context.Context, ws domain.Workspace, domainID domain.ID) error {
+	if !ws.Role.Can(domain.RoleOwner) {
+		return ErrWorkspaceForbidden
+	}
+	d, err := s.get(ctx, ws, domainID)
+	if err != nil {
+		return err
+	}
+
+	n, err := s.store.CountDomainURLs(ctx, ws.ID, d.Hostname)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrDomainInUse
+	}
+
+	deleted, err := s.store.DeleteDomain(ctx, ws.ID, domainID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrDomainNotFound
+	}
+	s.cache.InvalidateHost(d.Hostname)
+	return nil
+}
+
+func (s *domainService) get(ctx context.Context, ws domain.Workspace, domainID domain.ID) (domain.CustomDomain, error) {
+	d, err := s.store.GetDomain(ctx, ws.ID, domainID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.CustomDomain{}, ErrDomainNotFound
+	}
+	if err != nil {
+		return domain.CustomDomain{}, fmt.Errorf("failed to get domain: %w", err)
+	}
+	return d, nil
+}
+
+func generateDomainToken() (string, error) {
+	b := make([]byte, domainTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate domain token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+// memDomainStore keeps custom domains in memory, urls counts the links served
+// on each hostname.
+type memDomainStore struct {
+	domains map[domain.ID]domain.CustomDomain
+	urls    map[string]int
+}
+
+func newMemDomainStore() *memDomainStore {
+	return &memDomainStore{
+		domains: map[domain.ID]domain.CustomDomain{},
+		urls:    map[string]int{},
+	}
+}
+
+func (s *memDomainStore) ListDomains(_ context.Context, workspaceID domain.ID) ([]domain.CustomDomain, error) {
+	var domains []domain.CustomDomain
+	for _, d := range s.domains {
+		if d.WorkspaceID == workspaceID {
+			domains = append(domains, d)
+		}
+	}
+	return domains, nil
+}
+
+func (s *memDomainStore) GetDomain(_ context.Context, workspaceID, domainID domain.ID) (domain.CustomDomain, error) {
+	d, ok := s.domains[domainID]
+	if !ok || d.WorkspaceID != workspaceID {
+		return domain.CustomDomain{}, pgx.ErrNoRows
+	}
+	return d, nil
+}
+
+func (s *memDomainStore) CountDomains(ctx context.Context, workspaceID domain.ID) (int, error) {
+	domains, err := s.ListDomains(ctx, workspaceID)
+	return len(domains), err
+}
+
+func (s *memDomainStore) InsertDomain(_ context.Context, workspaceID domain.ID, hostname, token string) (domain.CustomDomain, error) {
+	for _, d := range s.domains {
+		if d.WorkspaceID == workspaceID && d.Hostname == hostname {
+			return domain.CustomDomain{}, &pgconn.PgError{Code: "23505", ConstraintName: domainsWorkspaceHostnameKey}
+		}
+	}
+	d := domain.CustomDomain{
+		ID:                domain.ID(len(s.domains) + 1),
+		WorkspaceID:       workspaceID,
+		Hostname:          hostname,
+		VerificationToken: token,
+	}
+	s.domains[d.ID] = d
+	return d, nil
+}
+
+func (s *memDomainStore) VerifyDomain(_ context.Context, workspaceID, domainID domain.ID) (domain.CustomDomain, error) {
+	d := s.domains[domainID]
+	for _, other := range s.domains {
+		if other.Hostname == d.Hostname && other.Verified() {
+			return domain.CustomDomain{}, &pgconn.PgError{Code: "23505", ConstraintName: domainsVerifiedHostnameKey}
+		}
+	}
+	d.VerifiedAt = time.Now()
+	s.domains[domainID] = d
+	return d, nil
+}
+
+func (s *memDomainStore) DeleteDomain(_ context.Context, workspaceID, domainID domain.ID) (bool, error) {
+	d, ok := s.domains[domainID]
+	if !ok || d.WorkspaceID != workspaceID {
+		return false, nil
+	}
+	delete(s.domains, domainID)
+	return true, nil
+}
+
+func (s *memDomainStore) CountDomainURLs(_ context.Context, _ domain.ID, hostname string) (int, error) {
+	return s.urls[hostname], nil
+}
+
+// stubTXTResolver answers the TXT lookups from records, unknown names don't
+// exist.
+type stubTXTResolver map[string][]string
+
+func (r stubTXTResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func TestNormalizeHostname(t *testing.T) {
+	tests := []struct {
+		hostname string
+		want     string
+		wantErr  error
+	}{
+		{hostname: " Go.Example.com. ", want: "go.example.com"},
+		{hostname: "xn--bcher-kva.example", want: "xn--bcher-kva.example"},
+		{hostname: "localhost", wantErr: ErrInvalidDomain},
+		{hostname: "https://go.example.com", wantErr: ErrInvalidDomain},
+		{hostname: "go.example.com:8080", wantErr: ErrInvalidDomain},
+		{hostname: "go.example.com/path", wantErr: ErrInvalidDomain},
+		{hostname: "-go.example.com", wantErr: ErrInvalidDomain},
+		{hostname: "192.168.1.1", wantErr: ErrInvalidDomain},
+		{hostname: "", wantErr: ErrInvalidDomain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hostname, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NormalizeHostname(tt.hostname)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAddDomain(t *testing.T) {
+	ctx := context.Background()
+	owner := domain.Workspace{ID: 1, Role: domain.RoleOwner}
+	svc := NewDomains(newMemDomainStore(), stubTXTResolver{}, nil, "https://sho.rt")
+
+	d, err := svc.Add(ctx, owner, "Go.Example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "go.example.com", d.Hostname)
+	assert.False(t, d.Verified())
+	assert.NotEmpty(t, d.VerificationToken)
+
+	_, err = svc.Add(ctx, owner, "go.example.com")
+	assert.ErrorIs(t, err, ErrDomainExists)
+	_, err = svc.Add(ctx, owner, "sho.rt")
+	assert.ErrorIs(t, err, ErrInvalidDomain, "the default domain can't be added")
+	_, err = svc.Add(ctx, domain.Workspace{ID: 1, Role: domain.RoleEditor}, "links.example.com")
+	assert.ErrorIs(t, err, ErrWorkspaceForbidden)
+
+	for _, hostname := range []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com"} {
+		_, err = svc.Add(ctx, owner, hostname)
+		assert.NoError(t, err)
+	}
+	_, err = svc.Add(ctx, owner, "e.example.com")
+	assert.ErrorIs(t, err, ErrTooManyDomains)
+}
+
+func TestVerifyDomain(t *testing.T) {
+	ctx := context.Background()
+	store := newMemDomainStore()
+	resolver := stubTXTResolver{}
+	cache := NewLinkCache(10, time.Minute)
+	svc := NewDomains(store, resolver, cache, "https://sho.rt")
+	owner := domain.Workspace{ID: 1, Role: domain.RoleOwner}
+	other := domain.Workspace{ID: 2, Role: domain.RoleOwner}
+
+	d, err := svc.Add(ctx, owner, "go.example.com")
+	assert.NoError(t, err)
+	claimed, err := svc.Add(ctx, other, "go.example.com")
+	assert.NoError(t, err, "anyone can claim a domain, only its owner can verify it")
+
+	_, err = svc.Verify(ctx, owner, d.ID)
+	assert.ErrorIs(t, err, ErrDomainVerification, "no TXT record")
+
+	resolver["_shortcut.go.example.com"] = []string{"v=spf1 -all", "shortcut-verify=guess"}
+	_, err = svc.Verify(ctx, owner, d.ID)
+	assert.ErrorIs(t, err, ErrDomainVerification, "wrong token")
+
+	_, err = svc.Verify(ctx, other, d.ID)
+	assert.ErrorIs(t, err, ErrDomainNotFound, "domains of other workspaces")
+
+	resolver["_shortcut.go.example.com"] = append(resolver["_shortcut.go.example.com"], d.VerificationValue())
+	cache.Add("go.example.com/docs", domain.URL{ID: 1})
+	cache.Add("sho.rt/docs", domain.URL{ID: 1})
+	d, err = svc.Verify(ctx, owner, d.ID)
+	assert.NoError(t, err)
+	assert.True(t, d.Verified())
+	_, ok := cache.Get("go.example.com/docs")
+	assert.False(t, ok, "the links of the default domain the host served are dropped")
+	_, ok = cache.Get("sho.rt/docs")
+	assert.True(t, ok)
+
+	resolver["_shortcut.go.example.com"] = append(resolver["_shortcut.go.example.com"], claimed.VerificationValue())
+	_, err = svc.Verify(ctx, other, claimed.ID)
+	assert.ErrorIs(t, err, ErrDomainTaken)
+}
+
+func TestRemoveDomain(t *testing.T) {
+	ctx := context.Background()
+	store := newMemDomainStore()
+	svc := NewDomains(store, stubTXTResolver{}, nil, "https://sho.rt")
+	owner := domain.Workspace{ID: 1, Role: domain.RoleOwner}
+
+	d, err := svc.Add(ctx, owner, "go.example.com")
+	assert.NoError(t, err)
+
+	store.urls["go.example.com"] = 2
+	assert.ErrorIs(t, svc.Remove(ctx, owner, d.ID), ErrDomainInUse)
+	assert.ErrorIs(t, svc.Remove(ctx, domain.Workspace{ID: 1, Role: domain.RoleEditor}, d.ID), ErrWorkspaceForbidden)
+
+	delete(store.urls, "go.example.com")
+	assert.NoError(t, svc.Remove(ctx, owner, d.ID))
+	assert.ErrorIs(t, svc.Remove(ctx, owner, d.ID), ErrDomainNotFound)
+}
+
+func TestShortenOnDomain(t *testing.T) {
+	ctx := context.Background()
+	store := newMemDomainStore()
+	store.domains[1] = domain.CustomDomain{ID: 1, WorkspaceID: 1, Hostname: "go.example.com", VerifiedAt: time.Now()}
+	store.domains[2] = domain.CustomDomain{ID: 2, WorkspaceID: 1, Hostname: "pending.example.com"}
+	store.domains[3] = domain.CustomDomain{ID: 3, WorkspaceID: 2, Hostname: "other.example.com", VerifiedAt: time.Now()}
+	svc := &urlService{domains: store}
+
+	tests := []struct {
+		hostname string
+		want     string
+		wantErr  error
+	}{
+		{hostname: "", want: ""},
+		{hostname: "GO.example.com", want: "go.example.com"},
+		{hostname: "pending.example.com", wantErr: ErrDomainNotVerified},
+		{hostname: "other.example.com", wantErr: ErrDomainNotVerified},
+		{hostname: "not a domain", wantErr: ErrInvalidDomain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hostname, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := svc.linkDomain(ctx, 1, tt.hostname)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestToURL(t *testing.T) {
+	assert.Equal(t, "https://sho.rt/docs", toURL("https://sho.rt", "", "docs"))
+	assert.Equal(t, "https://go.example.com/docs", toURL("https://sho.rt", "go.example.com", "docs"))
+	assert.Equal(t, "http://go.example.com/docs", toURL("http://localhost:8080", "go.example.com", "docs"))
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+// exportPageSize is the number of visits exports read from the database at a
+// time.
+const exportPageSize = 1000
+
+// ExportVisits calls fn with each visit in filter.Period, oldest first, of the
+// link ref of workspaceID, or of all its links when ref is zero.
+// Visits are read a page at a time so exports of any size use little memory;
+// fn returning an error stops the export.
+func (s *urlService) ExportVisits(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, filter domain.StatsFilter, fn func(domain.ExportedVisit) error) error {
+	if err := ValidatePeriod(filter.Period); err != nil {
+		return err
+	}
+
+	var urlID domain.ID
+	if ref != (domain.LinkRef{}) {
+		url, err := s.ownedURL(ctx, workspaceID, ref)
+		if err != nil {
+			return err
+		}
+		urlID = domain.ID(url.ID)
+	}
+
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var after domain.ID
+	for {
+		rows, err := s.repo.ListVisits(ctx, workspaceID, urlID, filter, after, exportPageSize)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to list visits: %w", err)
+		}
+		for _, row := range rows {
+			if err := fn(exportedVisit(row, loc)); err != nil {
+				return err
+			}
+		}
+		if len(rows) < exportPageSize {
+			return nil
+		}
+		after = domain.ID(rows[len(rows)-1].ID)
+	}
+}
+
+// ExportStats calls fn with the visits in filter.Period of the link ref of
+// workspaceID, or of each of its links when ref is zero, counted by bucket
+// of time, country, browser, device and referrer.
+func (s *urlService) ExportStats(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, filter domain.StatsFilter, fn func(domain.ExportedStat) error) error {
+	if err := ValidatePeriod(filter.Period); err != nil {
+		return err
+	}
+
+	if ref != (domain.LinkRef{}) {
+		url, err := s.ownedURL(ctx, workspaceID, ref)
+		if err != nil {
+			return err
+		}
+		return s.exportLinkStats(ctx, workspaceID, domain.ID(url.ID), url.ShortUrl, filter, fn)
+	}
+
+	for _, archived := range []bool{false, true} {
+		rows, err := s.repo.List(ctx, workspaceID, domain.LinkFilter{Archived: archived}, domain.Page{})
+		if err != nil {
+			return fmt.Errorf("failed to list shorten urls: %w", err)
+		}
+		for _, row := range rows {
+			if err := s.exportLinkStats(ctx, workspaceID, domain.ID(row.ID), row.ShortUrl, filter, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportLinkStats reads the statistics of one link; the distributions are
+// small enough to be read in full.
+func (s *urlService) exportLinkStats(ctx context.Context, workspaceID, urlID domain.ID, slug string, filter domain.StatsFilter, fn func(domain.ExportedStat) error) error {
+	series, err := visitSeries(filter, time.Now(), func(period domain.Period, bucket domain.Bucket) ([]domain.TimeSeriesData, error) {
+		return s.repo.VisitOverTime(ctx, urlID, period, bucket, filter)
+	})
+	if err != nil {
+		return err
+	}
+
+	var stats []domain.ExportedStat
+	total := series.Total()
+	for _, d := range series.Current {
+		stat := domain.ExportedStat{
+			Slug:   slug,
+			Metric: domain.StatMetricTime,
+			Label:  d.Time.In(series.Location).Format(time.RFC3339),
+			Visits: d.Count,
+		}
+		if total > 0 {
+			stat.Percentage = float32(d.Count) * 100 / float32(total)
+		}
+		stats = append(stats, stat)
+	}
+
+	locations, err := s.repo.LocationDistribution(ctx, workspaceID, urlID, filter)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get location distribution: %w", err)
+	}
+	for _, v := range locations {
+		stats = append(stats, domain.ExportedStat{
+			Slug:       slug,
+			Metric:     domain.StatMetricCountry,
+			Label:      v.CountryName.String,
+			Visits:     v.VisitCount,
+			Percentage: float32(v.Percentage),
+		})
+	}
+
+	browsers, err := s.repo.BrowserDistribution(ctx, workspaceID, urlID, filter)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get browser distribution: %w", err)
+	}
+	for _, v := range browsers {
+		stats = append(stats, domain.ExportedStat{
+			Slug:       slug,
+			Metric:     domain.StatMetricBrowser,
+			Label:      v.Name.String,
+			Visits:     v.VisitCount,
+			Percentage: float32(v.Percentage),
+		})
+	}
+
+	devices, err := s.repo.DeviceDistribution(ctx, workspaceID, urlID, filter)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get device distribution: %w", err)
+	}
+	for _, v := range devices {
+		stats = append(stats, domain.ExportedStat{
+			Slug:       slug,
+			Metric:     domain.StatMetricDevice,
+			Label:      string(deviceKind(v.Mobile.Bool)),
+			Visits:     v.VisitCount,
+			Percentage: float32(v.Percentage),
+		})
+	}
+
+	referrers, err := s.repo.RefererDistribution(ctx, workspaceID, urlID, filter)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get referer distribution: %w", err)
+	}
+	for _, v := range referrers {
+		stats = append(stats, domain.ExportedStat{
+			Slug:       slug,
+			Metric:     domain.StatMetricReferrer,
+			Label:      v.Source.String,
+			Visits:     v.ClickCount,
+			Percentage: float32(v.Percentage),
+		})
+	}
+
+	for _, stat := range stats {
+		if err := fn(stat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownedURL returns the link ref when it belongs to workspaceID.
+func (s *urlService) ownedURL(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) (datastore.Url, error) {
+	url, err := s.repo.Get(ctx, workspaceID, ref)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return datastore.Url{}, ErrURLNotFound
+	}
+	if err != nil {
+		return datastore.Url{}, fmt.Errorf("failed to get url: %w", err)
+	}
+	return url, nil
+}
+
+func exportedVisit(row datastore.ListVisitsRow, loc *time.Location) domain.ExportedVisit {
+	return domain.ExportedVisit{
+		ID:          domain.ID(row.ID),
+		Slug:        row.ShortUrl,
+		VisitedAt:   row.VisitedAt.Time.In(loc),
+		Country:     row.CountryName,
+		CountryCode: row.CountryCode,
+		City:        row.CityName,
+		Browser:     row.Browser,
+		Platform:    row.Platform,
+		Device:      deviceKind(row.Mobile),
+		Referrer:    row.Referrer,
+		IsBot:       row.IsBot,
+	}
+}
+
+func deviceKind(mobile bool) domain.DeviceKind {
+	if mobile {
+		return domain.DeviceKindMobile
+	}
+	return domain.DeviceKindDesktop
+}
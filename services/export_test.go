@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+// visitsURLStore serves n visits of the link "promo" of workspace 1 and counts
+// the pages they were read in.
+type visitsURLStore struct {
+	*memURLStore
+	n     int
+	pages int
+}
+
+func newVisitsURLStore(n int) *visitsURLStore {
+	return &visitsURLStore{memURLStore: newMemURLStore(testLink(7, "promo", "https://example.com")), n: n}
+}
+
+func (s *visitsURLStore) ListVisits(_ context.Context, _, urlID domain.ID, _ domain.StatsFilter, afterID domain.ID, limit int) ([]datastore.ListVisitsRow, error) {
+	s.pages++
+	var rows []datastore.ListVisitsRow
+	for id := int(afterID) + 1; id <= s.n && len(rows) < limit; id++ {
+		rows = append(rows, datastore.ListVisitsRow{
+			ID:        int32(id),
+			ShortUrl:  "promo",
+			VisitedAt: pgtype.Timestamp{Time: time.Date(2026, 9, 1, 22, 0, 0, 0, time.UTC), Valid: true},
+			Mobile:    id%2 == 0,
+		})
+	}
+	return rows, nil
+}
+
+func TestExportVisits(t *testing.T) {
+	ctx := context.Background()
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("timezone database not available")
+	}
+	filter := domain.StatsFilter{
+		Period:   domain.Period{Since: time.Date(2026, 9, 1, 0, 0, 0, 0, paris), Until: time.Date(2026, 10, 1, 0, 0, 0, 0, paris)},
+		Location: paris,
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		t.Parallel()
+
+		store := newVisitsURLStore(2*exportPageSize + 10)
+		svc := &urlService{repo: store}
+
+		var visits []domain.ExportedVisit
+		err := svc.ExportVisits(ctx, 1, domain.LinkRef{Slug: "promo"}, filter, func(v domain.ExportedVisit) error {
+			visits = append(visits, v)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, store.pages)
+		assert.Len(t, visits, store.n)
+		assert.Equal(t, domain.ID(store.n), visits[len(visits)-1].ID, "every visit is exported once, in order")
+		assert.Equal(t, "2026-09-02T00:00:00+02:00", visits[0].VisitedAt.Format(time.RFC3339), "times are in the viewer's timezone")
+		assert.Equal(t, domain.DeviceKindDesktop, visits[0].Device)
+		assert.Equal(t, domain.DeviceKindMobile, visits[1].Device)
+	})
+
+	t.Run("stops on error", func(t *testing.T) {
+		t.Parallel()
+
+		store := newVisitsURLStore(3 * exportPageSize)
+		svc := &urlService{repo: store}
+
+		errClosed := errors.New("client went away")
+		err := svc.ExportVisits(ctx, 1, domain.LinkRef{}, filter, func(v domain.ExportedVisit) error {
+			return errClosed
+		})
+		assert.ErrorIs(t, err, errClosed)
+		assert.Equal(t, 1, store.pages)
+	})
+
+	t.Run("owner only", func(t *testing.T) {
+		t.Parallel()
+
+		svc := &urlService{repo: newVisitsURLStore(1)}
+		err := svc.ExportVisits(ctx, 2, domain.LinkRef{Slug: "promo"}, filter, func(domain.ExportedVisit) error { return nil })
+		assert.ErrorIs(t, err, ErrURLNotFound)
+	})
+
+	t.Run("period required", func(t *testing.T) {
+		t.Parallel()
+
+		svc := &urlService{repo: newVisitsURLStore(1)}
+		err := svc.ExportVisits(ctx, 1, domain.LinkRef{Slug: "promo"}, domain.StatsFilter{}, func(domain.ExportedVisit) error { return nil })
+		assert.ErrorIs(t, err, ErrInvalidPeriod)
+	})
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+// memURLStore keeps links in memory, by LinkRef.String, and records what the
+// services did to them. The methods it leaves to the nil URLStore panic.
+type memURLStore struct {
+	URLStore
+	mu   sync.Mutex
+	urls map[string]datastore.Url
+	// verified are the custom domains serving their own links, the others
+	// serve the links of the default domain.
+	verified map[string]bool
+	// n is the number of links List and CountLinks page through.
+	n int
+
+	added     []domain.AddURLParams
+	history   []string
+	flagged   []domain.ID
+	suspended []domain.ID
+	lookups   int
+	counts    int
+}
+
+// newMemURLStore returns a store holding urls.
+func newMemURLStore(urls ...datastore.Url) *memURLStore {
+	s := &memURLStore{urls: make(map[string]datastore.Url, len(urls))}
+	for _, url := range urls {
+		s.urls[linkKey(url)] = url
+	}
+	return s
+}
+
+// testLink returns an active link of workspace 1, of the domain ref is on.
+func testLink(id int32, ref, longURL string) datastore.Url {
+	r := domain.ParseLinkRef(ref)
+	return datastore.Url{
+		ID:             id,
+		WorkspaceID:    1,
+		ShortUrl:       r.Slug,
+		Domain:         r.Domain,
+		LongUrl:        longURL,
+		IsActive:       true,
+		RedirectStatus: http.StatusFound,
+	}
+}
+
+func linkKey(url datastore.Url) string {
+	return domain.LinkRef{Domain: url.Domain, Slug: url.ShortUrl}.String()
+}
+
+func (s *memURLStore) Add(_ context.Context, params domain.AddURLParams) (domain.ID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.added = append(s.added, params)
+	id := int32(len(s.added))
+	url := datastore.Url{ID: id, WorkspaceID: int32(params.WorkspaceID), ShortUrl: params.Slug, Domain: params.Domain, Title: params.Title, LongUrl: params.Long, IsActive: params.IsActive}
+	if s.urls == nil {
+		s.urls = map[string]datastore.Url{}
+	}
+	s.urls[linkKey(url)] = url
+	return domain.ID(id), nil
+}
+
+func (s *memURLStore) Get(_ context.Context, workspaceID domain.ID, ref domain.LinkRef) (datastore.Url, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url, ok := s.urls[ref.String()]
+	if !ok || domain.ID(url.WorkspaceID) != workspaceID {
+		return datastore.Url{}, pgx.ErrNoRows
+	}
+	return url, nil
+}
+
+func (s *memURLStore) GetByID(_ context.Context, urlID domain.ID) (datastore.Url, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, url := range s.urls {
+		if domain.ID(url.ID) == urlID {
+			return url, nil
+		}
+	}
+	return datastore.Url{}, pgx.ErrNoRows
+}
+
+func (s *memURLStore) GetRedirect(_ context.Context, host, slug string) (datastore.Url, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	if !s.verified[host] {
+		host = ""
+	}
+	url, ok := s.urls[domain.LinkRef{Domain: host, Slug: slug}.String()]
+	if !ok {
+		return datastore.Url{}, pgx.ErrNoRows
+	}
+	return url, nil
+}
+
+func (s *memURLStore) List(_ context.Context, _ domain.ID, _ domain.LinkFilter, page domain.Page) ([]datastore.ListStatisticsPerAuthorRow, error) {
+	var rows []datastore.ListStatisticsPerAuthorRow
+	for id := page.Offset + 1; id <= s.n && (page.Limit == 0 || len(rows) < page.Limit); id++ {
+		rows = append(rows, datastore.ListStatisticsPerAuthorRow{ID: int32(id), LongUrl: "https://example.com"})
+	}
+	return rows, nil
+}
+
+func (s *memURLStore) CountLinks(context.Context, domain.ID, domain.LinkFilter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts++
+	return s.n, nil
+}
+
+func (s *memURLStore) Delete(_ context.Context, urlID, _ domain.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, url := range s.urls {
+		if domain.ID(url.ID) == urlID {
+			delete(s.urls, key)
+		}
+	}
+	return nil
+}
+
+func (s *memURLStore) CountClick(_ context.Context, urlID domain.ID) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, url := range s.urls {
+		if domain.ID(url.ID) != urlID || (url.MaxClicks.Valid && url.Clicks >= url.MaxClicks.Int32) {
+			continue
+		}
+		url.Clicks++
+		s.urls[key] = url
+		return int(url.Clicks), nil
+	}
+	return 0, pgx.ErrNoRows
+}
+
+func (s *memURLStore) UpdateDestination(_ context.Context, workspaceID, _ domain.ID, ref domain.LinkRef, title, longURL string, redirectStatus int, isActive bool) (datastore.Url, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url, ok := s.urls[ref.String()]
+	if !ok || domain.ID(url.WorkspaceID) != workspaceID {
+		return datastore.Url{}, pgx.ErrNoRows
+	}
+	if url.LongUrl != longURL {
+		s.history = append(s.history, url.LongUrl)
+	}
+	url.Title, url.LongUrl, url.IsActive = title, longURL, isActive
+	url.RedirectStatus = int16(redirectStatus)
+	s.urls[ref.String()] = url
+	return url, nil
+}
+
+func (s *memURLStore) UpdatePassword(_ context.Context, workspaceID domain.ID, ref domain.LinkRef, hash *domain.PasswordHash) (datastore.Url, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	url, ok := s.urls[ref.String()]
+	if !ok || domain.ID(url.WorkspaceID) != workspaceID {
+		return datastore.Url{}, pgx.ErrNoRows
+	}
+	url.PasswordHash, url.PasswordSalt = nil, nil
+	if hash != nil {
+		url.PasswordHash, url.PasswordSalt = hash.Hash, hash.Salt
+	}
+	s.urls[ref.String()] = url
+	return url, nil
+}
+
+func (s *memURLStore) UpdateURLStatus(_ context.Context, urlID domain.ID, isActive bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, url := range s.urls {
+		if domain.ID(url.ID) == urlID {
+			url.IsActive = isActive
+			s.urls[key] = url
+		}
+	}
+	return nil
+}
+
+func (s *memURLStore) InsertModerationFlag(_ context.Context, urlID, _ domain.ID, _ int, _ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flagged = append(s.flagged, urlID)
+	return nil
+}
+
+func (s *memURLStore) SuspendUserByID(_ context.Context, userID domain.ID, _ bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.suspended = append(s.suspended, userID)
+	return nil
+}
+
+type stubScanner map[string]string
+
+func (s stubScanner) Scan(_ context.Context, targetURL string) (int, string, error) {
+	if threat, ok := s[targetURL]; ok {
+		return 100, threat, nil
+	}
+	return 0, "", nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+
+	"github.com/oschwald/geoip2-golang/v2"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/drivers/ipquery"
+)
+
+// ErrIPNotLocated is returned by a GeoIPResolver that has no location for an
+// IP address.
+var ErrIPNotLocated = errors.New("ip address not located")
+
+// GeoIPResolver locates visitors from their IP address.
+type GeoIPResolver interface {
+	Resolve(ctx context.Context, ip string) (domain.IPLocation, error)
+}
+
+// BatchGeoIPResolver locates several IP addresses at once. The addresses it
+// couldn't locate are left out of the result.
+type BatchGeoIPResolver interface {
+	ResolveAll(ctx context.Context, ips []string) (map[string]domain.IPLocation, error)
+}
+
+// resolveAll locates ips with r, in a single call when r supports it. The
+// error reports the lookups that failed, for other reasons than the address
+// not being located.
+func resolveAll(ctx context.Context, r GeoIPResolver, ips []string) (map[string]domain.IPLocation, error) {
+	if batch, ok := r.(BatchGeoIPResolver); ok {
+		return batch.ResolveAll(ctx, ips)
+	}
+
+	locs := make(map[string]domain.IPLocation, len(ips))
+	var errs []error
+	for _, ip := range ips {
+		loc, err := r.Resolve(ctx, ip)
+		if err == nil {
+			locs[ip] = loc
+		} else if !errors.Is(err, ErrIPNotLocated) {
+			errs = append(errs, err)
+		}
+	}
+	return locs, errors.Join(errs...)
+}
+
+// MMDBResolver reads locations from a MaxMind or DB-IP city database in the
+// mmdb format, without any network call.
+type MMDBResolver struct {
+	db *geoip2.Reader
+}
+
+func NewMMDBResolver(path string) (*MMDBResolver, error) {
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geoip database %s: %w", path, err)
+	}
+	return &MMDBResolver{db: db}, nil
+}
+
+func (r *MMDBResolver) Resolve(_ context.Context, ip string) (domain.IPLocation, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return domain.IPLocation{}, fmt.Errorf("invalid ip address %q: %w", ip, err)
+	}
+
+	record, err := r.db.City(addr)
+	if err != nil {
+		return domain.IPLocation{}, fmt.Errorf("failed to look up %s: %w", ip, err)
+	}
+	if !record.HasData() {
+		return domain.IPLocation{}, ErrIPNotLocated
+	}
+
+	loc := domain.IPLocation{
+		IP:          ip,
+		Continent:   record.Continent.Names.English,
+		Country:     record.Country.Names.English,
+		CountryCode: record.Country.ISOCode,
+		City:        record.City.Names.English,
+		Zipcode:     record.Postal.Code,
+		Timezone:    record.Location.TimeZone,
+		Source:      "mmdb",
+	}
+	if len(record.Subdivisions) > 0 {
+		loc.State = record.Subdivisions[0].Names.English
+	}
+	if record.Location.HasCoordinates() {
+		loc.Latitude = *record.Location.Latitude
+		loc.Longitude = *record.Location.Longitude
+	}
+	return loc, nil
+}
+
+func (r *MMDBResolver) Close() error {
+	return r.db.Close()
+}
+
+// IPQueryResolver locates IP addresses with the api.ipquery.io web service.
+type IPQueryResolver struct{}
+
+func (IPQueryResolver) Resolve(ctx context.Context, ip string) (domain.IPLocation, error) {
+	resp, err := ipquery.QueryIP(ctx, ip)
+	if err != nil {
+		return domain.IPLocation{}, err
+	}
+	if resp.Location.CountryCode == "" {
+		return domain.IPLocation{}, ErrIPNotLocated
+	}
+	return fromIPQuery(*resp), nil
+}
+
+// maxIPQueryBatch is the number of addresses looked up per ipquery request.
+const maxIPQueryBatch = 100
+
+// ResolveAll looks ips up by batches of maxIPQueryBatch.
+func (IPQueryResolver) ResolveAll(ctx context.Context, ips []string) (map[string]domain.IPLocation, error) {
+	locs := make(map[string]domain.IPLocation, len(ips))
+	for chunk := range slices.Chunk(ips, maxIPQueryBatch) {
+		resps, err := ipquery.QueryIPs(ctx, chunk)
+		if err != nil {
+			return locs, err
+		}
+		for _, resp := range resps {
+			if resp.Location.CountryCode != "" {
+				locs[resp.IP] = fromIPQuery(resp)
+			}
+		}
+	}
+	return locs, nil
+}
+
+// FallbackResolver asks each of its resolvers in turn until one locates the
+// IP address.
+type FallbackResolver []GeoIPResolver
+
+func (f FallbackResolver) Resolve(ctx context.Context, ip string) (domain.IPLocation, error) {
+	var errs []error
+	for _, r := range f {
+		loc, err := r.Resolve(ctx, ip)
+		if err == nil {
+			return loc, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return domain.IPLocation{}, ErrIPNotLocated
+	}
+	return domain.IPLocation{}, errors.Join(errs...)
+}
+
+// ResolveAll asks each resolver in turn for the addresses the previous ones
+// didn't locate.
+func (f FallbackResolver) ResolveAll(ctx context.Context, ips []string) (map[string]domain.IPLocation, error) {
+	locs := make(map[string]domain.IPLocation, len(ips))
+	var errs []error
+	for _, r := range f {
+		missing := slices.DeleteFunc(slices.Clone(ips), func(ip string) bool {
+			_, ok := locs[ip]
+			return ok
+		})
+		if len(missing) == 0 {
+			break
+		}
+		found, err := resolveAll(ctx, r, missing)
+		maps.Copy(locs, found)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return locs, errors.Join(errs...)
+}
+
+func fromIPQuery(loc ipquery.Response) domain.IPLocation {
+	return domain.IPLocation{
+		IP:          loc.IP,
+		Country:     loc.Location.Country,
+		CountryCode: loc.Location.CountryCode,
+		City:        loc.Location.City,
+		State:       loc.Location.State,
+		Zipcode:     loc.Location.Zipcode,
+		Latitude:    loc.Location.Latitude,
+		Longitude:   loc.Location.Longitude,
+		Timezone:    loc.Location.Timezone,
+		Localtime:   loc.Location.Localtime,
+		Source:      "ipquery",
+
+		IsDatacenter: loc.Risk.IsDatacenter,
+		IsProxy:      loc.Risk.IsProxy,
+		IsTor:        loc.Risk.IsTor,
+	}
+}
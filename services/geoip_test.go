@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+type stubResolver struct {
+	loc domain.IPLocation
+	err error
+}
+
+func (s stubResolver) Resolve(context.Context, string) (domain.IPLocation, error) {
+	return s.loc, s.err
+}
+
+func TestFallbackResolver(t *testing.T) {
+	errDown := errors.New("service unavailable")
+	paris := domain.IPLocation{City: "Paris", Source: "mmdb"}
+	london := domain.IPLocation{City: "London", Source: "ipquery"}
+
+	tests := []struct {
+		name      string
+		resolvers FallbackResolver
+		want      domain.IPLocation
+		wantErr   error
+	}{
+		{"empty", nil, domain.IPLocation{}, ErrIPNotLocated},
+		{"first wins", FallbackResolver{stubResolver{loc: paris}, stubResolver{loc: london}}, paris, nil},
+		{"not located", FallbackResolver{stubResolver{err: ErrIPNotLocated}, stubResolver{loc: london}}, london, nil},
+		{"failing", FallbackResolver{stubResolver{err: errDown}, stubResolver{loc: london}}, london, nil},
+		{"all fail", FallbackResolver{stubResolver{err: ErrIPNotLocated}, stubResolver{err: errDown}}, domain.IPLocation{}, errDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.resolvers.Resolve(context.Background(), "203.0.113.7")
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// batchResolver locates the addresses it knows and records the batches it
+// was asked for.
+type batchResolver struct {
+	locs    map[string]domain.IPLocation
+	err     error
+	batches [][]string
+}
+
+func (r *batchResolver) Resolve(context.Context, string) (domain.IPLocation, error) {
+	panic("addresses are looked up by batch")
+}
+
+func (r *batchResolver) ResolveAll(_ context.Context, ips []string) (map[string]domain.IPLocation, error) {
+	r.batches = append(r.batches, ips)
+	locs := map[string]domain.IPLocation{}
+	for _, ip := range ips {
+		if loc, ok := r.locs[ip]; ok {
+			locs[ip] = loc
+		}
+	}
+	return locs, r.err
+}
+
+func TestFallbackResolverBatch(t *testing.T) {
+	errDown := errors.New("service unavailable")
+	mmdb := stubResolver{err: ErrIPNotLocated}
+	ipquery := &batchResolver{
+		locs: map[string]domain.IPLocation{"203.0.113.7": {City: "London", Source: "ipquery"}},
+		err:  errDown,
+	}
+
+	locs, err := FallbackResolver{mmdb, ipquery}.ResolveAll(context.Background(), []string{"203.0.113.7", "198.51.100.1"})
+	assert.ErrorIs(t, err, errDown)
+	assert.Equal(t, map[string]domain.IPLocation{"203.0.113.7": {City: "London", Source: "ipquery"}}, locs, "the addresses located are kept on error")
+	assert.Equal(t, [][]string{{"203.0.113.7", "198.51.100.1"}}, ipquery.batches, "the addresses are looked up at once")
+}
+
+func TestVisitTrackerLocate(t *testing.T) {
+	errDown := errors.New("service unavailable")
+	tests := []struct {
+		name          string
+		geoip         GeoIPResolver
+		country       string
+		want          *domain.IPLocation
+		wantUnlocated int64
+	}{
+		{"resolved", stubResolver{loc: domain.IPLocation{IP: "203.0.113.7", CountryCode: "FR", Source: "mmdb"}}, "DE", &domain.IPLocation{IP: "203.0.113.7", CountryCode: "FR", Source: "mmdb"}, 0},
+		{"cloudflare fallback", stubResolver{err: ErrIPNotLocated}, "DE", &domain.IPLocation{IP: "203.0.113.7", CountryCode: "DE", Source: "cf-ipcountry"}, 0},
+		{"no resolver", nil, "DE", &domain.IPLocation{IP: "203.0.113.7", CountryCode: "DE", Source: "cf-ipcountry"}, 0},
+		{"unknown", stubResolver{err: ErrIPNotLocated}, "", nil, 0},
+		{"lookup failed", stubResolver{err: errDown}, "", nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tracker := NewVisitTracker(&memVisitStore{}, tt.geoip, nil, VisitTrackerConfig{Workers: 1})
+			defer tracker.Shutdown(context.Background())
+
+			r := httptest.NewRequest("GET", "/abc", nil)
+			r.RemoteAddr = "203.0.113.7:1234"
+			r.Header.Set("CF-IPCountry", tt.country)
+			batch := []domain.Visit{{Request: parseRequest(r)}}
+			tracker.locate(batch)
+			assert.Equal(t, tt.want, batch[0].Location)
+			assert.Equal(t, tt.wantUnlocated, tracker.Stats().Unlocated)
+		})
+	}
+}
+
+func TestVisitTrackerLocateBatch(t *testing.T) {
+	geoip := &batchResolver{locs: map[string]domain.IPLocation{
+		"203.0.113.7":  {IP: "203.0.113.7", CountryCode: "FR"},
+		"198.51.100.1": {IP: "198.51.100.1", CountryCode: "US", IsDatacenter: true},
+	}}
+	tracker := NewVisitTracker(&memVisitStore{}, geoip, nil, VisitTrackerConfig{Workers: 1})
+	defer tracker.Shutdown(context.Background())
+
+	var batch []domain.Visit
+	for _, ip := range []string{"203.0.113.7", "198.51.100.1", "203.0.113.7", "192.0.2.1"} {
+		batch = append(batch, domain.Visit{Request: *domain.NewRequestInfo(ip, iphoneUA, "", "", "")})
+	}
+	tracker.locate(batch)
+
+	assert.Equal(t, [][]string{{"192.0.2.1", "198.51.100.1", "203.0.113.7"}}, geoip.batches, "each address is looked up once")
+	assert.Equal(t, "FR", batch[0].Location.CountryCode)
+	assert.Equal(t, "FR", batch[2].Location.CountryCode)
+	assert.Nil(t, batch[3].Location)
+	assert.False(t, batch[0].IsBot)
+	assert.True(t, batch[1].IsBot, "datacenter visits are bots")
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+const (
+	// ImportMaxRows bounds the number of links created by a bulk import.
+	ImportMaxRows = 1000
+	// importConcurrency is the number of destinations scanned, and whose
+	// title is extracted, at once.
+	importConcurrency = 8
+)
+
+var ErrTooManyImportRows = fmt.Errorf("a bulk import can create at most %d links", ImportMaxRows)
+
+// importCheck is what is learned about a destination before creating its
+// link.
+type importCheck struct {
+	title      string
+	riskScore  int
+	threatType string
+}
+
+// ShortenBatch creates a link in workspaceID on behalf of its member userID
+// for each of rows, whose URLs are already validated, and reports on each of
+// them in the same order. At most quota links are created, the rows after are
+// skipped.
+//
+// Destinations are checked by the safety scanner, and their title extracted,
+// concurrently before anything is created. Like with Shorten, dangerous ones
+// are created disabled and suspend the author pending moderation, the other
+// rows are then skipped.
+func (s *urlService) ShortenBatch(ctx context.Context, workspaceID, userID domain.ID, rows []domain.ImportRow, quota int64) ([]domain.ImportResult, error) {
+	if len(rows) > ImportMaxRows {
+		return nil, ErrTooManyImportRows
+	}
+
+	checks := make([]importCheck, len(rows))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(importConcurrency)
+	for i, row := range rows {
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			check := importCheck{title: row.Title}
+			if check.title == "" {
+				check.title = ExtractTitle(row.URL)
+			}
+			check.riskScore, check.threatType = s.scan(gctx, row.URL)
+			checks[i] = check
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	flagged := false
+	for _, c := range checks {
+		if c.threatType != "" || c.riskScore > 0 {
+			flagged = true
+			break
+		}
+	}
+
+	results := make([]domain.ImportResult, len(rows))
+	var created int64
+	for i, row := range rows {
+		check := checks[i]
+		results[i] = domain.ImportResult{Row: row}
+		dangerous := check.threatType != "" || check.riskScore > 0
+
+		switch {
+		case dangerous:
+			urlID, slug, err := s.add(ctx, domain.AddURLParams{
+				Title:          check.title,
+				Long:           row.URL,
+				WorkspaceID:    workspaceID,
+				AuthorID:       userID,
+				RedirectStatus: domain.DefaultRedirectStatus,
+			})
+			if err != nil {
+				log.Error("failed to add inactive URL after safety flag", "line", row.Line, "err", err)
+				results[i].Status, results[i].Error = domain.ImportFailed, "failed to create the link"
+				continue
+			}
+			s.flag(ctx, urlID, userID, check.riskScore, check.threatType)
+			results[i].Status, results[i].Slug, results[i].Error = domain.ImportFlagged, slug, ErrSuspiciousURL.Error()
+		case flagged:
+			results[i].Status, results[i].Error = domain.ImportSkipped, "your account is suspended pending review of the flagged links"
+		case created >= quota:
+			results[i].Status, results[i].Error = domain.ImportSkipped, "the workspace reached its monthly limit of URLs"
+		default:
+			u, err := s.create(ctx, domain.AddURLParams{
+				Title:          check.title,
+				Long:           row.URL,
+				WorkspaceID:    workspaceID,
+				AuthorID:       userID,
+				RedirectStatus: domain.DefaultRedirectStatus,
+			})
+			if err != nil {
+				log.Error("failed to import URL", "line", row.Line, "err", err)
+				results[i].Status, results[i].Error = domain.ImportFailed, "failed to create the link"
+				continue
+			}
+			created++
+			results[i].Status, results[i].Short, results[i].Slug = domain.ImportCreated, u.Short, u.Slug
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+func importRows(urls ...string) []domain.ImportRow {
+	rows := make([]domain.ImportRow, len(urls))
+	for i, u := range urls {
+		// titles keep the tests from fetching the pages
+		rows[i] = domain.ImportRow{Line: i + 2, URL: u, Title: fmt.Sprintf("Product %d", i)}
+	}
+	return rows
+}
+
+func importStatuses(results []domain.ImportResult) []domain.ImportStatus {
+	statuses := make([]domain.ImportStatus, len(results))
+	for i, r := range results {
+		statuses[i] = r.Status
+	}
+	return statuses
+}
+
+func TestShortenBatch(t *testing.T) {
+	ctx := context.Background()
+	scanner := stubScanner{"https://malware.test": "MALWARE"}
+
+	t.Run("created in order", func(t *testing.T) {
+		t.Parallel()
+
+		store := newMemURLStore()
+		svc := &urlService{repo: store, safetyScanner: scanner, idGenerator: NewShortIDGenerator(nil, ""), shortDomain: "https://sho.rt"}
+
+		var urls []string
+		for i := range 3 * importConcurrency {
+			urls = append(urls, fmt.Sprintf("https://example.com/product/%d", i))
+		}
+		results, err := svc.ShortenBatch(ctx, 1, 5, importRows(urls...), 100)
+		assert.NoError(t, err)
+		if assert.Len(t, results, len(urls)) {
+			for i, r := range results {
+				assert.Equal(t, domain.ImportCreated, r.Status)
+				assert.Equal(t, urls[i], r.Row.URL)
+				assert.Equal(t, "https://sho.rt/"+r.Slug, r.Short)
+			}
+		}
+		assert.Equal(t, "Product 0", store.added[0].Title)
+		assert.True(t, store.added[0].IsActive)
+	})
+
+	t.Run("quota", func(t *testing.T) {
+		t.Parallel()
+
+		store := newMemURLStore()
+		svc := &urlService{repo: store, safetyScanner: scanner, idGenerator: NewShortIDGenerator(nil, "")}
+
+		results, err := svc.ShortenBatch(ctx, 1, 5, importRows("https://example.com/a", "https://example.com/b", "https://example.com/c"), 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.ImportStatus{domain.ImportCreated, domain.ImportCreated, domain.ImportSkipped}, importStatuses(results))
+		assert.Len(t, store.added, 2)
+	})
+
+	t.Run("flagged", func(t *testing.T) {
+		t.Parallel()
+
+		store := newMemURLStore()
+		svc := &urlService{repo: store, safetyScanner: scanner, idGenerator: NewShortIDGenerator(nil, "")}
+
+		results, err := svc.ShortenBatch(ctx, 1, 5, importRows("https://example.com/a", "https://malware.test", "https://example.com/c"), 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.ImportStatus{domain.ImportSkipped, domain.ImportFlagged, domain.ImportSkipped}, importStatuses(results))
+		if assert.Len(t, store.added, 1, "only the dangerous link is created, disabled") {
+			assert.False(t, store.added[0].IsActive)
+		}
+		assert.Equal(t, []domain.ID{1}, store.flagged)
+		assert.Equal(t, []domain.ID{5}, store.suspended, "the member making the change is suspended")
+	})
+
+	t.Run("too many rows", func(t *testing.T) {
+		t.Parallel()
+
+		svc := &urlService{repo: newMemURLStore()}
+		_, err := svc.ShortenBatch(ctx, 1, 5, make([]domain.ImportRow, ImportMaxRows+1), ImportMaxRows+1)
+		assert.ErrorIs(t, err, ErrTooManyImportRows)
+	})
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+// LinkCacheStats are the counters of the redirect cache, exposed as metrics.
+type LinkCacheStats struct {
+	Size      int   `json:"size"`
+	Capacity  int   `json:"capacity"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+}
+
+type linkCacheEntry struct {
+	key     string
+	url     domain.URL
+	expires time.Time
+}
+
+// linkCache keeps the most recently followed links in memory so hot links
+// don't hit the database on every redirect. Links are keyed by the host and
+// slug they were requested with, so a link can be cached under several keys.
+// Entries live at most ttl, which bounds how stale a link can be on other
+// instances; changes made through this instance invalidate the entry right
+// away.
+//
+// Invalidations move the cache to a new generation. A link read from the
+// database is only added when it wasn't invalidated since the generation the
+// read started at, so a read racing with a change can't cache the link as it
+// was before the change.
+//
+// A nil *linkCache is a valid cache that never stores anything.
+type linkCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	byID    map[domain.ID]map[string]struct{}
+	lru     *list.List
+	// gen is the generation of the cache, invalidated the generation each
+	// link was last invalidated at, and flushed the one at which all links
+	// were. invalidated is cleared, moving flushed up, once it holds as
+	// many links as the cache.
+	gen         uint64
+	invalidated map[domain.ID]uint64
+	flushed     uint64
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+// NewLinkCache returns a cache of at most size links, each kept for ttl. It
+// returns nil, a disabled cache, when size or ttl is not positive.
+func NewLinkCache(size int, ttl time.Duration) *linkCache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &linkCache{
+		size:        size,
+		ttl:         ttl,
+		now:         time.Now,
+		entries:     make(map[string]*list.Element, size),
+		byID:        make(map[domain.ID]map[string]struct{}, size),
+		lru:         list.New(),
+		invalidated: make(map[domain.ID]uint64),
+	}
+}
+
+func (c *linkCache) Get(key string) (domain.URL, bool) {
+	if c == nil {
+		return domain.URL{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if ok && c.now().After(elem.Value.(*linkCacheEntry).expires) {
+		c.remove(elem)
+		ok = false
+	}
+	if !ok {
+		c.misses.Add(1)
+		return domain.URL{}, false
+	}
+
+	c.hits.Add(1)
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*linkCacheEntry).url, true
+}
+
+// Generation returns the current generation of the cache, to pass to
+// AddSince for the links read from now on.
+func (c *linkCache) Generation() uint64 {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+func (c *linkCache) Add(key string, url domain.URL) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(key, url)
+}
+
+// AddSince adds url, read from the database at generation gen, unless it was
+// invalidated since.
+func (c *linkCache) AddSince(gen uint64, key string, url domain.URL) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flushed > gen || c.invalidated[url.ID] > gen {
+		return
+	}
+	c.add(key, url)
+}
+
+func (c *linkCache) add(key string, url domain.URL) {
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	entry := &linkCacheEntry{key: key, url: url, expires: c.now().Add(c.ttl)}
+	c.entries[key] = c.lru.PushFront(entry)
+	if c.byID[url.ID] == nil {
+		c.byID[url.ID] = make(map[string]struct{})
+	}
+	c.byID[url.ID][key] = struct{}{}
+
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// Invalidate drops the link cached under key.
+func (c *linkCache) Invalidate(key string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// InvalidateID drops the link identified by id, under all its keys.
+func (c *linkCache) InvalidateID(id domain.ID) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.byID[id] {
+		c.remove(c.entries[key])
+	}
+
+	c.gen++
+	if len(c.invalidated) >= c.size {
+		clear(c.invalidated)
+		c.flushed = c.gen
+	}
+	c.invalidated[id] = c.gen
+}
+
+// InvalidateHost drops the links cached for the requests of host, whose
+// links change when it becomes, or stops being, a verified custom domain.
+func (c *linkCache) InvalidateHost(host string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := host + "/"
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(elem)
+		}
+	}
+	c.flush()
+}
+
+// Purge drops every link, for changes affecting many links at once.
+func (c *linkCache) Purge() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	clear(c.byID)
+	c.lru.Init()
+	c.flush()
+}
+
+func (c *linkCache) Stats() LinkCacheStats {
+	if c == nil {
+		return LinkCacheStats{}
+	}
+
+	c.mu.Lock()
+	size := c.lru.Len()
+	c.mu.Unlock()
+
+	return LinkCacheStats{
+		Size:      size,
+		Capacity:  c.size,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+// flush moves to a new generation at which all links are invalidated.
+func (c *linkCache) flush() {
+	c.gen++
+	c.flushed = c.gen
+	clear(c.invalidated)
+}
+
+func (c *linkCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*linkCacheEntry)
+	delete(c.entries, entry.key)
+	keys := c.byID[entry.url.ID]
+	delete(keys, entry.key)
+	if len(keys) == 0 {
+		delete(c.byID, entry.url.ID)
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+func TestLinkCache(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewLinkCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+
+	cache.Add("a", domain.URL{ID: 1, Long: "https://a.example"})
+	cache.Add("b", domain.URL{ID: 2, Long: "https://b.example"})
+	url, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "https://a.example", url.Long)
+
+	// b is the least recently used
+	cache.Add("c", domain.URL{ID: 3})
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	_, ok = cache.Get("a")
+	assert.True(t, ok)
+
+	cache.InvalidateID(1)
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+
+	cache.Add("a", domain.URL{ID: 1})
+	cache.Invalidate("c")
+	_, ok = cache.Get("c")
+	assert.False(t, ok)
+
+	now = now.Add(time.Minute + time.Second)
+	_, ok = cache.Get("a")
+	assert.False(t, ok, "entries expire after the ttl")
+
+	cache.Add("go.example.com/a", domain.URL{ID: 1})
+	cache.Add("sho.rt/a", domain.URL{ID: 1})
+	cache.InvalidateHost("go.example.com")
+	_, ok = cache.Get("go.example.com/a")
+	assert.False(t, ok)
+	_, ok = cache.Get("sho.rt/a")
+	assert.True(t, ok, "only the links of the host are dropped")
+
+	cache.Add("a", domain.URL{ID: 1})
+	cache.Add("b", domain.URL{ID: 2})
+	cache.Purge()
+	assert.Zero(t, cache.Stats().Size)
+
+	gen := cache.Generation()
+	cache.InvalidateID(1)
+	cache.AddSince(gen, "a", domain.URL{ID: 1})
+	cache.AddSince(gen, "b", domain.URL{ID: 2})
+	_, ok = cache.Get("a")
+	assert.False(t, ok, "links invalidated during their read are not added")
+	_, ok = cache.Get("b")
+	assert.True(t, ok)
+
+	gen = cache.Generation()
+	cache.InvalidateHost("go.example.com")
+	cache.AddSince(gen, "b", domain.URL{ID: 2})
+	assert.Equal(t, 1, cache.Stats().Size, "links read before a host changed are not added")
+	cache.Purge()
+
+	assert.Equal(t, LinkCacheStats{Size: 0, Capacity: 2, Hits: 4, Misses: 7, Evictions: 2}, cache.Stats())
+}
+
+func TestLinkCacheDisabled(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLinkCache(0, time.Minute)
+	assert.Nil(t, cache)
+
+	// a nil cache is usable
+	cache.Add("a", domain.URL{ID: 1})
+	cache.AddSince(cache.Generation(), "a", domain.URL{ID: 1})
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	cache.Invalidate("a")
+	cache.InvalidateID(1)
+	cache.InvalidateHost("go.example.com")
+	cache.Purge()
+	assert.Zero(t, cache.Stats())
+}
+
+func TestExpandCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := newMemURLStore(testLink(1, "docs", "https://example.com/docs"))
+	svc := &urlService{repo: store, cache: NewLinkCache(10, time.Minute), defaultHost: "sho.rt"}
+
+	for range 3 {
+		url, err := svc.Expand(ctx, "sho.rt", "docs")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/docs", url.Long)
+	}
+	assert.Equal(t, 1, store.lookups)
+
+	_, err := svc.Expand(ctx, "sho.rt", "missing")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	assert.NoError(t, svc.Delete(ctx, 1, 1))
+	_, err = svc.Expand(ctx, "sho.rt", "docs")
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "deleted links are not served from the cache")
+}
+
+// racingURLStore changes the links it reads before returning them, as a
+// change committed while a redirect reads its link.
+type racingURLStore struct {
+	*memURLStore
+	cache *linkCache
+}
+
+func (s racingURLStore) GetRedirect(ctx context.Context, host, slug string) (datastore.Url, error) {
+	url, err := s.memURLStore.GetRedirect(ctx, host, slug)
+	s.cache.InvalidateID(domain.ID(url.ID))
+	return url, err
+}
+
+func TestExpandRace(t *testing.T) {
+	t.Parallel()
+
+	cache := NewLinkCache(10, time.Minute)
+	store := racingURLStore{newMemURLStore(testLink(1, "docs", "https://example.com/docs")), cache}
+	svc := &urlService{repo: store, cache: cache, defaultHost: "sho.rt"}
+
+	_, err := svc.Expand(context.Background(), "sho.rt", "docs")
+	assert.NoError(t, err)
+	assert.Zero(t, cache.Stats().Size, "a link changed during its read is not cached")
+}
+
+func TestExpandHost(t *testing.T) {
+	store := newMemURLStore(
+		testLink(1, "docs", "https://example.com/docs"),
+		testLink(2, "docs@go.example.com", "https://example.com/internal/docs"),
+		testLink(3, "wiki@go.example.com", "https://example.com/wiki"),
+	)
+	store.verified = map[string]bool{"go.example.com": true}
+	// the cache is shared to check that it keeps the links of each host apart
+	cache := NewLinkCache(10, time.Minute)
+	svc := &urlService{repo: store, cache: cache, defaultHost: "sho.rt"}
+
+	tests := []struct {
+		name     string
+		host     string
+		slug     string
+		wantLong string
+	}{
+		{"default domain", "sho.rt", "docs", "https://example.com/docs"},
+		{"same slug on a custom domain", "go.example.com", "docs", "https://example.com/internal/docs"},
+		{"port and case are ignored", "Go.Example.com:443", "docs", "https://example.com/internal/docs"},
+		{"unknown hosts serve the default domain", "localhost:8080", "docs", "https://example.com/docs"},
+		{"custom domains only serve their links", "sho.rt", "wiki", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := svc.Expand(context.Background(), tt.host, tt.slug)
+			if tt.wantLong == "" {
+				assert.ErrorIs(t, err, pgx.ErrNoRows)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantLong, url.Long)
+		})
+	}
+	_, ok := cache.Get("localhost/docs")
+	assert.False(t, ok, "links are not cached under unknown hosts")
+	_, ok = cache.Get("/docs")
+	assert.True(t, ok, "links of the default domain are cached under it")
+
+	assert.NoError(t, svc.Delete(context.Background(), 1, 0))
+	for _, host := range []string{"sho.rt", "localhost:8080"} {
+		_, err := svc.Expand(context.Background(), host, "docs")
+		assert.ErrorIs(t, err, pgx.ErrNoRows, host)
+	}
+}
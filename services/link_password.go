@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+const (
+	minLinkPasswordLength = 8
+	maxLinkPasswordLength = 128
+
+	// passwordAttemptWindow is the period over which password attempts are
+	// counted. Every attempt of an IP address counts, hashing is expensive,
+	// only the failed ones count against a link. Past its limit, a link is
+	// not locked, which would lock its visitors out too, but takes one
+	// attempt per passwordTurn.
+	passwordAttemptWindow      = 15 * time.Minute
+	maxPasswordAttemptsPerIP   = 10
+	maxPasswordFailuresPerLink = 50
+	passwordTurn               = 2 * time.Second
+	maxPasswordTurnWait        = 10 * time.Second
+)
+
+var (
+	ErrInvalidLinkPassword     = fmt.Errorf("password must be between %d and %d characters", minLinkPasswordLength, maxLinkPasswordLength)
+	ErrWrongLinkPassword       = errors.New("wrong password")
+	ErrTooManyPasswordAttempts = errors.New("too many password attempts, please try again later")
+)
+
+// ValidateLinkPassword checks the length of a link password.
+func ValidateLinkPassword(plain string) error {
+	if n := utf8.RuneCountInString(plain); n < minLinkPasswordLength || n > maxLinkPasswordLength {
+		return ErrInvalidLinkPassword
+	}
+	return nil
+}
+
+func (s *urlService) hashPassword(plain string) (*domain.PasswordHash, error) {
+	if err := ValidateLinkPassword(plain); err != nil {
+		return nil, err
+	}
+
+	hs, err := s.hasher.Hash([]byte(plain), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash link password: %w", err)
+	}
+	return &domain.PasswordHash{Hash: hs.Hash, Salt: hs.Salt}, nil
+}
+
+// UpdatePassword protects a URL of workspaceID with plain. An empty plain
+// removes the protection.
+func (s *urlService) UpdatePassword(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, plain string) (domain.URL, error) {
+	var hash *domain.PasswordHash
+	if plain != "" {
+		var err error
+		if hash, err = s.hashPassword(plain); err != nil {
+			return domain.URL{}, err
+		}
+	}
+
+	row, err := s.repo.UpdatePassword(ctx, workspaceID, ref, hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.URL{}, ErrURLNotFound
+	}
+	if err != nil {
+		return domain.URL{}, err
+	}
+	s.cache.InvalidateID(domain.ID(row.ID))
+
+	return s.fromRow(row), nil
+}
+
+// VerifyPassword checks plain, entered by a visitor from ip, against the
+// password of the link urlID. ErrWrongLinkPassword is returned on mismatch
+// and ErrTooManyPasswordAttempts once ip made too many attempts. Attempts on a
+// link that failed too often wait for their turn, or get
+// ErrTooManyPasswordAttempts when too many are already waiting.
+func (s *urlService) VerifyPassword(ctx context.Context, urlID domain.ID, ip, plain string) error {
+	row, err := s.repo.GetByID(ctx, urlID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrURLNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(row.PasswordHash) == 0 {
+		return nil
+	}
+
+	linkKey := "link:" + strconv.FormatInt(int64(urlID), 10)
+	if !s.passwordAttempts.Allow("ip:"+ip, maxPasswordAttemptsPerIP) {
+		return ErrTooManyPasswordAttempts
+	}
+	wait, ok := s.passwordAttempts.Turn(linkKey, maxPasswordFailuresPerLink, passwordTurn, maxPasswordTurnWait)
+	if !ok {
+		return ErrTooManyPasswordAttempts
+	}
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := s.hasher.Compare(row.PasswordHash, row.PasswordSalt, []byte(plain)); err != nil {
+		s.passwordAttempts.Add(linkKey)
+		return ErrWrongLinkPassword
+	}
+	return nil
+}
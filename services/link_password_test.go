@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/services/password"
+)
+
+func TestLinkPassword(t *testing.T) {
+	ctx := context.Background()
+	store := newMemURLStore(testLink(1, "docs", "https://example.com/docs"))
+	svc := &urlService{repo: store, hasher: password.DefaultArgon2iHasher()}
+
+	// unprotected links accept anything
+	assert.NoError(t, svc.VerifyPassword(ctx, 1, "203.0.113.7", ""))
+
+	_, err := svc.UpdatePassword(ctx, 2, domain.LinkRef{Slug: "docs"}, "correct horse")
+	assert.ErrorIs(t, err, ErrURLNotFound, "only the owner can set a password")
+
+	_, err = svc.UpdatePassword(ctx, 1, domain.LinkRef{Slug: "docs"}, "hunter2")
+	assert.ErrorIs(t, err, ErrInvalidLinkPassword)
+	_, err = svc.UpdatePassword(ctx, 1, domain.LinkRef{Slug: "docs"}, strings.Repeat("a", maxLinkPasswordLength+1))
+	assert.ErrorIs(t, err, ErrInvalidLinkPassword)
+
+	url, err := svc.UpdatePassword(ctx, 1, domain.LinkRef{Slug: "docs"}, "correct horse")
+	assert.NoError(t, err)
+	assert.True(t, url.HasPassword)
+	assert.NotEqual(t, []byte("correct horse"), store.urls["docs"].PasswordHash, "password must not be stored in plain text")
+
+	assert.NoError(t, svc.VerifyPassword(ctx, 1, "203.0.113.7", "correct horse"))
+	assert.ErrorIs(t, svc.VerifyPassword(ctx, 1, "203.0.113.7", "correct donkey"), ErrWrongLinkPassword)
+	assert.ErrorIs(t, svc.VerifyPassword(ctx, 2, "203.0.113.7", "correct horse"), ErrURLNotFound)
+
+	url, err = svc.UpdatePassword(ctx, 1, domain.LinkRef{Slug: "docs"}, "")
+	assert.NoError(t, err)
+	assert.False(t, url.HasPassword)
+	assert.NoError(t, svc.VerifyPassword(ctx, 1, "203.0.113.7", "anything"))
+}
+
+func TestLinkPasswordAttempts(t *testing.T) {
+	ctx := context.Background()
+	store := newMemURLStore(testLink(1, "docs", "https://example.com/docs"), testLink(2, "blog", "https://example.com/blog"))
+	svc := &urlService{repo: store, hasher: password.DefaultArgon2iHasher(), passwordAttempts: newAttemptLimiter(passwordAttemptWindow)}
+	for _, slug := range []string{"docs", "blog"} {
+		_, err := svc.UpdatePassword(ctx, 1, domain.LinkRef{Slug: slug}, "correct horse")
+		assert.NoError(t, err)
+	}
+
+	for range maxPasswordAttemptsPerIP {
+		assert.ErrorIs(t, svc.VerifyPassword(ctx, 1, "203.0.113.7", "guess"), ErrWrongLinkPassword)
+	}
+	assert.ErrorIs(t, svc.VerifyPassword(ctx, 1, "203.0.113.7", "correct horse"), ErrTooManyPasswordAttempts, "the right password is refused too")
+	assert.ErrorIs(t, svc.VerifyPassword(ctx, 2, "203.0.113.7", "correct horse"), ErrTooManyPasswordAttempts, "on every link")
+	assert.NoError(t, svc.VerifyPassword(ctx, 1, "198.51.100.1", "correct horse"), "other visitors are not blocked")
+
+	// a guess spread over many addresses slows the link down, its visitors
+	// can still unlock it
+	for i := range maxPasswordFailuresPerLink {
+		_ = svc.VerifyPassword(ctx, 2, fmt.Sprintf("10.0.%d.%d", i/256, i%256), "guess")
+	}
+	assert.NoError(t, svc.VerifyPassword(ctx, 2, "198.51.100.1", "correct horse"))
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, svc.VerifyPassword(canceled, 2, "198.51.100.2", "correct horse"), context.Canceled, "the next attempt waits for its turn")
+	assert.NoError(t, svc.VerifyPassword(canceled, 1, "198.51.100.2", "correct horse"), "other links don't wait")
+}
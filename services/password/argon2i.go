@@ -1,8 +1,8 @@
 package password
 
 import (
-	"bytes"
 	"crypto/rand"
+	"crypto/subtle"
 	"errors"
 
 	"golang.org/x/crypto/argon2"
@@ -91,7 +91,7 @@ func (a *Argon2idHash) Compare(hash, salt, password []byte) error {
 	}
 	// Compare the generated hash with the stored hash.
 	// If they don't match return error.
-	if !bytes.Equal(hash, hashSalt.Hash) {
+	if subtle.ConstantTimeCompare(hash, hashSalt.Hash) != 1 {
 		return errors.New("hash doesn't match")
 	}
 	return nil
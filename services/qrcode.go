@@ -0,0 +1,230 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+// QRParam marks the visits coming from a QR code: the codes of short links
+// encode their short URL with qr=1.
+const QRParam = "qr"
+
+// qrLogoRatio is the width of the logo relative to the code. The modules it
+// hides are recovered by error correction, hence the Q or H level it needs.
+const qrLogoRatio = 0.22
+
+var (
+	ErrInvalidQRColor = errors.New("colors are written as 6 hexadecimal digits, e.g. 4f46e5")
+	ErrQRLogoLevel    = errors.New("a logo needs the Q or H error correction level")
+)
+
+var qrLevels = map[domain.QRLevel]qrcode.RecoveryLevel{
+	domain.QRLevelLow:      qrcode.Low,
+	domain.QRLevelMedium:   qrcode.Medium,
+	domain.QRLevelQuartile: qrcode.High,
+	domain.QRLevelHigh:     qrcode.Highest,
+}
+
+// qrCodes renders the QR codes of short links.
+type qrCodes struct {
+	logo    image.Image
+	logoPNG []byte
+}
+
+// NewQRCodes renders QR codes with logo, a PNG image, drawn in their middle
+// when asked to.
+func NewQRCodes(logo []byte) (*qrCodes, error) {
+	img, err := png.Decode(bytes.NewReader(logo))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode QR code logo: %w", err)
+	}
+	return &qrCodes{
+		logo:    img,
+		logoPNG: logo,
+	}, nil
+}
+
+// QRContent is what the QR code of a short link encodes: its short URL marked
+// with QRParam so that scans are told apart from other visits.
+func QRContent(short string) string {
+	u, err := url.Parse(short)
+	if err != nil {
+		return short
+	}
+	q := u.Query()
+	q.Set(QRParam, "1")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// ValidateQROptions checks the options of a QR code are supported and leave
+// it scannable.
+func ValidateQROptions(opts domain.QROptions) error {
+	if opts.Format != domain.QRFormatPNG && opts.Format != domain.QRFormatSVG {
+		return errors.New("format must be png or svg")
+	}
+	if opts.Size < domain.QRMinSize || opts.Size > domain.QRMaxSize {
+		return fmt.Errorf("size must be between %d and %d pixels", domain.QRMinSize, domain.QRMaxSize)
+	}
+	if _, ok := qrLevels[opts.Level]; !ok {
+		return errors.New("level must be L, M, Q or H")
+	}
+	if opts.Logo && opts.Level != domain.QRLevelQuartile && opts.Level != domain.QRLevelHigh {
+		return ErrQRLogoLevel
+	}
+	if opts.Foreground == opts.Background {
+		return errors.New("the background must differ from the foreground")
+	}
+	return nil
+}
+
+// ParseHexColor parses an opaque color written as 6 hexadecimal digits, with
+// or without a leading #.
+func ParseHexColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return color.RGBA{}, ErrInvalidQRColor
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, ErrInvalidQRColor
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// HexColor writes c as #rrggbb.
+func HexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Render draws the QR code of content with opts, which must be valid.
+func (c *qrCodes) Render(content string, opts domain.QROptions) ([]byte, error) {
+	level, ok := qrLevels[opts.Level]
+	if !ok {
+		return nil, fmt.Errorf("unknown error correction level %q", opts.Level)
+	}
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	code.ForegroundColor = opts.Foreground
+	code.BackgroundColor = opts.Background
+
+	switch opts.Format {
+	case domain.QRFormatPNG:
+		return c.png(code, opts)
+	case domain.QRFormatSVG:
+		return c.svg(code, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown QR code format %q", opts.Format)
+	}
+}
+
+func (c *qrCodes) png(code *qrcode.QRCode, opts domain.QROptions) ([]byte, error) {
+	var img image.Image = code.Image(opts.Size)
+
+	if opts.Logo {
+		bounds := img.Bounds()
+		canvas := image.NewRGBA(bounds)
+		draw.Draw(canvas, bounds, img, image.Point{}, draw.Src)
+
+		side := int(float64(bounds.Dx()) * qrLogoRatio)
+		pad := side / 8
+		at := (bounds.Dx() - side) / 2
+		logo := image.Rect(at, at, at+side, at+side)
+		draw.Draw(canvas, logo.Inset(-pad), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		draw.Draw(canvas, logo, scaleImage(c.logo, side), image.Point{}, draw.Over)
+		img = canvas
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// svg draws the code with one module per unit, each row of dark modules
+// merged into a single rectangle.
+func (c *qrCodes) svg(code *qrcode.QRCode, opts domain.QROptions) []byte {
+	bitmap := code.Bitmap()
+	n := len(bitmap)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, opts.Size, opts.Size, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, n, n, HexColor(opts.Background))
+	fmt.Fprintf(&b, `<path fill="%s" d="`, HexColor(opts.Foreground))
+	for y, row := range bitmap {
+		for x := 0; x < n; {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < n && row[x] {
+				x++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	b.WriteString(`"/>`)
+
+	if opts.Logo {
+		side := float64(n) * qrLogoRatio
+		pad := side / 8
+		at := (float64(n) - side) / 2
+		fmt.Fprintf(&b, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`, at-pad, at-pad, side+2*pad, side+2*pad, HexColor(opts.Background))
+		fmt.Fprintf(&b, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" href="data:image/png;base64,%s"/>`, at, at, side, side, base64.StdEncoding.EncodeToString(c.logoPNG))
+	}
+
+	b.WriteString("</svg>")
+	return b.Bytes()
+}
+
+// scaleImage resizes src to a square of size pixels, each of them averaging
+// the pixels of src it covers.
+func scaleImage(src image.Image, size int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	b := src.Bounds()
+	for y := range size {
+		y0 := b.Min.Y + y*b.Dy()/size
+		y1 := max(b.Min.Y+(y+1)*b.Dy()/size, y0+1)
+		for x := range size {
+			x0 := b.Min.X + x*b.Dx()/size
+			x1 := max(b.Min.X+(x+1)*b.Dx()/size, x0+1)
+
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a, n = r+cr, g+cg, bl+cb, a+ca, n+1
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// visitSource tells the visits coming from a QR code apart.
+func visitSource(r *http.Request) domain.VisitSource {
+	if r.URL.Query().Get(QRParam) == "1" {
+		return domain.VisitSourceQR
+	}
+	return domain.VisitSourceDirect
+}
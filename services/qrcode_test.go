@@ -0,0 +1,179 @@
+package services
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/static"
+)
+
+func TestQRContent(t *testing.T) {
+	tests := []struct {
+		short string
+		want  string
+	}{
+		{"https://sho.rt/promo", "https://sho.rt/promo?qr=1"},
+		{"https://sho.rt/promo?ref=x", "https://sho.rt/promo?qr=1&ref=x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.short, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, QRContent(tt.short))
+		})
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    color.RGBA
+		wantErr error
+	}{
+		{in: "4f46e5", want: color.RGBA{R: 0x4f, G: 0x46, B: 0xe5, A: 0xff}},
+		{in: "#FFFFFF", want: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{in: "fff", wantErr: ErrInvalidQRColor},
+		{in: "zzzzzz", wantErr: ErrInvalidQRColor},
+		{in: "-12345", wantErr: ErrInvalidQRColor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseHexColor(tt.in)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			if err == nil {
+				assert.Equal(t, strings.ToLower(strings.TrimPrefix(tt.in, "#")), strings.TrimPrefix(HexColor(got), "#"))
+			}
+		})
+	}
+}
+
+func TestValidateQROptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(*domain.QROptions)
+		wantErr bool
+	}{
+		{name: "default", edit: func(*domain.QROptions) {}},
+		{name: "svg", edit: func(o *domain.QROptions) { o.Format = domain.QRFormatSVG }},
+		{name: "unknown format", edit: func(o *domain.QROptions) { o.Format = "gif" }, wantErr: true},
+		{name: "too small", edit: func(o *domain.QROptions) { o.Size = domain.QRMinSize - 1 }, wantErr: true},
+		{name: "too large", edit: func(o *domain.QROptions) { o.Size = domain.QRMaxSize + 1 }, wantErr: true},
+		{name: "unknown level", edit: func(o *domain.QROptions) { o.Level = "X" }, wantErr: true},
+		{name: "logo", edit: func(o *domain.QROptions) { o.Logo, o.Level = true, domain.QRLevelQuartile }},
+		{name: "logo with a low level", edit: func(o *domain.QROptions) { o.Logo = true }, wantErr: true},
+		{name: "no contrast", edit: func(o *domain.QROptions) { o.Background = o.Foreground }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := domain.DefaultQROptions
+			tt.edit(&opts)
+			err := ValidateQROptions(opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestQRCodesRender(t *testing.T) {
+	codes, err := NewQRCodes(static.Logo)
+	if !assert.NoError(t, err) {
+		return
+	}
+	indigo := color.RGBA{R: 0x4f, G: 0x46, B: 0xe5, A: 0xff}
+	cream := color.RGBA{R: 0xff, G: 0xf8, B: 0xe7, A: 0xff}
+
+	t.Run("png", func(t *testing.T) {
+		t.Parallel()
+
+		opts := domain.DefaultQROptions
+		opts.Size, opts.Foreground, opts.Background = 300, indigo, cream
+		data, err := codes.Render("https://sho.rt/promo?qr=1", opts)
+		if !assert.NoError(t, err) {
+			return
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 300, img.Bounds().Dx())
+		assert.Equal(t, 300, img.Bounds().Dy())
+		// the quiet zone is drawn with the background
+		assert.Equal(t, cream, color.RGBAModel.Convert(img.At(0, 0)))
+	})
+
+	t.Run("png with logo", func(t *testing.T) {
+		t.Parallel()
+
+		opts := domain.DefaultQROptions
+		opts.Logo, opts.Level = true, domain.QRLevelHigh
+		data, err := codes.Render("https://sho.rt/promo?qr=1", opts)
+		if !assert.NoError(t, err) {
+			return
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, opts.Size, img.Bounds().Dx())
+		_, _, _, a := img.At(opts.Size/2, opts.Size/2).RGBA()
+		assert.NotZero(t, a)
+	})
+
+	t.Run("svg", func(t *testing.T) {
+		t.Parallel()
+
+		opts := domain.DefaultQROptions
+		opts.Format, opts.Foreground, opts.Background = domain.QRFormatSVG, indigo, cream
+		data, err := codes.Render("https://sho.rt/promo?qr=1", opts)
+		if !assert.NoError(t, err) {
+			return
+		}
+		svg := string(data)
+		assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256"`))
+		assert.Contains(t, svg, `fill="#fff8e7"`)
+		assert.Contains(t, svg, `<path fill="#4f46e5" d="M`)
+		assert.NotContains(t, svg, "<image")
+		assert.True(t, strings.HasSuffix(svg, "</svg>"))
+
+		opts.Logo, opts.Level = true, domain.QRLevelHigh
+		data, err = codes.Render("https://sho.rt/promo?qr=1", opts)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `href="data:image/png;base64,`)
+	})
+}
+
+func TestVisitSource(t *testing.T) {
+	tests := []struct {
+		target string
+		want   domain.VisitSource
+	}{
+		{"/promo", domain.VisitSourceDirect},
+		{"/promo?qr=1", domain.VisitSourceQR},
+		{"/promo?ref=x&qr=1", domain.VisitSourceQR},
+		{"/promo?qr=0", domain.VisitSourceDirect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, visitSource(httptest.NewRequest("GET", tt.target, nil)))
+		})
+	}
+}
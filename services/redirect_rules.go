@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+// RedirectRuleStore persists the redirect rules of links.
+type RedirectRuleStore interface {
+	ListRedirectRules(ctx context.Context, urlID domain.ID) ([]datastore.UrlRedirectRule, error)
+	CountRedirectRuleVisits(ctx context.Context, urlID domain.ID) (map[domain.ID]int, error)
+	ReplaceRedirectRules(ctx context.Context, urlID domain.ID, rules []domain.RedirectRule) ([]datastore.UrlRedirectRule, error)
+}
+
+const maxRedirectRules = 20
+
+var (
+	ErrTooManyRedirectRules  = fmt.Errorf("a link can have at most %d redirect rules", maxRedirectRules)
+	ErrRedirectRuleNotFound  = errors.New("redirect rule not found")
+	ErrRuleWithoutCondition  = errors.New("a redirect rule needs at least one condition")
+	ErrInvalidRulePlatform   = errors.New("platform must be one of ios, android, windows, macos or linux")
+	ErrInvalidRuleCountry    = errors.New("countries must be two letter ISO codes, e.g. US")
+	ErrInvalidRuleLanguage   = errors.New("languages must be language tags, e.g. fr or pt-br")
+	ErrInvalidRuleTimeWindow = errors.New("a redirect rule must end after it starts")
+)
+
+var (
+	countryCodeRe = regexp.MustCompile(`^[A-Z]{2}$`)
+	languageTagRe = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+)
+
+// NormalizeRedirectRule validates the conditions of rule and puts them in the
+// form they are matched in: upper case countries, lower case languages,
+// without duplicates. The destination is left to the caller to validate.
+func NormalizeRedirectRule(rule domain.RedirectRule) (domain.RedirectRule, error) {
+	if !rule.HasConditions() {
+		return rule, ErrRuleWithoutCondition
+	}
+
+	for _, p := range rule.Platforms {
+		if !slices.Contains(domain.Platforms, p) {
+			return rule, ErrInvalidRulePlatform
+		}
+	}
+	rule.Platforms = dedup(rule.Platforms)
+
+	countries := make([]string, 0, len(rule.Countries))
+	for _, c := range rule.Countries {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if !countryCodeRe.MatchString(c) {
+			return rule, ErrInvalidRuleCountry
+		}
+		countries = append(countries, c)
+	}
+	rule.Countries = dedup(countries)
+
+	languages := make([]string, 0, len(rule.Languages))
+	for _, l := range rule.Languages {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if !languageTagRe.MatchString(l) {
+			return rule, ErrInvalidRuleLanguage
+		}
+		languages = append(languages, l)
+	}
+	rule.Languages = dedup(languages)
+
+	if rule.StartsAt != nil && rule.EndsAt != nil && !rule.EndsAt.After(*rule.StartsAt) {
+		return rule, ErrInvalidRuleTimeWindow
+	}
+	return rule, nil
+}
+
+// dedup returns s without duplicates, keeping the first occurrence.
+func dedup[T comparable](s []T) []T {
+	seen := make(map[T]bool, len(s))
+	out := make([]T, 0, len(s))
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// RedirectRules lists, in order, the redirect rules of a URL of workspaceID
+// along with the number of visits each of them redirected.
+func (s *urlService) RedirectRules(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) ([]domain.RedirectRule, error) {
+	url, err := s.Get(ctx, workspaceID, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.rules.ListRedirectRules(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	clicks, err := s.rules.CountRedirectRuleVisits(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]domain.RedirectRule, 0, len(rows))
+	for _, row := range rows {
+		rule := ruleFromRow(row)
+		rule.Clicks = clicks[rule.ID]
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// SetRedirectRules replaces the redirect rules of a URL of workspaceID with
+// rules, evaluated in this order, and returns them as saved. Rules with an ID
+// update an existing rule of the link, the others are created. New
+// destinations go through the safety scanner: if one is flagged nothing is
+// saved, the link is disabled and userID, the member making the change,
+// suspended, as when shortening.
+func (s *urlService) SetRedirectRules(ctx context.Context, workspaceID, userID domain.ID, ref domain.LinkRef, rules []domain.RedirectRule) ([]domain.RedirectRule, error) {
+	if len(rules) > maxRedirectRules {
+		return nil, ErrTooManyRedirectRules
+	}
+
+	url, err := s.Get(ctx, workspaceID, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.rules.ListRedirectRules(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{url.Long: true}
+	ids := make(map[domain.ID]bool, len(existing))
+	for _, row := range existing {
+		ids[domain.ID(row.ID)] = true
+		known[row.LongUrl] = true
+	}
+
+	destinations := make([]string, 0, len(rules))
+	for i, rule := range rules {
+		if rule.ID != 0 && !ids[rule.ID] {
+			return nil, ErrRedirectRuleNotFound
+		}
+		if rules[i], err = NormalizeRedirectRule(rule); err != nil {
+			return nil, err
+		}
+		destinations = append(destinations, rule.LongURL)
+	}
+	if err := s.scanDestinations(ctx, url, userID, known, destinations); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.rules.ReplaceRedirectRules(ctx, url.ID, rules)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.InvalidateID(url.ID)
+
+	clicks, err := s.rules.CountRedirectRuleVisits(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	saved := make([]domain.RedirectRule, 0, len(rows))
+	for _, row := range rows {
+		rule := ruleFromRow(row)
+		rule.Clicks = clicks[rule.ID]
+		saved = append(saved, rule)
+	}
+	return saved, nil
+}
+
+// scanDestinations runs the destinations that are not known yet through the
+// safety scanner. When one is flagged the link is disabled, userID suspended
+// and ErrSuspiciousURL returned, as when shortening.
+func (s *urlService) scanDestinations(ctx context.Context, url domain.URL, userID domain.ID, known map[string]bool, destinations []string) error {
+	for _, dest := range destinations {
+		if known[dest] {
+			continue
+		}
+		known[dest] = true
+		if riskScore, threatType := s.scan(ctx, dest); threatType != "" || riskScore > 0 {
+			if err := s.ToggleLinkStatus(ctx, url.ID, false); err != nil {
+				return err
+			}
+			s.flag(ctx, url.ID, userID, riskScore, threatType)
+			return ErrSuspiciousURL
+		}
+	}
+	return nil
+}
+
+// listRules returns the redirect rules of the link urlID, none when the
+// service has no rule store.
+func (s *urlService) listRules(ctx context.Context, urlID domain.ID) ([]domain.RedirectRule, error) {
+	if s.rules == nil {
+		return nil, nil
+	}
+
+	rows, err := s.rules.ListRedirectRules(ctx, urlID)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]domain.RedirectRule, 0, len(rows))
+	for _, row := range rows {
+		rules = append(rules, ruleFromRow(row))
+	}
+	return rules, nil
+}
+
+func ruleFromRow(row datastore.UrlRedirectRule) domain.RedirectRule {
+	rule := domain.RedirectRule{
+		ID:        domain.ID(row.ID),
+		LongURL:   row.LongUrl,
+		Platforms: make([]domain.Platform, 0, len(row.Platforms)),
+		Countries: row.Countries,
+		Languages: row.Languages,
+	}
+	for _, p := range row.Platforms {
+		rule.Platforms = append(rule.Platforms, domain.Platform(p))
+	}
+	if row.Mobile.Valid {
+		mobile := row.Mobile.Bool
+		rule.Mobile = &mobile
+	}
+	if row.StartsAt.Valid {
+		startsAt := row.StartsAt.Time
+		rule.StartsAt = &startsAt
+	}
+	if row.EndsAt.Valid {
+		endsAt := row.EndsAt.Time
+		rule.EndsAt = &endsAt
+	}
+	return rule
+}
+
+// Destination returns where url sends the visitor of r and what picked it:
+// the first redirect rule matching the visitor, otherwise one of the variants
+// of the link, otherwise its destination.
+func Destination(url domain.URL, r *http.Request) (string, domain.Route) {
+	if len(url.Rules) > 0 {
+		info := parseRequest(r)
+		if target, rule := url.Destination(&info, time.Now()); rule != nil {
+			return target, domain.Route{RuleID: rule.ID}
+		}
+	}
+
+	if len(url.Variants) == 0 {
+		return url.Long, domain.Route{}
+	}
+	v := pickVariant(url, r)
+	return v.LongURL, domain.Route{VariantID: v.ID}
+}
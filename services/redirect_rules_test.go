@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+const (
+	iphoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1"
+	ipadUA    = "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1"
+	androidUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36"
+	windowsUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	macUA     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15"
+)
+
+func TestDestination(t *testing.T) {
+	yes, no := true, false
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	url := domain.URL{
+		Long: "https://example.com",
+		Rules: []domain.RedirectRule{
+			{ID: 1, LongURL: "https://apps.apple.com/app", Platforms: []domain.Platform{domain.PlatformIOS}},
+			{ID: 2, LongURL: "https://play.google.com/app", Platforms: []domain.Platform{domain.PlatformAndroid}},
+			{ID: 3, LongURL: "https://example.fr", Countries: []string{"FR", "BE"}, Mobile: &no},
+			{ID: 4, LongURL: "https://example.com/de", Languages: []string{"de"}},
+			{ID: 5, LongURL: "https://example.com/pt-br", Languages: []string{"pt-br"}},
+			{ID: 6, LongURL: "https://example.com/sale", Mobile: &yes, StartsAt: &past, EndsAt: &future},
+			{ID: 7, LongURL: "https://example.com/soon", StartsAt: &future},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		userAgent string
+		country   string
+		language  string
+		want      string
+		wantRule  domain.ID
+	}{
+		{"iphone", iphoneUA, "", "", "https://apps.apple.com/app", 1},
+		{"ipad", ipadUA, "FR", "", "https://apps.apple.com/app", 1},
+		{"android", androidUA, "", "", "https://play.google.com/app", 2},
+		{"desktop in france", windowsUA, "FR", "", "https://example.fr", 3},
+		{"desktop in belgium lower case", macUA, "be", "", "https://example.fr", 3},
+		{"german speaker", macUA, "US", "de-AT,de;q=0.9,en;q=0.8", "https://example.com/de", 4},
+		{"brazilian portuguese", windowsUA, "", "pt-BR", "https://example.com/pt-br", 5},
+		{"portuguese", windowsUA, "", "pt-PT", "https://example.com", 0},
+		{"no match", windowsUA, "US", "en-US", "https://example.com", 0},
+		{"empty user agent", "", "", "", "https://example.com", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest("GET", "/abc", nil)
+			r.Header.Set("User-Agent", tt.userAgent)
+			r.Header.Set("CF-IPCountry", tt.country)
+			r.Header.Set("Accept-Language", tt.language)
+
+			got, route := Destination(url, r)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantRule, route.RuleID)
+		})
+	}
+}
+
+func TestDestinationTimeWindow(t *testing.T) {
+	t.Parallel()
+
+	yes := true
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	url := domain.URL{
+		Long: "https://example.com",
+		Rules: []domain.RedirectRule{
+			{ID: 1, LongURL: "https://example.com/ended", Mobile: &yes, EndsAt: &past},
+			{ID: 2, LongURL: "https://example.com/sale", Mobile: &yes, StartsAt: &past, EndsAt: &future},
+		},
+	}
+
+	r := httptest.NewRequest("GET", "/abc", nil)
+	r.Header.Set("User-Agent", androidUA)
+	got, route := Destination(url, r)
+	assert.Equal(t, "https://example.com/sale", got)
+	assert.Equal(t, domain.ID(2), route.RuleID)
+}
+
+func TestNormalizeRedirectRule(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		rule    domain.RedirectRule
+		want    domain.RedirectRule
+		wantErr error
+	}{
+		{
+			name: "normalized",
+			rule: domain.RedirectRule{Countries: []string{"us", " CA", "US"}, Languages: []string{"FR", "pt-BR"}, Platforms: []domain.Platform{"ios", "ios"}},
+			want: domain.RedirectRule{Countries: []string{"US", "CA"}, Languages: []string{"fr", "pt-br"}, Platforms: []domain.Platform{"ios"}},
+		},
+		{"no condition", domain.RedirectRule{}, domain.RedirectRule{}, ErrRuleWithoutCondition},
+		{"unknown platform", domain.RedirectRule{Platforms: []domain.Platform{"beos"}}, domain.RedirectRule{}, ErrInvalidRulePlatform},
+		{"country name", domain.RedirectRule{Countries: []string{"France"}}, domain.RedirectRule{}, ErrInvalidRuleCountry},
+		{"language", domain.RedirectRule{Languages: []string{"english!"}}, domain.RedirectRule{}, ErrInvalidRuleLanguage},
+		{"empty window", domain.RedirectRule{StartsAt: &end, EndsAt: &start}, domain.RedirectRule{}, ErrInvalidRuleTimeWindow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NormalizeRedirectRule(tt.rule)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+// memRuleStore keeps the redirect rules of links in memory.
+type memRuleStore struct {
+	rules  map[domain.ID][]datastore.UrlRedirectRule
+	nextID int32
+}
+
+func (s *memRuleStore) ListRedirectRules(_ context.Context, urlID domain.ID) ([]datastore.UrlRedirectRule, error) {
+	return s.rules[urlID], nil
+}
+
+func (s *memRuleStore) CountRedirectRuleVisits(_ context.Context, _ domain.ID) (map[domain.ID]int, error) {
+	return nil, nil
+}
+
+func (s *memRuleStore) ReplaceRedirectRules(_ context.Context, urlID domain.ID, rules []domain.RedirectRule) ([]datastore.UrlRedirectRule, error) {
+	rows := make([]datastore.UrlRedirectRule, 0, len(rules))
+	for i, rule := range rules {
+		id := int32(rule.ID)
+		if id == 0 {
+			s.nextID++
+			id = s.nextID
+		}
+		rows = append(rows, datastore.UrlRedirectRule{
+			ID:        id,
+			UrlID:     int32(urlID),
+			Position:  int32(i),
+			LongUrl:   rule.LongURL,
+			Countries: rule.Countries,
+			Languages: rule.Languages,
+		})
+	}
+	s.rules[urlID] = rows
+	return rows, nil
+}
+
+func TestSetRedirectRules(t *testing.T) {
+	ctx := context.Background()
+	store := newMemURLStore(testLink(1, "app", "https://example.com"))
+	rules := &memRuleStore{rules: map[domain.ID][]datastore.UrlRedirectRule{}}
+	svc := &urlService{repo: store, rules: rules, safetyScanner: stubScanner{"https://malware.test": "MALWARE"}}
+
+	_, err := svc.SetRedirectRules(ctx, 2, 5, domain.LinkRef{Slug: "app"}, nil)
+	assert.ErrorIs(t, err, ErrURLNotFound, "only the owner can set rules")
+
+	saved, err := svc.SetRedirectRules(ctx, 1, 5, domain.LinkRef{Slug: "app"}, []domain.RedirectRule{
+		{LongURL: "https://example.fr", Countries: []string{"fr"}},
+		{LongURL: "https://example.de", Countries: []string{"de"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ID{1, 2}, ruleIDs(saved))
+	assert.Equal(t, []string{"FR"}, saved[0].Countries)
+
+	// reordering keeps the ids, leaving a rule out deletes it
+	saved, err = svc.SetRedirectRules(ctx, 1, 5, domain.LinkRef{Slug: "app"}, []domain.RedirectRule{saved[1], {LongURL: "https://example.es", Countries: []string{"es"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ID{2, 3}, ruleIDs(saved))
+
+	_, err = svc.SetRedirectRules(ctx, 1, 5, domain.LinkRef{Slug: "app"}, []domain.RedirectRule{{ID: 1, LongURL: "https://example.fr", Countries: []string{"fr"}}})
+	assert.ErrorIs(t, err, ErrRedirectRuleNotFound, "deleted rules can't be updated")
+
+	_, err = svc.SetRedirectRules(ctx, 1, 5, domain.LinkRef{Slug: "app"}, []domain.RedirectRule{{LongURL: "https://malware.test", Countries: []string{"fr"}}})
+	assert.ErrorIs(t, err, ErrSuspiciousURL)
+	assert.Equal(t, []domain.ID{2, 3}, rowIDs(rules.rules[1]), "flagged rules are not saved")
+	assert.False(t, store.urls["app"].IsActive, "a flagged destination disables the link")
+	assert.Equal(t, []domain.ID{1}, store.flagged)
+	assert.Equal(t, []domain.ID{5}, store.suspended, "the member making the change is suspended")
+}
+
+func ruleIDs(rules []domain.RedirectRule) []domain.ID {
+	ids := make([]domain.ID, 0, len(rules))
+	for _, rule := range rules {
+		ids = append(ids, rule.ID)
+	}
+	return ids
+}
+
+func rowIDs(rows []datastore.UrlRedirectRule) []domain.ID {
+	ids := make([]domain.ID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, domain.ID(row.ID))
+	}
+	return ids
+}
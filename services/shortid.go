@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/zaibon/shortcut/log"
+)
+
+const (
+	// DefaultAlphabet is the URL safe base64 alphabet historically used for slugs.
+	DefaultAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	// UnambiguousAlphabet drops the characters that are easy to confuse when a
+	// link is read aloud or typed by hand: '-', '_', 'l', '1', 'I', 'O' and '0'.
+	UnambiguousAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+
+	minShortIDLength = 6
+	maxShortIDLength = 16
+
+	// maxShortIDLoad is the highest acceptable probability for a freshly
+	// generated ID to collide with an existing one.
+	maxShortIDLoad = 0.001
+	// maxShortIDAttempts bounds the number of inserts tried on collision.
+	maxShortIDAttempts = 5
+	// shortIDRefreshInterval is how often the link count is read back from
+	// the database to adapt the ID length.
+	shortIDRefreshInterval = 10 * time.Minute
+)
+
+type URLCounter interface {
+	EstimateURLCount(ctx context.Context) (int64, error)
+}
+
+// shortIDGenerator generates random slugs whose length grows with the number
+// of stored links, so the chance of a collision stays below maxShortIDLoad.
+type shortIDGenerator struct {
+	alphabet string
+	counter  URLCounter
+
+	mu          sync.Mutex
+	length      int
+	refreshedAt time.Time
+}
+
+func NewShortIDGenerator(counter URLCounter, alphabet string) *shortIDGenerator {
+	if alphabet == "" {
+		alphabet = DefaultAlphabet
+	}
+	return &shortIDGenerator{
+		alphabet: alphabet,
+		counter:  counter,
+		length:   minShortIDLength,
+	}
+}
+
+// Generate returns a new random ID. attempt is the number of collisions
+// already hit for the current link; every other collision adds a character.
+// IDs matching a reserved slug are drawn again, they would be shadowed by a
+// page of the application.
+func (g *shortIDGenerator) Generate(ctx context.Context, attempt int) (string, error) {
+	length := min(g.currentLength(ctx)+attempt/2, maxShortIDLength)
+	for {
+		id, err := randomString(g.alphabet, length)
+		if err != nil || !isReservedSlug(id) {
+			return id, err
+		}
+	}
+}
+
+// ReportCollision forces the link count to be refreshed on the next call to
+// Generate: a collision is a hint that the keyspace is filling up.
+func (g *shortIDGenerator) ReportCollision() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.refreshedAt = time.Time{}
+}
+
+func (g *shortIDGenerator) currentLength(ctx context.Context) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.counter == nil || time.Since(g.refreshedAt) < shortIDRefreshInterval {
+		return g.length
+	}
+
+	count, err := g.counter.EstimateURLCount(ctx)
+	if err != nil {
+		// keep the previous length, we'll retry at the next interval
+		log.Error("failed to count urls, keeping current short id length", "length", g.length, "err", err)
+	} else {
+		g.length = shortIDLength(len(g.alphabet), count)
+	}
+	g.refreshedAt = time.Now()
+
+	return g.length
+}
+
+// shortIDLength returns the smallest length for which count existing IDs
+// occupy less than maxShortIDLoad of the keyspace.
+func shortIDLength(alphabetSize int, count int64) int {
+	needed := float64(count) / maxShortIDLoad
+	for length := minShortIDLength; length < maxShortIDLength; length++ {
+		if math.Pow(float64(alphabetSize), float64(length)) > needed {
+			return length
+		}
+	}
+	return maxShortIDLength
+}
+
+// randomString draws length characters uniformly from alphabet. Bytes that
+// would bias the modulo are rejected.
+func randomString(alphabet string, length int) (string, error) {
+	n := len(alphabet)
+	limit := 256 - 256%n
+
+	out := make([]byte, 0, length)
+	buf := make([]byte, length*2)
+	for len(out) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			out = append(out, alphabet[int(b)%n])
+			if len(out) == length {
+				break
+			}
+		}
+	}
+	return string(out), nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubURLCounter struct {
+	count int64
+	err   error
+	calls int
+}
+
+func (s *stubURLCounter) EstimateURLCount(context.Context) (int64, error) {
+	s.calls++
+	return s.count, s.err
+}
+
+func TestShortIDLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+		count    int64
+		want     int
+	}{
+		{"empty table", DefaultAlphabet, 0, minShortIDLength},
+		{"below threshold", DefaultAlphabet, 60_000_000, 6},
+		{"grows past threshold", DefaultAlphabet, 70_000_000, 7},
+		{"smaller alphabet grows sooner", UnambiguousAlphabet, 70_000_000, 7},
+		{"smaller alphabet threshold", UnambiguousAlphabet, 40_000_000, 7},
+		{"capped", "ab", 1_000_000, maxShortIDLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, shortIDLength(len(tt.alphabet), tt.count))
+		})
+	}
+}
+
+func TestShortIDGenerator(t *testing.T) {
+	t.Run("uses only the configured alphabet", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewShortIDGenerator(nil, UnambiguousAlphabet)
+		for range 200 {
+			id, err := g.Generate(context.Background(), 0)
+			assert.NoError(t, err)
+			assert.Len(t, id, minShortIDLength)
+			for _, c := range id {
+				assert.True(t, strings.ContainsRune(UnambiguousAlphabet, c), "unexpected character %q in %q", c, id)
+			}
+		}
+	})
+
+	t.Run("grows with retries", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewShortIDGenerator(nil, "")
+		id, err := g.Generate(context.Background(), 3)
+		assert.NoError(t, err)
+		assert.Len(t, id, minShortIDLength+1)
+	})
+
+	t.Run("adapts to link count", func(t *testing.T) {
+		t.Parallel()
+
+		counter := &stubURLCounter{count: 70_000_000}
+		g := NewShortIDGenerator(counter, DefaultAlphabet)
+
+		id, err := g.Generate(context.Background(), 0)
+		assert.NoError(t, err)
+		assert.Len(t, id, 7)
+
+		// cached until the next refresh or collision
+		_, _ = g.Generate(context.Background(), 0)
+		assert.Equal(t, 1, counter.calls)
+
+		g.ReportCollision()
+		_, _ = g.Generate(context.Background(), 0)
+		assert.Equal(t, 2, counter.calls)
+	})
+
+	t.Run("keeps length when count fails", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewShortIDGenerator(&stubURLCounter{err: errors.New("db down")}, DefaultAlphabet)
+		id, err := g.Generate(context.Background(), 0)
+		assert.NoError(t, err)
+		assert.Len(t, id, minShortIDLength)
+	})
+	t.Run("skips reserved slugs", func(t *testing.T) {
+		t.Parallel()
+
+		// "static" is drawn once every 5^6 IDs from this alphabet
+		g := NewShortIDGenerator(nil, "stacI")
+		for range 100_000 {
+			id, err := g.Generate(context.Background(), 0)
+			assert.NoError(t, err)
+			if !assert.False(t, isReservedSlug(id), "generated reserved slug %q", id) {
+				return
+			}
+		}
+	})
+}
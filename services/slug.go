@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	minSlugLength = 3
+	maxSlugLength = 64
+
+	// urlsShortURLKey is the unique constraint on the slug of the links of a
+	// domain.
+	urlsShortURLKey = "urls_domain_short_url_key"
+)
+
+var (
+	ErrInvalidSlug  = fmt.Errorf("custom alias must be %d to %d characters long and only contain letters, digits, '-' and '_'", minSlugLength, maxSlugLength)
+	ErrReservedSlug = errors.New("this alias is reserved, please choose another one")
+	ErrSlugTaken    = errors.New("this alias is already taken, please choose another one")
+)
+
+var slugPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9_-]*[A-Za-z0-9])?$`)
+
+// reservedSlugs are first path segments served by the application itself. A
+// link using one of them would either be unreachable or shadow a page.
+var reservedSlugs = map[string]struct{}{
+	"about":        {},
+	"account":      {},
+	"admin":        {},
+	"api":          {},
+	"auth":         {},
+	"dashboard":    {},
+	"docs":         {},
+	"favicon":      {},
+	"folders":      {},
+	"healthz":      {},
+	"help":         {},
+	"invitations":  {},
+	"links":        {},
+	"login":        {},
+	"logout":       {},
+	"oauth":        {},
+	"pricing":      {},
+	"privacy":      {},
+	"robot":        {},
+	"robots":       {},
+	"settings":     {},
+	"shorten":      {},
+	"signup":       {},
+	"sitemap":      {},
+	"static":       {},
+	"subscription": {},
+	"tags":         {},
+	"terms":        {},
+	"urls":         {},
+	"urls-search":  {},
+	"urls-sort":    {},
+	"workspace":    {},
+	"workspaces":   {},
+	"www":          {},
+}
+
+// ValidateSlug checks that a custom slug is well formed and not reserved.
+func ValidateSlug(slug string) error {
+	if len(slug) < minSlugLength || len(slug) > maxSlugLength || !slugPattern.MatchString(slug) {
+		return ErrInvalidSlug
+	}
+	if isReservedSlug(slug) {
+		return ErrReservedSlug
+	}
+	return nil
+}
+
+// isReservedSlug reports whether slug is one of the reservedSlugs, whatever
+// its case.
+func isReservedSlug(slug string) bool {
+	_, ok := reservedSlugs[strings.ToLower(slug)]
+	return ok
+}
+
+// isSlugConflict reports whether err is a unique violation on the slug of a
+// link.
+func isSlugConflict(err error) bool {
+	return isUniqueViolation(err, urlsShortURLKey)
+}
+
+// isUniqueViolation reports whether err violates the unique constraint or
+// index named constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) &&
+		pgErr.Code == "23505" &&
+		pgErr.ConstraintName == constraint
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		slug string
+		want error
+	}{
+		{"simple", "launch-2026", nil},
+		{"underscore", "spring_sale", nil},
+		{"mixed case", "BlackFriday", nil},
+		{"min length", "abc", nil},
+		{"max length", strings.Repeat("a", maxSlugLength), nil},
+		{"too short", "ab", ErrInvalidSlug},
+		{"too long", strings.Repeat("a", maxSlugLength+1), ErrInvalidSlug},
+		{"slash", "a/b/c", ErrInvalidSlug},
+		{"dot", "file.txt", ErrInvalidSlug},
+		{"space", "my link", ErrInvalidSlug},
+		{"unicode", "café-menu", ErrInvalidSlug},
+		{"leading dash", "-abc", ErrInvalidSlug},
+		{"trailing underscore", "abc_", ErrInvalidSlug},
+		{"reserved", "admin", ErrReservedSlug},
+		{"reserved case insensitive", "Subscription", ErrReservedSlug},
+		{"reserved with dash", "urls-search", ErrReservedSlug},
+		{"reserved by the folders routes", "folders", ErrReservedSlug},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.ErrorIs(t, ValidateSlug(tt.slug), tt.want)
+		})
+	}
+}
+
+func TestIsSlugConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"short_url violation", &pgconn.PgError{Code: "23505", ConstraintName: urlsShortURLKey}, true},
+		{"wrapped", fmt.Errorf("failed to add shorten url: %w", &pgconn.PgError{Code: "23505", ConstraintName: urlsShortURLKey}), true},
+		{"other constraint", &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, false},
+		{"other error", fmt.Errorf("connection reset"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, isSlugConflict(tt.err))
+		})
+	}
+}
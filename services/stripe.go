@@ -23,11 +23,11 @@ type stripeStore interface {
 	Txer
 
 	InsertCustomer(ctx context.Context, customer datastore.InsertCustomerParams) (datastore.Customer, error)
-	GetCustomer(ctx context.Context, user *domain.User) (datastore.Customer, error)
+	GetCustomer(ctx context.Context, workspaceGUID domain.GUID) (datastore.Customer, error)
 	GetCustomerByStripeId(ctx context.Context, id string) (datastore.Customer, error)
 	InsertSubscription(ctx context.Context, subscription datastore.InsertSubscriptionParams) error
 	UpdateSubscription(ctx context.Context, subscription datastore.UpdateSubscriptionParams) error
-	ListSubscriptions(ctx context.Context, user *domain.User, status string) ([]datastore.Subscription, error)
+	ListSubscriptions(ctx context.Context, workspaceGUID domain.GUID, status string) ([]datastore.Subscription, error)
 }
 
 type stripeService struct {
@@ -56,13 +56,14 @@ func (s *stripeService) HandleSessionCheckout(ctx context.Context, session *stri
 		}
 
 		if errors.Is(err, pgx.ErrNoRows) {
-			userGUID, err := uuid.Parse(session.ClientReferenceID)
+			// the checkout is started for a workspace, see CreateCheckoutSession
+			workspaceGUID, err := uuid.Parse(session.ClientReferenceID)
 			if err != nil {
 				return err
 			}
 			customer, err = s.store.InsertCustomer(ctx, datastore.InsertCustomerParams{
-				UserID: pgtype.UUID{
-					Bytes: userGUID,
+				WorkspaceGuid: pgtype.UUID{
+					Bytes: workspaceGUID,
 					Valid: true,
 				},
 				StripeID: session.Customer.ID,
@@ -85,7 +86,7 @@ func (s *stripeService) HandleSessionCheckout(ctx context.Context, session *stri
 
 		err = s.store.InsertSubscription(ctx, datastore.InsertSubscriptionParams{
 			StripeID:          sub.ID,
-			CustomerID:        customer.UserID,
+			CustomerID:        customer.WorkspaceGuid,
 			StripePriceID:     sub.Items.Data[0].Price.ID,
 			StripeProductName: sub.Items.Data[0].Price.Product.Name,
 			Status:            string(sub.Status),
@@ -126,9 +127,10 @@ func (s stripeService) HandleSubscriptionUpdated(ctx context.Context, sub *strip
 	return nil
 }
 
-// GetSubscription returns the active subscription for the user
-func (s *stripeService) GetSubscription(ctx context.Context, user *domain.User) (*domain.Subscription, error) {
-	rows, err := s.store.ListSubscriptions(ctx, user, "active")
+// GetSubscription returns the active subscription of the workspace, shared by
+// all its members.
+func (s *stripeService) GetSubscription(ctx context.Context, ws domain.Workspace) (*domain.Subscription, error) {
+	rows, err := s.store.ListSubscriptions(ctx, ws.GUID, "active")
 	if err != nil {
 		return nil, err
 	}
@@ -149,9 +151,9 @@ func (s *stripeService) GetSubscription(ctx context.Context, user *domain.User)
 	return domain.NewSubscription(sub), nil
 }
 
-func (s *stripeService) GenerateCustomerPortalURL(ctx context.Context, user *domain.User) (string, error) {
+func (s *stripeService) GenerateCustomerPortalURL(ctx context.Context, ws domain.Workspace) (string, error) {
 
-	customer, err := s.store.GetCustomer(ctx, user)
+	customer, err := s.store.GetCustomer(ctx, ws.GUID)
 	if err != nil {
 		return "", fmt.Errorf("error getting customer: %w", err)
 	}
@@ -184,8 +186,10 @@ func (s *stripeService) GenerateCustomerPortalURL(ctx context.Context, user *dom
 	return sess.URL, nil
 }
 
-func (s *stripeService) CreateCheckoutSession(ctx context.Context, user *domain.User, priceID string) (string, error) {
-	customer, err := s.store.GetCustomer(ctx, user)
+// CreateCheckoutSession starts the subscription of ws to priceID. The Stripe
+// customer created for a workspace without one gets email.
+func (s *stripeService) CreateCheckoutSession(ctx context.Context, ws domain.Workspace, email, priceID string) (string, error) {
+	customer, err := s.store.GetCustomer(ctx, ws.GUID)
 	var customerID *string
 	if err == nil {
 		customerID = &customer.StripeID
@@ -197,7 +201,7 @@ func (s *stripeService) CreateCheckoutSession(ctx context.Context, user *domain.
 		SuccessURL:        stripe.String(s.returnURL + "?success=true"),
 		CancelURL:         stripe.String(s.returnURL + "?canceled=true"),
 		Mode:              stripe.String(string(stripe.CheckoutSessionModeSubscription)),
-		ClientReferenceID: stripe.String(user.GUID.String()),
+		ClientReferenceID: stripe.String(ws.GUID.String()),
 		LineItems: []*stripe.CheckoutSessionLineItemParams{
 			{
 				Price:    stripe.String(priceID),
@@ -209,7 +213,7 @@ func (s *stripeService) CreateCheckoutSession(ctx context.Context, user *domain.
 	if customerID != nil {
 		params.Customer = customerID
 	} else {
-		params.CustomerEmail = stripe.String(user.Email)
+		params.CustomerEmail = stripe.String(email)
 	}
 
 	sess, err := s.client.CheckoutSessions.New(params)
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+// TagStore persists the tags and folders links are organised with.
+type TagStore interface {
+	ListFolders(ctx context.Context, workspaceID domain.ID) ([]datastore.ListFoldersRow, error)
+	GetFolder(ctx context.Context, workspaceID, folderID domain.ID) (datastore.Folder, error)
+	CountFolders(ctx context.Context, workspaceID domain.ID) (int, error)
+	InsertFolder(ctx context.Context, workspaceID domain.ID, name string) (datastore.Folder, error)
+	DeleteFolder(ctx context.Context, workspaceID, folderID domain.ID) (bool, error)
+	SetURLFolder(ctx context.Context, urlID, folderID domain.ID) error
+	ListURLTags(ctx context.Context, urlID domain.ID) ([]string, error)
+	ReplaceTags(ctx context.Context, workspaceID, urlID domain.ID, names []string) error
+	TagStatistics(ctx context.Context, workspaceID domain.ID, filter domain.StatsFilter) ([]datastore.TagStatisticsRow, error)
+}
+
+const (
+	maxTagsPerLink   = 10
+	maxTagLength     = 32
+	maxFolders       = 100
+	maxFolderNameLen = 64
+
+	// foldersWorkspaceNameKey is the unique constraint on the names of the
+	// folders of a workspace.
+	foldersWorkspaceNameKey = "folders_workspace_id_name_key"
+)
+
+var (
+	ErrInvalidTag        = fmt.Errorf("tags must be 1 to %d characters long and only contain letters, digits, '-' and '_'", maxTagLength)
+	ErrTooManyTags       = fmt.Errorf("a link can have at most %d tags", maxTagsPerLink)
+	ErrInvalidFolderName = fmt.Errorf("folder names must be 1 to %d characters long", maxFolderNameLen)
+	ErrTooManyFolders    = fmt.Errorf("you can create at most %d folders", maxFolders)
+	ErrFolderExists      = errors.New("a folder with this name already exists")
+	ErrFolderNotFound    = errors.New("folder not found")
+)
+
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// NormalizeTags lowercases and trims tags, drops the empty and duplicated ones
+// and sorts them. Commas separate tags within a single value, as typed in a
+// form.
+func NormalizeTags(tags []string) ([]string, error) {
+	var names []string
+	for _, v := range tags {
+		for _, tag := range strings.Split(v, ",") {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag == "" {
+				continue
+			}
+			if utf8.RuneCountInString(tag) > maxTagLength || !tagPattern.MatchString(tag) {
+				return nil, ErrInvalidTag
+			}
+			names = append(names, tag)
+		}
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	if len(names) > maxTagsPerLink {
+		return nil, ErrTooManyTags
+	}
+	return names, nil
+}
+
+// ValidateFolderName checks name, once trimmed, can be given to a folder.
+func ValidateFolderName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > maxFolderNameLen {
+		return ErrInvalidFolderName
+	}
+	return nil
+}
+
+// Folders lists the folders of workspaceID by name.
+func (s *urlService) Folders(ctx context.Context, workspaceID domain.ID) ([]domain.Folder, error) {
+	rows, err := s.tags.ListFolders(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	folders := make([]domain.Folder, len(rows))
+	for i, row := range rows {
+		folders[i] = domain.Folder{
+			ID:    domain.ID(row.ID),
+			Name:  row.Name,
+			Links: int(row.Links),
+		}
+	}
+	return folders, nil
+}
+
+// CreateFolder creates a folder named name for workspaceID. Names are unique per
+// workspace, ErrFolderExists is returned when name is taken.
+func (s *urlService) CreateFolder(ctx context.Context, workspaceID domain.ID, name string) (domain.Folder, error) {
+	name = strings.TrimSpace(name)
+	if err := ValidateFolderName(name); err != nil {
+		return domain.Folder{}, err
+	}
+
+	n, err := s.tags.CountFolders(ctx, workspaceID)
+	if err != nil {
+		return domain.Folder{}, err
+	}
+	if n >= maxFolders {
+		return domain.Folder{}, ErrTooManyFolders
+	}
+
+	row, err := s.tags.InsertFolder(ctx, workspaceID, name)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == foldersWorkspaceNameKey {
+			return domain.Folder{}, ErrFolderExists
+		}
+		return domain.Folder{}, fmt.Errorf("failed to create folder: %w", err)
+	}
+	return domain.Folder{ID: domain.ID(row.ID), Name: row.Name}, nil
+}
+
+// DeleteFolder deletes a folder of workspaceID. Its links are kept, outside of any
+// folder.
+func (s *urlService) DeleteFolder(ctx context.Context, workspaceID, folderID domain.ID) error {
+	deleted, err := s.tags.DeleteFolder(ctx, workspaceID, folderID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrFolderNotFound
+	}
+	return nil
+}
+
+// SetFolder files a URL of workspaceID in one of its folders, or takes it
+// out of its folder when folderID is 0.
+func (s *urlService) SetFolder(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, folderID domain.ID) (domain.Folder, error) {
+	url, err := s.Get(ctx, workspaceID, ref)
+	if err != nil {
+		return domain.Folder{}, err
+	}
+
+	var folder domain.Folder
+	if folderID != 0 {
+		row, err := s.tags.GetFolder(ctx, workspaceID, folderID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Folder{}, ErrFolderNotFound
+		}
+		if err != nil {
+			return domain.Folder{}, fmt.Errorf("failed to get folder: %w", err)
+		}
+		folder = domain.Folder{ID: domain.ID(row.ID), Name: row.Name}
+	}
+
+	if err := s.tags.SetURLFolder(ctx, url.ID, folderID); err != nil {
+		return domain.Folder{}, fmt.Errorf("failed to set folder: %w", err)
+	}
+	return folder, nil
+}
+
+// SetTags replaces the tags of a URL of workspaceID and returns them
+// normalized, see NormalizeTags.
+func (s *urlService) SetTags(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, tags []string) ([]string, error) {
+	names, err := NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := s.Get(ctx, workspaceID, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tags.ReplaceTags(ctx, workspaceID, url.ID, names); err != nil {
+		return nil, err
+	}
+	if names == nil {
+		names = []string{}
+	}
+	return names, nil
+}
+
+// TagStats aggregates, per tag, the links of workspaceID wearing it and their
+// visits in filter.Period, all of them when it is zero. The most visited tags
+// come first.
+func (s *urlService) TagStats(ctx context.Context, workspaceID domain.ID, filter domain.StatsFilter) ([]domain.TagStat, error) {
+	rows, err := s.tags.TagStatistics(ctx, workspaceID, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]domain.TagStat, len(rows))
+	for i, row := range rows {
+		stats[i] = domain.TagStat{
+			Name:           row.Name,
+			Links:          int(row.Links),
+			Clicks:         int(row.Clicks),
+			UniqueVisitors: int(row.UniqueVisitors),
+		}
+	}
+	return stats, nil
+}
+
+// WithOrganization returns url, a link of workspaceID, with its tags and the name
+// of its folder.
+func (s *urlService) WithOrganization(ctx context.Context, workspaceID domain.ID, url domain.URL) (domain.URL, error) {
+	if err := s.withOrganization(ctx, workspaceID, &url); err != nil {
+		return domain.URL{}, err
+	}
+	return url, nil
+}
+
+// withOrganization fills the tags and folder name of url, a link of
+// workspaceID.
+func (s *urlService) withOrganization(ctx context.Context, workspaceID domain.ID, url *domain.URL) error {
+	tags, err := s.tags.ListURLTags(ctx, url.ID)
+	if err != nil {
+		return err
+	}
+	url.Tags = tags
+
+	if url.Folder.ID != 0 {
+		row, err := s.tags.GetFolder(ctx, workspaceID, url.Folder.ID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to get folder: %w", err)
+		}
+		url.Folder.Name = row.Name
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr error
+	}{
+		{name: "none", tags: nil, want: nil},
+		{name: "lowercased and sorted", tags: []string{"Summer", "blog"}, want: []string{"blog", "summer"}},
+		{name: "comma separated", tags: []string{"blog, summer-sale,, Blog"}, want: []string{"blog", "summer-sale"}},
+		{name: "unicode letters", tags: []string{"Été_2026"}, want: []string{"été_2026"}},
+		{name: "spaces", tags: []string{"black friday"}, wantErr: ErrInvalidTag},
+		{name: "too long", tags: []string{strings.Repeat("a", maxTagLength+1)}, wantErr: ErrInvalidTag},
+		{name: "too many", tags: []string{"a,b,c,d,e,f,g,h,i,j,k"}, wantErr: ErrTooManyTags},
+		{name: "duplicates don't count", tags: []string{"a,b,c,d,e,f,g,h,i,j,a"}, want: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NormalizeTags(tt.tags)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// memTagStore keeps folders, the tags of each link and the folder it is filed
+// in.
+type memTagStore struct {
+	TagStore
+	folders map[domain.ID]datastore.Folder
+	urlTags map[domain.ID][]string
+	linked  map[domain.ID]domain.ID
+}
+
+func (s *memTagStore) GetFolder(_ context.Context, workspaceID, folderID domain.ID) (datastore.Folder, error) {
+	f, ok := s.folders[folderID]
+	if !ok || domain.ID(f.WorkspaceID) != workspaceID {
+		return datastore.Folder{}, pgx.ErrNoRows
+	}
+	return f, nil
+}
+
+func (s *memTagStore) CountFolders(_ context.Context, workspaceID domain.ID) (int, error) {
+	n := 0
+	for _, f := range s.folders {
+		if domain.ID(f.WorkspaceID) == workspaceID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memTagStore) InsertFolder(_ context.Context, workspaceID domain.ID, name string) (datastore.Folder, error) {
+	for _, f := range s.folders {
+		if domain.ID(f.WorkspaceID) == workspaceID && f.Name == name {
+			return datastore.Folder{}, &pgconn.PgError{Code: "23505", ConstraintName: foldersWorkspaceNameKey}
+		}
+	}
+	f := datastore.Folder{ID: int32(len(s.folders) + 1), WorkspaceID: int32(workspaceID), Name: name}
+	s.folders[domain.ID(f.ID)] = f
+	return f, nil
+}
+
+func (s *memTagStore) SetURLFolder(_ context.Context, urlID, folderID domain.ID) error {
+	s.linked[urlID] = folderID
+	return nil
+}
+
+func (s *memTagStore) ReplaceTags(_ context.Context, _, urlID domain.ID, names []string) error {
+	s.urlTags[urlID] = names
+	return nil
+}
+
+func TestCreateFolder(t *testing.T) {
+	ctx := context.Background()
+	tags := &memTagStore{folders: map[domain.ID]datastore.Folder{}}
+	svc := &urlService{tags: tags}
+
+	folder, err := svc.CreateFolder(ctx, 1, "  Marketing ")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Folder{ID: 1, Name: "Marketing"}, folder)
+
+	_, err = svc.CreateFolder(ctx, 1, "Marketing")
+	assert.ErrorIs(t, err, ErrFolderExists)
+
+	_, err = svc.CreateFolder(ctx, 2, "Marketing")
+	assert.NoError(t, err, "names are unique per workspace")
+
+	_, err = svc.CreateFolder(ctx, 1, " ")
+	assert.ErrorIs(t, err, ErrInvalidFolderName)
+
+	for i := range maxFolders {
+		id := int32(100 + i)
+		tags.folders[domain.ID(id)] = datastore.Folder{ID: id, WorkspaceID: 3}
+	}
+	_, err = svc.CreateFolder(ctx, 3, "One too many")
+	assert.ErrorIs(t, err, ErrTooManyFolders)
+}
+
+func TestOrganizeLink(t *testing.T) {
+	ctx := context.Background()
+	store := newMemURLStore(testLink(7, "promo", "https://example.com"))
+	tags := &memTagStore{
+		folders: map[domain.ID]datastore.Folder{
+			1: {ID: 1, WorkspaceID: 1, Name: "Marketing"},
+			2: {ID: 2, WorkspaceID: 2, Name: "Another workspace's"},
+		},
+		urlTags: map[domain.ID][]string{},
+		linked:  map[domain.ID]domain.ID{},
+	}
+	svc := &urlService{repo: store, tags: tags}
+
+	saved, err := svc.SetTags(ctx, 1, domain.LinkRef{Slug: "promo"}, []string{"Summer, blog"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"blog", "summer"}, saved)
+	assert.Equal(t, saved, tags.urlTags[7])
+
+	saved, err = svc.SetTags(ctx, 1, domain.LinkRef{Slug: "promo"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, saved, "tags can all be removed")
+
+	_, err = svc.SetTags(ctx, 2, domain.LinkRef{Slug: "promo"}, []string{"blog"})
+	assert.ErrorIs(t, err, ErrURLNotFound, "only the owner can tag a link")
+
+	folder, err := svc.SetFolder(ctx, 1, domain.LinkRef{Slug: "promo"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Folder{ID: 1, Name: "Marketing"}, folder)
+	assert.Equal(t, domain.ID(1), tags.linked[7])
+
+	_, err = svc.SetFolder(ctx, 1, domain.LinkRef{Slug: "promo"}, 2)
+	assert.ErrorIs(t, err, ErrFolderNotFound, "links only go to the folders of their owner")
+	assert.Equal(t, domain.ID(1), tags.linked[7])
+
+	folder, err = svc.SetFolder(ctx, 1, domain.LinkRef{Slug: "promo"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Folder{}, folder)
+	assert.Equal(t, domain.ID(0), tags.linked[7])
+}
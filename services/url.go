@@ -2,12 +2,13 @@ package services
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -15,132 +16,311 @@ import (
 
 	"github.com/zaibon/shortcut/db/datastore"
 	"github.com/zaibon/shortcut/domain"
-	"github.com/zaibon/shortcut/drivers/ipquery"
 	"github.com/zaibon/shortcut/log"
+	"github.com/zaibon/shortcut/services/password"
 )
 
-const idLength = 6 //TODO: make this dynamic by reading the amount of url stored in DB.
-
 type URLStore interface {
-	Add(ctx context.Context, title, shortURL, longURL string, authorID domain.ID, isActive bool) (domain.ID, error)
-	List(ctx context.Context, authorID domain.ID, search string) ([]datastore.ListStatisticsPerAuthorRow, error)
-	Get(ctx context.Context, slug string) (datastore.Url, error)
+	Add(ctx context.Context, params domain.AddURLParams) (domain.ID, error)
+	List(ctx context.Context, workspaceID domain.ID, filter domain.LinkFilter, page domain.Page) ([]datastore.ListStatisticsPerAuthorRow, error)
+	CountLinks(ctx context.Context, workspaceID domain.ID, filter domain.LinkFilter) (int, error)
+	Get(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) (datastore.Url, error)
+	GetRedirect(ctx context.Context, host, slug string) (datastore.Url, error)
 	GetByID(ctx context.Context, id domain.ID) (datastore.Url, error)
-	Delete(ctx context.Context, urlID, authorID domain.ID) error
-
-	TrackRedirect(ctx context.Context, urlID domain.ID, request domain.RequestInfo) (datastore.Visit, error)
-	InsertVisitLocation(ctx context.Context, visitID domain.ID, loc domain.IPLocation) error
-	UpsertBrowser(ctx context.Context, requestInfo domain.Browser) (domain.Browser, error)
-
-	Statistics(ctx context.Context, authorID domain.ID) ([]datastore.ListStatisticsPerAuthorRow, error)
-	StatisticsDetail(ctx context.Context, authorID domain.ID, slug string) (datastore.StatisticPerURLRow, error)
-
-	CountMonthlyURL(ctx context.Context, authorID domain.ID) (int64, error)
-	CountMonthlyVisit(ctx context.Context, authorID domain.ID) (int64, error)
-	VisitOverTime(ctx context.Context, urlID domain.ID, period domain.Period, timeTrunc string) ([]domain.TimeSeriesData, error)
-
-	LocationDistribution(ctx context.Context, authorID, urlID domain.ID) ([]datastore.LocationDistributionRow, error)
-	BrowserDistribution(ctx context.Context, authorID, urlID domain.ID) ([]datastore.BrowserDistributionRow, error)
-	DeviceDistribution(ctx context.Context, authorID, urlID domain.ID) ([]datastore.DeviceDistributionRow, error)
-	RefererDistribution(ctx context.Context, authorID, urlID domain.ID) ([]datastore.ReferrerDistributionRow, error)
-	UniqueVisitCount(ctx context.Context, urlID domain.ID) (int64, error)
-	TotalVisit(ctx context.Context, urlID domain.ID) (int64, error)
-	UpdateURLStatus(ctx context.Context, shortURL string, isActive bool) error
+	EstimateURLCount(ctx context.Context) (int64, error)
+	Delete(ctx context.Context, urlID, workspaceID domain.ID) error
+
+	Statistics(ctx context.Context, workspaceID domain.ID) ([]datastore.ListStatisticsPerAuthorRow, error)
+	StatisticsDetail(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) (datastore.StatisticPerURLRow, error)
+	UpdateTitle(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, title string) (datastore.Url, error)
+	UpdateDestination(ctx context.Context, workspaceID, changedBy domain.ID, ref domain.LinkRef, title, longURL string, redirectStatus int, isActive bool) (datastore.Url, error)
+	ListDestinationHistory(ctx context.Context, urlID domain.ID) ([]datastore.ListDestinationHistoryRow, error)
+	UpdateExpiration(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, exp domain.Expiration) (datastore.Url, error)
+	CountClick(ctx context.Context, urlID domain.ID) (int, error)
+	ArchiveURL(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) error
+	UnarchiveURLs(ctx context.Context, workspaceID domain.ID, refs []domain.LinkRef) (int64, error)
+	UpdatePassword(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, password *domain.PasswordHash) (datastore.Url, error)
+	UpdateForwardQuery(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, forward bool) (datastore.Url, error)
+	UpdateStickyVariants(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, sticky bool) (datastore.Url, error)
+
+	CountMonthlyURL(ctx context.Context, workspaceID domain.ID) (int64, error)
+	CountMonthlyVisit(ctx context.Context, workspaceID domain.ID) (int64, error)
+	VisitOverTime(ctx context.Context, urlID domain.ID, period domain.Period, bucket domain.Bucket, filter domain.StatsFilter) ([]domain.TimeSeriesData, error)
+
+	LocationDistribution(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter) ([]datastore.LocationDistributionRow, error)
+	BrowserDistribution(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter) ([]datastore.BrowserDistributionRow, error)
+	DeviceDistribution(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter) ([]datastore.DeviceDistributionRow, error)
+	RefererDistribution(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter) ([]datastore.ReferrerDistributionRow, error)
+	UniqueVisitCount(ctx context.Context, urlID domain.ID, filter domain.StatsFilter) (int64, error)
+	TotalVisit(ctx context.Context, urlID domain.ID, filter domain.StatsFilter) (int64, error)
+	QRScanCount(ctx context.Context, urlID domain.ID, filter domain.StatsFilter) (int64, error)
+	ListVisits(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter, afterID domain.ID, limit int) ([]datastore.ListVisitsRow, error)
+	UpdateURLStatus(ctx context.Context, urlID domain.ID, isActive bool) error
 	InsertModerationFlag(ctx context.Context, urlID, userID domain.ID, riskScore int, threatType string) error
 	SuspendUserByID(ctx context.Context, userID domain.ID, isSuspended bool) error
 }
 
-var ErrSuspiciousURL = errors.New("the URL you entered has been flagged as suspicious; your account has been suspended pending review")
+var (
+	ErrSuspiciousURL = errors.New("the URL you entered has been flagged as suspicious; your account has been suspended pending review")
+	ErrURLNotFound   = errors.New("url not found")
+	ErrURLExpired    = errors.New("url expired")
+
+	ErrExpiryInPast      = errors.New("expiration date must be in the future")
+	ErrInvalidClickLimit = errors.New("click limit must be a positive number")
+
+	ErrInvalidRedirectStatus = errors.New("redirect status must be one of 301, 302, 307 or 308")
+
+	ErrInvalidPeriod   = errors.New("the end of the period must be after its start")
+	ErrPeriodTooLong   = errors.New("statistics cover at most 5 years")
+	ErrInvalidDate     = errors.New("dates must be formatted as YYYY-MM-DD")
+	ErrInvalidTimezone = errors.New("unknown timezone")
+)
+
+// maxPeriod bounds the span of statistics.
+const maxPeriod = 5 * 366 * 24 * time.Hour
 
 type urlService struct {
 	repo          URLStore
+	rules         RedirectRuleStore
+	variants      VariantStore
+	tags          TagStore
+	domains       DomainStore
 	safetyScanner SafetyScanner
-	shortDomain   string
+	idGenerator   *shortIDGenerator
+	hasher        password.PasswordHasher
+	// passwordAttempts throttles the guesses of link passwords
+	passwordAttempts *attemptLimiter
+	cache            *linkCache
+	// events is told about the links created, it may be nil
+	events      LinkEvents
+	shortDomain string
+	// defaultHost is the hostname of shortDomain
+	defaultHost string
 }
 
-func NewURL(repo URLStore, safetyScanner SafetyScanner, shortDomain string) *urlService {
-	return &urlService{
-		repo:          repo,
-		safetyScanner: safetyScanner,
-		shortDomain:   shortDomain,
+func NewURL(repo URLStore, rules RedirectRuleStore, variants VariantStore, tags TagStore, domains DomainStore, safetyScanner SafetyScanner, idGenerator *shortIDGenerator, cache *linkCache, events LinkEvents, shortDomain string) *urlService {
+	s := &urlService{
+		repo:             repo,
+		rules:            rules,
+		variants:         variants,
+		tags:             tags,
+		domains:          domains,
+		safetyScanner:    safetyScanner,
+		idGenerator:      idGenerator,
+		hasher:           password.DefaultArgon2iHasher(),
+		passwordAttempts: newAttemptLimiter(passwordAttemptWindow),
+		cache:            cache,
+		events:           events,
+		shortDomain:      shortDomain,
 	}
+	if u, err := url.Parse(shortDomain); err == nil {
+		s.defaultHost = requestHostname(u.Host)
+	}
+	return s
 }
 
-// Shorten creates a new short URL, if title is empty it will try to extract it from the URL.
-func (s *urlService) Shorten(ctx context.Context, targetURL, title string, userID domain.ID) (string, error) {
-	shortURL, err := generateShortID(idLength)
+// Shorten creates a new short URL in workspaceID on behalf of its member
+// userID, if title is empty it will try to extract it from the URL.
+// ErrSlugTaken is returned when opts.Slug is already used by another link of
+// the domain or of the workspace.
+func (s *urlService) Shorten(ctx context.Context, targetURL, title string, workspaceID, userID domain.ID, opts domain.ShortenOptions) (domain.URL, error) {
+	if opts.Slug != "" {
+		if err := ValidateSlug(opts.Slug); err != nil {
+			return domain.URL{}, err
+		}
+	}
+	if err := ValidateExpiration(opts.Expiration, time.Now()); err != nil {
+		return domain.URL{}, err
+	}
+	if opts.RedirectStatus == 0 {
+		opts.RedirectStatus = domain.DefaultRedirectStatus
+	}
+	if err := ValidateRedirectStatus(opts.RedirectStatus); err != nil {
+		return domain.URL{}, err
+	}
+	targetURL, err := ApplyUTM(targetURL, opts.UTM)
 	if err != nil {
-		return "", err
+		return domain.URL{}, err
+	}
+	if opts.Domain, err = s.linkDomain(ctx, workspaceID, opts.Domain); err != nil {
+		return domain.URL{}, err
 	}
 
 	if title == "" {
 		title = ExtractTitle(targetURL)
 	}
 
-	// Scan URL for safety threats
-	var riskScore int
-	var threatType string
-	if s.safetyScanner != nil {
-		var scanErr error
-		riskScore, threatType, scanErr = s.safetyScanner.Scan(ctx, targetURL)
-		if scanErr != nil {
-			log.Error("Safety Scanner error scanning URL", "url", targetURL, "err", scanErr)
+	params := domain.AddURLParams{
+		Title:          title,
+		Slug:           opts.Slug,
+		Domain:         opts.Domain,
+		Long:           targetURL,
+		WorkspaceID:    workspaceID,
+		AuthorID:       userID,
+		Expiration:     opts.Expiration,
+		RedirectStatus: opts.RedirectStatus,
+		ForwardQuery:   opts.ForwardQuery,
+	}
+	if opts.Password != "" {
+		hash, err := s.hashPassword(opts.Password)
+		if err != nil {
+			return domain.URL{}, err
 		}
+		params.Password = hash
 	}
 
+	riskScore, threatType := s.scan(ctx, targetURL)
 	if threatType != "" || riskScore > 0 {
-		log.Info("Safety Scanner: URL flagged as dangerous", "url", targetURL, "threat", threatType, "score", riskScore)
-
 		// 1. Add URL as INACTIVE
-		urlID, addErr := s.repo.Add(ctx, title, shortURL, targetURL, userID, false)
+		params.IsActive = false
+		urlID, _, addErr := s.add(ctx, params)
 		if addErr != nil {
-			return "", fmt.Errorf("failed to add inactive URL after safety flag: %w", addErr)
+			return domain.URL{}, fmt.Errorf("failed to add inactive URL after safety flag: %w", addErr)
 		}
 
-		// 2. Insert Moderation Flag
-		if err := s.repo.InsertModerationFlag(ctx, urlID, userID, riskScore, threatType); err != nil {
-			log.Error("failed to log moderation flag for url", "url_id", urlID, "err", err)
-		}
+		// 2. Flag the URL and suspend its author
+		s.flag(ctx, urlID, userID, riskScore, threatType)
 
-		// 3. Suspend User
-		if err := s.repo.SuspendUserByID(ctx, userID, true); err != nil {
-			log.Error("failed to suspend user", "user_id", userID, "err", err)
-		}
+		return domain.URL{}, ErrSuspiciousURL
+	}
+
+	params.IsActive = true
+	return s.create(ctx, params)
+}
 
-		return "", ErrSuspiciousURL
+// linkDomain normalizes hostname, the domain a new link of workspaceID is
+// served on, which must be one of its verified domains. An empty hostname is
+// the default domain.
+func (s *urlService) linkDomain(ctx context.Context, workspaceID domain.ID, hostname string) (string, error) {
+	if strings.TrimSpace(hostname) == "" {
+		return "", nil
+	}
+	hostname, err := NormalizeHostname(hostname)
+	if err != nil {
+		return "", err
 	}
 
-	if _, err := s.repo.Add(ctx, title, shortURL, targetURL, userID, true); err != nil {
+	domains, err := s.domains.ListDomains(ctx, workspaceID)
+	if err != nil {
 		return "", err
 	}
+	for _, d := range domains {
+		if d.Hostname == hostname && d.Verified() {
+			return hostname, nil
+		}
+	}
+	return "", ErrDomainNotVerified
+}
+
+// create inserts an active link and tells the events about it.
+func (s *urlService) create(ctx context.Context, params domain.AddURLParams) (domain.URL, error) {
+	params.IsActive = true
+	urlID, shortURL, err := s.add(ctx, params)
+	if err != nil {
+		return domain.URL{}, err
+	}
+
+	u := domain.URL{
+		ID:             urlID,
+		Title:          params.Title,
+		Long:           params.Long,
+		Short:          toURL(s.shortDomain, params.Domain, shortURL),
+		Slug:           shortURL,
+		Domain:         params.Domain,
+		IsActive:       true,
+		CreatedAt:      time.Now(),
+		Expiration:     params.Expiration,
+		HasPassword:    params.Password != nil,
+		RedirectStatus: params.RedirectStatus,
+		ForwardQuery:   params.ForwardQuery,
+	}
+	if s.events != nil {
+		s.events.LinkCreated(ctx, params.WorkspaceID, u)
+	}
+	return u, nil
+}
+
+// scan checks targetURL with the safety scanner. A scanner error is logged
+// and the URL is considered safe.
+func (s *urlService) scan(ctx context.Context, targetURL string) (int, string) {
+	if s.safetyScanner == nil {
+		return 0, ""
+	}
 
-	return toURL(s.shortDomain, shortURL), nil
+	riskScore, threatType, err := s.safetyScanner.Scan(ctx, targetURL)
+	if err != nil {
+		log.Error("Safety Scanner error scanning URL", "url", targetURL, "err", err)
+	}
+	if threatType != "" || riskScore > 0 {
+		log.Info("Safety Scanner: URL flagged as dangerous", "url", targetURL, "threat", threatType, "score", riskScore)
+	}
+	return riskScore, threatType
 }
 
-func (s *urlService) ToggleLinkStatus(ctx context.Context, shortURL string, isActive bool) error {
-	return s.repo.UpdateURLStatus(ctx, shortURL, isActive)
+// flag records a moderation flag for an inactive dangerous URL and suspends
+// its author pending review.
+func (s *urlService) flag(ctx context.Context, urlID, userID domain.ID, riskScore int, threatType string) {
+	if err := s.repo.InsertModerationFlag(ctx, urlID, userID, riskScore, threatType); err != nil {
+		log.Error("failed to log moderation flag for url", "url_id", urlID, "err", err)
+	}
+
+	if err := s.repo.SuspendUserByID(ctx, userID, true); err != nil {
+		log.Error("failed to suspend user", "user_id", userID, "err", err)
+	}
+}
+
+// add inserts the link under params.Slug, or under a generated ID when the
+// slug is empty. Generated IDs are retried on collision; a taken custom slug
+// fails with ErrSlugTaken.
+func (s *urlService) add(ctx context.Context, params domain.AddURLParams) (domain.ID, string, error) {
+	if params.Slug != "" {
+		urlID, err := s.repo.Add(ctx, params)
+		if isSlugConflict(err) {
+			return 0, "", ErrSlugTaken
+		}
+		return urlID, params.Slug, err
+	}
+
+	for attempt := range maxShortIDAttempts {
+		shortURL, err := s.idGenerator.Generate(ctx, attempt)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to generate short id: %w", err)
+		}
+
+		params.Slug = shortURL
+		urlID, err := s.repo.Add(ctx, params)
+		if isSlugConflict(err) {
+			log.Info("short id collision, retrying", "attempt", attempt+1)
+			s.idGenerator.ReportCollision()
+			continue
+		}
+		return urlID, shortURL, err
+	}
+
+	return 0, "", fmt.Errorf("failed to generate a unique short id after %d attempts", maxShortIDAttempts)
+}
+
+func (s *urlService) ToggleLinkStatus(ctx context.Context, urlID domain.ID, isActive bool) error {
+	defer s.cache.InvalidateID(urlID)
+	return s.repo.UpdateURLStatus(ctx, urlID, isActive)
 }
 
 func (s *urlService) ExtractTitle(url string) string {
 	return ExtractTitle(url)
 }
 
-func (s *urlService) Get(ctx context.Context, authorID domain.ID, slug string) (domain.URL, error) {
-	row, err := s.repo.Get(ctx, slug)
+// Get returns the URL identified by ref. ErrURLNotFound is returned when the
+// link does not exist or belongs to another workspace.
+func (s *urlService) Get(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) (domain.URL, error) {
+	row, err := s.repo.Get(ctx, workspaceID, ref)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.URL{}, ErrURLNotFound
+	}
 	if err != nil {
 		return domain.URL{}, err
 	}
 
-	return domain.URL{
-		Title:      row.Title,
-		ID:         domain.ID(row.ID),
-		Long:       row.LongUrl,
-		Short:      toURL(s.shortDomain, row.ShortUrl),
-		Slug:       row.ShortUrl,
-		IsArchived: row.IsArchived.Bool,
-		IsActive:   row.IsActive,
-		CreatedAt:  row.CreatedAt.Time,
-	}, nil
+	return s.fromRow(row), nil
 }
 
 func (s *urlService) GetByID(ctx context.Context, id domain.ID) (domain.URL, error) {
@@ -149,22 +329,197 @@ func (s *urlService) GetByID(ctx context.Context, id domain.ID) (domain.URL, err
 		return domain.URL{}, err
 	}
 
+	return s.fromRow(row), nil
+}
+
+// Edit changes the title, destination and redirect status of a URL of
+// workspaceID on behalf of its member userID. A new destination goes through
+// the safety scanner again: if it is flagged the link is disabled and userID
+// suspended, as when shortening.
+// An empty title is extracted from the destination when it changed, kept
+// otherwise. A zero redirectStatus keeps the current one.
+func (s *urlService) Edit(ctx context.Context, workspaceID, userID domain.ID, ref domain.LinkRef, title, longURL string, redirectStatus int) (domain.URL, error) {
+	current, err := s.Get(ctx, workspaceID, ref)
+	if err != nil {
+		return domain.URL{}, err
+	}
+
+	if redirectStatus == 0 {
+		redirectStatus = current.RedirectStatus
+	}
+	if err := ValidateRedirectStatus(redirectStatus); err != nil {
+		return domain.URL{}, err
+	}
+
+	changed := longURL != current.Long
+	if title == "" {
+		title = current.Title
+		if changed {
+			title = ExtractTitle(longURL)
+		}
+	}
+
+	var riskScore int
+	var threatType string
+	if changed {
+		riskScore, threatType = s.scan(ctx, longURL)
+	}
+	dangerous := threatType != "" || riskScore > 0
+
+	row, err := s.repo.UpdateDestination(ctx, workspaceID, userID, ref, title, longURL, redirectStatus, current.IsActive && !dangerous)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.URL{}, ErrURLNotFound
+	}
+	if err != nil {
+		return domain.URL{}, err
+	}
+	s.cache.InvalidateID(domain.ID(row.ID))
+
+	if dangerous {
+		s.flag(ctx, current.ID, userID, riskScore, threatType)
+		return domain.URL{}, ErrSuspiciousURL
+	}
+
+	return s.fromRow(row), nil
+}
+
+// DestinationHistory lists the previous destinations of a URL of
+// workspaceID, most recent first.
+func (s *urlService) DestinationHistory(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) ([]domain.DestinationChange, error) {
+	url, err := s.Get(ctx, workspaceID, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListDestinationHistory(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]domain.DestinationChange, 0, len(rows))
+	for _, row := range rows {
+		history = append(history, domain.DestinationChange{
+			LongURL:   row.LongUrl,
+			ChangedBy: row.ChangedBy,
+			ChangedAt: row.ChangedAt.Time,
+		})
+	}
+	return history, nil
+}
+
+func (s *urlService) fromRow(row datastore.Url) domain.URL {
 	return domain.URL{
-		Title:      row.Title,
-		ID:         domain.ID(row.ID),
-		Long:       row.LongUrl,
-		Short:      toURL(s.shortDomain, row.ShortUrl),
-		Slug:       row.ShortUrl,
-		IsArchived: row.IsArchived.Bool,
-		IsActive:   row.IsActive,
-		CreatedAt:  row.CreatedAt.Time,
-	}, nil
-}
-
-func (s *urlService) List(ctx context.Context, authorID domain.ID, search string) ([]domain.URLStat, error) {
-	rows, err := s.repo.List(ctx, authorID, search)
+		Title:          row.Title,
+		ID:             domain.ID(row.ID),
+		Long:           row.LongUrl,
+		Short:          toURL(s.shortDomain, row.Domain, row.ShortUrl),
+		Slug:           row.ShortUrl,
+		Domain:         row.Domain,
+		IsArchived:     row.IsArchived.Bool,
+		IsActive:       row.IsActive,
+		CreatedAt:      row.CreatedAt.Time,
+		Expiration:     expirationFromRow(row),
+		HasPassword:    len(row.PasswordHash) > 0,
+		RedirectStatus: int(row.RedirectStatus),
+		ForwardQuery:   row.ForwardQuery,
+		StickyVariants: row.StickyVariants,
+		Folder:         domain.Folder{ID: domain.ID(row.FolderID.Int32)},
+		Clicks:         int(row.Clicks),
+	}
+}
+
+func expirationFromRow(row datastore.Url) domain.Expiration {
+	var exp domain.Expiration
+	if row.ExpiresAt.Valid {
+		expiresAt := row.ExpiresAt.Time
+		exp.ExpiresAt = &expiresAt
+	}
+	if row.MaxClicks.Valid {
+		maxClicks := int(row.MaxClicks.Int32)
+		exp.MaxClicks = &maxClicks
+	}
+	return exp
+}
+
+// ValidateExpiration checks that exp can be set on a link at time now.
+func ValidateExpiration(exp domain.Expiration, now time.Time) error {
+	if exp.ExpiresAt != nil && !exp.ExpiresAt.After(now) {
+		return ErrExpiryInPast
+	}
+	if exp.MaxClicks != nil && *exp.MaxClicks <= 0 {
+		return ErrInvalidClickLimit
+	}
+	return nil
+}
+
+// ValidateRedirectStatus checks that status is one of domain.RedirectStatuses.
+func ValidateRedirectStatus(status int) error {
+	if !slices.Contains(domain.RedirectStatuses, status) {
+		return ErrInvalidRedirectStatus
+	}
+	return nil
+}
+
+// UpdateExpiration replaces the expiration of a URL of workspaceID. A zero
+// Expiration removes any limit.
+func (s *urlService) UpdateExpiration(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, exp domain.Expiration) (domain.URL, error) {
+	if err := ValidateExpiration(exp, time.Now()); err != nil {
+		return domain.URL{}, err
+	}
+
+	row, err := s.repo.UpdateExpiration(ctx, workspaceID, ref, exp)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.URL{}, ErrURLNotFound
+	}
+	if err != nil {
+		return domain.URL{}, err
+	}
+	s.cache.InvalidateID(domain.ID(row.ID))
+
+	return s.fromRow(row), nil
+}
+
+// IsExpired reports whether url reached its expiry date or its click limit.
+// The clicks of url may be stale when it comes from the cache, CountClick has
+// the last word on the limit.
+func (s *urlService) IsExpired(_ context.Context, url domain.URL) (bool, error) {
+	return url.Expired(time.Now(), url.Clicks), nil
+}
+
+// CountClick counts the redirect of r to url toward its click limit, and
+// reports whether the limit was already reached, in which case r must not be
+// redirected. Visits of bots don't count, so that link previews don't use up
+// the clicks of a link.
+func (s *urlService) CountClick(ctx context.Context, url domain.URL, r *http.Request) (bool, error) {
+	info := parseRequest(r)
+	if IsBot(&info, nil) {
+		return false, nil
+	}
+
+	_, err := s.repo.CountClick(ctx, url.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return true, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to list shorten urls: %w", err)
+		return false, fmt.Errorf("failed to count click: %w", err)
+	}
+	return false, nil
+}
+
+// List returns a page of the links of workspaceID matching filter, in the order
+// of filter.Sort, and the number of links matching it. Archived links are only
+// listed when filter.Archived is true, and the other way around.
+func (s *urlService) List(ctx context.Context, workspaceID domain.ID, filter domain.LinkFilter, page domain.Page) ([]domain.URLStat, int, error) {
+	rows, err := s.repo.List(ctx, workspaceID, filter, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list shorten urls: %w", err)
+	}
+	// a page that isn't full is the last one and tells the total
+	total := page.Offset + len(rows)
+	if page.Limit > 0 && (len(rows) == page.Limit || (len(rows) == 0 && page.Offset > 0)) {
+		if total, err = s.repo.CountLinks(ctx, workspaceID, filter); err != nil {
+			return nil, 0, fmt.Errorf("failed to count shorten urls: %w", err)
+		}
 	}
 	urls := make([]domain.URLStat, len(rows))
 	for i, v := range rows {
@@ -175,74 +530,98 @@ func (s *urlService) List(ctx context.Context, authorID domain.ID, search string
 		}
 		urls[i] = domain.URLStat{
 			URL: domain.URL{
-				Title: v.Title,
-				ID:    domain.ID(v.ID),
-				Long:  v.LongUrl,
-				Short: toURL(s.shortDomain, v.ShortUrl),
-				Slug:  v.ShortUrl,
-				// IsArchived: v.IsArchived.Bool,
-				CreatedAt: v.CreatedAt.Time,
-				NrVisited: int(v.NrVisits),
+				Title:      v.Title,
+				ID:         domain.ID(v.ID),
+				Long:       v.LongUrl,
+				Short:      toURL(s.shortDomain, v.Domain, v.ShortUrl),
+				Slug:       v.ShortUrl,
+				Domain:     v.Domain,
+				IsArchived: v.IsArchived,
+				CreatedAt:  v.CreatedAt.Time,
+				Folder:     domain.Folder{ID: domain.ID(v.FolderID), Name: v.FolderName},
+				Tags:       v.Tags,
+				NrVisited:  int(v.NrVisits),
 			},
 		}
 	}
 
-	return urls, nil
+	return urls, total, nil
 }
 
-func (s *urlService) Delete(ctx context.Context, urlID, authorID domain.ID) error {
-	return s.repo.Delete(ctx, urlID, authorID)
+// Archive hides a link of workspaceID from the dashboard. Archiving only
+// declutters the list: an archived link keeps redirecting, disabling it is
+// done with an expiration.
+func (s *urlService) Archive(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) error {
+	if _, err := s.Get(ctx, workspaceID, ref); err != nil {
+		return err
+	}
+	return s.repo.ArchiveURL(ctx, workspaceID, ref)
 }
 
-func (s *urlService) Expand(ctx context.Context, short string) (domain.URL, error) {
-	item, err := s.repo.Get(ctx, short)
-	if err != nil {
-		return domain.URL{}, err
+// Unarchive brings the links of workspaceID back to the dashboard. Links that
+// don't exist or belong to someone else are ignored, the number of links
+// actually unarchived is returned.
+func (s *urlService) Unarchive(ctx context.Context, workspaceID domain.ID, refs ...domain.LinkRef) (int64, error) {
+	if len(refs) == 0 {
+		return 0, nil
 	}
-
-	return domain.URL{
-		ID:       domain.ID(item.ID),
-		Long:     item.LongUrl,
-		Short:    short,
-		IsActive: item.IsActive,
-	}, nil
+	return s.repo.UnarchiveURLs(ctx, workspaceID, refs)
 }
 
-func (s *urlService) TrackRedirect(ctx context.Context, urlID domain.ID, r *http.Request) error {
-	requestInfo := parseRequest(r)
+func (s *urlService) Delete(ctx context.Context, urlID, workspaceID domain.ID) error {
+	defer s.cache.InvalidateID(urlID)
+	return s.repo.Delete(ctx, urlID, workspaceID)
+}
 
-	visit, err := s.repo.TrackRedirect(ctx, urlID, requestInfo)
-	if err != nil {
-		return fmt.Errorf("failed to track redirect: %w", err)
+// Expand returns what the redirect of short needs to know about its link,
+// from the cache when possible. host is the Host header of the redirect: a
+// verified custom domain only serves its own links, any other host the ones
+// of the default domain.
+//
+// Links are cached under the domain they belong to, so only the redirects of
+// the default host and of verified custom domains are served from the cache:
+// the Host header of a request is not trusted to build a key.
+func (s *urlService) Expand(ctx context.Context, host, short string) (domain.URL, error) {
+	host = requestHostname(host)
+	cached := host
+	if host == s.defaultHost {
+		cached = ""
+	}
+	if url, ok := s.cache.Get(cached + "/" + short); ok {
+		return url, nil
 	}
 
-	var loc domain.IPLocation
-
-	ip := requestInfo.IpAddress()
-	ipLoc, err := ipquery.QueryIP(ip)
+	gen := s.cache.Generation()
+	item, err := s.repo.GetRedirect(ctx, host, short)
 	if err != nil {
-		log.Warn("failed to get country", "err", err, "ip", ip)
-		if requestInfo.Country() != "" {
-			loc = domain.IPLocation{
-				IP:          ip,
-				CountryCode: requestInfo.Country(),
-			}
-		} else {
-			return nil
-		}
-	} else {
-		loc = fromIPQuery(*ipLoc)
+		return domain.URL{}, err
 	}
 
-	if err := s.repo.InsertVisitLocation(ctx, domain.ID(visit.ID), loc); err != nil {
-		log.Warn("failed to insert visit location", "err", err)
+	url := domain.URL{
+		ID:             domain.ID(item.ID),
+		Long:           item.LongUrl,
+		Short:          short,
+		Slug:           short,
+		Domain:         item.Domain,
+		IsActive:       item.IsActive,
+		Expiration:     expirationFromRow(item),
+		HasPassword:    len(item.PasswordHash) > 0,
+		RedirectStatus: int(item.RedirectStatus),
+		ForwardQuery:   item.ForwardQuery,
+		StickyVariants: item.StickyVariants,
 	}
-
-	return nil
+	if url.Rules, err = s.listRules(ctx, url.ID); err != nil {
+		return domain.URL{}, err
+	}
+	if url.Variants, err = s.listVariants(ctx, url.ID); err != nil {
+		return domain.URL{}, err
+	}
+	s.cache.AddSince(gen, item.Domain+"/"+short, url)
+	return url, nil
 }
 
-func (s *urlService) Statistics(ctx context.Context, authorID domain.ID) ([]domain.URLStat, error) {
-	rows, err := s.repo.Statistics(ctx, authorID)
+func (s *urlService) Statistics(ctx context.Context, workspaceID domain.ID) ([]domain.URLStat, error) {
+	rows, err := s.repo.Statistics(ctx, workspaceID)
 	if err != nil {
 		return nil, fmt.Errorf(
 			"failed to list shorten urls: %w", err)
@@ -255,7 +634,7 @@ func (s *urlService) Statistics(ctx context.Context, authorID domain.ID) ([]doma
 				ID:        domain.ID(r.ID),
 				Slug:      r.ShortUrl,
 				Long:      r.LongUrl,
-				Short:     toURL(s.shortDomain, r.ShortUrl),
+				Short:     toURL(s.shortDomain, r.Domain, r.ShortUrl),
 				CreatedAt: r.CreatedAt.Time,
 				NrVisited: int(r.NrVisits),
 			},
@@ -265,8 +644,13 @@ func (s *urlService) Statistics(ctx context.Context, authorID domain.ID) ([]doma
 	return stats, nil
 }
 
-func (s *urlService) StatisticsDetail(ctx context.Context, authorID domain.ID, slug string) (domain.URLStat, error) {
-	url, err := s.repo.Get(ctx, slug)
+// StatisticsDetail computes the statistics of a URL of workspaceID from the
+// visits selected by filter.
+func (s *urlService) StatisticsDetail(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, filter domain.StatsFilter) (domain.URLStat, error) {
+	url, err := s.repo.Get(ctx, workspaceID, ref)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.URLStat{}, ErrURLNotFound
+	}
 	if err != nil {
 		return domain.URLStat{}, fmt.Errorf("failed to get url: %w", err)
 	}
@@ -275,20 +659,13 @@ func (s *urlService) StatisticsDetail(ctx context.Context, authorID domain.ID, s
 	var (
 		g, gCtx = errgroup.WithContext(ctx)
 		stats   = domain.URLStat{
-			URL: domain.URL{
-				ID:        domain.ID(url.ID),
-				Title:     url.Title,
-				Long:      url.LongUrl,
-				Short:     toURL(s.shortDomain, url.ShortUrl),
-				Slug:      slug,
-				CreatedAt: url.CreatedAt.Time,
-				NrVisited: 0,
-			},
+			URL:    s.fromRow(url),
+			Filter: filter,
 		}
 	)
 
 	g.Go(func() error {
-		ld, err := s.repo.LocationDistribution(gCtx, authorID, urlID)
+		ld, err := s.repo.LocationDistribution(gCtx, workspaceID, urlID, filter)
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get location distribution: %w", err)
 		}
@@ -309,7 +686,7 @@ func (s *urlService) StatisticsDetail(ctx context.Context, authorID domain.ID, s
 	})
 
 	g.Go(func() error {
-		bd, err := s.repo.BrowserDistribution(gCtx, authorID, urlID)
+		bd, err := s.repo.BrowserDistribution(gCtx, workspaceID, urlID, filter)
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get browser distribution: %w", err)
 		}
@@ -324,7 +701,7 @@ func (s *urlService) StatisticsDetail(ctx context.Context, authorID domain.ID, s
 	})
 
 	g.Go(func() error {
-		dd, err := s.repo.DeviceDistribution(gCtx, authorID, urlID)
+		dd, err := s.repo.DeviceDistribution(gCtx, workspaceID, urlID, filter)
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get device distribution: %w", err)
 		}
@@ -339,7 +716,7 @@ func (s *urlService) StatisticsDetail(ctx context.Context, authorID domain.ID, s
 	})
 
 	g.Go(func() error {
-		rd, err := s.repo.RefererDistribution(gCtx, authorID, urlID)
+		rd, err := s.repo.RefererDistribution(gCtx, workspaceID, urlID, filter)
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get referer distribution: %w", err)
 		}
@@ -354,7 +731,7 @@ func (s *urlService) StatisticsDetail(ctx context.Context, authorID domain.ID, s
 	})
 
 	g.Go(func() error {
-		total, err := s.repo.TotalVisit(gCtx, urlID)
+		total, err := s.repo.TotalVisit(gCtx, urlID, filter)
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get total visit: %w", err)
 		}
@@ -366,38 +743,57 @@ func (s *urlService) StatisticsDetail(ctx context.Context, authorID domain.ID, s
 	})
 
 	g.Go(func() error {
-		unique, err := s.repo.UniqueVisitCount(gCtx, urlID)
+		scans, err := s.repo.QRScanCount(gCtx, urlID, filter)
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("failed to get unique visit count: %w", err)
-		}
-		if errors.Is(err, pgx.ErrNoRows) {
-			unique = 0
+			return fmt.Errorf("failed to get QR scan count: %w", err)
 		}
-
-		stats.UniqueVisitors = int(unique)
+		stats.QRScans = int(scans)
 		return nil
 	})
 
 	g.Go(func() error {
-		now := time.Now()
-		since := now.AddDate(0, 0, -1).Truncate(time.Hour)
-		until := now
-		period := domain.Period{Since: since, Until: until}
-
-		visitsPerDay, err := s.repo.VisitOverTime(gCtx, urlID, period, "hour")
+		unique, err := s.repo.UniqueVisitCount(gCtx, urlID, filter)
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("failed to get unique visit count: %w", err)
 		}
-
 		if errors.Is(err, pgx.ErrNoRows) {
-			visitsPerDay = []domain.TimeSeriesData{}
+			unique = 0
 		}
 
-		stats.VisitPerDay = visitPerDay(visitsPerDay, period, time.Hour)
+		stats.UniqueVisitors = int(unique)
+		return nil
+	})
 
+	g.Go(func() error {
+		series, err := visitSeries(filter, time.Now(), func(period domain.Period, bucket domain.Bucket) ([]domain.TimeSeriesData, error) {
+			return s.repo.VisitOverTime(gCtx, urlID, period, bucket, filter)
+		})
+		if err != nil {
+			return err
+		}
+		stats.VisitsOverTime = series
 		return nil
 	})
 
+	if s.variants != nil {
+		g.Go(func() error {
+			rows, err := s.variants.ListVariants(gCtx, urlID)
+			if err != nil {
+				return err
+			}
+			if stats.Variants, err = s.withVariantVisits(gCtx, urlID, rows); err != nil {
+				return fmt.Errorf("failed to get variant visits: %w", err)
+			}
+			return nil
+		})
+	}
+
+	if s.tags != nil {
+		g.Go(func() error {
+			return s.withOrganization(gCtx, workspaceID, &stats.URL)
+		})
+	}
+
 	if err := g.Wait(); err != nil {
 		return domain.URLStat{}, err
 	}
@@ -405,98 +801,133 @@ func (s *urlService) StatisticsDetail(ctx context.Context, authorID domain.ID, s
 	return stats, nil
 }
 
-func (s *urlService) ClickOverTime(ctx context.Context, urlID domain.ID, period domain.Period, timeRange string) ([]domain.TimeSeriesData, error) {
-	timeTrunc := "hour"
-	trunc := time.Hour
-	switch timeRange {
-	case "day":
-		timeTrunc = "hour"
-		trunc = time.Hour
-	case "week":
-		timeTrunc = "day"
-		trunc = time.Hour * 24
-	case "month":
-		timeTrunc = "day"
-		trunc = time.Hour * 24
-	}
-	data, err := s.repo.VisitOverTime(ctx, urlID, period, timeTrunc)
+// ClickOverTime counts the visits of a URL of workspaceID by bucket over
+// filter.Period, along with the period before it.
+func (s *urlService) ClickOverTime(ctx context.Context, workspaceID, urlID domain.ID, filter domain.StatsFilter) (domain.VisitSeries, error) {
+	url, err := s.repo.GetByID(ctx, urlID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.VisitSeries{}, ErrURLNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get visit over time: %w", err)
+		return domain.VisitSeries{}, fmt.Errorf("failed to get url: %w", err)
+	}
+	if domain.ID(url.WorkspaceID) != workspaceID {
+		return domain.VisitSeries{}, ErrURLNotFound
 	}
 
-	data = visitPerDay(data, period, trunc)
+	return visitSeries(filter, time.Now(), func(period domain.Period, bucket domain.Bucket) ([]domain.TimeSeriesData, error) {
+		return s.repo.VisitOverTime(ctx, urlID, period, bucket, filter)
+	})
+}
 
-	return data, nil
+// ValidatePeriod checks that statistics can be computed over p.
+func ValidatePeriod(p domain.Period) error {
+	if !p.Until.After(p.Since) {
+		return ErrInvalidPeriod
+	}
+	if p.Until.Sub(p.Since) > maxPeriod {
+		return ErrPeriodTooLong
+	}
+	return nil
 }
 
-func visitPerDay(data []domain.TimeSeriesData, period domain.Period, trunc time.Duration) []domain.TimeSeriesData {
-	var (
-		current = period.Since.Truncate(trunc)
-		end     = period.Until.Truncate(trunc)
-		newData = []domain.TimeSeriesData{}
-	)
+// visitSeries builds the time series of visits over filter.Period, the last 24
+// hours before now when zero, and over the period before it. count returns the
+// visits of a period by bucket, only the buckets with visits.
+func visitSeries(filter domain.StatsFilter, now time.Time, count func(domain.Period, domain.Bucket) ([]domain.TimeSeriesData, error)) (domain.VisitSeries, error) {
+	series := domain.VisitSeries{
+		Period:   filter.Period,
+		Location: filter.Location,
+	}
+	if series.Period == (domain.Period{}) {
+		series.Period = domain.Period{Since: now.Add(-24 * time.Hour), Until: now}
+	}
+	if series.Location == nil {
+		series.Location = time.UTC
+	}
+	series.Bucket = domain.BucketFor(series.Period)
+	previous := series.Period.Previous()
 
-	for current.Before(end) || current.Equal(end) {
-		found := false
-		for _, d := range data {
-			if d.Time.Equal(current) {
-				newData = append(newData, d)
-				found = true
-				break
-			}
-		}
-		if !found {
-			newData = append(newData, domain.TimeSeriesData{
-				Time:  current,
-				Count: 0,
-			})
+	data, err := count(series.Period, series.Bucket)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return domain.VisitSeries{}, fmt.Errorf("failed to get visit over time: %w", err)
+	}
+	series.Current = visitPerDay(data, series.Period, series.Bucket, series.Location)
+
+	data, err = count(previous, series.Bucket)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return domain.VisitSeries{}, fmt.Errorf("failed to get visit over time: %w", err)
+	}
+	series.Previous = visitPerDay(data, previous, series.Bucket, series.Location)
+
+	// months don't all have the same length, the previous period can span
+	// one bucket more or less
+	if len(series.Previous) > len(series.Current) {
+		series.Previous = series.Previous[:len(series.Current)]
+	}
+	for len(series.Previous) < len(series.Current) {
+		next := series.Bucket.Truncate(previous.Since, series.Location)
+		if n := len(series.Previous); n > 0 {
+			next = series.Bucket.Next(series.Previous[n-1].Time)
 		}
-		current = current.Add(trunc)
+		series.Previous = append(series.Previous, domain.TimeSeriesData{Time: next})
 	}
-	return newData
-}
 
-func (s *urlService) CountMonthlyURL(ctx context.Context, authorID domain.ID) (int64, error) {
-	return s.repo.CountMonthlyURL(ctx, authorID)
+	return series, nil
 }
 
-func (s *urlService) CountMonthlyVisit(ctx context.Context, authorID domain.ID) (int64, error) {
-	return s.repo.CountMonthlyVisit(ctx, authorID)
-}
+// visitPerDay returns a point for every bucket of period, starting in loc,
+// taking the count of the buckets found in data and 0 for the others.
+func visitPerDay(data []domain.TimeSeriesData, period domain.Period, bucket domain.Bucket, loc *time.Location) []domain.TimeSeriesData {
+	counts := make(map[int64]int64, len(data))
+	for _, d := range data {
+		counts[d.Time.Unix()] = d.Count
+	}
 
-func generateShortID(length int) (string, error) {
-	// Generate a random byte slice of the desired length
-	b := make([]byte, length)
-	_, err := rand.Read(b)
-	if err != nil {
-		return "", err
+	newData := []domain.TimeSeriesData{}
+	for current := bucket.Truncate(period.Since, loc); current.Before(period.Until); current = bucket.Next(current) {
+		newData = append(newData, domain.TimeSeriesData{
+			Time:  current,
+			Count: counts[current.Unix()],
+		})
 	}
+	return newData
+}
 
-	// Encode the byte slice to a base64 string
-	s := base64.URLEncoding.EncodeToString(b)
+func (s *urlService) CountMonthlyURL(ctx context.Context, workspaceID domain.ID) (int64, error) {
+	return s.repo.CountMonthlyURL(ctx, workspaceID)
+}
 
-	// Trim the string to the desired length
-	return s[:length], nil
+func (s *urlService) CountMonthlyVisit(ctx context.Context, workspaceID domain.ID) (int64, error) {
+	return s.repo.CountMonthlyVisit(ctx, workspaceID)
 }
 
+// parseRequest reads the visitor of r. Its address is the remote address,
+// which the RealIP middleware sets to the client forwarded by trusted proxies.
 func parseRequest(r *http.Request) domain.RequestInfo {
 	var ipAddress, userAgent, referer, country string
-	ipAddress = r.Header.Get("CF-Connecting-IP")
-	if ipAddress == "" {
-		ipAddress = r.Header.Get("X-Forwarded-For")
-	}
-	if ipAddress == "" {
-		ipAddress = r.RemoteAddr
+	ipAddress = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ipAddress = host
 	}
 	userAgent = r.Header.Get("User-Agent")
 	referer = r.Header.Get("Referer")
 	country = r.Header.Get("CF-IPCountry")
 
-	return *domain.NewRequestInfo(ipAddress, userAgent, referer, country)
+	return *domain.NewRequestInfo(ipAddress, userAgent, referer, country, r.Header.Get("Accept-Language"))
 }
 
-func toURL(domain, id string) string {
-	u, _ := url.JoinPath(domain, id)
+// toURL is the short link of id on host, the custom domain it is served on.
+// Links of the default domain, whose host is empty, are under shortDomain.
+func toURL(shortDomain, host, id string) string {
+	if host != "" {
+		scheme := "https"
+		if u, err := url.Parse(shortDomain); err == nil && u.Scheme != "" {
+			scheme = u.Scheme
+		}
+		shortDomain = scheme + "://" + host
+	}
+	u, _ := url.JoinPath(shortDomain, id)
 	return u
 }
 
@@ -601,18 +1032,3 @@ func refererChart(data []datastore.ReferrerDistributionRow) []domain.TwoDimensio
 	}
 	return s
 }
-
-func fromIPQuery(loc ipquery.Response) domain.IPLocation {
-	return domain.IPLocation{
-		IP:          loc.IP,
-		Country:     loc.Location.Country,
-		CountryCode: loc.Location.CountryCode,
-		City:        loc.Location.City,
-		State:       loc.Location.State,
-		Zipcode:     loc.Location.Zipcode,
-		Latitude:    loc.Location.Latitude,
-		Longitude:   loc.Location.Longitude,
-		Timezone:    loc.Location.Timezone,
-		Localtime:   loc.Location.Localtime,
-	}
-}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+func TestEditURL(t *testing.T) {
+	ctx := context.Background()
+	docs, goDocs := testLink(1, "docs", "https://example.com/docs"), testLink(2, "docs@go.example.com", "https://go.example.com/docs")
+	docs.Title, goDocs.Title = "Docs", "Go docs"
+	store := newMemURLStore(docs, goDocs)
+	svc := &urlService{repo: store, safetyScanner: stubScanner{"https://malware.test": "MALWARE"}}
+
+	_, err := svc.Edit(ctx, 2, 5, domain.LinkRef{Slug: "docs"}, "Mine", "https://example.com/mine", 0)
+	assert.ErrorIs(t, err, ErrURLNotFound, "only the owner can edit a link")
+	_, err = svc.Edit(ctx, 1, 5, domain.LinkRef{Slug: "missing"}, "Missing", "https://example.com", 0)
+	assert.ErrorIs(t, err, ErrURLNotFound)
+
+	url, err := svc.Edit(ctx, 1, 5, domain.LinkRef{Slug: "docs"}, "Documentation", "https://example.com/docs", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "Documentation", url.Title)
+	assert.Equal(t, http.StatusFound, url.RedirectStatus, "a zero redirect status keeps the current one")
+	assert.Empty(t, store.history, "a title change does not record history")
+	assert.Equal(t, "Go docs", store.urls["docs@go.example.com"].Title, "the same slug on another domain is another link")
+
+	_, err = svc.Edit(ctx, 1, 5, domain.LinkRef{Slug: "docs"}, "Documentation", "https://example.com/docs", http.StatusSeeOther)
+	assert.ErrorIs(t, err, ErrInvalidRedirectStatus)
+
+	url, err = svc.Edit(ctx, 1, 5, domain.LinkRef{Slug: "docs"}, "Docs v2", "https://example.com/v2/docs", http.StatusPermanentRedirect)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPermanentRedirect, url.RedirectStatus)
+	assert.Equal(t, "https://example.com/v2/docs", url.Long)
+	assert.True(t, url.IsActive)
+	assert.Equal(t, []string{"https://example.com/docs"}, store.history)
+
+	_, err = svc.Edit(ctx, 1, 5, domain.LinkRef{Slug: "docs"}, "Docs", "https://malware.test", 0)
+	assert.ErrorIs(t, err, ErrSuspiciousURL)
+	assert.False(t, store.urls["docs"].IsActive, "a flagged destination disables the link")
+	assert.Equal(t, []domain.ID{1}, store.flagged)
+	assert.Equal(t, []domain.ID{5}, store.suspended, "the member making the change is suspended")
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountClick(t *testing.T) {
+	ctx := context.Background()
+	link := testLink(1, "promo", "https://example.com")
+	link.MaxClicks = pgtype.Int4{Int32: 2, Valid: true}
+	store := newMemURLStore(link)
+	svc := &urlService{repo: store}
+	// the link as served from the cache, before any click
+	url := svc.fromRow(link)
+
+	visit := func(userAgent string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/promo", nil)
+		r.Header.Set("User-Agent", userAgent)
+		return r
+	}
+
+	for range 2 {
+		expired, err := svc.CountClick(ctx, url, visit(iphoneUA))
+		assert.NoError(t, err)
+		assert.False(t, expired)
+	}
+	expired, err := svc.CountClick(ctx, url, visit("Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"))
+	assert.NoError(t, err)
+	assert.False(t, expired, "bots don't count toward the limit")
+	assert.Equal(t, int32(2), store.urls["promo"].Clicks)
+
+	expired, err = svc.CountClick(ctx, url, visit(iphoneUA))
+	assert.NoError(t, err)
+	assert.True(t, expired, "the counter has the last word over a stale link")
+	assert.Equal(t, int32(2), store.urls["promo"].Clicks)
+
+	expired, err = svc.IsExpired(ctx, svc.fromRow(store.urls["promo"]))
+	assert.NoError(t, err)
+	assert.True(t, expired)
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+func TestBucketFor(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		length time.Duration
+		want   domain.Bucket
+	}{
+		{"day", 24 * time.Hour, domain.BucketHour},
+		{"two days", 48 * time.Hour, domain.BucketHour},
+		{"week", 7 * 24 * time.Hour, domain.BucketDay},
+		{"quarter", 90 * 24 * time.Hour, domain.BucketDay},
+		{"half year", 180 * 24 * time.Hour, domain.BucketWeek},
+		{"year", 365 * 24 * time.Hour, domain.BucketWeek},
+		{"two years", 2 * 365 * 24 * time.Hour, domain.BucketMonth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := domain.BucketFor(domain.Period{Since: since, Until: since.Add(tt.length)})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBucketTruncate(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("timezone database not available")
+	}
+
+	// Sunday 18 October 2026, 00:30 in Paris, still Saturday in UTC
+	at := time.Date(2026, 10, 17, 22, 30, 0, 0, time.UTC)
+	tests := []struct {
+		bucket domain.Bucket
+		loc    *time.Location
+		want   time.Time
+	}{
+		{domain.BucketHour, paris, time.Date(2026, 10, 18, 0, 0, 0, 0, paris)},
+		{domain.BucketDay, paris, time.Date(2026, 10, 18, 0, 0, 0, 0, paris)},
+		{domain.BucketDay, time.UTC, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{domain.BucketWeek, paris, time.Date(2026, 10, 12, 0, 0, 0, 0, paris)},
+		{domain.BucketMonth, paris, time.Date(2026, 10, 1, 0, 0, 0, 0, paris)},
+	}
+
+	for _, tt := range tests {
+		got := tt.bucket.Truncate(at, tt.loc)
+		assert.True(t, tt.want.Equal(got), "%s in %s: got %s, want %s", tt.bucket, tt.loc, got, tt.want)
+	}
+}
+
+func TestVisitSeries(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("timezone database not available")
+	}
+
+	t.Run("daily buckets across a DST change", func(t *testing.T) {
+		t.Parallel()
+
+		// clocks go forward on 29 March 2026, that day lasts 23 hours
+		period := domain.Period{
+			Since: time.Date(2026, 3, 28, 0, 0, 0, 0, paris),
+			Until: time.Date(2026, 3, 31, 0, 0, 0, 0, paris),
+		}
+		filter := domain.StatsFilter{Period: period, Location: paris}
+
+		var asked []domain.Period
+		series, err := visitSeries(filter, time.Now(), func(p domain.Period, b domain.Bucket) ([]domain.TimeSeriesData, error) {
+			assert.Equal(t, domain.BucketDay, b)
+			asked = append(asked, p)
+			if len(asked) == 1 {
+				return []domain.TimeSeriesData{
+					{Time: time.Date(2026, 3, 29, 0, 0, 0, 0, paris).UTC(), Count: 5},
+					{Time: time.Date(2026, 3, 30, 0, 0, 0, 0, paris).UTC(), Count: 2},
+				}, nil
+			}
+			return []domain.TimeSeriesData{
+				{Time: time.Date(2026, 3, 26, 0, 0, 0, 0, paris), Count: 4},
+			}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Period{period, period.Previous()}, asked)
+
+		assert.Equal(t, domain.BucketDay, series.Bucket)
+		assert.Equal(t, []domain.TimeSeriesData{
+			{Time: time.Date(2026, 3, 28, 0, 0, 0, 0, paris), Count: 0},
+			{Time: time.Date(2026, 3, 29, 0, 0, 0, 0, paris), Count: 5},
+			{Time: time.Date(2026, 3, 30, 0, 0, 0, 0, paris), Count: 2},
+		}, series.Current)
+		assert.Equal(t, []domain.TimeSeriesData{
+			{Time: time.Date(2026, 3, 25, 0, 0, 0, 0, paris), Count: 0},
+			{Time: time.Date(2026, 3, 26, 0, 0, 0, 0, paris), Count: 4},
+			{Time: time.Date(2026, 3, 27, 0, 0, 0, 0, paris), Count: 0},
+		}, series.Previous)
+
+		assert.Equal(t, int64(7), series.Total())
+		assert.Equal(t, int64(4), series.PreviousTotal())
+		change, ok := series.Change()
+		assert.True(t, ok)
+		assert.InDelta(t, 75, change, 0.01)
+	})
+
+	t.Run("monthly buckets", func(t *testing.T) {
+		t.Parallel()
+
+		filter := domain.StatsFilter{
+			Period: domain.Period{
+				Since: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				Until: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			},
+		}
+		series, err := visitSeries(filter, time.Now(), func(domain.Period, domain.Bucket) ([]domain.TimeSeriesData, error) {
+			return nil, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, domain.BucketMonth, series.Bucket)
+		assert.Equal(t, time.UTC, series.Location)
+		assert.Len(t, series.Current, 14)
+		assert.Len(t, series.Previous, 14, "the previous period has a point for each current one")
+		_, ok := series.Change()
+		assert.False(t, ok, "no change without previous visits")
+	})
+
+	t.Run("last day by default", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)
+		series, err := visitSeries(domain.StatsFilter{}, now, func(domain.Period, domain.Bucket) ([]domain.TimeSeriesData, error) {
+			return nil, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, domain.Period{Since: now.Add(-24 * time.Hour), Until: now}, series.Period)
+		assert.Equal(t, domain.BucketHour, series.Bucket)
+		assert.Len(t, series.Current, 25)
+		assert.Equal(t, time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), series.Current[0].Time)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		t.Parallel()
+
+		errStore := errors.New("connection refused")
+		_, err := visitSeries(domain.StatsFilter{}, time.Now(), func(domain.Period, domain.Bucket) ([]domain.TimeSeriesData, error) {
+			return nil, errStore
+		})
+		assert.ErrorIs(t, err, errStore)
+	})
+}
+
+func TestValidatePeriod(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, ValidatePeriod(domain.Period{Since: since, Until: since.AddDate(1, 0, 0)}))
+	assert.ErrorIs(t, ValidatePeriod(domain.Period{Since: since, Until: since}), ErrInvalidPeriod)
+	assert.ErrorIs(t, ValidatePeriod(domain.Period{Since: since, Until: since.AddDate(-1, 0, 0)}), ErrInvalidPeriod)
+	assert.ErrorIs(t, ValidatePeriod(domain.Period{Since: since, Until: since.AddDate(6, 0, 0)}), ErrPeriodTooLong)
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name       string
+		page       domain.Page
+		wantLinks  int
+		wantCounts int
+	}{
+		{name: "first page", page: domain.Page{Limit: 10}, wantLinks: 10, wantCounts: 1},
+		{name: "last page", page: domain.Page{Offset: 20, Limit: 10}, wantLinks: 5},
+		{name: "past the end", page: domain.Page{Offset: 30, Limit: 10}, wantCounts: 1},
+		{name: "everything", page: domain.Page{}, wantLinks: 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &memURLStore{n: 25}
+			svc := &urlService{repo: store}
+			urls, total, err := svc.List(context.Background(), 1, domain.LinkFilter{}, tt.page)
+			assert.NoError(t, err)
+			assert.Len(t, urls, tt.wantLinks)
+			assert.Equal(t, 25, total)
+			assert.Equal(t, tt.wantCounts, store.counts)
+			if len(urls) > 0 {
+				assert.Equal(t, "example.com", urls[0].Title)
+			}
+		})
+	}
+}
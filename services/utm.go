@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+// ApplyUTM merges the campaign parameters of utm into the query string of
+// target, replacing the ones already there.
+func ApplyUTM(target string, utm domain.UTM) (string, error) {
+	if utm.IsZero() {
+		return target, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid url %q: %w", target, err)
+	}
+	q := u.Query()
+	for key, value := range utm.Params() {
+		q.Set(key, strings.TrimSpace(value))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// ForwardQuery appends the parameters of the incoming rawQuery to target.
+// Parameters already set on target win, so visitors can't override the
+// campaign parameters chosen by the owner of the link. The query string of
+// target is otherwise kept as is, and the QR code marker is left out.
+func ForwardQuery(target, rawQuery string) string {
+	// malformed pairs are skipped, the valid ones still go through
+	incoming, _ := url.ParseQuery(rawQuery)
+	if len(incoming) == 0 {
+		return target
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	existing := u.Query()
+	extra := url.Values{}
+	for key, values := range incoming {
+		// the QR code marker is ours, not the destination's
+		if key == QRParam {
+			continue
+		}
+		if !existing.Has(key) {
+			extra[key] = values
+		}
+	}
+	if len(extra) == 0 {
+		return target
+	}
+
+	if u.RawQuery == "" {
+		u.RawQuery = extra.Encode()
+	} else {
+		u.RawQuery += "&" + extra.Encode()
+	}
+	return u.String()
+}
+
+// UpdateForwardQuery sets whether visits of a URL of workspaceID pass their
+// query string on to its destination.
+func (s *urlService) UpdateForwardQuery(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, forward bool) (domain.URL, error) {
+	row, err := s.repo.UpdateForwardQuery(ctx, workspaceID, ref, forward)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.URL{}, ErrURLNotFound
+	}
+	if err != nil {
+		return domain.URL{}, err
+	}
+	s.cache.InvalidateID(domain.ID(row.ID))
+
+	return s.fromRow(row), nil
+}
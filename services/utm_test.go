@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+func TestApplyUTM(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		utm    domain.UTM
+		want   string
+	}{
+		{"no parameters", "https://example.com/page?b=2&a=1", domain.UTM{}, "https://example.com/page?b=2&a=1"},
+		{"added", "https://example.com/page", domain.UTM{Source: "newsletter", Medium: "email"}, "https://example.com/page?utm_medium=email&utm_source=newsletter"},
+		{"kept query", "https://example.com/page?id=42", domain.UTM{Campaign: "spring sale"}, "https://example.com/page?id=42&utm_campaign=spring+sale"},
+		{"replaced", "https://example.com/?utm_source=old", domain.UTM{Source: "new"}, "https://example.com/?utm_source=new"},
+		{"all", "https://example.com", domain.UTM{Source: "s", Medium: "m", Campaign: "c", Term: "t", Content: "x"}, "https://example.com?utm_campaign=c&utm_content=x&utm_medium=m&utm_source=s&utm_term=t"},
+		{"fragment", "https://example.com/docs#install", domain.UTM{Source: "s"}, "https://example.com/docs?utm_source=s#install"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ApplyUTM(tt.target, tt.utm)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestForwardQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		query  string
+		want   string
+	}{
+		{"empty", "https://example.com/page", "", "https://example.com/page"},
+		{"appended", "https://example.com/page", "ref=x", "https://example.com/page?ref=x"},
+		{"target query kept as is", "https://example.com/page?b=2&a=1", "ref=x", "https://example.com/page?b=2&a=1&ref=x"},
+		{"target wins", "https://example.com/?utm_source=owner", "utm_source=visitor&ref=x", "https://example.com/?utm_source=owner&ref=x"},
+		{"only duplicates", "https://example.com/?ref=owner", "ref=visitor", "https://example.com/?ref=owner"},
+		{"repeated keys", "https://example.com/", "tag=a&tag=b", "https://example.com/?tag=a&tag=b"},
+		{"malformed pair skipped", "https://example.com/", "ref=x&bad=%zz", "https://example.com/?ref=x"},
+		{"fragment", "https://example.com/docs#install", "ref=x", "https://example.com/docs?ref=x#install"},
+		{"qr marker left out", "https://example.com/", "qr=1&ref=x", "https://example.com/?ref=x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, ForwardQuery(tt.target, tt.query))
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+// VariantStore persists the weighted destinations of links.
+type VariantStore interface {
+	ListVariants(ctx context.Context, urlID domain.ID) ([]datastore.UrlVariant, error)
+	CountVariantVisits(ctx context.Context, urlID domain.ID) ([]datastore.CountVariantVisitsRow, error)
+	ReplaceVariants(ctx context.Context, urlID domain.ID, variants []domain.Variant) ([]datastore.UrlVariant, error)
+}
+
+const (
+	maxVariants      = 10
+	maxVariantWeight = 1000
+
+	variantCookieName   = "shortcut_variant"
+	variantCookieMaxAge = 90 * 24 * time.Hour
+)
+
+var (
+	ErrTooManyVariants      = fmt.Errorf("a link can have at most %d variants", maxVariants)
+	ErrVariantNotFound      = errors.New("variant not found")
+	ErrInvalidVariantWeight = fmt.Errorf("variant weight must be between 1 and %d", maxVariantWeight)
+)
+
+// ValidateVariantWeight checks that weight can be given to a variant.
+func ValidateVariantWeight(weight int) error {
+	if weight < 1 || weight > maxVariantWeight {
+		return ErrInvalidVariantWeight
+	}
+	return nil
+}
+
+// Variants lists, in order, the variants of a URL of workspaceID along with
+// the visits and unique visitors each of them received.
+func (s *urlService) Variants(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef) ([]domain.Variant, error) {
+	url, err := s.Get(ctx, workspaceID, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.variants.ListVariants(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withVariantVisits(ctx, url.ID, rows)
+}
+
+// SetVariants replaces the variants of a URL of workspaceID with variants, on
+// behalf of its member userID, and returns them as saved. Variants with an ID
+// update an existing variant of the link, the others are created. New
+// destinations go through the safety scanner like the ones of redirect rules.
+func (s *urlService) SetVariants(ctx context.Context, workspaceID, userID domain.ID, ref domain.LinkRef, variants []domain.Variant) ([]domain.Variant, error) {
+	if len(variants) > maxVariants {
+		return nil, ErrTooManyVariants
+	}
+
+	url, err := s.Get(ctx, workspaceID, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.variants.ListVariants(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{url.Long: true}
+	ids := make(map[domain.ID]bool, len(existing))
+	for _, row := range existing {
+		ids[domain.ID(row.ID)] = true
+		known[row.LongUrl] = true
+	}
+
+	destinations := make([]string, 0, len(variants))
+	for _, v := range variants {
+		if v.ID != 0 && !ids[v.ID] {
+			return nil, ErrVariantNotFound
+		}
+		if err := ValidateVariantWeight(v.Weight); err != nil {
+			return nil, err
+		}
+		destinations = append(destinations, v.LongURL)
+	}
+	if err := s.scanDestinations(ctx, url, userID, known, destinations); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.variants.ReplaceVariants(ctx, url.ID, variants)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.InvalidateID(url.ID)
+
+	return s.withVariantVisits(ctx, url.ID, rows)
+}
+
+// UpdateStickyVariants sets whether returning visitors of a URL of
+// workspaceID keep being sent to the variant they were first sent to.
+func (s *urlService) UpdateStickyVariants(ctx context.Context, workspaceID domain.ID, ref domain.LinkRef, sticky bool) (domain.URL, error) {
+	row, err := s.repo.UpdateStickyVariants(ctx, workspaceID, ref, sticky)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.URL{}, ErrURLNotFound
+	}
+	if err != nil {
+		return domain.URL{}, err
+	}
+	s.cache.InvalidateID(domain.ID(row.ID))
+
+	return s.fromRow(row), nil
+}
+
+func (s *urlService) withVariantVisits(ctx context.Context, urlID domain.ID, rows []datastore.UrlVariant) ([]domain.Variant, error) {
+	counts, err := s.variants.CountVariantVisits(ctx, urlID)
+	if err != nil {
+		return nil, err
+	}
+	visits := make(map[domain.ID]datastore.CountVariantVisitsRow, len(counts))
+	for _, c := range counts {
+		visits[domain.ID(c.VariantID)] = c
+	}
+
+	variants := make([]domain.Variant, 0, len(rows))
+	for _, row := range rows {
+		v := variantFromRow(row)
+		v.Clicks = int(visits[v.ID].Visits)
+		v.UniqueVisitors = int(visits[v.ID].UniqueVisitors)
+		variants = append(variants, v)
+	}
+	return variants, nil
+}
+
+// listVariants returns the variants of the link urlID, none when the service
+// has no variant store.
+func (s *urlService) listVariants(ctx context.Context, urlID domain.ID) ([]domain.Variant, error) {
+	if s.variants == nil {
+		return nil, nil
+	}
+
+	rows, err := s.variants.ListVariants(ctx, urlID)
+	if err != nil {
+		return nil, err
+	}
+	variants := make([]domain.Variant, 0, len(rows))
+	for _, row := range rows {
+		variants = append(variants, variantFromRow(row))
+	}
+	return variants, nil
+}
+
+func variantFromRow(row datastore.UrlVariant) domain.Variant {
+	return domain.Variant{
+		ID:      domain.ID(row.ID),
+		LongURL: row.LongUrl,
+		Weight:  int(row.Weight),
+	}
+}
+
+// pickVariant splits the visitors of url between its variants according to
+// their weight. A visitor already sent to a variant of a sticky link gets the
+// same one back, as long as it still exists.
+func pickVariant(url domain.URL, r *http.Request) domain.Variant {
+	if url.StickyVariants {
+		if c, err := r.Cookie(variantCookieName); err == nil {
+			if id, err := strconv.ParseInt(c.Value, 10, 32); err == nil {
+				if v, ok := url.Variant(domain.ID(id)); ok {
+					return v
+				}
+			}
+		}
+	}
+	return domain.PickVariant(url.Variants, rand.IntN(domain.TotalWeight(url.Variants)))
+}
+
+// VariantCookie remembers that the visitor was sent to variantID by the link
+// slug. It is scoped to the path of the link.
+func VariantCookie(slug string, variantID domain.ID) *http.Cookie {
+	return &http.Cookie{
+		Name:     variantCookieName,
+		Value:    strconv.Itoa(int(variantID)),
+		Path:     "/" + slug,
+		MaxAge:   int(variantCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
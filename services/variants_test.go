@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+func TestPickVariant(t *testing.T) {
+	variants := []domain.Variant{
+		{ID: 1, Weight: 1},
+		{ID: 2, Weight: 3},
+		{ID: 3, Weight: 6},
+	}
+
+	tests := []struct {
+		roll int
+		want domain.ID
+	}{
+		{0, 1},
+		{1, 2},
+		{3, 2},
+		{4, 3},
+		{9, 3},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, domain.PickVariant(variants, tt.roll).ID, "roll %d", tt.roll)
+	}
+	assert.Equal(t, 10, domain.TotalWeight(variants))
+	assert.InDelta(t, 30, variants[1].Share(variants), 0.01)
+}
+
+func TestDestinationVariants(t *testing.T) {
+	url := domain.URL{
+		Slug: "promo",
+		Long: "https://example.com",
+		Rules: []domain.RedirectRule{
+			{ID: 1, LongURL: "https://apps.apple.com/app", Platforms: []domain.Platform{domain.PlatformIOS}},
+		},
+		Variants: []domain.Variant{
+			{ID: 10, LongURL: "https://example.com/a", Weight: 1},
+			{ID: 11, LongURL: "https://example.com/b", Weight: 1},
+		},
+	}
+
+	t.Run("rules come first", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest("GET", "/promo", nil)
+		r.Header.Set("User-Agent", iphoneUA)
+		got, route := Destination(url, r)
+		assert.Equal(t, "https://apps.apple.com/app", got)
+		assert.Equal(t, domain.Route{RuleID: 1}, route)
+	})
+
+	t.Run("split between variants", func(t *testing.T) {
+		t.Parallel()
+
+		seen := map[domain.ID]bool{}
+		for range 200 {
+			got, route := Destination(url, httptest.NewRequest("GET", "/promo", nil))
+			v, ok := url.Variant(route.VariantID)
+			assert.True(t, ok)
+			assert.Equal(t, v.LongURL, got)
+			seen[route.VariantID] = true
+		}
+		assert.Len(t, seen, 2, "both variants get visitors")
+	})
+
+	t.Run("sticky", func(t *testing.T) {
+		t.Parallel()
+
+		sticky := url
+		sticky.StickyVariants = true
+		for range 20 {
+			r := httptest.NewRequest("GET", "/promo", nil)
+			r.AddCookie(VariantCookie("promo", 11))
+			got, route := Destination(sticky, r)
+			assert.Equal(t, "https://example.com/b", got)
+			assert.Equal(t, domain.ID(11), route.VariantID)
+		}
+
+		r := httptest.NewRequest("GET", "/promo", nil)
+		r.AddCookie(VariantCookie("promo", 42))
+		_, route := Destination(sticky, r)
+		assert.Contains(t, []domain.ID{10, 11}, route.VariantID, "a deleted variant is replaced")
+	})
+
+	t.Run("no variants", func(t *testing.T) {
+		t.Parallel()
+
+		got, route := Destination(domain.URL{Long: "https://example.com"}, httptest.NewRequest("GET", "/promo", nil))
+		assert.Equal(t, "https://example.com", got)
+		assert.Equal(t, domain.Route{}, route)
+	})
+}
+
+// memVariantStore keeps the variants of links in memory.
+type memVariantStore struct {
+	variants map[domain.ID][]datastore.UrlVariant
+	nextID   int32
+}
+
+func (s *memVariantStore) ListVariants(_ context.Context, urlID domain.ID) ([]datastore.UrlVariant, error) {
+	return s.variants[urlID], nil
+}
+
+func (s *memVariantStore) CountVariantVisits(_ context.Context, _ domain.ID) ([]datastore.CountVariantVisitsRow, error) {
+	return []datastore.CountVariantVisitsRow{{VariantID: 1, Visits: 5, UniqueVisitors: 3}}, nil
+}
+
+func (s *memVariantStore) ReplaceVariants(_ context.Context, urlID domain.ID, variants []domain.Variant) ([]datastore.UrlVariant, error) {
+	rows := make([]datastore.UrlVariant, 0, len(variants))
+	for i, v := range variants {
+		id := int32(v.ID)
+		if id == 0 {
+			s.nextID++
+			id = s.nextID
+		}
+		rows = append(rows, datastore.UrlVariant{
+			ID:       id,
+			UrlID:    int32(urlID),
+			Position: int32(i),
+			LongUrl:  v.LongURL,
+			Weight:   int32(v.Weight),
+		})
+	}
+	s.variants[urlID] = rows
+	return rows, nil
+}
+
+func TestSetVariants(t *testing.T) {
+	ctx := context.Background()
+	store := newMemURLStore(testLink(1, "promo", "https://example.com"))
+	variants := &memVariantStore{variants: map[domain.ID][]datastore.UrlVariant{}}
+	svc := &urlService{repo: store, variants: variants, safetyScanner: stubScanner{"https://malware.test": "MALWARE"}}
+
+	_, err := svc.SetVariants(ctx, 2, 5, domain.LinkRef{Slug: "promo"}, nil)
+	assert.ErrorIs(t, err, ErrURLNotFound, "only the owner can set variants")
+
+	_, err = svc.SetVariants(ctx, 1, 5, domain.LinkRef{Slug: "promo"}, []domain.Variant{{LongURL: "https://example.com/a", Weight: 0}})
+	assert.ErrorIs(t, err, ErrInvalidVariantWeight)
+
+	saved, err := svc.SetVariants(ctx, 1, 5, domain.LinkRef{Slug: "promo"}, []domain.Variant{
+		{LongURL: "https://example.com/a", Weight: 1},
+		{LongURL: "https://example.com/b", Weight: 3},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, saved, 2)
+	assert.Equal(t, domain.Variant{ID: 1, LongURL: "https://example.com/a", Weight: 1, Clicks: 5, UniqueVisitors: 3}, saved[0])
+
+	saved[1].Weight = 2
+	saved, err = svc.SetVariants(ctx, 1, 5, domain.LinkRef{Slug: "promo"}, saved[1:])
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Variant{{ID: 2, LongURL: "https://example.com/b", Weight: 2}}, saved)
+
+	_, err = svc.SetVariants(ctx, 1, 5, domain.LinkRef{Slug: "promo"}, []domain.Variant{{ID: 1, LongURL: "https://example.com/a", Weight: 1}})
+	assert.ErrorIs(t, err, ErrVariantNotFound, "deleted variants can't be updated")
+
+	_, err = svc.SetVariants(ctx, 1, 5, domain.LinkRef{Slug: "promo"}, []domain.Variant{{LongURL: "https://malware.test", Weight: 1}})
+	assert.ErrorIs(t, err, ErrSuspiciousURL)
+	assert.Len(t, variants.variants[1], 1, "flagged variants are not saved")
+	assert.False(t, store.urls["promo"].IsActive, "a flagged destination disables the link")
+}
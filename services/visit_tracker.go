@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+// VisitStore writes batches of visits.
+type VisitStore interface {
+	InsertVisits(ctx context.Context, visits []domain.Visit) error
+}
+
+// VisitTrackerConfig sizes the visit tracking pipeline.
+type VisitTrackerConfig struct {
+	// QueueSize is the number of visits buffered before new ones are dropped.
+	QueueSize int
+	// Workers is the number of goroutines locating and writing visits.
+	Workers int
+	// BatchSize is the maximum number of visits written at once.
+	BatchSize int
+	// FlushInterval bounds how long a visit waits for its batch to fill up.
+	FlushInterval time.Duration
+}
+
+// DefaultVisitTrackerConfig is tuned for a single instance serving a few
+// hundred redirects per second.
+var DefaultVisitTrackerConfig = VisitTrackerConfig{
+	QueueSize:     10_000,
+	Workers:       8,
+	BatchSize:     200,
+	FlushInterval: time.Second,
+}
+
+const (
+	// flushTimeout bounds a single batch write.
+	flushTimeout = 10 * time.Second
+	// lookupTimeout bounds the location lookup of the visitors of a batch.
+	lookupTimeout = 2 * time.Second
+)
+
+// VisitStats are the counters of the visit tracking pipeline, exposed as
+// metrics.
+type VisitStats struct {
+	// Queued is the number of visits waiting in the queue.
+	Queued int `json:"queued"`
+	// Capacity is the size of the queue.
+	Capacity int   `json:"capacity"`
+	Enqueued int64 `json:"enqueued"`
+	// Dropped visits were rejected because the queue was full.
+	Dropped  int64 `json:"dropped"`
+	Recorded int64 `json:"recorded"`
+	// Failed visits were part of a batch that could not be written.
+	Failed  int64 `json:"failed"`
+	Batches int64 `json:"batches"`
+	// Unlocated visits were recorded without a location because its lookup
+	// failed.
+	Unlocated int64 `json:"unlocated"`
+}
+
+// visitTracker records visits off the redirect path. Visits go through a
+// bounded queue consumed by a fixed pool of workers, each locating and writing
+// them by batch. When the queue is full visits are dropped rather than slowing
+// down redirects.
+type visitTracker struct {
+	store  VisitStore
+	geoip  GeoIPResolver
+	events LinkEvents
+	config VisitTrackerConfig
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan domain.Visit
+	wg     sync.WaitGroup
+
+	// lookups is canceled on shutdown so that remote lookups fail fast while
+	// the queue drains
+	lookups       context.Context
+	cancelLookups context.CancelFunc
+
+	enqueued  atomic.Int64
+	dropped   atomic.Int64
+	recorded  atomic.Int64
+	failed    atomic.Int64
+	batches   atomic.Int64
+	unlocated atomic.Int64
+}
+
+// NewVisitTracker starts the workers of the visit tracking pipeline. Call
+// Shutdown to stop them. Visitors are located with geoip, or only from the
+// CF-IPCountry header when geoip is nil. events, when not nil, is told about
+// the visits once written.
+func NewVisitTracker(store VisitStore, geoip GeoIPResolver, events LinkEvents, config VisitTrackerConfig) *visitTracker {
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultVisitTrackerConfig.QueueSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = DefaultVisitTrackerConfig.Workers
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultVisitTrackerConfig.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultVisitTrackerConfig.FlushInterval
+	}
+
+	t := &visitTracker{
+		store:  store,
+		geoip:  geoip,
+		events: events,
+		config: config,
+		queue:  make(chan domain.Visit, config.QueueSize),
+	}
+	t.lookups, t.cancelLookups = context.WithCancel(context.Background())
+
+	t.wg.Add(config.Workers)
+	for range config.Workers {
+		go t.work()
+	}
+	return t
+}
+
+// Track queues a visit of urlID that was sent to its destination by route.
+// It never blocks: the visit is dropped when the queue is full or the tracker
+// is shut down.
+func (t *visitTracker) Track(urlID domain.ID, route domain.Route, r *http.Request) {
+	visit := domain.Visit{
+		URLID:     urlID,
+		Route:     route,
+		VisitedAt: time.Now(),
+		Request:   parseRequest(r),
+		Source:    visitSource(r),
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		t.dropped.Add(1)
+		return
+	}
+
+	select {
+	case t.queue <- visit:
+		t.enqueued.Add(1)
+	default:
+		if t.dropped.Add(1)%1000 == 1 {
+			log.Warn("visit queue is full, dropping visits", "capacity", cap(t.queue))
+		}
+	}
+}
+
+func (t *visitTracker) Stats() VisitStats {
+	return VisitStats{
+		Queued:    len(t.queue),
+		Capacity:  cap(t.queue),
+		Enqueued:  t.enqueued.Load(),
+		Dropped:   t.dropped.Load(),
+		Recorded:  t.recorded.Load(),
+		Failed:    t.failed.Load(),
+		Batches:   t.batches.Load(),
+		Unlocated: t.unlocated.Load(),
+	}
+}
+
+// Shutdown stops accepting visits and waits for the queued ones to be written
+// or for ctx to be done.
+func (t *visitTracker) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		t.cancelLookups()
+		close(t.queue)
+	}
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		log.Error("visit queue not drained", "remaining", len(t.queue))
+		return ctx.Err()
+	}
+}
+
+func (t *visitTracker) work() {
+	defer t.wg.Done()
+
+	batch := make([]domain.Visit, 0, t.config.BatchSize)
+	ticker := time.NewTicker(t.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case visit, ok := <-t.queue:
+			if !ok {
+				t.flush(batch)
+				return
+			}
+			batch = append(batch, visit)
+			if len(batch) >= t.config.BatchSize {
+				t.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			t.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (t *visitTracker) flush(batch []domain.Visit) {
+	if len(batch) == 0 {
+		return
+	}
+
+	t.locate(batch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	t.batches.Add(1)
+	if err := t.store.InsertVisits(ctx, batch); err != nil {
+		t.failed.Add(int64(len(batch)))
+		log.Error("failed to record visits", "count", len(batch), "err", err)
+		return
+	}
+	t.recorded.Add(int64(len(batch)))
+
+	if t.events != nil {
+		t.events.VisitsRecorded(ctx, batch)
+	}
+}
+
+// locate finds where the visitors of batch come from, looking their
+// addresses up at once, and falls back on the country sent by Cloudflare.
+// Visitors are flagged as bots once located.
+func (t *visitTracker) locate(batch []domain.Visit) {
+	var (
+		located map[string]domain.IPLocation
+		err     error
+	)
+	if t.geoip != nil {
+		ips := make([]string, 0, len(batch))
+		for _, visit := range batch {
+			ips = append(ips, visit.Request.IpAddress())
+		}
+		slices.Sort(ips)
+		ips = slices.Compact(ips)
+
+		ctx, cancel := context.WithTimeout(t.lookups, lookupTimeout)
+		located, err = resolveAll(ctx, t.geoip, ips)
+		cancel()
+		if err != nil {
+			log.Warn("failed to locate visitors", "err", err, "ips", len(ips))
+		}
+	}
+
+	for i := range batch {
+		visit := &batch[i]
+		ip := visit.Request.IpAddress()
+		if loc, ok := located[ip]; ok {
+			visit.Location = &loc
+		} else if country := visit.Request.Country(); country != "" {
+			visit.Location = &domain.IPLocation{
+				IP:          ip,
+				CountryCode: country,
+				Source:      "cf-ipcountry",
+			}
+		}
+		if visit.Location == nil && err != nil {
+			t.unlocated.Add(1)
+		}
+		visit.IsBot = IsBot(&visit.Request, visit.Location)
+	}
+}
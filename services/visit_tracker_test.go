@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+type memVisitStore struct {
+	mu      sync.Mutex
+	batches [][]domain.Visit
+	err     error
+	// block, when set, holds every write until it is closed
+	block chan struct{}
+}
+
+func (s *memVisitStore) InsertVisits(_ context.Context, visits []domain.Visit) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, append([]domain.Visit(nil), visits...))
+	return nil
+}
+
+func (s *memVisitStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, b := range s.batches {
+		n += len(b)
+	}
+	return n
+}
+
+// echoResolver locates every IP address in the same place.
+type echoResolver struct{}
+
+func (echoResolver) Resolve(_ context.Context, ip string) (domain.IPLocation, error) {
+	return domain.IPLocation{IP: ip, Source: "echo"}, nil
+}
+
+func newTestVisitTracker(store VisitStore, config VisitTrackerConfig) *visitTracker {
+	return NewVisitTracker(store, echoResolver{}, nil, config)
+}
+
+func TestVisitTrackerBatches(t *testing.T) {
+	t.Parallel()
+
+	store := &memVisitStore{}
+	tracker := newTestVisitTracker(store, VisitTrackerConfig{QueueSize: 10, Workers: 1, BatchSize: 3, FlushInterval: time.Hour})
+
+	for i := range 7 {
+		r := httptest.NewRequest("GET", "/abc", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		tracker.Track(domain.ID(i), domain.Route{}, r)
+	}
+	assert.NoError(t, tracker.Shutdown(context.Background()))
+
+	// two full batches, the rest is flushed on shutdown
+	assert.Len(t, store.batches, 3)
+	assert.Equal(t, 7, store.count())
+	assert.Equal(t, "10.0.0.1", store.batches[0][0].Location.IP)
+
+	stats := tracker.Stats()
+	assert.Equal(t, int64(7), stats.Enqueued)
+	assert.Equal(t, int64(7), stats.Recorded)
+	assert.Equal(t, int64(3), stats.Batches)
+	assert.Zero(t, stats.Queued)
+}
+
+func TestVisitTrackerFlushInterval(t *testing.T) {
+	t.Parallel()
+
+	store := &memVisitStore{}
+	tracker := newTestVisitTracker(store, VisitTrackerConfig{QueueSize: 10, Workers: 1, BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer tracker.Shutdown(context.Background())
+
+	tracker.Track(1, domain.Route{}, httptest.NewRequest("GET", "/abc", nil))
+	assert.Eventually(t, func() bool { return store.count() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestVisitTrackerDropsWhenFull(t *testing.T) {
+	t.Parallel()
+
+	store := &memVisitStore{block: make(chan struct{})}
+	tracker := newTestVisitTracker(store, VisitTrackerConfig{QueueSize: 2, Workers: 1, BatchSize: 1, FlushInterval: time.Hour})
+
+	// the worker takes the first visit and blocks writing it, two more fill
+	// the queue
+	tracker.Track(1, domain.Route{}, httptest.NewRequest("GET", "/abc", nil))
+	assert.Eventually(t, func() bool { return tracker.Stats().Queued == 0 }, time.Second, time.Millisecond)
+	for range 4 {
+		tracker.Track(1, domain.Route{}, httptest.NewRequest("GET", "/abc", nil))
+	}
+
+	stats := tracker.Stats()
+	assert.Equal(t, int64(3), stats.Enqueued)
+	assert.Equal(t, int64(2), stats.Dropped)
+	assert.Equal(t, 2, stats.Queued)
+
+	close(store.block)
+	assert.NoError(t, tracker.Shutdown(context.Background()))
+	assert.Equal(t, 3, store.count())
+
+	// visits after shutdown are dropped, not sent on the closed queue
+	tracker.Track(1, domain.Route{}, httptest.NewRequest("GET", "/abc", nil))
+	assert.Equal(t, int64(3), tracker.Stats().Dropped)
+}
+
+func TestVisitTrackerShutdownTimeout(t *testing.T) {
+	t.Parallel()
+
+	store := &memVisitStore{block: make(chan struct{})}
+	defer close(store.block)
+	tracker := newTestVisitTracker(store, VisitTrackerConfig{QueueSize: 2, Workers: 1, BatchSize: 1, FlushInterval: time.Hour})
+	tracker.Track(1, domain.Route{}, httptest.NewRequest("GET", "/abc", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, tracker.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func TestVisitTrackerFailedBatch(t *testing.T) {
+	t.Parallel()
+
+	store := &memVisitStore{err: errors.New("connection reset")}
+	tracker := newTestVisitTracker(store, VisitTrackerConfig{QueueSize: 10, Workers: 2, BatchSize: 10, FlushInterval: time.Hour})
+	tracker.Track(1, domain.Route{}, httptest.NewRequest("GET", "/abc", nil))
+	tracker.Track(2, domain.Route{}, httptest.NewRequest("GET", "/abc", nil))
+	assert.NoError(t, tracker.Shutdown(context.Background()))
+
+	stats := tracker.Stats()
+	assert.Equal(t, int64(2), stats.Failed)
+	assert.Zero(t, stats.Recorded)
+}
@@ -0,0 +1,458 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookSecretBytes  = 32
+	webhookMaxEndpoints = 10
+	webhookMaxURLLen    = 2048
+	// webhookDeliveriesShown is the length of the delivery log of a workspace.
+	webhookDeliveriesShown = 50
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhookURL       = errors.New("webhook URL must be an http or https URL")
+	ErrInvalidWebhookEvents    = errors.New("pick at least one of the webhook events")
+	ErrTooManyWebhooks         = fmt.Errorf("a workspace can have at most %d webhook endpoints", webhookMaxEndpoints)
+)
+
+type WebhookStore interface {
+	InsertEndpoint(ctx context.Context, workspaceID domain.ID, url, secret string, events []domain.WebhookEvent) (datastore.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, workspaceID domain.ID) ([]datastore.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, workspaceID, endpointID domain.ID) (bool, error)
+	SubscribedEndpoints(ctx context.Context, workspaceID domain.ID, event domain.WebhookEvent) ([]int32, error)
+	LinkEndpoints(ctx context.Context, urlIDs []domain.ID, events []domain.WebhookEvent) ([]datastore.ListLinkWebhookEndpointsRow, error)
+	CountVisits(ctx context.Context, urlIDs []domain.ID) (map[domain.ID]int64, error)
+	RaiseThreshold(ctx context.Context, endpointID, urlID domain.ID, threshold int64) (bool, error)
+	InsertDeliveries(ctx context.Context, deliveries []datastore.InsertWebhookDeliveriesParams) error
+	ListDeliveries(ctx context.Context, workspaceID domain.ID, limit int) ([]datastore.ListWebhookDeliveriesRow, error)
+	Redeliver(ctx context.Context, workspaceID domain.ID, deliveryID int64, now time.Time) (int64, error)
+}
+
+// LinkEvents is told about what happens to links, to notify their workspace.
+// Notifying never fails the caller, errors are only logged.
+type LinkEvents interface {
+	LinkCreated(ctx context.Context, workspaceID domain.ID, url domain.URL)
+	LinkDisabled(ctx context.Context, workspaceID domain.ID, url domain.URL)
+	VisitsRecorded(ctx context.Context, visits []domain.Visit)
+}
+
+// webhookService manages the webhook endpoints of workspaces and queues the
+// events they subscribed to, which the webhook dispatcher then delivers.
+type webhookService struct {
+	store       WebhookStore
+	shortDomain string
+}
+
+func NewWebhooks(store WebhookStore, shortDomain string) *webhookService {
+	return &webhookService{
+		store:       store,
+		shortDomain: shortDomain,
+	}
+}
+
+// CreateEndpoint adds an endpoint receiving events. Its secret, used to sign
+// the payloads, is generated.
+func (s *webhookService) CreateEndpoint(ctx context.Context, workspaceID domain.ID, rawURL string, events []domain.WebhookEvent) (domain.WebhookEndpoint, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if err := ValidateWebhookURL(rawURL); err != nil {
+		return domain.WebhookEndpoint{}, err
+	}
+	events, err := validateWebhookEvents(events)
+	if err != nil {
+		return domain.WebhookEndpoint{}, err
+	}
+
+	endpoints, err := s.store.ListEndpoints(ctx, workspaceID)
+	if err != nil {
+		return domain.WebhookEndpoint{}, err
+	}
+	if len(endpoints) >= webhookMaxEndpoints {
+		return domain.WebhookEndpoint{}, ErrTooManyWebhooks
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return domain.WebhookEndpoint{}, err
+	}
+
+	row, err := s.store.InsertEndpoint(ctx, workspaceID, rawURL, secret, events)
+	if err != nil {
+		return domain.WebhookEndpoint{}, err
+	}
+	return webhookEndpointFromRow(row), nil
+}
+
+func (s *webhookService) ListEndpoints(ctx context.Context, workspaceID domain.ID) ([]domain.WebhookEndpoint, error) {
+	rows, err := s.store.ListEndpoints(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]domain.WebhookEndpoint, 0, len(rows))
+	for _, row := range rows {
+		endpoints = append(endpoints, webhookEndpointFromRow(row))
+	}
+	return endpoints, nil
+}
+
+// DeleteEndpoint removes an endpoint along with its deliveries, the pending
+// ones are not sent.
+func (s *webhookService) DeleteEndpoint(ctx context.Context, workspaceID, endpointID domain.ID) error {
+	deleted, err := s.store.DeleteEndpoint(ctx, workspaceID, endpointID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListDeliveries returns the latest deliveries to the endpoints of the
+// workspace, most recent first.
+func (s *webhookService) ListDeliveries(ctx context.Context, workspaceID domain.ID) ([]domain.WebhookDelivery, error) {
+	rows, err := s.store.ListDeliveries(ctx, workspaceID, webhookDeliveriesShown)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]domain.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		d := domain.WebhookDelivery{
+			ID:             row.ID,
+			EndpointID:     domain.ID(row.EndpointID),
+			EndpointURL:    row.EndpointUrl,
+			Event:          domain.WebhookEvent(row.Event),
+			Status:         domain.WebhookDeliveryStatus(row.Status),
+			Attempts:       int(row.Attempts),
+			NextAttemptAt:  row.NextAttemptAt.Time,
+			ResponseStatus: int(row.ResponseStatus.Int32),
+			LastError:      row.LastError,
+			CreatedAt:      row.CreatedAt.Time,
+		}
+		if row.LastAttemptAt.Valid {
+			lastAttempt := row.LastAttemptAt.Time
+			d.LastAttemptAt = &lastAttempt
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues the payload of a past delivery again. The original
+// delivery stays in the log as it was.
+func (s *webhookService) Redeliver(ctx context.Context, workspaceID domain.ID, deliveryID int64) error {
+	if _, err := s.store.Redeliver(ctx, workspaceID, deliveryID, time.Now()); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrWebhookDeliveryNotFound
+		}
+		return fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+	return nil
+}
+
+// LinkCreated queues link.created for the endpoints of the workspace.
+func (s *webhookService) LinkCreated(ctx context.Context, workspaceID domain.ID, u domain.URL) {
+	s.notify(ctx, workspaceID, domain.WebhookLinkCreated, webhookData{Link: newWebhookLink(u)})
+}
+
+// LinkDisabled queues link.disabled for the endpoints of the workspace.
+func (s *webhookService) LinkDisabled(ctx context.Context, workspaceID domain.ID, u domain.URL) {
+	s.notify(ctx, workspaceID, domain.WebhookLinkDisabled, webhookData{
+		Link:   newWebhookLink(u),
+		Reason: "moderation",
+	})
+}
+
+func (s *webhookService) notify(ctx context.Context, workspaceID domain.ID, event domain.WebhookEvent, data webhookData) {
+	endpoints, err := s.store.SubscribedEndpoints(ctx, workspaceID, event)
+	if err != nil {
+		log.Error("failed to list webhook endpoints", "event", event, "err", err)
+		return
+	}
+
+	now := time.Now()
+	deliveries := make([]datastore.InsertWebhookDeliveriesParams, 0, len(endpoints))
+	for _, id := range endpoints {
+		d, err := newWebhookDelivery(domain.ID(id), event, data, now)
+		if err != nil {
+			log.Error("failed to prepare webhook delivery", "event", event, "err", err)
+			return
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := s.store.InsertDeliveries(ctx, deliveries); err != nil {
+		log.Error("failed to queue webhook deliveries", "event", event, "err", err)
+	}
+}
+
+// VisitsRecorded queues link.clicked for every visit by a person, and
+// link.click_threshold when a link reached one of the ClickThresholds. Each
+// threshold is sent once per endpoint even when batches of visits of the same
+// link are recorded concurrently.
+func (s *webhookService) VisitsRecorded(ctx context.Context, visits []domain.Visit) {
+	clicks := make(map[domain.ID][]domain.Visit)
+	var urlIDs []domain.ID
+	for _, v := range visits {
+		if v.IsBot {
+			continue
+		}
+		if _, ok := clicks[v.URLID]; !ok {
+			urlIDs = append(urlIDs, v.URLID)
+		}
+		clicks[v.URLID] = append(clicks[v.URLID], v)
+	}
+	if len(urlIDs) == 0 {
+		return
+	}
+
+	rows, err := s.store.LinkEndpoints(ctx, urlIDs, []domain.WebhookEvent{domain.WebhookLinkClicked, domain.WebhookLinkClickThreshold})
+	if err != nil {
+		log.Error("failed to list webhook endpoints", "event", domain.WebhookLinkClicked, "err", err)
+		return
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	now := time.Now()
+	var (
+		deliveries []datastore.InsertWebhookDeliveriesParams
+		thresholds []datastore.ListLinkWebhookEndpointsRow
+		counted    []domain.ID
+	)
+	for _, row := range rows {
+		urlID := domain.ID(row.UrlID)
+		if slices.Contains(row.Events, string(domain.WebhookLinkClickThreshold)) {
+			thresholds = append(thresholds, row)
+			if !slices.Contains(counted, urlID) {
+				counted = append(counted, urlID)
+			}
+		}
+		if !slices.Contains(row.Events, string(domain.WebhookLinkClicked)) {
+			continue
+		}
+		for _, v := range clicks[urlID] {
+			d, err := newWebhookDelivery(domain.ID(row.EndpointID), domain.WebhookLinkClicked, webhookData{
+				Link:  s.linkFromRow(row),
+				Visit: newWebhookVisit(v),
+			}, now)
+			if err != nil {
+				log.Error("failed to prepare webhook delivery", "event", domain.WebhookLinkClicked, "err", err)
+				return
+			}
+			deliveries = append(deliveries, d)
+		}
+	}
+
+	if len(thresholds) > 0 {
+		counts, err := s.store.CountVisits(ctx, counted)
+		if err != nil {
+			log.Error("failed to count link visits", "err", err)
+		}
+		for _, row := range thresholds {
+			total := counts[domain.ID(row.UrlID)]
+			threshold, ok := domain.ReachedThreshold(total)
+			if !ok {
+				continue
+			}
+			// the endpoint may already know about it, from another batch
+			raised, err := s.store.RaiseThreshold(ctx, domain.ID(row.EndpointID), domain.ID(row.UrlID), threshold)
+			if err != nil {
+				log.Error("failed to record click threshold", "url_id", row.UrlID, "err", err)
+				continue
+			}
+			if !raised {
+				continue
+			}
+			d, err := newWebhookDelivery(domain.ID(row.EndpointID), domain.WebhookLinkClickThreshold, webhookData{
+				Link:      s.linkFromRow(row),
+				Threshold: threshold,
+				Clicks:    total,
+			}, now)
+			if err != nil {
+				log.Error("failed to prepare webhook delivery", "event", domain.WebhookLinkClickThreshold, "err", err)
+				continue
+			}
+			deliveries = append(deliveries, d)
+		}
+	}
+
+	if err := s.store.InsertDeliveries(ctx, deliveries); err != nil {
+		log.Error("failed to queue webhook deliveries", "count", len(deliveries), "err", err)
+	}
+}
+
+// webhookPayload is the body posted to endpoints. ID identifies the event,
+// redeliveries keep it so that receivers can tell them apart from new events.
+type webhookPayload struct {
+	ID        string              `json:"id"`
+	Type      domain.WebhookEvent `json:"type"`
+	CreatedAt time.Time           `json:"created_at"`
+	Data      webhookData         `json:"data"`
+}
+
+type webhookData struct {
+	Link  webhookLink   `json:"link"`
+	Visit *webhookVisit `json:"visit,omitempty"`
+	// Reason is why the link was disabled.
+	Reason string `json:"reason,omitempty"`
+	// Threshold is the one of the ClickThresholds the link reached, with
+	// Clicks visits.
+	Threshold int64 `json:"threshold,omitempty"`
+	Clicks    int64 `json:"clicks,omitempty"`
+}
+
+type webhookLink struct {
+	Slug      string    `json:"slug"`
+	ShortURL  string    `json:"short_url"`
+	LongURL   string    `json:"long_url"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type webhookVisit struct {
+	VisitedAt   time.Time `json:"visited_at"`
+	Country     string    `json:"country"`
+	CountryCode string    `json:"country_code"`
+	City        string    `json:"city"`
+	Browser     string    `json:"browser"`
+	Platform    string    `json:"platform"`
+	Device      string    `json:"device"`
+	Referrer    string    `json:"referrer"`
+}
+
+func newWebhookLink(u domain.URL) webhookLink {
+	return webhookLink{
+		Slug:      u.Slug,
+		ShortURL:  u.Short,
+		LongURL:   u.Long,
+		Title:     u.Title,
+		CreatedAt: u.CreatedAt.UTC(),
+	}
+}
+
+func (s *webhookService) linkFromRow(row datastore.ListLinkWebhookEndpointsRow) webhookLink {
+	return webhookLink{
+		Slug:      row.ShortUrl,
+		ShortURL:  toURL(s.shortDomain, row.Domain, row.ShortUrl),
+		LongURL:   row.LongUrl,
+		Title:     row.Title,
+		CreatedAt: row.UrlCreatedAt.Time,
+	}
+}
+
+func newWebhookVisit(v domain.Visit) *webhookVisit {
+	browser := v.Request.Browser()
+	visit := &webhookVisit{
+		VisitedAt: v.VisitedAt.UTC(),
+		Browser:   browser.Name,
+		Platform:  browser.Platform,
+		Device:    string(deviceKind(browser.IsMobile)),
+		Referrer:  v.Request.Referer(),
+	}
+	if v.Location != nil {
+		visit.Country = v.Location.Country
+		visit.CountryCode = v.Location.CountryCode
+		visit.City = v.Location.City
+	}
+	return visit
+}
+
+func newWebhookDelivery(endpointID domain.ID, event domain.WebhookEvent, data webhookData, now time.Time) (datastore.InsertWebhookDeliveriesParams, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return datastore.InsertWebhookDeliveriesParams{}, fmt.Errorf("failed to generate webhook event id: %w", err)
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		ID:        "evt_" + hex.EncodeToString(id),
+		Type:      event,
+		CreatedAt: now.UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return datastore.InsertWebhookDeliveriesParams{}, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	return datastore.InsertWebhookDeliveriesParams{
+		EndpointID:    int32(endpointID),
+		Event:         string(event),
+		Payload:       payload,
+		NextAttemptAt: pgtype.Timestamp{Time: now.UTC(), Valid: true},
+	}, nil
+}
+
+// ValidateWebhookURL accepts absolute http and https URLs. Where they point to
+// is checked when delivering, see NewWebhookClient.
+func ValidateWebhookURL(rawURL string) error {
+	if len(rawURL) > webhookMaxURLLen {
+		return ErrInvalidWebhookURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// validateWebhookEvents drops duplicates and rejects unknown events.
+func validateWebhookEvents(events []domain.WebhookEvent) ([]domain.WebhookEvent, error) {
+	valid := make([]domain.WebhookEvent, 0, len(events))
+	for _, e := range events {
+		if !slices.Contains(domain.WebhookEvents, e) {
+			return nil, ErrInvalidWebhookEvents
+		}
+		if !slices.Contains(valid, e) {
+			valid = append(valid, e)
+		}
+	}
+	if len(valid) == 0 {
+		return nil, ErrInvalidWebhookEvents
+	}
+	return valid, nil
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return webhookSecretPrefix + hex.EncodeToString(b), nil
+}
+
+func webhookEndpointFromRow(row datastore.WebhookEndpoint) domain.WebhookEndpoint {
+	events := make([]domain.WebhookEvent, len(row.Events))
+	for i, e := range row.Events {
+		events[i] = domain.WebhookEvent(e)
+	}
+	return domain.WebhookEndpoint{
+		ID:        domain.ID(row.ID),
+		URL:       row.Url,
+		Secret:    row.Secret,
+		Events:    events,
+		CreatedAt: row.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+// WebhookDispatchStore hands out the deliveries to send and records how they
+// went.
+type WebhookDispatchStore interface {
+	ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]datastore.ClaimWebhookDeliveriesRow, error)
+	RecordAttempt(ctx context.Context, deliveryID int64, status domain.WebhookDeliveryStatus, attemptedAt, nextAttemptAt time.Time, responseStatus int, lastError string) error
+	DeleteDeliveriesBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+// WebhookDispatcherConfig sizes the webhook delivery pipeline.
+type WebhookDispatcherConfig struct {
+	// Workers is the number of deliveries sent concurrently.
+	Workers int
+	// BatchSize is the number of deliveries a worker claims at once.
+	BatchSize int
+	// PollInterval is how long an idle worker waits before looking for due
+	// deliveries again.
+	PollInterval time.Duration
+	// Timeout bounds a single attempt.
+	Timeout time.Duration
+	// Retention is how long delivered and failed deliveries stay in the log.
+	Retention time.Duration
+}
+
+var DefaultWebhookDispatcherConfig = WebhookDispatcherConfig{
+	Workers:      4,
+	BatchSize:    10,
+	PollInterval: 5 * time.Second,
+	Timeout:      10 * time.Second,
+	Retention:    30 * 24 * time.Hour,
+}
+
+const (
+	// webhookMaxAttempts is the number of attempts before a delivery is
+	// marked as failed, spread over about 4 hours by webhookBackoff.
+	webhookMaxAttempts = 10
+	// webhookFirstRetry is the wait after the first failed attempt, doubled
+	// after each of the next ones.
+	webhookFirstRetry = 30 * time.Second
+	// webhookMaxErrorLen bounds the error, or response body, kept in the log.
+	webhookMaxErrorLen  = 256
+	webhookCleanupEvery = time.Hour
+
+	webhookSignatureHeader = "Shortcut-Signature"
+	webhookEventHeader     = "Shortcut-Event"
+	webhookDeliveryHeader  = "Shortcut-Delivery"
+)
+
+// webhookDispatcher posts the queued webhook deliveries to their endpoints.
+// Deliveries are claimed from the database with a lease, so several instances
+// can dispatch side by side and the deliveries of a dispatcher stopped midway
+// are sent again once their lease expires.
+type webhookDispatcher struct {
+	store  WebhookDispatchStore
+	client *http.Client
+	config WebhookDispatcherConfig
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+
+	// sends is canceled when shutdown times out, to abort the attempts in
+	// flight
+	sends       context.Context
+	cancelSends context.CancelFunc
+}
+
+// NewWebhookDispatcher starts the workers delivering webhooks with client.
+// Call Shutdown to stop them.
+func NewWebhookDispatcher(store WebhookDispatchStore, client *http.Client, config WebhookDispatcherConfig) *webhookDispatcher {
+	if config.Workers <= 0 {
+		config.Workers = DefaultWebhookDispatcherConfig.Workers
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultWebhookDispatcherConfig.BatchSize
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultWebhookDispatcherConfig.PollInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultWebhookDispatcherConfig.Timeout
+	}
+	if config.Retention <= 0 {
+		config.Retention = DefaultWebhookDispatcherConfig.Retention
+	}
+
+	d := &webhookDispatcher{
+		store:  store,
+		client: client,
+		config: config,
+		stop:   make(chan struct{}),
+	}
+	d.sends, d.cancelSends = context.WithCancel(context.Background())
+
+	d.wg.Add(config.Workers + 1)
+	for range config.Workers {
+		go d.work()
+	}
+	go d.cleanup()
+	return d
+}
+
+// Shutdown stops claiming deliveries and waits for the attempts in flight to
+// complete or for ctx to be done. Deliveries left unsent are sent again once
+// their lease expires.
+func (d *webhookDispatcher) Shutdown(ctx context.Context) error {
+	d.once.Do(func() { close(d.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.cancelSends()
+		return ctx.Err()
+	}
+}
+
+func (d *webhookDispatcher) work() {
+	defer d.wg.Done()
+
+	for {
+		// a full batch means more deliveries are probably due
+		wait := d.config.PollInterval
+		if d.dispatch() == d.config.BatchSize {
+			wait = 0
+		}
+		select {
+		case <-d.stop:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// dispatch sends a batch of due deliveries and returns its size.
+func (d *webhookDispatcher) dispatch() int {
+	now := time.Now()
+	// the lease outlasts the attempts of the whole batch
+	lease := time.Duration(d.config.BatchSize)*d.config.Timeout + time.Minute
+	ctx, cancel := context.WithTimeout(d.sends, flushTimeout)
+	deliveries, err := d.store.ClaimDeliveries(ctx, now, now.Add(lease), d.config.BatchSize)
+	cancel()
+	if err != nil {
+		log.Error("failed to claim webhook deliveries", "err", err)
+		return 0
+	}
+
+	for _, delivery := range deliveries {
+		d.deliver(delivery)
+	}
+	return len(deliveries)
+}
+
+func (d *webhookDispatcher) deliver(delivery datastore.ClaimWebhookDeliveriesRow) {
+	attemptedAt := time.Now()
+	status, err := d.send(delivery, attemptedAt)
+
+	attempts := int(delivery.Attempts) + 1
+	result, next, lastError := domain.WebhookDeliveryDelivered, attemptedAt, ""
+	if err != nil {
+		lastError = webhookError(err)
+		result = domain.WebhookDeliveryPending
+		next = attemptedAt.Add(webhookBackoff(attempts))
+		if attempts >= webhookMaxAttempts {
+			result = domain.WebhookDeliveryFailed
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	if err := d.store.RecordAttempt(ctx, delivery.ID, result, attemptedAt, next, status, lastError); err != nil {
+		log.Error("failed to record webhook attempt", "delivery_id", delivery.ID, "err", err)
+	}
+}
+
+// send posts the payload of a delivery, signed with the secret of its
+// endpoint. It returns the status of the response, 0 when none was received,
+// and an error unless the endpoint answered with a 2xx status.
+func (d *webhookDispatcher) send(delivery datastore.ClaimWebhookDeliveriesRow, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(d.sends, d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Shortcut-Webhooks/1.0")
+	req.Header.Set(webhookEventHeader, delivery.Event)
+	req.Header.Set(webhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(webhookSignatureHeader, signWebhook(delivery.Secret, now, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxErrorLen))
+	if len(bytes.TrimSpace(body)) == 0 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(body))
+}
+
+// cleanup removes the old deliveries that are done from the log.
+func (d *webhookDispatcher) cleanup() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(webhookCleanupEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(d.sends, flushTimeout)
+			n, err := d.store.DeleteDeliveriesBefore(ctx, time.Now().Add(-d.config.Retention))
+			cancel()
+			if err != nil {
+				log.Error("failed to delete old webhook deliveries", "err", err)
+			} else if n > 0 {
+				log.Info("deleted old webhook deliveries", "count", n)
+			}
+		}
+	}
+}
+
+// webhookBackoff is the wait after the attempts-th failed attempt.
+func webhookBackoff(attempts int) time.Duration {
+	return webhookFirstRetry << (attempts - 1)
+}
+
+// signWebhook signs the payload sent at t, so that endpoints can check it
+// comes from us and is not replayed: v1 is the hex encoded HMAC-SHA256, keyed
+// with the secret of the endpoint, of the unix time t, a dot and the payload.
+func signWebhook(secret string, t time.Time, payload []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookError keeps the start of an error for the log. Postgres only stores
+// valid UTF-8, which response bodies, and a cut, don't always are.
+func webhookError(err error) string {
+	msg := err.Error()
+	if len(msg) > webhookMaxErrorLen {
+		msg = msg[:webhookMaxErrorLen]
+	}
+	return strings.ToValidUTF8(msg, "")
+}
+
+var errWebhookAddressNotAllowed = errors.New("webhook endpoints must resolve to a public address")
+
+// NewWebhookClient returns the client deliveries are sent with. Endpoints are
+// set by users, so it only connects to public addresses, checked once resolved
+// so that DNS can't be used to reach the internal network, and doesn't follow
+// redirects.
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(addrPort.Addr()) {
+				return errWebhookAddressNotAllowed
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// a proxy would connect on our behalf, out of the reach of the
+			// dialer checks
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConnsPerHost:   2,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, not routed on the
+// internet either.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!sharedAddressSpace.Contains(addr)
+}
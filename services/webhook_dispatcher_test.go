@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/db/datastore"
+	"github.com/zaibon/shortcut/domain"
+)
+
+type webhookAttempt struct {
+	deliveryID     int64
+	status         domain.WebhookDeliveryStatus
+	attemptedAt    time.Time
+	nextAttemptAt  time.Time
+	responseStatus int
+	lastError      string
+}
+
+// memDispatchStore hands out its deliveries once.
+type memDispatchStore struct {
+	mu         sync.Mutex
+	deliveries []datastore.ClaimWebhookDeliveriesRow
+	attempts   []webhookAttempt
+}
+
+func (s *memDispatchStore) ClaimDeliveries(_ context.Context, _, _ time.Time, limit int) ([]datastore.ClaimWebhookDeliveriesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := min(limit, len(s.deliveries))
+	claimed := s.deliveries[:n]
+	s.deliveries = s.deliveries[n:]
+	return claimed, nil
+}
+
+func (s *memDispatchStore) RecordAttempt(_ context.Context, deliveryID int64, status domain.WebhookDeliveryStatus, attemptedAt, nextAttemptAt time.Time, responseStatus int, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts = append(s.attempts, webhookAttempt{deliveryID, status, attemptedAt, nextAttemptAt, responseStatus, lastError})
+	return nil
+}
+
+func (s *memDispatchStore) DeleteDeliveriesBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *memDispatchStore) recorded() []webhookAttempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]webhookAttempt(nil), s.attempts...)
+}
+
+// webhookReceiver checks the signature of the events it receives and answers
+// with the status in their path.
+func webhookReceiver(t *testing.T, secret string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var ts, sig string
+		for _, part := range strings.Split(r.Header.Get(webhookSignatureHeader), ",") {
+			k, v, _ := strings.Cut(part, "=")
+			switch k {
+			case "t":
+				ts = v
+			case "v1":
+				sig = v
+			}
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(ts + "." + string(body)))
+		if !hmac.Equal([]byte(sig), []byte(hex.EncodeToString(mac.Sum(nil)))) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "link.created", r.Header.Get(webhookEventHeader))
+
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusNoContent)
+		case "/redirect":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		default:
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWebhookDeliver(t *testing.T) {
+	const secret = "whsec_test"
+	srv := webhookReceiver(t, secret)
+
+	tests := []struct {
+		name       string
+		path       string
+		secret     string
+		attempts   int32
+		wantStatus domain.WebhookDeliveryStatus
+		wantCode   int
+		wantError  string
+		wantRetry  time.Duration
+	}{
+		{name: "delivered", path: "/ok", secret: secret, wantStatus: domain.WebhookDeliveryDelivered, wantCode: http.StatusNoContent},
+		{name: "server error", path: "/fail", secret: secret, wantStatus: domain.WebhookDeliveryPending, wantCode: http.StatusInternalServerError, wantError: "boom", wantRetry: 30 * time.Second},
+		{name: "backoff", path: "/fail", secret: secret, attempts: 3, wantStatus: domain.WebhookDeliveryPending, wantCode: http.StatusInternalServerError, wantError: "boom", wantRetry: 4 * time.Minute},
+		{name: "out of attempts", path: "/fail", secret: secret, attempts: webhookMaxAttempts - 1, wantStatus: domain.WebhookDeliveryFailed, wantCode: http.StatusInternalServerError, wantError: "boom"},
+		{name: "wrong secret", path: "/ok", secret: "whsec_other", wantStatus: domain.WebhookDeliveryPending, wantCode: http.StatusUnauthorized, wantError: "bad signature", wantRetry: 30 * time.Second},
+		{name: "redirects not followed", path: "/redirect", secret: secret, wantStatus: domain.WebhookDeliveryPending, wantCode: http.StatusFound, wantRetry: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &memDispatchStore{}
+			client := *srv.Client()
+			client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+			d := &webhookDispatcher{store: store, client: &client, config: DefaultWebhookDispatcherConfig, sends: context.Background()}
+
+			d.deliver(datastore.ClaimWebhookDeliveriesRow{
+				ID:       42,
+				Event:    "link.created",
+				Payload:  []byte(`{"type":"link.created"}`),
+				Attempts: tt.attempts,
+				Url:      srv.URL + tt.path,
+				Secret:   tt.secret,
+			})
+
+			attempts := store.recorded()
+			if !assert.Len(t, attempts, 1) {
+				return
+			}
+			got := attempts[0]
+			assert.Equal(t, int64(42), got.deliveryID)
+			assert.Equal(t, tt.wantStatus, got.status)
+			assert.Equal(t, tt.wantCode, got.responseStatus)
+			assert.Contains(t, got.lastError, tt.wantError)
+			if tt.wantStatus == domain.WebhookDeliveryPending {
+				assert.Equal(t, tt.wantRetry, got.nextAttemptAt.Sub(got.attemptedAt))
+			}
+		})
+	}
+}
+
+func TestWebhookDispatcher(t *testing.T) {
+	t.Parallel()
+
+	srv := webhookReceiver(t, "whsec_test")
+
+	store := &memDispatchStore{}
+	for i := range 25 {
+		store.deliveries = append(store.deliveries, datastore.ClaimWebhookDeliveriesRow{
+			ID:      int64(i + 1),
+			Event:   "link.created",
+			Payload: []byte(`{}`),
+			Url:     srv.URL + "/ok",
+			Secret:  "whsec_test",
+		})
+	}
+
+	d := NewWebhookDispatcher(store, srv.Client(), WebhookDispatcherConfig{Workers: 2, BatchSize: 10, PollInterval: 10 * time.Millisecond})
+	assert.Eventually(t, func() bool { return len(store.recorded()) == 25 }, 2*time.Second, 10*time.Millisecond)
+	assert.NoError(t, d.Shutdown(context.Background()))
+
+	for _, a := range store.recorded() {
+		assert.Equal(t, domain.WebhookDeliveryDelivered, a.status)
+	}
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.215.14", want: true},
+		{addr: "2606:2800:21f:cb07:6820:80da:af6b:8b2c", want: true},
+		{addr: "127.0.0.1"},
+		{addr: "10.0.0.8"},
+		{addr: "172.16.4.1"},
+		{addr: "192.168.1.1"},
+		{addr: "169.254.169.254"},
+		{addr: "100.64.0.1"},
+		{addr: "0.0.0.0"},
+		{addr: "::1"},
+		{addr: "fd00::1"},
+		{addr: "fe80::1"},
+		{addr: "::ffff:127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isPublicAddr(netip.MustParseAddr(tt.addr)), tt.addr)
+	}
+}
+
+func TestWebhookClientRejectsPrivateAddresses(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	_, err := NewWebhookClient(time.Second).Post(srv.URL, "application/json", strings.NewReader("{}"))
+	assert.ErrorIs(t, err, errWebhookAddressNotAllowed)
+}
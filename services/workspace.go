@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/zaibon/shortcut/domain"
+	"github.com/zaibon/shortcut/log"
+)
+
+// WorkspaceStore persists workspaces, their members and the invitations to
+// join them.
+type WorkspaceStore interface {
+	Txer
+
+	PersonalWorkspace(ctx context.Context, user domain.User) (domain.Workspace, error)
+	MemberWorkspace(ctx context.Context, userID, workspaceID domain.ID) (domain.Workspace, error)
+	ListWorkspaces(ctx context.Context, userID domain.ID) ([]domain.Workspace, error)
+	CountOwnedWorkspaces(ctx context.Context, userID domain.ID) (int, error)
+	InsertWorkspace(ctx context.Context, userID domain.ID, name string) (domain.Workspace, error)
+
+	ListMembers(ctx context.Context, workspaceID domain.ID) ([]domain.WorkspaceMember, error)
+	LockOwners(ctx context.Context, workspaceID domain.ID) ([]domain.ID, error)
+	UpdateMemberRole(ctx context.Context, workspaceID, userID domain.ID, role domain.Role) (bool, error)
+	DeleteMember(ctx context.Context, workspaceID, userID domain.ID) (bool, error)
+
+	UpsertInvitation(ctx context.Context, inv domain.WorkspaceInvitation, invitedBy domain.ID, tokenHash []byte) (domain.WorkspaceInvitation, error)
+	ListInvitations(ctx context.Context, workspaceID domain.ID) ([]domain.WorkspaceInvitation, error)
+	GetInvitation(ctx context.Context, tokenHash []byte) (domain.WorkspaceInvitation, error)
+	DeleteInvitation(ctx context.Context, workspaceID, invitationID domain.ID) (bool, error)
+	AcceptInvitation(ctx context.Context, invitationID, userID domain.ID) error
+}
+
+const (
+	maxWorkspaceNameLen = 64
+	// maxOwnedWorkspaces bounds the shared workspaces a user can own.
+	maxOwnedWorkspaces   = 10
+	invitationTokenBytes = 32
+	invitationTTL        = 7 * 24 * time.Hour
+)
+
+var (
+	ErrWorkspaceNotFound    = errors.New("workspace not found")
+	ErrInvalidWorkspaceName = fmt.Errorf("workspace names must be 1 to %d characters long", maxWorkspaceNameLen)
+	ErrTooManyWorkspaces    = fmt.Errorf("you can own at most %d workspaces", maxOwnedWorkspaces)
+	ErrWorkspaceForbidden   = errors.New("your role in this workspace doesn't allow this")
+	ErrPersonalWorkspace    = errors.New("a personal workspace can't be shared")
+	ErrInvalidRole          = errors.New("role must be one of owner, editor or viewer")
+	ErrInvalidEmail         = errors.New("invalid email address")
+	ErrMemberNotFound       = errors.New("member not found")
+	ErrLastOwner            = errors.New("a workspace must keep at least one owner")
+	ErrInvitationNotFound   = errors.New("invitation not found or expired")
+	ErrInvitationEmail      = errors.New("this invitation was sent to another email address")
+)
+
+type workspaceService struct {
+	store WorkspaceStore
+	// mailer sends the invitations, when nil their link has to be shared by
+	// the owner
+	mailer  Mailer
+	baseURL string
+}
+
+// NewWorkspaces manages workspaces. Invitations link to baseURL, the scheme
+// and host of the dashboard.
+func NewWorkspaces(store WorkspaceStore, mailer Mailer, baseURL string) *workspaceService {
+	return &workspaceService{
+		store:   store,
+		mailer:  mailer,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Personal returns the personal workspace of user, created the first time.
+func (s *workspaceService) Personal(ctx context.Context, user domain.User) (domain.Workspace, error) {
+	return s.store.PersonalWorkspace(ctx, user)
+}
+
+// Get returns the workspace workspaceID with the role of userID in it.
+// ErrWorkspaceNotFound is returned when userID isn't a member.
+func (s *workspaceService) Get(ctx context.Context, userID, workspaceID domain.ID) (domain.Workspace, error) {
+	ws, err := s.store.MemberWorkspace(ctx, userID, workspaceID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Workspace{}, ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return domain.Workspace{}, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return ws, nil
+}
+
+// List returns the workspaces userID is a member of, their personal one first.
+func (s *workspaceService) List(ctx context.Context, userID domain.ID) ([]domain.Workspace, error) {
+	return s.store.ListWorkspaces(ctx, userID)
+}
+
+// Create creates a shared workspace owned by userID.
+func (s *workspaceService) Create(ctx context.Context, userID domain.ID, name string) (domain.Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxWorkspaceNameLen {
+		return domain.Workspace{}, ErrInvalidWorkspaceName
+	}
+
+	n, err := s.store.CountOwnedWorkspaces(ctx, userID)
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+	if n >= maxOwnedWorkspaces {
+		return domain.Workspace{}, ErrTooManyWorkspaces
+	}
+	return s.store.InsertWorkspace(ctx, userID, name)
+}
+
+// Members lists the members of ws, the oldest first.
+func (s *workspaceService) Members(ctx context.Context, ws domain.Workspace) ([]domain.WorkspaceMember, error) {
+	return s.store.ListMembers(ctx, ws.ID)
+}
+
+// Invitations lists the pending invitations of ws, only its owners see them.
+func (s *workspaceService) Invitations(ctx context.Context, ws domain.Workspace) ([]domain.WorkspaceInvitation, error) {
+	if !ws.Role.Can(domain.RoleOwner) {
+		return nil, ErrWorkspaceForbidden
+	}
+	return s.store.ListInvitations(ctx, ws.ID)
+}
+
+// Invite invites email to join ws with role, on behalf of one of its owners.
+// The invitation is emailed when a mailer is configured, its link is returned
+// either way so that the owner can share it. Inviting an email again replaces
+// its pending invitation.
+func (s *workspaceService) Invite(ctx context.Context, ws domain.Workspace, inviter domain.User, email string, role domain.Role) (domain.CreatedInvitation, error) {
+	if !ws.Role.Can(domain.RoleOwner) {
+		return domain.CreatedInvitation{}, ErrWorkspaceForbidden
+	}
+	if ws.Personal {
+		return domain.CreatedInvitation{}, ErrPersonalWorkspace
+	}
+	if !role.Valid() {
+		return domain.CreatedInvitation{}, ErrInvalidRole
+	}
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return domain.CreatedInvitation{}, err
+	}
+
+	token, err := generateInvitationToken()
+	if err != nil {
+		return domain.CreatedInvitation{}, err
+	}
+	inv, err := s.store.UpsertInvitation(ctx, domain.WorkspaceInvitation{
+		WorkspaceID: ws.ID,
+		Workspace:   ws.Name,
+		Email:       email,
+		Role:        role,
+		InvitedBy:   inviter.Name,
+		ExpiresAt:   time.Now().Add(invitationTTL),
+	}, inviter.ID, hashInvitationToken(token))
+	if err != nil {
+		return domain.CreatedInvitation{}, err
+	}
+
+	created := domain.CreatedInvitation{
+		WorkspaceInvitation: inv,
+		Link:                s.baseURL + "/invitations/" + token,
+	}
+	if s.mailer != nil {
+		subject := fmt.Sprintf("%s invited you to %s on Shortcut", inviter.Name, ws.Name)
+		body := fmt.Sprintf("%s invited you to join the workspace %s on Shortcut as %s.\n\nAccept the invitation before %s:\n%s\n",
+			inviter.Name, ws.Name, role, inv.ExpiresAt.Format("January 2, 2006"), created.Link)
+		if err := s.mailer.Send(ctx, email, subject, body); err != nil {
+			log.Error("failed to send invitation", "workspace_id", ws.ID, "err", err)
+		} else {
+			created.Sent = true
+		}
+	}
+	return created, nil
+}
+
+// RevokeInvitation deletes a pending invitation of ws.
+func (s *workspaceService) RevokeInvitation(ctx context.Context, ws domain.Workspace, invitationID domain.ID) error {
+	if !ws.Role.Can(domain.RoleOwner) {
+		return ErrWorkspaceForbidden
+	}
+	deleted, err := s.store.DeleteInvitation(ctx, ws.ID, invitationID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// Invitation returns the pending invitation of the link token.
+func (s *workspaceService) Invitation(ctx context.Context, token string) (domain.WorkspaceInvitation, error) {
+	inv, err := s.store.GetInvitation(ctx, hashInvitationToken(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.WorkspaceInvitation{}, ErrInvitationNotFound
+	}
+	if err != nil {
+		return domain.WorkspaceInvitation{}, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return inv, nil
+}
+
+// AcceptInvitation makes user a member of the workspace of the invitation
+// token, which must have been sent to their email, and returns it. Members
+// accepting another invitation keep their role.
+func (s *workspaceService) AcceptInvitation(ctx context.Context, user domain.User, token string) (domain.Workspace, error) {
+	inv, err := s.Invitation(ctx, token)
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+	if !strings.EqualFold(inv.Email, strings.TrimSpace(user.Email)) {
+		return domain.Workspace{}, ErrInvitationEmail
+	}
+	if err := s.store.AcceptInvitation(ctx, inv.ID, user.ID); err != nil {
+		return domain.Workspace{}, err
+	}
+	return s.Get(ctx, user.ID, inv.WorkspaceID)
+}
+
+// UpdateMemberRole gives role to the member userID of ws. The last owner of a
+// workspace can't step down.
+func (s *workspaceService) UpdateMemberRole(ctx context.Context, ws domain.Workspace, userID domain.ID, role domain.Role) error {
+	if !ws.Role.Can(domain.RoleOwner) {
+		return ErrWorkspaceForbidden
+	}
+	if ws.Personal {
+		return ErrPersonalWorkspace
+	}
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	return s.changeMember(ctx, ws.ID, userID, role == domain.RoleOwner, func(ctx context.Context) (bool, error) {
+		return s.store.UpdateMemberRole(ctx, ws.ID, userID, role)
+	})
+}
+
+// RemoveMember removes userID from ws on behalf of actorID, one of its owners
+// or userID leaving it. The last owner of a workspace can't leave it.
+func (s *workspaceService) RemoveMember(ctx context.Context, ws domain.Workspace, actorID, userID domain.ID) error {
+	if actorID != userID && !ws.Role.Can(domain.RoleOwner) {
+		return ErrWorkspaceForbidden
+	}
+	if ws.Personal {
+		return ErrPersonalWorkspace
+	}
+
+	return s.changeMember(ctx, ws.ID, userID, false, func(ctx context.Context) (bool, error) {
+		return s.store.DeleteMember(ctx, ws.ID, userID)
+	})
+}
+
+// changeMember runs change, which updates or removes the member userID of
+// workspaceID and reports whether they are one. The owners of the workspace are
+// locked in the same transaction so that concurrent changes can't leave it
+// without any: unless userID stays an owner, the change is refused with
+// ErrLastOwner when they are the only one.
+func (s *workspaceService) changeMember(ctx context.Context, workspaceID, userID domain.ID, staysOwner bool, change func(context.Context) (bool, error)) error {
+	// refused is returned as is, Tx wraps the errors of the transaction
+	var refused error
+	err := s.store.Tx(ctx, func(ctx context.Context) error {
+		if !staysOwner {
+			owners, err := s.store.LockOwners(ctx, workspaceID)
+			if err != nil {
+				return err
+			}
+			if len(owners) == 1 && owners[0] == userID {
+				refused = ErrLastOwner
+				return refused
+			}
+		}
+
+		ok, err := change(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			refused = ErrMemberNotFound
+			return refused
+		}
+		return nil
+	}, pgx.TxOptions{})
+	if refused != nil {
+		return refused
+	}
+	return err
+}
+
+func normalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+func generateInvitationToken() (string, error) {
+	b := make([]byte, invitationTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashInvitationToken uses a plain SHA-256 for the same reasons as
+// hashAPIToken.
+func hashInvitationToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
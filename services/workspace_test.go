@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zaibon/shortcut/domain"
+)
+
+type membership struct {
+	workspaceID, userID domain.ID
+}
+
+// memWorkspaceStore starts with the personal workspace of user 10 and the
+// "Marketing" workspace user 10 owns and user 11 edits.
+type memWorkspaceStore struct {
+	WorkspaceStore
+	workspaces  map[domain.ID]domain.Workspace
+	roles       map[membership]domain.Role
+	invitations map[string]domain.WorkspaceInvitation
+}
+
+func newMemWorkspaceStore() *memWorkspaceStore {
+	return &memWorkspaceStore{
+		workspaces: map[domain.ID]domain.Workspace{
+			1: {ID: 1, Name: "Personal", Personal: true},
+			2: {ID: 2, Name: "Marketing"},
+		},
+		roles: map[membership]domain.Role{
+			{1, 10}: domain.RoleOwner,
+			{2, 10}: domain.RoleOwner,
+			{2, 11}: domain.RoleEditor,
+		},
+		invitations: map[string]domain.WorkspaceInvitation{},
+	}
+}
+
+func (s *memWorkspaceStore) MemberWorkspace(_ context.Context, userID, workspaceID domain.ID) (domain.Workspace, error) {
+	role, ok := s.roles[membership{workspaceID, userID}]
+	if !ok {
+		return domain.Workspace{}, pgx.ErrNoRows
+	}
+	ws := s.workspaces[workspaceID]
+	ws.Role = role
+	return ws, nil
+}
+
+func (s *memWorkspaceStore) CountOwnedWorkspaces(_ context.Context, userID domain.ID) (int, error) {
+	n := 0
+	for m, role := range s.roles {
+		if m.userID == userID && role == domain.RoleOwner && !s.workspaces[m.workspaceID].Personal {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memWorkspaceStore) InsertWorkspace(_ context.Context, userID domain.ID, name string) (domain.Workspace, error) {
+	ws := domain.Workspace{ID: domain.ID(len(s.workspaces) + 1), Name: name}
+	s.workspaces[ws.ID] = ws
+	s.roles[membership{ws.ID, userID}] = domain.RoleOwner
+	ws.Role = domain.RoleOwner
+	return ws, nil
+}
+
+func (s *memWorkspaceStore) Tx(ctx context.Context, fn func(context.Context) error, _ pgx.TxOptions) error {
+	return fn(ctx)
+}
+
+func (s *memWorkspaceStore) LockOwners(_ context.Context, workspaceID domain.ID) ([]domain.ID, error) {
+	var owners []domain.ID
+	for m, role := range s.roles {
+		if m.workspaceID == workspaceID && role == domain.RoleOwner {
+			owners = append(owners, m.userID)
+		}
+	}
+	return owners, nil
+}
+
+func (s *memWorkspaceStore) UpdateMemberRole(_ context.Context, workspaceID, userID domain.ID, role domain.Role) (bool, error) {
+	m := membership{workspaceID, userID}
+	if _, ok := s.roles[m]; !ok {
+		return false, nil
+	}
+	s.roles[m] = role
+	return true, nil
+}
+
+func (s *memWorkspaceStore) DeleteMember(_ context.Context, workspaceID, userID domain.ID) (bool, error) {
+	m := membership{workspaceID, userID}
+	if _, ok := s.roles[m]; !ok {
+		return false, nil
+	}
+	delete(s.roles, m)
+	return true, nil
+}
+
+func (s *memWorkspaceStore) UpsertInvitation(_ context.Context, inv domain.WorkspaceInvitation, _ domain.ID, tokenHash []byte) (domain.WorkspaceInvitation, error) {
+	inv.ID = domain.ID(len(s.invitations) + 1)
+	s.invitations[string(tokenHash)] = inv
+	return inv, nil
+}
+
+func (s *memWorkspaceStore) GetInvitation(_ context.Context, tokenHash []byte) (domain.WorkspaceInvitation, error) {
+	inv, ok := s.invitations[string(tokenHash)]
+	if !ok || inv.ExpiresAt.Before(time.Now()) {
+		return domain.WorkspaceInvitation{}, pgx.ErrNoRows
+	}
+	return inv, nil
+}
+
+func (s *memWorkspaceStore) AcceptInvitation(_ context.Context, invitationID, userID domain.ID) error {
+	for hash, inv := range s.invitations {
+		if inv.ID == invitationID {
+			m := membership{inv.WorkspaceID, userID}
+			if _, ok := s.roles[m]; !ok {
+				s.roles[m] = inv.Role
+			}
+			delete(s.invitations, hash)
+		}
+	}
+	return nil
+}
+
+type sentMail struct {
+	to, subject, body string
+}
+
+type memMailer struct {
+	sent []sentMail
+}
+
+func (m *memMailer) Send(_ context.Context, to, subject, body string) error {
+	m.sent = append(m.sent, sentMail{to, subject, body})
+	return nil
+}
+
+func TestCreateWorkspace(t *testing.T) {
+	ctx := context.Background()
+	store := newMemWorkspaceStore()
+	svc := NewWorkspaces(store, nil, "https://sho.rt")
+
+	ws, err := svc.Create(ctx, 10, "  Sales ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Sales", ws.Name)
+	assert.Equal(t, domain.RoleOwner, ws.Role)
+
+	_, err = svc.Create(ctx, 10, " ")
+	assert.ErrorIs(t, err, ErrInvalidWorkspaceName)
+	_, err = svc.Create(ctx, 10, strings.Repeat("a", maxWorkspaceNameLen+1))
+	assert.ErrorIs(t, err, ErrInvalidWorkspaceName)
+
+	for range maxOwnedWorkspaces - 2 {
+		_, err = svc.Create(ctx, 10, "Team")
+		assert.NoError(t, err)
+	}
+	_, err = svc.Create(ctx, 10, "One too many")
+	assert.ErrorIs(t, err, ErrTooManyWorkspaces, "the personal workspace doesn't count")
+}
+
+func TestInvite(t *testing.T) {
+	ctx := context.Background()
+	owner := domain.User{ID: 10, Name: "Ada"}
+	shared := domain.Workspace{ID: 2, Name: "Marketing", Role: domain.RoleOwner}
+
+	tests := []struct {
+		name    string
+		ws      domain.Workspace
+		email   string
+		role    domain.Role
+		wantErr error
+	}{
+		{name: "editor", ws: shared, email: " Bob@Example.com ", role: domain.RoleEditor},
+		{name: "not an owner", ws: domain.Workspace{ID: 2, Role: domain.RoleEditor}, email: "bob@example.com", role: domain.RoleViewer, wantErr: ErrWorkspaceForbidden},
+		{name: "personal workspace", ws: domain.Workspace{ID: 1, Personal: true, Role: domain.RoleOwner}, email: "bob@example.com", role: domain.RoleViewer, wantErr: ErrPersonalWorkspace},
+		{name: "unknown role", ws: shared, email: "bob@example.com", role: "admin", wantErr: ErrInvalidRole},
+		{name: "invalid email", ws: shared, email: "bob", role: domain.RoleViewer, wantErr: ErrInvalidEmail},
+		{name: "email with a name", ws: shared, email: "Bob <bob@example.com>", role: domain.RoleViewer, wantErr: ErrInvalidEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newMemWorkspaceStore()
+			mailer := &memMailer{}
+			svc := NewWorkspaces(store, mailer, "https://sho.rt/")
+
+			created, err := svc.Invite(ctx, tt.ws, owner, tt.email, tt.role)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				assert.Empty(t, mailer.sent)
+				return
+			}
+
+			assert.Equal(t, "bob@example.com", created.Email)
+			assert.True(t, created.Sent)
+			token, ok := strings.CutPrefix(created.Link, "https://sho.rt/invitations/")
+			assert.True(t, ok, created.Link)
+			assert.Contains(t, store.invitations, string(hashInvitationToken(token)), "only the hash of the token is stored")
+			if assert.Len(t, mailer.sent, 1) {
+				assert.Equal(t, "bob@example.com", mailer.sent[0].to)
+				assert.Contains(t, mailer.sent[0].body, created.Link)
+			}
+		})
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	ctx := context.Background()
+	store := newMemWorkspaceStore()
+	svc := NewWorkspaces(store, nil, "https://sho.rt")
+
+	created, err := svc.Invite(ctx, domain.Workspace{ID: 2, Name: "Marketing", Role: domain.RoleOwner}, domain.User{ID: 10}, "bob@example.com", domain.RoleViewer)
+	assert.NoError(t, err)
+	assert.False(t, created.Sent, "without a mailer the link is shared by hand")
+	token := created.Link[strings.LastIndex(created.Link, "/")+1:]
+
+	_, err = svc.AcceptInvitation(ctx, domain.User{ID: 12, Email: "eve@example.com"}, token)
+	assert.ErrorIs(t, err, ErrInvitationEmail)
+
+	ws, err := svc.AcceptInvitation(ctx, domain.User{ID: 12, Email: "Bob@example.com"}, token)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ID(2), ws.ID)
+	assert.Equal(t, domain.RoleViewer, ws.Role)
+
+	_, err = svc.AcceptInvitation(ctx, domain.User{ID: 12, Email: "bob@example.com"}, token)
+	assert.ErrorIs(t, err, ErrInvitationNotFound, "invitations are used once")
+
+	_, err = svc.Invitation(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrInvitationNotFound)
+}
+
+func TestManageMembers(t *testing.T) {
+	ctx := context.Background()
+	store := newMemWorkspaceStore()
+	svc := NewWorkspaces(store, nil, "https://sho.rt")
+	owner := domain.Workspace{ID: 2, Role: domain.RoleOwner}
+	editor := domain.Workspace{ID: 2, Role: domain.RoleEditor}
+
+	assert.ErrorIs(t, svc.UpdateMemberRole(ctx, editor, 11, domain.RoleOwner), ErrWorkspaceForbidden)
+	assert.ErrorIs(t, svc.UpdateMemberRole(ctx, owner, 10, domain.RoleEditor), ErrLastOwner)
+	assert.Equal(t, ErrLastOwner, svc.RemoveMember(ctx, owner, 10, 10), "the error is shown as is")
+	assert.ErrorIs(t, svc.UpdateMemberRole(ctx, owner, 99, domain.RoleEditor), ErrMemberNotFound)
+	assert.ErrorIs(t, svc.UpdateMemberRole(ctx, owner, 11, "admin"), ErrInvalidRole)
+
+	assert.NoError(t, svc.UpdateMemberRole(ctx, owner, 11, domain.RoleOwner))
+	assert.NoError(t, svc.UpdateMemberRole(ctx, owner, 10, domain.RoleEditor), "another owner is left")
+	assert.Equal(t, domain.RoleEditor, store.roles[membership{2, 10}])
+
+	assert.ErrorIs(t, svc.RemoveMember(ctx, editor, 10, 11), ErrWorkspaceForbidden, "editors only remove themselves")
+	assert.NoError(t, svc.RemoveMember(ctx, editor, 10, 10))
+	assert.NotContains(t, store.roles, membership{2, 10})
+
+	personal := domain.Workspace{ID: 1, Personal: true, Role: domain.RoleOwner}
+	assert.ErrorIs(t, svc.RemoveMember(ctx, personal, 10, 10), ErrPersonalWorkspace)
+}
@@ -38,3 +38,8 @@ func RobotsHandler() http.Handler {
 		w.Write(robottxt) //nolint:errcheck
 	})
 }
+
+// Logo is the logo drawn in the middle of QR codes.
+//
+//go:embed favicon/android-chrome-192x192.png
+var Logo []byte
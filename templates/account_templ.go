@@ -58,7 +58,7 @@ func AccountPage(user domain.User, linkedProviders []domain.AccountProvider, sta
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</div><!-- Right Column: Subscription & Actions --><div class=\"space-y-8\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<div hx-get=\"/account/tokens\" hx-trigger=\"load\" hx-swap=\"outerHTML\"></div></div><!-- Right Column: Subscription & Actions --><div class=\"space-y-8\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -36,17 +36,29 @@ func HeroSection(user *domain.User) templ.Component {
 			return templ_7745c5c3_Err
 		}
 		if user != nil {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<form class=\"flex flex-col sm:flex-row gap-3\" hx-post=\"/shorten\" hx-target=\"#result-container\" hx-swap=\"innerHTML\" hx-indicator=\"#spinner\"><div class=\"relative flex-grow\"><div class=\"absolute inset-y-0 left-0 pl-4 flex items-center pointer-events-none\"><i class=\"fas fa-link text-slate-400\"></i></div><input type=\"url\" name=\"url\" required class=\"block w-full pl-11 pr-4 py-4 bg-slate-50 border border-slate-200 focus:bg-white focus:border-indigo-500 focus:ring-4 focus:ring-indigo-500/10 rounded-xl text-slate-900 placeholder-slate-400 text-lg transition-[background-color,border-color,box-shadow] duration-150 ease-out\" placeholder=\"Paste your long URL here...\"></div><button type=\"submit\" class=\"inline-flex items-center justify-center px-8 py-4 border border-transparent text-lg font-bold rounded-xl text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500 shadow-lg shadow-indigo-200 transition-[transform,background-color,box-shadow] duration-150 ease-out hover:scale-[1.01] active:scale-[0.97]\" data-umami-event=\"Shorten Link\">Shorten <i class=\"fas fa-arrow-right ml-2 text-sm\"></i></button></form>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<form class=\"space-y-3\" hx-post=\"/shorten\" hx-target=\"#result-container\" hx-swap=\"innerHTML\" hx-indicator=\"#spinner\"><div class=\"flex flex-col sm:flex-row gap-3\"><div class=\"relative flex-grow\"><div class=\"absolute inset-y-0 left-0 pl-4 flex items-center pointer-events-none\"><i class=\"fas fa-link text-slate-400\"></i></div><input type=\"url\" name=\"url\" required class=\"block w-full pl-11 pr-4 py-4 bg-slate-50 border border-slate-200 focus:bg-white focus:border-indigo-500 focus:ring-4 focus:ring-indigo-500/10 rounded-xl text-slate-900 placeholder-slate-400 text-lg transition-[background-color,border-color,box-shadow] duration-150 ease-out\" placeholder=\"Paste your long URL here...\"></div><button type=\"submit\" class=\"inline-flex items-center justify-center px-8 py-4 border border-transparent text-lg font-bold rounded-xl text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500 shadow-lg shadow-indigo-200 transition-[transform,background-color,box-shadow] duration-150 ease-out hover:scale-[1.01] active:scale-[0.97]\" data-umami-event=\"Shorten Link\">Shorten <i class=\"fas fa-arrow-right ml-2 text-sm\"></i></button></div><details class=\"text-left\"><summary class=\"cursor-pointer text-sm text-slate-500 hover:text-indigo-600 select-none\"><i class=\"fas fa-sliders-h text-xs mr-1\"></i> More options</summary><div class=\"mt-3 flex items-center rounded-xl bg-slate-50 border border-slate-200 focus-within:bg-white focus-within:border-indigo-500\"><div hx-get=\"/workspace/domains/select\" hx-trigger=\"load\" hx-swap=\"outerHTML\"></div><span class=\"pl-4 text-slate-400 text-sm\">/</span> <input type=\"text\" name=\"slug\" minlength=\"3\" maxlength=\"64\" pattern=\"[A-Za-z0-9]([A-Za-z0-9_\\-]*[A-Za-z0-9])?\" title=\"Letters, digits, '-' and '_' only\" class=\"block w-full pl-1 pr-4 py-3 bg-transparent border-0 focus:ring-0 rounded-xl text-slate-900 placeholder-slate-400\" placeholder=\"launch-2026 (optional)\"></div><div class=\"mt-3 grid grid-cols-1 sm:grid-cols-3 gap-3\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = ExpirationFields(domain.Expiration{}).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = RedirectStatusField(domain.DefaultRedirectStatus).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "</div><label class=\"mt-3 block text-left\"><span class=\"block text-xs font-semibold text-slate-400 uppercase tracking-wider mb-1\">Password</span> <input type=\"password\" name=\"password\" minlength=\"8\" maxlength=\"128\" autocomplete=\"new-password\" placeholder=\"Leave empty for a public link\" class=\"block w-full text-sm border border-slate-300 rounded-lg px-3 py-2 focus:ring-indigo-500 focus:border-indigo-500\"></label><fieldset class=\"mt-3 text-left\"><legend class=\"block text-xs font-semibold text-slate-400 uppercase tracking-wider mb-1\">UTM parameters</legend><div class=\"grid grid-cols-1 sm:grid-cols-3 gap-3\"><input type=\"text\" name=\"utm_source\" maxlength=\"255\" placeholder=\"Source, e.g. newsletter\" class=\"block w-full text-sm border border-slate-300 rounded-lg px-3 py-2 focus:ring-indigo-500 focus:border-indigo-500\"> <input type=\"text\" name=\"utm_medium\" maxlength=\"255\" placeholder=\"Medium, e.g. email\" class=\"block w-full text-sm border border-slate-300 rounded-lg px-3 py-2 focus:ring-indigo-500 focus:border-indigo-500\"> <input type=\"text\" name=\"utm_campaign\" maxlength=\"255\" placeholder=\"Campaign, e.g. spring_sale\" class=\"block w-full text-sm border border-slate-300 rounded-lg px-3 py-2 focus:ring-indigo-500 focus:border-indigo-500\"> <input type=\"text\" name=\"utm_term\" maxlength=\"255\" placeholder=\"Term\" class=\"block w-full text-sm border border-slate-300 rounded-lg px-3 py-2 focus:ring-indigo-500 focus:border-indigo-500\"> <input type=\"text\" name=\"utm_content\" maxlength=\"255\" placeholder=\"Content\" class=\"block w-full text-sm border border-slate-300 rounded-lg px-3 py-2 focus:ring-indigo-500 focus:border-indigo-500\"></div></fieldset><label class=\"mt-3 flex items-center gap-2 text-sm text-slate-600\"><input type=\"checkbox\" name=\"forward_query\" class=\"rounded border-slate-300 text-indigo-600 focus:ring-indigo-500\"> Forward the query string of visits to the destination</label></details></form>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		} else {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<div class=\"text-center\"><h3 class=\"text-2xl font-bold text-slate-900 mb-4\">Start shortening links today</h3><p class=\"text-slate-500 mb-8\">Sign up for a free account to create, track, and manage your shortened URLs.</p><div class=\"flex flex-col sm:flex-row justify-center gap-4\"><a href=\"/auth\" class=\"inline-flex items-center justify-center px-8 py-4 border border-transparent text-lg font-medium rounded-xl text-white bg-indigo-600 hover:bg-indigo-700 shadow-sm transition-[transform,background-color] duration-150 ease-out hover:scale-[1.01] active:scale-[0.97]\" data-umami-event=\"Sign Up CTA Clicked\">Sign Up Free</a> <a href=\"/auth\" class=\"inline-flex items-center justify-center px-8 py-4 border border-slate-200 text-lg font-medium rounded-xl text-slate-700 bg-white hover:bg-slate-50 shadow-sm transition-[transform,background-color] duration-150 ease-out active:scale-[0.97]\" data-umami-event=\"Log In CTA Clicked\">Log In</a></div></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<div class=\"text-center\"><h3 class=\"text-2xl font-bold text-slate-900 mb-4\">Start shortening links today</h3><p class=\"text-slate-500 mb-8\">Sign up for a free account to create, track, and manage your shortened URLs.</p><div class=\"flex flex-col sm:flex-row justify-center gap-4\"><a href=\"/auth\" class=\"inline-flex items-center justify-center px-8 py-4 border border-transparent text-lg font-medium rounded-xl text-white bg-indigo-600 hover:bg-indigo-700 shadow-sm transition-[transform,background-color] duration-150 ease-out hover:scale-[1.01] active:scale-[0.97]\" data-umami-event=\"Sign Up CTA Clicked\">Sign Up Free</a> <a href=\"/auth\" class=\"inline-flex items-center justify-center px-8 py-4 border border-slate-200 text-lg font-medium rounded-xl text-slate-700 bg-white hover:bg-slate-50 shadow-sm transition-[transform,background-color] duration-150 ease-out active:scale-[0.97]\" data-umami-event=\"Log In CTA Clicked\">Log In</a></div></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<!-- Loading Indicator --><div id=\"spinner\" class=\"htmx-indicator flex justify-center mt-6\"><div class=\"inline-flex items-center px-4 py-2 rounded-full bg-slate-50 border border-slate-100 text-slate-500 text-sm font-medium animate-pulse\"><svg class=\"animate-spin-fast -ml-1 mr-3 h-4 w-4 text-indigo-500\" xmlns=\"http://www.w3.org/2000/svg\" fill=\"none\" viewBox=\"0 0 24 24\"><circle class=\"opacity-25\" cx=\"12\" cy=\"12\" r=\"10\" stroke=\"currentColor\" stroke-width=\"4\"></circle> <path class=\"opacity-75\" fill=\"currentColor\" d=\"M4 12a8 8 0 018-8V0C5.373 0 0 5.373 0 12h4zm2 5.291A7.962 7.962 0 014 12H0c0 3.042 1.135 5.824 3 7.938l3-2.647z\"></path></svg> Creating your link...</div></div><!-- Result Container --><div id=\"result-container\" class=\"empty:hidden mt-6 pt-6 border-t border-slate-100 transition-[opacity,transform] duration-200 ease-out\"></div></div></div></div></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<!-- Loading Indicator --><div id=\"spinner\" class=\"htmx-indicator flex justify-center mt-6\"><div class=\"inline-flex items-center px-4 py-2 rounded-full bg-slate-50 border border-slate-100 text-slate-500 text-sm font-medium animate-pulse\"><svg class=\"animate-spin-fast -ml-1 mr-3 h-4 w-4 text-indigo-500\" xmlns=\"http://www.w3.org/2000/svg\" fill=\"none\" viewBox=\"0 0 24 24\"><circle class=\"opacity-25\" cx=\"12\" cy=\"12\" r=\"10\" stroke=\"currentColor\" stroke-width=\"4\"></circle> <path class=\"opacity-75\" fill=\"currentColor\" d=\"M4 12a8 8 0 018-8V0C5.373 0 0 5.373 0 12h4zm2 5.291A7.962 7.962 0 014 12H0c0 3.042 1.135 5.824 3 7.938l3-2.647z\"></path></svg> Creating your link...</div></div><!-- Result Container --><div id=\"result-container\" class=\"empty:hidden mt-6 pt-6 border-t border-slate-100 transition-[opacity,transform] duration-200 ease-out\"></div></div></div></div></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -75,7 +87,7 @@ func FeaturesSection() templ.Component {
 			templ_7745c5c3_Var2 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<div class=\"bg-white py-24 border-t border-slate-100\"><div class=\"max-w-7xl mx-auto px-4 sm:px-6 lg:px-8\"><div class=\"text-center mb-16 max-w-3xl mx-auto\"><h2 class=\"text-3xl sm:text-4xl font-extrabold text-slate-900 tracking-tight mb-4\">More than just a link shortener</h2><p class=\"text-lg text-slate-500\">Shortcut provides the performance, analytics, and security you need in a modern URL platform.</p></div><div class=\"grid grid-cols-1 gap-8 sm:grid-cols-2 lg:grid-cols-3\"><!-- Feature 1 --><div class=\"relative group bg-slate-50 p-8 rounded-2xl hover:bg-white hover:shadow-xl hover:shadow-indigo-50/50 transition-all duration-300 border border-slate-100\"><div class=\"absolute top-8 right-8 text-indigo-100 group-hover:text-indigo-50 transition-colors\"><i class=\"fas fa-bolt text-6xl\"></i></div><div class=\"relative\"><div class=\"inline-flex items-center justify-center p-3 bg-indigo-600 rounded-xl shadow-lg shadow-indigo-200 mb-5\"><i class=\"fas fa-bolt text-white text-xl\"></i></div><h3 class=\"text-xl font-bold text-slate-900 mb-3\">Lightning Fast</h3><p class=\"text-slate-500 leading-relaxed\">Optimized infrastructure ensures your redirects happen in milliseconds. No lag, no waiting.</p></div></div><!-- Feature 2 --><div class=\"relative group bg-slate-50 p-8 rounded-2xl hover:bg-white hover:shadow-xl hover:shadow-indigo-50/50 transition-all duration-300 border border-slate-100\"><div class=\"absolute top-8 right-8 text-indigo-100 group-hover:text-indigo-50 transition-colors\"><i class=\"fas fa-chart-pie text-6xl\"></i></div><div class=\"relative\"><div class=\"inline-flex items-center justify-center p-3 bg-purple-600 rounded-xl shadow-lg shadow-purple-200 mb-5\"><i class=\"fas fa-chart-line text-white text-xl\"></i></div><h3 class=\"text-xl font-bold text-slate-900 mb-3\">Deep Analytics</h3><p class=\"text-slate-500 leading-relaxed\">Know your audience. Track clicks, geographic locations, and referrers in real-time.</p></div></div><!-- Feature 3 --><div class=\"relative group bg-slate-50 p-8 rounded-2xl hover:bg-white hover:shadow-xl hover:shadow-indigo-50/50 transition-all duration-300 border border-slate-100\"><div class=\"absolute top-8 right-8 text-indigo-100 group-hover:text-indigo-50 transition-colors\"><i class=\"fas fa-shield-alt text-6xl\"></i></div><div class=\"relative\"><div class=\"inline-flex items-center justify-center p-3 bg-emerald-600 rounded-xl shadow-lg shadow-emerald-200 mb-5\"><i class=\"fas fa-lock text-white text-xl\"></i></div><h3 class=\"text-xl font-bold text-slate-900 mb-3\">Secure & Reliable</h3><p class=\"text-slate-500 leading-relaxed\">Your links are secure and will never expire unless you want them to.</p></div></div></div></div></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<div class=\"bg-white py-24 border-t border-slate-100\"><div class=\"max-w-7xl mx-auto px-4 sm:px-6 lg:px-8\"><div class=\"text-center mb-16 max-w-3xl mx-auto\"><h2 class=\"text-3xl sm:text-4xl font-extrabold text-slate-900 tracking-tight mb-4\">More than just a link shortener</h2><p class=\"text-lg text-slate-500\">Shortcut provides the performance, analytics, and security you need in a modern URL platform.</p></div><div class=\"grid grid-cols-1 gap-8 sm:grid-cols-2 lg:grid-cols-3\"><!-- Feature 1 --><div class=\"relative group bg-slate-50 p-8 rounded-2xl hover:bg-white hover:shadow-xl hover:shadow-indigo-50/50 transition-all duration-300 border border-slate-100\"><div class=\"absolute top-8 right-8 text-indigo-100 group-hover:text-indigo-50 transition-colors\"><i class=\"fas fa-bolt text-6xl\"></i></div><div class=\"relative\"><div class=\"inline-flex items-center justify-center p-3 bg-indigo-600 rounded-xl shadow-lg shadow-indigo-200 mb-5\"><i class=\"fas fa-bolt text-white text-xl\"></i></div><h3 class=\"text-xl font-bold text-slate-900 mb-3\">Lightning Fast</h3><p class=\"text-slate-500 leading-relaxed\">Optimized infrastructure ensures your redirects happen in milliseconds. No lag, no waiting.</p></div></div><!-- Feature 2 --><div class=\"relative group bg-slate-50 p-8 rounded-2xl hover:bg-white hover:shadow-xl hover:shadow-indigo-50/50 transition-all duration-300 border border-slate-100\"><div class=\"absolute top-8 right-8 text-indigo-100 group-hover:text-indigo-50 transition-colors\"><i class=\"fas fa-chart-pie text-6xl\"></i></div><div class=\"relative\"><div class=\"inline-flex items-center justify-center p-3 bg-purple-600 rounded-xl shadow-lg shadow-purple-200 mb-5\"><i class=\"fas fa-chart-line text-white text-xl\"></i></div><h3 class=\"text-xl font-bold text-slate-900 mb-3\">Deep Analytics</h3><p class=\"text-slate-500 leading-relaxed\">Know your audience. Track clicks, geographic locations, and referrers in real-time.</p></div></div><!-- Feature 3 --><div class=\"relative group bg-slate-50 p-8 rounded-2xl hover:bg-white hover:shadow-xl hover:shadow-indigo-50/50 transition-all duration-300 border border-slate-100\"><div class=\"absolute top-8 right-8 text-indigo-100 group-hover:text-indigo-50 transition-colors\"><i class=\"fas fa-shield-alt text-6xl\"></i></div><div class=\"relative\"><div class=\"inline-flex items-center justify-center p-3 bg-emerald-600 rounded-xl shadow-lg shadow-emerald-200 mb-5\"><i class=\"fas fa-lock text-white text-xl\"></i></div><h3 class=\"text-xl font-bold text-slate-900 mb-3\">Secure & Reliable</h3><p class=\"text-slate-500 leading-relaxed\">Your links are secure and will never expire unless you want them to.</p></div></div></div></div></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
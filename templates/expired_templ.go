@@ -0,0 +1,40 @@
+// Code generated by templ - DO NOT EDIT.
+
+// templ: version: v0.3.977
+package templates
+
+//lint:file-ignore SA4006 This context is only used if a nested component is present.
+
+import "github.com/a-h/templ"
+import templruntime "github.com/a-h/templ/runtime"
+
+func ExpiredPage() templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var1 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var1 == nil {
+			templ_7745c5c3_Var1 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Link Expired - Shortcut</title><script src=\"https://cdn.tailwindcss.com/3.4.17\"></script><script src=\"/static/dist/bundle.js\" defer></script><link rel=\"stylesheet\" href=\"https://cdnjs.cloudflare.com/ajax/libs/font-awesome/6.0.0-beta3/css/all.min.css\"><link rel=\"stylesheet\" href=\"/static/css/styles.css\"><link rel=\"stylesheet\" href=\"/static/dist/bundle.css\"></head><body class=\"bg-gray-50 text-gray-900 min-h-screen flex flex-col\"><div class=\"flex-grow flex items-center justify-center py-12 px-4 sm:px-6 lg:px-8\"><div class=\"max-w-lg w-full text-center\"><!-- Shortcut Logo --><div class=\"mb-8\"><a href=\"/\" class=\"text-indigo-600 font-bold text-2xl\"><i class=\"fas fa-link mr-2\"></i>shortcut</a></div><!-- Expired Illustration --><div class=\"mb-8\"><div class=\"mx-auto w-24 h-24 bg-amber-100 rounded-full flex items-center justify-center mb-6\"><i class=\"fas fa-hourglass-end text-3xl text-amber-600\"></i></div><h1 class=\"text-4xl font-bold text-gray-900 mb-2\">Link Expired</h1><h2 class=\"text-xl font-semibold text-gray-700 mb-4\">This short URL is no longer available</h2></div><div class=\"mb-8 bg-white p-6 rounded-lg shadow-sm border border-gray-200\"><p class=\"text-gray-600\">The owner of this link set it to stop working after a certain date or number of visits, and that limit has been reached. If you think you should still have access, contact the person who shared it with you.</p></div><!-- Action Buttons --><div class=\"flex flex-col sm:flex-row gap-3 justify-center\"><a href=\"/\" class=\"inline-flex items-center justify-center px-6 py-3 border border-transparent text-base font-medium rounded-md text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500\"><i class=\"fas fa-plus mr-2\"></i> Create Your Own Short Link</a> <a href=\"/\" class=\"inline-flex items-center justify-center px-6 py-3 border border-gray-300 text-base font-medium rounded-md text-gray-700 bg-white hover:bg-gray-50 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500\"><i class=\"fas fa-home mr-2\"></i> Go to Homepage</a></div><div class=\"mt-8\"><p class=\"mt-4 text-xs text-gray-400\">&copy; 2025 Shortcut. All rights reserved.</p></div></div></div></body></html>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+var _ = templruntime.GeneratedTemplate
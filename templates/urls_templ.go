@@ -9,11 +9,12 @@ import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
 import (
+	"fmt"
 	"github.com/zaibon/shortcut/domain"
 	"github.com/zaibon/shortcut/templates/components"
 )
 
-func URLSPage(urls []domain.URLStat, paginationLinks domain.PaginationLinks) templ.Component {
+func URLSPage(urls []domain.URLStat, paginationLinks domain.PaginationLinks, filter domain.LinkFilter, folders []domain.Folder, tags []domain.TagStat) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -46,23 +47,132 @@ func URLSPage(urls []domain.URLStat, paginationLinks domain.PaginationLinks) tem
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div x-data=\"{ showQR: false, qrUrl: '', copyFeedback: null }\"><main class=\"max-w-7xl mx-auto px-4 sm:px-6 lg:px-8 py-8\"><!-- Header Section --><div class=\"flex flex-col md:flex-row md:items-end justify-between gap-4 mb-8\"><div><h1 class=\"text-2xl font-bold text-slate-900\">Your Links</h1><p class=\"text-slate-500 mt-1\">Manage your active shortened URLs.</p></div><!-- Filters -->")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div x-data=\"{ showQR: false, qrUrl: '', qrRef: '', copyFeedback: null }\"><main class=\"max-w-7xl mx-auto px-4 sm:px-6 lg:px-8 py-8\"><!-- Header Section --><div class=\"flex flex-col md:flex-row md:items-end justify-between gap-4 mb-8\"><div><h1 class=\"text-2xl font-bold text-slate-900\">Your Links</h1>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = URLFilter().Render(ctx, templ_7745c5c3_Buffer)
+			if filter.Archived {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<p class=\"text-slate-500 mt-1\">Archived links are hidden from your dashboard but keep redirecting.</p>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			} else {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<p class=\"text-slate-500 mt-1\">Manage your active shortened URLs.</p>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "</div><!-- Filters -->")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = URLFilter(filter).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</div><!-- Tabs --><div class=\"flex items-center justify-between border-b border-slate-200 mb-6\"><nav class=\"flex gap-6 -mb-px\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 = []any{"pb-3 text-sm font-medium border-b-2", templ.KV("border-indigo-600 text-indigo-600", !filter.Archived), templ.KV("border-transparent text-slate-500 hover:text-slate-700", filter.Archived)}
+			templ_7745c5c3_Err = templ.RenderCSSItems(ctx, templ_7745c5c3_Buffer, templ_7745c5c3_Var3...)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<a href=\"/urls\" class=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var4 string
+			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(templ.CSSClasses(templ_7745c5c3_Var3).String())
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `urls.templ`, Line: 1, Col: 0}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "\">Links</a> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var5 = []any{"pb-3 text-sm font-medium border-b-2", templ.KV("border-indigo-600 text-indigo-600", filter.Archived), templ.KV("border-transparent text-slate-500 hover:text-slate-700", !filter.Archived)}
+			templ_7745c5c3_Err = templ.RenderCSSItems(ctx, templ_7745c5c3_Buffer, templ_7745c5c3_Var5...)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "<a href=\"/urls?archived=true\" class=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var6 string
+			templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(templ.CSSClasses(templ_7745c5c3_Var5).String())
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `urls.templ`, Line: 1, Col: 0}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "\">Archived</a></nav>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</div><!-- Loading Indicator --><div id=\"loading-indicator\" class=\"htmx-indicator flex justify-center py-4\"><div class=\"animate-spin rounded-full h-8 w-8 border-b-2 border-indigo-600\"></div></div><!-- Links List Container -->")
+			if filter.Archived {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "<button type=\"submit\" form=\"unarchive-form\" class=\"mb-2 inline-flex items-center px-3 py-1.5 text-xs font-medium rounded-lg text-white bg-indigo-600 hover:bg-indigo-700 transition-colors shadow-sm\"><i class=\"fas fa-box-open mr-2\"></i> Restore selected</button>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			} else {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "<div class=\"mb-2 flex items-center gap-2\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = components.ImportMenu().Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = components.ExportMenu("/urls/export", false).Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "</div>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "</div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = URLList(urls, paginationLinks).Render(ctx, templ_7745c5c3_Buffer)
+			templ_7745c5c3_Err = components.LinkOrganizer(filter, folders, tags).Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "</main><!-- QR Modal --><div x-show=\"showQR\" class=\"relative z-50\" aria-labelledby=\"modal-title\" role=\"dialog\" aria-modal=\"true\" x-cloak><div x-show=\"showQR\" x-transition:enter=\"ease-out duration-300\" x-transition:enter-start=\"opacity-0\" x-transition:enter-end=\"opacity-100\" x-transition:leave=\"ease-in duration-200\" x-transition:leave-start=\"opacity-100\" x-transition:leave-end=\"opacity-0\" class=\"fixed inset-0 bg-slate-900 bg-opacity-75 transition-opacity backdrop-blur-sm\"></div><div class=\"fixed inset-0 z-10 overflow-y-auto\"><div class=\"flex min-h-full items-end justify-center p-4 text-center sm:items-center sm:p-0\"><div x-show=\"showQR\" @click.away=\"showQR = false\" x-transition:enter=\"ease-out duration-300\" x-transition:enter-start=\"opacity-0 translate-y-4 sm:translate-y-0 sm:scale-95\" x-transition:enter-end=\"opacity-100 translate-y-0 sm:scale-100\" x-transition:leave=\"ease-in duration-200\" x-transition:leave-start=\"opacity-100 translate-y-0 sm:scale-100\" x-transition:leave-end=\"opacity-0 translate-y-4 sm:translate-y-0 sm:scale-95\" class=\"relative transform overflow-hidden rounded-xl bg-white px-4 pb-4 pt-5 text-left shadow-xl transition-all sm:my-8 sm:w-full sm:max-w-sm sm:p-6\"><div class=\"absolute right-0 top-0 pr-4 pt-4\"><button @click=\"showQR = false\" type=\"button\" class=\"rounded-md bg-white text-slate-400 hover:text-slate-500 focus:outline-none\"><i class=\"fas fa-times\"></i></button></div><div><div class=\"mx-auto flex h-12 w-12 items-center justify-center rounded-full bg-indigo-100 mb-4\"><i class=\"fas fa-qrcode text-indigo-600 text-xl\"></i></div><div class=\"text-center\"><h3 class=\"text-lg font-semibold leading-6 text-slate-900\" id=\"modal-title\">QR Code</h3><div class=\"mt-2\"><p class=\"text-sm text-slate-500 mb-4\">Scan to visit the link immediately.</p><div class=\"bg-white p-4 border border-slate-200 rounded-lg inline-block shadow-sm\"><div class=\"w-48 h-48 bg-slate-100 flex items-center justify-center relative\"><img :src=\"`https://api.qrserver.com/v1/create-qr-code/?size=200x200&data=${qrUrl}`\" alt=\"QR Code\" class=\"w-full h-full object-contain\"></div></div><p class=\"mt-4 text-xs font-mono text-slate-400 bg-slate-50 py-1 px-2 rounded truncate\" x-text=\"qrUrl\"></p></div></div></div><div class=\"mt-5 sm:mt-6\"><button type=\"button\" class=\"inline-flex w-full justify-center rounded-lg bg-indigo-600 px-3 py-2.5 text-sm font-semibold text-white shadow-sm hover:bg-indigo-500 focus-visible:outline focus-visible:outline-2 focus-visible:outline-offset-2 focus-visible:outline-indigo-600\" @click=\"showQR = false\">Done</button></div></div></div></div></div></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "<div id=\"import-report\"></div><!-- Loading Indicator --><div id=\"loading-indicator\" class=\"htmx-indicator flex justify-center py-4\"><div class=\"animate-spin rounded-full h-8 w-8 border-b-2 border-indigo-600\"></div></div><!-- Links List Container -->")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if filter.Archived {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "<form id=\"unarchive-form\" hx-post=\"/urls/unarchive\" hx-target=\"#url-list\" hx-swap=\"outerHTML\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = URLList(urls, paginationLinks, filter).Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "</form>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			} else {
+				templ_7745c5c3_Err = URLList(urls, paginationLinks, filter).Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "</main><!-- QR Modal --><div x-show=\"showQR\" class=\"relative z-50\" aria-labelledby=\"modal-title\" role=\"dialog\" aria-modal=\"true\" x-cloak><div x-show=\"showQR\" x-transition:enter=\"ease-out duration-300\" x-transition:enter-start=\"opacity-0\" x-transition:enter-end=\"opacity-100\" x-transition:leave=\"ease-in duration-200\" x-transition:leave-start=\"opacity-100\" x-transition:leave-end=\"opacity-0\" class=\"fixed inset-0 bg-slate-900 bg-opacity-75 transition-opacity backdrop-blur-sm\"></div><div class=\"fixed inset-0 z-10 overflow-y-auto\"><div class=\"flex min-h-full items-end justify-center p-4 text-center sm:items-center sm:p-0\"><div x-show=\"showQR\" @click.away=\"showQR = false\" x-transition:enter=\"ease-out duration-300\" x-transition:enter-start=\"opacity-0 translate-y-4 sm:translate-y-0 sm:scale-95\" x-transition:enter-end=\"opacity-100 translate-y-0 sm:scale-100\" x-transition:leave=\"ease-in duration-200\" x-transition:leave-start=\"opacity-100 translate-y-0 sm:scale-100\" x-transition:leave-end=\"opacity-0 translate-y-4 sm:translate-y-0 sm:scale-95\" class=\"relative transform overflow-hidden rounded-xl bg-white px-4 pb-4 pt-5 text-left shadow-xl transition-all sm:my-8 sm:w-full sm:max-w-sm sm:p-6\"><div class=\"absolute right-0 top-0 pr-4 pt-4\"><button @click=\"showQR = false\" type=\"button\" class=\"rounded-md bg-white text-slate-400 hover:text-slate-500 focus:outline-none\"><i class=\"fas fa-times\"></i></button></div><div><div class=\"mx-auto flex h-12 w-12 items-center justify-center rounded-full bg-indigo-100 mb-4\"><i class=\"fas fa-qrcode text-indigo-600 text-xl\"></i></div><div class=\"text-center\"><h3 class=\"text-lg font-semibold leading-6 text-slate-900\" id=\"modal-title\">QR Code</h3><div class=\"mt-2\"><p class=\"text-sm text-slate-500 mb-4\">Scan to visit the link immediately.</p><div class=\"bg-white p-4 border border-slate-200 rounded-lg inline-block shadow-sm\"><div class=\"w-48 h-48 bg-slate-100 flex items-center justify-center relative\"><img x-show=\"qrRef\" :src=\"`/urls/${qrRef}/qr?size=384`\" alt=\"QR Code\" class=\"w-full h-full object-contain\"></div></div><p class=\"mt-4 text-xs font-mono text-slate-400 bg-slate-50 py-1 px-2 rounded truncate\" x-text=\"qrUrl\"></p></div></div></div><div class=\"mt-5 sm:mt-6 grid grid-cols-2 gap-3\"><a :href=\"`/urls/${qrRef}/qr?size=1024&download=true`\" class=\"inline-flex w-full justify-center items-center rounded-lg bg-white border border-slate-300 px-3 py-2.5 text-sm font-semibold text-slate-700 shadow-sm hover:bg-slate-50\"><i class=\"fas fa-download mr-2\"></i> Download</a> <button type=\"button\" class=\"inline-flex w-full justify-center rounded-lg bg-indigo-600 px-3 py-2.5 text-sm font-semibold text-white shadow-sm hover:bg-indigo-500 focus-visible:outline focus-visible:outline-2 focus-visible:outline-offset-2 focus-visible:outline-indigo-600\" @click=\"showQR = false\">Done</button></div></div></div></div></div></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -76,7 +186,7 @@ func URLSPage(urls []domain.URLStat, paginationLinks domain.PaginationLinks) tem
 	})
 }
 
-func URLFilter() templ.Component {
+func URLFilter(filter domain.LinkFilter) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -92,12 +202,123 @@ func URLFilter() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var3 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var3 == nil {
-			templ_7745c5c3_Var3 = templ.NopComponent
+		templ_7745c5c3_Var7 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var7 == nil {
+			templ_7745c5c3_Var7 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<div class=\"flex flex-col sm:flex-row gap-3 w-full md:w-auto\"><!-- Search --><div class=\"relative group w-full md:w-64\"><div class=\"absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none\"><i class=\"fas fa-search text-slate-400 group-focus-within:text-indigo-500 transition-colors\"></i></div><input type=\"text\" name=\"search\" class=\"block w-full pl-10 pr-3 py-2 border border-slate-200 rounded-lg leading-5 bg-white placeholder-slate-400 focus:outline-none focus:placeholder-slate-300 focus:ring-2 focus:ring-indigo-500 focus:border-indigo-500 sm:text-sm transition-all shadow-sm\" placeholder=\"Search links...\" hx-get=\"/urls-search\" hx-trigger=\"keyup changed delay:500ms\" hx-target=\"#url-list\" hx-indicator=\"#loading-indicator\"></div><!-- Sort --><div class=\"relative w-full md:w-40\"><select name=\"sort\" class=\"block w-full pl-3 pr-10 py-2 text-base border-gray-200 focus:outline-none focus:ring-indigo-500 focus:border-indigo-500 sm:text-sm rounded-lg shadow-sm bg-white\" hx-get=\"/urls-sort\" hx-trigger=\"change\" hx-target=\"#url-list\"><option value=\"newest\">Newest First</option> <option value=\"most-clicked\">Most Popular</option> <option value=\"oldest\">Oldest First</option></select></div></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "<div class=\"flex flex-col sm:flex-row gap-3 w-full md:w-auto\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if filter.Archived {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "<input type=\"hidden\" name=\"archived\" value=\"true\"> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		if filter.Tag != "" {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "<input type=\"hidden\" name=\"tag\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var8 string
+			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(filter.Tag)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `urls.templ`, Line: 108, Col: 53}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "\"> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		if filter.FolderID != 0 {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "<input type=\"hidden\" name=\"folder\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var9 string
+			templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprint(filter.FolderID))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `urls.templ`, Line: 111, Col: 73}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "<!-- Search --><div class=\"relative group w-full md:w-64\"><div class=\"absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none\"><i class=\"fas fa-search text-slate-400 group-focus-within:text-indigo-500 transition-colors\"></i></div><input type=\"text\" name=\"search\" class=\"block w-full pl-10 pr-3 py-2 border border-slate-200 rounded-lg leading-5 bg-white placeholder-slate-400 focus:outline-none focus:placeholder-slate-300 focus:ring-2 focus:ring-indigo-500 focus:border-indigo-500 sm:text-sm transition-all shadow-sm\" placeholder=\"Search links...\" value=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var10 string
+		templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(filter.Search)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `urls.templ`, Line: 123, Col: 25}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "\" hx-get=\"/urls-search\" hx-trigger=\"keyup changed delay:500ms\" hx-include=\"[name='archived'],[name='tag'],[name='folder'],[name='sort']\" hx-target=\"#url-list\" hx-indicator=\"#loading-indicator\"></div><!-- Sort --><div class=\"relative w-full md:w-40\"><select name=\"sort\" class=\"block w-full pl-3 pr-10 py-2 text-base border-gray-200 focus:outline-none focus:ring-indigo-500 focus:border-indigo-500 sm:text-sm rounded-lg shadow-sm bg-white\" hx-get=\"/urls-sort\" hx-trigger=\"change\" hx-include=\"[name='archived'],[name='tag'],[name='folder'],[name='search']\" hx-target=\"#url-list\"><option value=\"newest\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if filter.Sort == domain.LinkSortNewest {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, " selected")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, ">Newest First</option> <option value=\"most-clicked\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if filter.Sort == domain.LinkSortMostClicked {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, " selected")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, ">Most Popular</option> <option value=\"least-clicked\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if filter.Sort == domain.LinkSortLeastClicked {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, " selected")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, ">Least Popular</option> <option value=\"oldest\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if filter.Sort == domain.LinkSortOldest {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, " selected")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, ">Oldest First</option> <option value=\"folder\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if filter.Sort == domain.LinkSortFolder {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, " selected")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, ">By Folder</option></select></div></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -105,7 +326,7 @@ func URLFilter() templ.Component {
 	})
 }
 
-func URLList(urls []domain.URLStat, paginationLinks domain.PaginationLinks) templ.Component {
+func URLList(urls []domain.URLStat, paginationLinks domain.PaginationLinks, filter domain.LinkFilter) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -121,17 +342,32 @@ func URLList(urls []domain.URLStat, paginationLinks domain.PaginationLinks) temp
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var4 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var4 == nil {
-			templ_7745c5c3_Var4 = templ.NopComponent
+		templ_7745c5c3_Var11 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var11 == nil {
+			templ_7745c5c3_Var11 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<div id=\"url-list\" class=\"space-y-4\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "<div id=\"url-list\" class=\"space-y-4\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		if len(urls) == 0 {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<div class=\"bg-white rounded-xl shadow-sm border border-slate-200 p-10 text-center\"><div class=\"flex flex-col items-center justify-center\"><div class=\"mb-4 text-indigo-100 bg-indigo-50 p-4 rounded-full\"><i class=\"fas fa-link text-4xl text-indigo-500\"></i></div><h3 class=\"mt-2 text-lg font-medium text-gray-900\">No URLs found</h3><p class=\"mt-1 text-sm text-gray-500\">Get started by creating your first shortened link.</p><div class=\"mt-6\"><a href=\"/\" class=\"inline-flex items-center px-4 py-2 border border-transparent shadow-sm text-sm font-medium rounded-md text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500\"><i class=\"fas fa-plus mr-2\"></i> Create New URL</a></div></div></div>")
+		if len(urls) == 0 && filter.Archived {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "<div class=\"bg-white rounded-xl shadow-sm border border-slate-200 p-10 text-center\"><div class=\"flex flex-col items-center justify-center\"><div class=\"mb-4 text-indigo-100 bg-indigo-50 p-4 rounded-full\"><i class=\"fas fa-archive text-4xl text-indigo-500\"></i></div><h3 class=\"mt-2 text-lg font-medium text-gray-900\">No archived links</h3><p class=\"mt-1 text-sm text-gray-500\">Links you archive will show up here.</p></div></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		} else if len(urls) == 0 && filter.Search != "" {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "<div class=\"bg-white rounded-xl shadow-sm border border-slate-200 p-10 text-center\"><div class=\"flex flex-col items-center justify-center\"><div class=\"mb-4 text-indigo-100 bg-indigo-50 p-4 rounded-full\"><i class=\"fas fa-search text-4xl text-indigo-500\"></i></div><h3 class=\"mt-2 text-lg font-medium text-gray-900\">No links match your search</h3><p class=\"mt-1 text-sm text-gray-500\">Links are searched by title, slug and destination.</p></div></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		} else if len(urls) == 0 && (filter.Tag != "" || filter.FolderID != 0) {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, "<div class=\"bg-white rounded-xl shadow-sm border border-slate-200 p-10 text-center\"><div class=\"flex flex-col items-center justify-center\"><div class=\"mb-4 text-indigo-100 bg-indigo-50 p-4 rounded-full\"><i class=\"fas fa-tags text-4xl text-indigo-500\"></i></div><h3 class=\"mt-2 text-lg font-medium text-gray-900\">No links here yet</h3><p class=\"mt-1 text-sm text-gray-500\">Add tags and folders to your links from their detail page.</p></div></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		} else if len(urls) == 0 {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "<div class=\"bg-white rounded-xl shadow-sm border border-slate-200 p-10 text-center\"><div class=\"flex flex-col items-center justify-center\"><div class=\"mb-4 text-indigo-100 bg-indigo-50 p-4 rounded-full\"><i class=\"fas fa-link text-4xl text-indigo-500\"></i></div><h3 class=\"mt-2 text-lg font-medium text-gray-900\">No URLs found</h3><p class=\"mt-1 text-sm text-gray-500\">Get started by creating your first shortened link.</p><div class=\"mt-6\"><a href=\"/\" class=\"inline-flex items-center px-4 py-2 border border-transparent shadow-sm text-sm font-medium rounded-md text-white bg-indigo-600 hover:bg-indigo-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-indigo-500\"><i class=\"fas fa-plus mr-2\"></i> Create New URL</a></div></div></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -143,7 +379,7 @@ func URLList(urls []domain.URLStat, paginationLinks domain.PaginationLinks) temp
 				}
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "<!-- Pagination -->")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "<!-- Pagination -->")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -151,7 +387,7 @@ func URLList(urls []domain.URLStat, paginationLinks domain.PaginationLinks) temp
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "</div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}